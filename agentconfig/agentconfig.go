@@ -139,6 +139,10 @@ type config struct {
 	guestPoliciesEnabled    bool
 	osInventoryEnabled      bool
 	scalibrLinuxEnabled     bool
+	scalibrLegacyMerge      string
+	inventoryCollectors     string
+	osInfoCacheTTL          time.Duration
+	packageUpdatesDisabled  bool
 	guestAttributesEnabled  bool
 	traceGetInventory       bool
 }
@@ -235,6 +239,10 @@ type attributesJSON struct {
 	EnableGuestAttributes string       `json:"enable-guest-attributes"`
 	TraceGetInventory     string       `json:"trace-get-inventory"`
 	ScalibrLinuxEnabled   string       `json:"enable-scalibr-linux"`
+	ScalibrLegacyMerge    string       `json:"scalibr-linux-legacy-merge"`
+	InventoryCollectors   string       `json:"osconfig-inventory-collectors"`
+	InventoryOSInfoCache  string       `json:"osconfig-inventory-osinfo-cache"`
+	PackageUpdates        string       `json:"osconfig-inventory-package-updates"`
 }
 
 func createConfigFromMetadata(md metadataJSON) *config {
@@ -372,6 +380,10 @@ func createConfigFromMetadata(md metadataJSON) *config {
 	}
 
 	setScalibrEnablement(md, c)
+	setScalibrLegacyMerge(md, c)
+	setInventoryCollectors(md, c)
+	setInventoryOSInfoCache(md, c)
+	setInventoryPackageUpdates(md, c)
 	setSVCEndpoint(md, c)
 	setTraceGetInventory(md, c)
 
@@ -389,6 +401,59 @@ func setScalibrEnablement(md metadataJSON, c *config) {
 	}
 }
 
+// Values of the scalibr-linux-legacy-merge metadata attribute.
+const (
+	// ScalibrLegacyMergePreferScalibr merges the legacy package lists into the scalibr
+	// ones, keeping the scalibr entry of packages found by both.
+	ScalibrLegacyMergePreferScalibr = "prefer-scalibr"
+	// ScalibrLegacyMergePreferLegacy merges the package lists keeping the legacy entry
+	// of packages found by both.
+	ScalibrLegacyMergePreferLegacy = "prefer-legacy"
+)
+
+func setScalibrLegacyMerge(md metadataJSON, c *config) {
+	for _, setting := range []string{md.Project.Attributes.ScalibrLegacyMerge, md.Instance.Attributes.ScalibrLegacyMerge} {
+		switch v := strings.ToLower(strings.TrimSpace(setting)); v {
+		case "":
+		case ScalibrLegacyMergePreferScalibr, ScalibrLegacyMergePreferLegacy:
+			c.scalibrLegacyMerge = v
+		default:
+			// Any other value, e.g. "false", disables the merge.
+			c.scalibrLegacyMerge = ""
+		}
+	}
+}
+
+func setInventoryCollectors(md metadataJSON, c *config) {
+	for _, setting := range []string{md.Project.Attributes.InventoryCollectors, md.Instance.Attributes.InventoryCollectors} {
+		if setting != "" {
+			c.inventoryCollectors = setting
+		}
+	}
+}
+
+func setInventoryOSInfoCache(md metadataJSON, c *config) {
+	for _, setting := range []string{md.Project.Attributes.InventoryOSInfoCache, md.Instance.Attributes.InventoryOSInfoCache} {
+		if setting == "" {
+			continue
+		}
+		// Bad entries and negative durations disable the cache.
+		ttl, err := time.ParseDuration(strings.TrimSpace(setting))
+		if err != nil || ttl < 0 {
+			ttl = 0
+		}
+		c.osInfoCacheTTL = ttl
+	}
+}
+
+func setInventoryPackageUpdates(md metadataJSON, c *config) {
+	for _, setting := range []string{md.Project.Attributes.PackageUpdates, md.Instance.Attributes.PackageUpdates} {
+		if setting != "" {
+			c.packageUpdatesDisabled = !parseBool(setting)
+		}
+	}
+}
+
 func setSVCEndpoint(md metadataJSON, c *config) {
 	switch {
 	case *endpoint != prodEndpoint:
@@ -591,6 +656,62 @@ func ScalibrLinuxEnabled() bool {
 	return getAgentConfig().scalibrLinuxEnabled
 }
 
+// ScalibrLegacyMerge returns how the packages found by the legacy inventory extractors are
+// merged into the scalibr ones, ScalibrLegacyMergePreferScalibr or
+// ScalibrLegacyMergePreferLegacy. It is empty when they are not merged.
+func ScalibrLegacyMerge() string {
+	return getAgentConfig().scalibrLegacyMerge
+}
+
+// InventoryCollector is an opt-in inventory collector enabled with the
+// osconfig-inventory-collectors metadata attribute, e.g. "go-binaries" with the roots
+// to scan as Args.
+type InventoryCollector struct {
+	Name string
+	Args []string
+}
+
+// InventoryCollectors returns the opt-in inventory collectors to enable. The
+// osconfig-inventory-collectors attribute is a comma separated list of collector names,
+// each optionally followed by "=" and its semicolon separated arguments, e.g.
+// "kernel-modules,loaded-libraries=sshd;nginx". Instance metadata overrides project metadata.
+func InventoryCollectors() []InventoryCollector {
+	return parseInventoryCollectors(getAgentConfig().inventoryCollectors)
+}
+
+func parseInventoryCollectors(setting string) []InventoryCollector {
+	var collectors []InventoryCollector
+	for _, entry := range strings.Split(setting, ",") {
+		name, args, _ := strings.Cut(strings.TrimSpace(entry), "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		collector := InventoryCollector{Name: name}
+		for _, arg := range strings.Split(args, ";") {
+			if arg = strings.TrimSpace(arg); arg != "" {
+				collector.Args = append(collector.Args, arg)
+			}
+		}
+		collectors = append(collectors, collector)
+	}
+	return collectors
+}
+
+// InventoryOSInfoCacheTTL returns how long the OS info reported with the inventory is
+// cached across collections, set with the osconfig-inventory-osinfo-cache metadata
+// attribute as a duration, e.g. "1h". It is 0 when the OS info is collected every time.
+func InventoryOSInfoCacheTTL() time.Duration {
+	return getAgentConfig().osInfoCacheTTL
+}
+
+// InventoryPackageUpdatesEnabled answers whether the available package updates are
+// collected with the inventory, it is disabled by setting the
+// osconfig-inventory-package-updates metadata attribute to false.
+func InventoryPackageUpdatesEnabled() bool {
+	return !getAgentConfig().packageUpdatesDisabled
+}
+
 // SvcEndpoint is the OS Config service endpoint.
 func SvcEndpoint() string {
 	return getAgentConfig().svcEndpoint
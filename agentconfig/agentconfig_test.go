@@ -1045,6 +1045,172 @@ func TestSetScalibrEnablement(t *testing.T) {
 	}
 }
 
+// TestSetScalibrLegacyMerge applies metadata precedence for merging legacy packages.
+func TestSetScalibrLegacyMerge(t *testing.T) {
+	tests := []struct {
+		name string
+		md   metadataJSON
+		want string
+	}{
+		{
+			name: "project and instance values are empty, returns merge disabled",
+			want: "",
+		},
+		{
+			name: "project prefers scalibr and instance is empty, returns prefer scalibr",
+			md: metadataJSON{
+				Project: projectJSON{Attributes: attributesJSON{ScalibrLegacyMerge: "prefer-scalibr"}},
+			},
+			want: ScalibrLegacyMergePreferScalibr,
+		},
+		{
+			name: "instance prefers legacy and project prefers scalibr, returns instance override",
+			md: metadataJSON{
+				Project:  projectJSON{Attributes: attributesJSON{ScalibrLegacyMerge: "prefer-scalibr"}},
+				Instance: instanceJSON{Attributes: attributesJSON{ScalibrLegacyMerge: "Prefer-Legacy"}},
+			},
+			want: ScalibrLegacyMergePreferLegacy,
+		},
+		{
+			name: "instance disables merge and project prefers scalibr, returns merge disabled",
+			md: metadataJSON{
+				Project:  projectJSON{Attributes: attributesJSON{ScalibrLegacyMerge: "prefer-scalibr"}},
+				Instance: instanceJSON{Attributes: attributesJSON{ScalibrLegacyMerge: "false"}},
+			},
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &config{}
+			setScalibrLegacyMerge(tt.md, c)
+
+			utiltest.AssertEquals(t, c.scalibrLegacyMerge, tt.want)
+		})
+	}
+}
+
+func TestSetInventoryCollectors(t *testing.T) {
+	tests := []struct {
+		name string
+		md   metadataJSON
+		want []InventoryCollector
+	}{
+		{
+			name: "project and instance values are empty, returns no collectors",
+		},
+		{
+			name: "project value is used when instance is empty",
+			md: metadataJSON{
+				Project: projectJSON{Attributes: attributesJSON{InventoryCollectors: "kernel-modules"}},
+			},
+			want: []InventoryCollector{{Name: "kernel-modules"}},
+		},
+		{
+			name: "instance value overrides project, arguments are split",
+			md: metadataJSON{
+				Project:  projectJSON{Attributes: attributesJSON{InventoryCollectors: "kernel-modules"}},
+				Instance: instanceJSON{Attributes: attributesJSON{InventoryCollectors: " Certificates , loaded-libraries=sshd; nginx ,, go-binaries="}},
+			},
+			want: []InventoryCollector{
+				{Name: "certificates"},
+				{Name: "loaded-libraries", Args: []string{"sshd", "nginx"}},
+				{Name: "go-binaries"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &config{}
+			setInventoryCollectors(tt.md, c)
+
+			utiltest.AssertEquals(t, parseInventoryCollectors(c.inventoryCollectors), tt.want)
+		})
+	}
+}
+
+func TestSetInventoryOSInfoCache(t *testing.T) {
+	tests := []struct {
+		name string
+		md   metadataJSON
+		want time.Duration
+	}{
+		{
+			name: "project and instance values are empty, returns cache disabled",
+		},
+		{
+			name: "project value is used when instance is empty",
+			md: metadataJSON{
+				Project: projectJSON{Attributes: attributesJSON{InventoryOSInfoCache: "1h"}},
+			},
+			want: time.Hour,
+		},
+		{
+			name: "instance value overrides project",
+			md: metadataJSON{
+				Project:  projectJSON{Attributes: attributesJSON{InventoryOSInfoCache: "1h"}},
+				Instance: instanceJSON{Attributes: attributesJSON{InventoryOSInfoCache: "30m"}},
+			},
+			want: 30 * time.Minute,
+		},
+		{
+			name: "invalid instance value disables the cache",
+			md: metadataJSON{
+				Project:  projectJSON{Attributes: attributesJSON{InventoryOSInfoCache: "1h"}},
+				Instance: instanceJSON{Attributes: attributesJSON{InventoryOSInfoCache: "true"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &config{}
+			setInventoryOSInfoCache(tt.md, c)
+
+			utiltest.AssertEquals(t, c.osInfoCacheTTL, tt.want)
+		})
+	}
+}
+
+func TestSetInventoryPackageUpdates(t *testing.T) {
+	tests := []struct {
+		name string
+		md   metadataJSON
+		want bool
+	}{
+		{
+			name: "project and instance values are empty, returns updates enabled",
+			want: true,
+		},
+		{
+			name: "project disables updates and instance is empty, returns updates disabled",
+			md: metadataJSON{
+				Project: projectJSON{Attributes: attributesJSON{PackageUpdates: "false"}},
+			},
+			want: false,
+		},
+		{
+			name: "instance enables updates and project disables them, returns instance override",
+			md: metadataJSON{
+				Project:  projectJSON{Attributes: attributesJSON{PackageUpdates: "false"}},
+				Instance: instanceJSON{Attributes: attributesJSON{PackageUpdates: "true"}},
+			},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &config{}
+			setInventoryPackageUpdates(tt.md, c)
+
+			utiltest.AssertEquals(t, !c.packageUpdatesDisabled, tt.want)
+		})
+	}
+}
+
 // TestSetTraceGetInventory applies metadata precedence for inventory tracing.
 func TestSetTraceGetInventory(t *testing.T) {
 	tests := []struct {
@@ -16,6 +16,7 @@
 package agentendpoint
 
 import (
+	"compress/gzip"
 	"context"
 	"errors"
 	"fmt"
@@ -61,10 +62,346 @@ type Client struct {
 	mx     sync.Mutex
 
 	inventoryProvider inventory.Provider
+	// inventoryOptions configure the provider NewClient creates when no inventoryProvider
+	// is set with WithInventoryProvider.
+	inventoryOptions []inventory.Option
+	metrics          MetricsRecorder
+
+	excludedPackageTypes map[string]bool
+	attributeWriteLogger AttributeWriteLogger
+	attributes           AttributeWriter
+
+	// maxInventoryItems caps the number of installed and of available packages reported,
+	// 0 reports all packages.
+	maxInventoryItems int
+
+	// securityUpdatesOnly drops the available updates that are not flagged as security updates.
+	securityUpdatesOnly bool
+
+	// maxMetadataFields caps the number of metadata fields of each VmInventory item,
+	// 0 reports all fields.
+	maxMetadataFields int
+
+	// maxVersionLength caps the length in characters of the version of each VmInventory
+	// item, 0 reports versions of any length.
+	maxVersionLength int
+
+	// metadataRedactor redacts the packages reported to the agent endpoint, nil reports
+	// them unchanged.
+	metadataRedactor MetadataRedactor
+
+	// reportJitter is the upper bound of the random delay before the inventory is
+	// collected, 0 collects it right away.
+	reportJitter time.Duration
+
+	// normalizedNameTypes are the VmInventory item types whose names are reported in
+	// lowercase, none when empty.
+	normalizedNameTypes map[string]bool
+
+	// labels are reported to the agent endpoint in the reportInfoType item of the
+	// VmInventory.
+	labels map[string]string
+
+	// minZypperPatchSeverity is the rank of the lowest zypper patch severity reported,
+	// 0 reports patches of all severities.
+	minZypperPatchSeverity int
+
+	// compressStringThreshold is the size in bytes above which string inventory
+	// fields are written compressed, 0 disables compression of string fields.
+	compressStringThreshold int
+
+	// compressionLevel is the gzip level of compressed guest attributes, nil uses
+	// gzip.DefaultCompression.
+	compressionLevel *int
+
+	// disableLegacyInventory skips building the legacy Inventory and falling back to
+	// the legacy ReportInventory API.
+	disableLegacyInventory bool
+
+	// reportChunkSize is the maximum number of packages of each request when the full
+	// inventory is reported, 0 reports it in a single request.
+	reportChunkSize int
+
+	// fingerprintOSInfoFields are the proto names of the OsInfo fields included in the
+	// stable inventory fingerprint, empty includes all fields.
+	fingerprintOSInfoFields []string
+
+	// reportSizeWarning is the payload size in bytes above which a report logs a warning,
+	// 0 disables the warning.
+	reportSizeWarning int
+
+	// endpoint is the address of the agent endpoint, empty dials agentconfig.SvcEndpoint.
+	endpoint string
+	// dialOptions are appended to the default gRPC dial options of the agent endpoint.
+	dialOptions []grpc.DialOption
+
+	// breaker skips reporting inventory while the agent endpoint keeps failing.
+	breaker reportBreaker
+
+	// reportMx serializes inventory report cycles, it guards the fields below which are
+	// read and updated by every cycle. It is separate from mx, which Close holds.
+	reportMx sync.Mutex
+	// writeFailures counts consecutive failed guest attribute writes.
+	writeFailures int
+	// lastReportedStateFingerprint is the fingerprint of the last collected inventory
+	// formatted for a report and lastReportedFingerprint the stable fingerprint of the
+	// VmInventory formatted from it, they let unchanged inventories skip formatting.
+	lastReportedStateFingerprint string
+	lastReportedFingerprint      string
+	// lastWrittenFingerprint is the Fingerprint of the last inventory written to guest attributes.
+	lastWrittenFingerprint string
+	// writtenAttributes maps the URL of each guest attribute successfully written to the
+	// digest of its content, unchanged attributes are not posted again.
+	writtenAttributes map[string]string
+}
+
+// ClientOption configures optional behavior of a Client.
+type ClientOption func(*Client)
+
+// WithMetricsRecorder sets the MetricsRecorder notified about inventory reporting outcomes.
+func WithMetricsRecorder(m MetricsRecorder) ClientOption {
+	return func(c *Client) {
+		c.metrics = m
+	}
+}
+
+// WithExcludedPackageTypes excludes packages of the given types (e.g. "deb", "rpm", "gem", "pypi",
+// "zypperPatch", "wuaPackage") from both installed and available packages reported to the agent endpoint.
+func WithExcludedPackageTypes(types ...string) ClientOption {
+	return func(c *Client) {
+		c.excludedPackageTypes = make(map[string]bool, len(types))
+		for _, t := range types {
+			c.excludedPackageTypes[t] = true
+		}
+	}
+}
+
+// WithMinZypperPatchSeverity drops zypper patches with a known severity below severity
+// ("low" < "moderate" < "important" < "critical") from the reported inventory.
+// Patches with an unknown severity are always reported.
+func WithMinZypperPatchSeverity(severity string) ClientOption {
+	return func(c *Client) {
+		c.minZypperPatchSeverity = zypperPatchSeverityRank(severity)
+	}
+}
+
+// WithMaxInventoryItems caps the number of installed and of available packages reported
+// to the agent endpoint at max each. Language packages are dropped before OS packages,
+// items that are not packages, e.g. services or certificates, are never dropped.
+func WithMaxInventoryItems(max int) ClientOption {
+	return func(c *Client) {
+		c.maxInventoryItems = max
+	}
+}
+
+// WithSecurityUpdatesOnly reports only the available updates flagged as security updates:
+// apt updates from security repositories, yum updates fixing security advisories, zypper
+// patches of the security category and WUA security updates. Updates of package managers
+// without security metadata and installed packages are reported unchanged.
+func WithSecurityUpdatesOnly() ClientOption {
+	return func(c *Client) {
+		c.securityUpdatesOnly = true
+	}
+}
+
+// WithReportCircuitBreaker stops reporting inventory for cooldown after threshold consecutive
+// failed reports, the next report then probes whether the agent endpoint recovered.
+func WithReportCircuitBreaker(threshold int, cooldown time.Duration) ClientOption {
+	return func(c *Client) {
+		c.breaker.threshold = threshold
+		c.breaker.cooldown = cooldown
+	}
+}
+
+// ReportBreakerState returns the state of the circuit breaker around inventory reports.
+func (c *Client) ReportBreakerState() BreakerState {
+	return c.breaker.currentState()
+}
+
+// WithMaxMetadataFields caps the number of metadata fields of each package reported
+// to the agent endpoint at max, the fields are kept in key order.
+func WithMaxMetadataFields(max int) ClientOption {
+	return func(c *Client) {
+		c.maxMetadataFields = max
+	}
+}
+
+// WithCompressionLevel sets the gzip level, e.g. gzip.BestSpeed for CPU constrained hosts,
+// used to compress the inventory written to guest attributes. Levels outside of
+// gzip.HuffmanOnly to gzip.BestCompression are ignored.
+func WithCompressionLevel(level int) ClientOption {
+	return func(c *Client) {
+		if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+			return
+		}
+		c.compressionLevel = &level
+	}
+}
+
+// WithAttributeWriteLogger logs every guest attribute write with the provided logger
+// instead of the default printf style messages.
+func WithAttributeWriteLogger(l AttributeWriteLogger) ClientOption {
+	return func(c *Client) {
+		c.attributeWriteLogger = l
+	}
+}
+
+// WithMaxVersionLength truncates package versions longer than max characters, e.g. git
+// hashes with build metadata, the full version is reported in the FullVersion metadata
+// field of VmInventory items. 0 reports versions of any length, the default is 256.
+func WithMaxVersionLength(max int) ClientOption {
+	return func(c *Client) {
+		c.maxVersionLength = max
+	}
+}
+
+// WithMetadataRedactor calls r with every package reported to the agent endpoint before
+// it is sent, e.g. to drop file locations considered sensitive.
+func WithMetadataRedactor(r MetadataRedactor) ClientOption {
+	return func(c *Client) {
+		c.metadataRedactor = r
+	}
+}
+
+// WithLabels attaches labels, e.g. env=prod, to the VmInventory reported to the agent
+// endpoint so that the inventory can be filtered downstream. They are reported in the
+// "Labels" metadata of its inventory-report item, the legacy Inventory has no field to
+// carry them.
+func WithLabels(labels map[string]string) ClientOption {
+	return func(c *Client) {
+		c.labels = make(map[string]string, len(labels))
+		for k, v := range labels {
+			c.labels[k] = v
+		}
+	}
+}
+
+// WithReportJitter delays each ReportInventory by a random duration below max so that
+// instances driven by the same schedule do not report at the same time.
+func WithReportJitter(max time.Duration) ClientOption {
+	return func(c *Client) {
+		c.reportJitter = max
+	}
+}
+
+// WithNormalizedNames reports the names of the packages of the given VmInventory item
+// types in lowercase, so that the same package is reported identically across hosts, the
+// original name is kept in the DisplayName metadata field. Without types, the names of
+// the case-insensitive windowsApplication, windows-service and pypi types are normalized.
+// Legacy Inventory packages are normalized by the type of their VmInventory item.
+func WithNormalizedNames(types ...string) ClientOption {
+	return func(c *Client) {
+		if len(types) == 0 {
+			types = defaultNormalizedNameTypes
+		}
+		c.normalizedNameTypes = make(map[string]bool, len(types))
+		for _, t := range types {
+			c.normalizedNameTypes[t] = true
+		}
+	}
+}
+
+// WithDisableLegacyInventory stops reporting inventory with the legacy ReportInventory
+// API when the endpoint rejects ReportVmInventory, the legacy Inventory is not built.
+func WithDisableLegacyInventory() ClientOption {
+	return func(c *Client) {
+		c.disableLegacyInventory = true
+	}
+}
+
+// WithReportChunkSize splits full inventory reports with more than size installed and
+// available packages across multiple requests of at most size packages, for hosts whose
+// inventory exceeds the gRPC message size limit. Every chunk carries the OsInfo and the
+// checksum of the whole inventory, and its position as "<index>/<total>" in the
+// x-osconfig-inventory-chunk request metadata. Only enable it against an endpoint that
+// reassembles the chunks, other endpoints store each chunk as the whole inventory. When
+// the endpoint rejects a chunk as Unimplemented the inventory is reported in a single
+// request instead.
+func WithReportChunkSize(size int) ClientOption {
+	return func(c *Client) {
+		c.reportChunkSize = size
+	}
+}
+
+// WithFingerprintOSInfoFields limits the OsInfo fields, by proto field name e.g. "short_name",
+// that are part of the stable inventory fingerprint. Leaving out frequently changing fields
+// such as "kernel_version" avoids reporting the full inventory when no package changed.
+func WithFingerprintOSInfoFields(fields ...string) ClientOption {
+	return func(c *Client) {
+		c.fingerprintOSInfoFields = fields
+	}
+}
+
+// WithAttributeWriter posts the inventory guest attributes with w instead of writing
+// them to the metadata server.
+func WithAttributeWriter(w AttributeWriter) ClientOption {
+	return func(c *Client) {
+		c.attributes = w
+	}
+}
+
+// WithStringCompressionThreshold makes string inventory fields larger than threshold bytes
+// to be gzip-compressed and written to the "<field>Compressed" guest attribute instead.
+func WithStringCompressionThreshold(threshold int) ClientOption {
+	return func(c *Client) {
+		c.compressStringThreshold = threshold
+	}
+}
+
+// WithReportSizeWarning logs a warning when the VmInventory or legacy Inventory payload
+// of a report is larger than size bytes, to catch inventory bloat before the endpoint
+// rejects it. The payload size of every report is logged regardless.
+func WithReportSizeWarning(size int) ClientOption {
+	return func(c *Client) {
+		c.reportSizeWarning = size
+	}
+}
+
+// WithInventoryOptions configures the inventory provider of the Client, e.g. to enable
+// opt-in collectors with inventory.WithKernelModules. It is ignored when the provider is
+// replaced with WithInventoryProvider.
+func WithInventoryOptions(opts ...inventory.Option) ClientOption {
+	return func(c *Client) {
+		c.inventoryOptions = append(c.inventoryOptions, opts...)
+	}
+}
+
+// WithInventoryProvider replaces the provider the Client collects the inventory with.
+func WithInventoryProvider(p inventory.Provider) ClientOption {
+	return func(c *Client) {
+		c.inventoryProvider = p
+	}
+}
+
+// WithEndpoint reports to the agent endpoint at address, e.g. a staging endpoint,
+// instead of the one configured in agentconfig. It applies to every call of the Client,
+// including inventory reports.
+func WithEndpoint(address string) ClientOption {
+	return func(c *Client) {
+		c.endpoint = address
+	}
+}
+
+// WithGRPCDialOptions appends opts to the gRPC dial options of the agent endpoint, e.g. a
+// custom dialer or plaintext transport credentials for a local test endpoint.
+func WithGRPCDialOptions(opts ...grpc.DialOption) ClientOption {
+	return func(c *Client) {
+		c.dialOptions = append(c.dialOptions, opts...)
+	}
 }
 
 // NewClient a new agentendpoint Client.
-func NewClient(ctx context.Context) (*Client, error) {
+func NewClient(ctx context.Context, clientOpts ...ClientOption) (*Client, error) {
+	client := newClient(clientOpts)
+	client.noti = make(chan struct{}, 1)
+	if client.inventoryProvider == nil {
+		client.inventoryProvider = inventory.NewProvider(client.inventoryOptions...)
+	}
+	endpoint := client.endpoint
+	if endpoint == "" {
+		endpoint = agentconfig.SvcEndpoint()
+	}
+
 	keepAliveConf := keepalive.ClientParameters{
 		Time:                100 * time.Second,
 		Timeout:             5 * time.Second,
@@ -77,20 +414,19 @@ func NewClient(ctx context.Context) (*Client, error) {
 		// Because we disabled Auth we need to specifically enable TLS.
 		option.WithGRPCDialOption(grpc.WithTransportCredentials(credentials.NewTLS(nil))),
 		option.WithGRPCDialOption(grpc.WithKeepaliveParams(keepAliveConf)),
-		option.WithEndpoint(agentconfig.SvcEndpoint()),
+		option.WithEndpoint(endpoint),
 		option.WithUserAgent(agentconfig.UserAgent()),
 	}
-	clog.Debugf(ctx, "Creating new agentendpoint client.")
+	for _, o := range client.dialOptions {
+		opts = append(opts, option.WithGRPCDialOption(o))
+	}
+	clog.Debugf(ctx, "Creating new agentendpoint client for %s.", endpoint)
 	c, err := agentendpoint.NewClient(ctx, opts...)
 	if err != nil {
 		return nil, err
 	}
-
-	return &Client{
-		raw:               c,
-		noti:              make(chan struct{}, 1),
-		inventoryProvider: inventory.NewProvider(),
-	}, nil
+	client.raw = c
+	return client, nil
 }
 
 // Close cancels WaitForTaskNotification and closes the underlying ClientConn.
@@ -154,7 +490,7 @@ func (c *Client) reportInventory(ctx context.Context, inventory *agentendpointpb
 		return nil, err
 	}
 
-	checksum, err := computeStableFingerprint(ctx, inventory)
+	checksum, err := computeStableFingerprint(ctx, inventory, c.fingerprintOSInfoFields...)
 	if err != nil {
 		return nil, fmt.Errorf("unable to compute hash, err: %w", err)
 	}
@@ -173,14 +509,29 @@ func (c *Client) reportInventory(ctx context.Context, inventory *agentendpointpb
 }
 
 func (c *Client) reportVMInventory(ctx context.Context, inventory *agentendpointpb.VmInventory, reportFull bool) (*agentendpointpb.ReportVmInventoryResponse, error) {
+	checksum, err := computeStableFingerprintVMInventory(ctx, inventory, c.fingerprintOSInfoFields...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to compute hash, err: %w", err)
+	}
+	return c.reportVMInventoryChecksum(ctx, checksum, inventory, reportFull)
+}
+
+// reportVMInventoryChecksum calls ReportVmInventory with checksum, inventory is only sent,
+// and only needed, when reportFull is set.
+func (c *Client) reportVMInventoryChecksum(ctx context.Context, checksum string, inventory *agentendpointpb.VmInventory, reportFull bool) (*agentendpointpb.ReportVmInventoryResponse, error) {
 	token, err := agentconfig.IDToken()
 	if err != nil {
 		return nil, err
 	}
 
-	checksum, err := computeStableFingerprintVMInventory(ctx, inventory)
-	if err != nil {
-		return nil, fmt.Errorf("unable to compute hash, err: %w", err)
+	if reportFull && c.reportChunkSize > 0 {
+		if chunks := chunkVMInventory(inventory, c.reportChunkSize); len(chunks) > 1 {
+			resp, err := c.reportVMInventoryChunks(ctx, token, checksum, chunks)
+			if status.Code(err) != codes.Unimplemented {
+				return resp, err
+			}
+			clog.Infof(ctx, "Agent endpoint does not support chunked inventory reports, reporting the full inventory in one request.")
+		}
 	}
 
 	req := &agentendpointpb.ReportVmInventoryRequest{InventoryChecksum: checksum}
@@ -34,13 +34,18 @@ import (
 
 	agentendpoint "cloud.google.com/go/osconfig/agentendpoint/apiv1"
 	"github.com/GoogleCloudPlatform/guest-logging-go/logger"
+	"github.com/GoogleCloudPlatform/osconfig/inventory"
 	utilmocks "github.com/GoogleCloudPlatform/osconfig/util/mocks"
+	"github.com/GoogleCloudPlatform/osconfig/util/utiltest"
+	"github.com/google/go-cmp/cmp"
 	"golang.org/x/oauth2/jws"
 	"google.golang.org/api/option"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/testing/protocmp"
 
 	"cloud.google.com/go/osconfig/agentendpoint/apiv1/agentendpointpb"
 )
@@ -71,6 +76,9 @@ func TestMain(m *testing.M) {
 		os.Exit(1)
 	}
 
+	// Read ambiguous QFE dates month first regardless of the locale of the test host.
+	qfeDateLocale = func() string { return "en-US" }
+
 	opts := logger.LogOpts{LoggerName: "OSConfigAgent", Debug: true, Writers: []io.Writer{os.Stdout}}
 	logger.Init(context.Background(), opts)
 
@@ -506,3 +514,127 @@ func TestRegisterAgent(t *testing.T) {
 		t.Errorf("RegisterAgent() error: %v", err)
 	}
 }
+
+// reportTestServer records the inventory reports it receives.
+type reportTestServer struct {
+	agentendpointpb.UnimplementedAgentEndpointServiceServer
+	vmInventoryReqs chan *agentendpointpb.ReportVmInventoryRequest
+	inventoryReqs   chan *agentendpointpb.ReportInventoryRequest
+}
+
+func (s *reportTestServer) ReportVmInventory(ctx context.Context, req *agentendpointpb.ReportVmInventoryRequest) (*agentendpointpb.ReportVmInventoryResponse, error) {
+	s.vmInventoryReqs <- req
+	return &agentendpointpb.ReportVmInventoryResponse{}, nil
+}
+
+// startReportTestServer serves srv on a local port and returns a Client reporting to it.
+func startReportTestServer(ctx context.Context, t *testing.T, srv *reportTestServer, opts ...ClientOption) *Client {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen error: %v", err)
+	}
+	s := grpc.NewServer()
+	agentendpointpb.RegisterAgentEndpointServiceServer(s, srv)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	opts = append(opts, WithEndpoint(lis.Addr().String()), WithGRPCDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials())))
+	client, err := NewClient(ctx, opts...)
+	if err != nil {
+		t.Fatalf("NewClient error: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestReportVmInventoryChunkSize(t *testing.T) {
+	ctx := context.Background()
+	inv := chunkTestInventory(5, 3)
+
+	tests := []struct {
+		name string
+		opts []ClientOption
+		// wantRequests is the number of requests of each of two consecutive reports.
+		wantRequests []int
+	}{
+		{name: "Default", wantRequests: []int{1, 1}},
+		{name: "ChunkSize", opts: []ClientOption{WithReportChunkSize(3)}, wantRequests: []int{3, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := &reportTestServer{
+				vmInventoryReqs: make(chan *agentendpointpb.ReportVmInventoryRequest, 10),
+			}
+			client := startReportTestServer(ctx, t, srv, tt.opts...)
+
+			var gotRequests []int
+			for range tt.wantRequests {
+				if _, err := client.reportVMInventory(ctx, inv, true); err != nil {
+					t.Fatalf("reportVMInventory error: %v", err)
+				}
+				n := len(srv.vmInventoryReqs)
+				if n == 1 {
+					if diff := cmp.Diff(inv, (<-srv.vmInventoryReqs).GetVmInventory(), protocmp.Transform()); diff != "" {
+						t.Errorf("single request inventory mismatch (-want +got):\n%s", diff)
+					}
+				}
+				for len(srv.vmInventoryReqs) > 0 {
+					<-srv.vmInventoryReqs
+				}
+				gotRequests = append(gotRequests, n)
+			}
+			utiltest.AssertEquals(t, gotRequests, tt.wantRequests)
+		})
+	}
+}
+
+func (s *reportTestServer) ReportInventory(ctx context.Context, req *agentendpointpb.ReportInventoryRequest) (*agentendpointpb.ReportInventoryResponse, error) {
+	s.inventoryReqs <- req
+	return &agentendpointpb.ReportInventoryResponse{}, nil
+}
+
+func TestNewClientWithEndpoint(t *testing.T) {
+	ctx := context.Background()
+	srv := &reportTestServer{
+		vmInventoryReqs: make(chan *agentendpointpb.ReportVmInventoryRequest, 1),
+		inventoryReqs:   make(chan *agentendpointpb.ReportInventoryRequest, 1),
+	}
+	client := startReportTestServer(ctx, t, srv)
+
+	if _, err := client.reportVMInventory(ctx, &agentendpointpb.VmInventory{}, true); err != nil {
+		t.Fatalf("reportVMInventory error: %v", err)
+	}
+	if req := <-srv.vmInventoryReqs; req.GetVmInventory() == nil {
+		t.Errorf("ReportVmInventory request %v has no VmInventory", req)
+	}
+	if _, err := client.reportInventory(ctx, &agentendpointpb.Inventory{}, false); err != nil {
+		t.Fatalf("reportInventory error: %v", err)
+	}
+	if req := <-srv.inventoryReqs; req.GetInventoryChecksum() == "" {
+		t.Errorf("ReportInventory request %v has no checksum", req)
+	}
+}
+
+func TestNewClientWithInventoryProvider(t *testing.T) {
+	ctx := context.Background()
+	provider := &countingInventoryProvider{}
+	client, err := NewClient(ctx, WithEndpoint("localhost:0"), WithInventoryProvider(provider), WithInventoryOptions(inventory.WithKernelModules()))
+	if err != nil {
+		t.Fatalf("NewClient error: %v", err)
+	}
+	defer client.Close()
+	if client.inventoryProvider != provider {
+		t.Errorf("NewClient() inventory provider = %v, want the provider set with WithInventoryProvider", client.inventoryProvider)
+	}
+
+	client, err = NewClient(ctx, WithEndpoint("localhost:0"), WithInventoryOptions(inventory.WithKernelModules()))
+	if err != nil {
+		t.Fatalf("NewClient error: %v", err)
+	}
+	defer client.Close()
+	if client.inventoryProvider == nil {
+		t.Errorf("NewClient() with inventory options did not create an inventory provider")
+	}
+	utiltest.AssertEquals(t, len(client.inventoryOptions), 1)
+}
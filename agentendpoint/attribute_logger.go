@@ -0,0 +1,69 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package agentendpoint
+
+import (
+	"context"
+
+	"github.com/GoogleCloudPlatform/osconfig/clog"
+)
+
+const (
+	attributeWriteSucceeded = "success"
+	attributeWriteFailed    = "failure"
+)
+
+// AttributeWriteRecord describes the outcome of writing a single guest attribute.
+type AttributeWriteRecord struct {
+	// URL is the guest attribute URL the value was written to.
+	URL string `json:"url"`
+	// Field is the name of the InstanceInventory field that was written.
+	Field string `json:"field"`
+	// Size is the size of the uncompressed value in bytes.
+	Size int `json:"size"`
+	// Outcome is either "success" or "failure".
+	Outcome string `json:"outcome"`
+	// Error is the error message of a failed write.
+	Error string `json:"error,omitempty"`
+}
+
+// AttributeWriteLogger receives a record for every guest attribute written during ReportInventory.
+type AttributeWriteLogger interface {
+	LogAttributeWrite(ctx context.Context, record AttributeWriteRecord)
+}
+
+func newAttributeWriteRecord(field, url string, size int, err error) AttributeWriteRecord {
+	record := AttributeWriteRecord{URL: url, Field: field, Size: size, Outcome: attributeWriteSucceeded}
+	if err != nil {
+		record.Outcome = attributeWriteFailed
+		record.Error = err.Error()
+	}
+	return record
+}
+
+type structuredAttributeWriteLogger struct{}
+
+// NewStructuredAttributeWriteLogger returns an AttributeWriteLogger that sends every record
+// as a JSON structured payload, so it can be indexed by log pipelines.
+func NewStructuredAttributeWriteLogger() AttributeWriteLogger {
+	return structuredAttributeWriteLogger{}
+}
+
+func (structuredAttributeWriteLogger) LogAttributeWrite(ctx context.Context, record AttributeWriteRecord) {
+	if record.Outcome == attributeWriteFailed {
+		clog.Errorf(ctx, "Error writing guest attribute %s: %s", record.URL, record.Error)
+	}
+	clog.DebugStructured(ctx, record, "Guest attribute %s written to %s, size: %d, outcome: %s", record.Field, record.URL, record.Size, record.Outcome)
+}
@@ -0,0 +1,59 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package agentendpoint
+
+import (
+	"io"
+
+	"github.com/GoogleCloudPlatform/osconfig/attributes"
+)
+
+// AttributeWriter posts guest attributes, by default to the metadata server.
+type AttributeWriter interface {
+	// PostAttribute writes value to the guest attribute at url.
+	PostAttribute(url string, value io.Reader) error
+	// PostAttributeCompressed writes body as gzip-compressed JSON, compressed with the
+	// given gzip level, to the guest attribute at url.
+	PostAttributeCompressed(url string, body any, level int) error
+}
+
+// AttributeDeleter is implemented by AttributeWriters that can delete guest attributes, the
+// Client deletes the attribute a string field was written to before it switched between the
+// uncompressed and the compressed attribute. Stale attributes are left without it.
+type AttributeDeleter interface {
+	// DeleteAttribute deletes the guest attribute at url, it succeeds when it is not set.
+	DeleteAttribute(url string) error
+}
+
+type metadataAttributeWriter struct{}
+
+func (metadataAttributeWriter) PostAttribute(url string, value io.Reader) error {
+	return attributes.PostAttribute(url, value)
+}
+
+func (metadataAttributeWriter) PostAttributeCompressed(url string, body any, level int) error {
+	return attributes.PostAttributeCompressedLevel(url, body, level)
+}
+
+func (metadataAttributeWriter) DeleteAttribute(url string) error {
+	return attributes.DeleteAttribute(url)
+}
+
+func (c *Client) attributeWriter() AttributeWriter {
+	if c.attributes == nil {
+		return metadataAttributeWriter{}
+	}
+	return c.attributes
+}
@@ -2,25 +2,38 @@ package agentendpoint
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand"
+	"net"
 	"reflect"
+	"regexp"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/GoogleCloudPlatform/osconfig/agentconfig"
 	"github.com/GoogleCloudPlatform/osconfig/attributes"
 	"github.com/GoogleCloudPlatform/osconfig/clog"
 	"github.com/GoogleCloudPlatform/osconfig/inventory"
+	"github.com/GoogleCloudPlatform/osconfig/osinfo"
 	"github.com/GoogleCloudPlatform/osconfig/packages"
 	"github.com/GoogleCloudPlatform/osconfig/retryutil"
+	"github.com/package-url/packageurl-go"
+	"golang.org/x/text/language"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/types/known/structpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
@@ -33,77 +46,1208 @@ const (
 	dateTimeFormat = "2006-01-02 15:04:05 +0000 GMT"
 )
 
+// MetricsRecorder records outcomes of inventory reports, e.g. to export them as counters.
+type MetricsRecorder interface {
+	// IncReportSuccess is called when inventory was reported successfully.
+	IncReportSuccess()
+	// IncReportFailure is called when reporting inventory failed, code is the status code of the last API error.
+	IncReportFailure(code codes.Code)
+	// IncReportFullInventory is called when the endpoint requested the full inventory.
+	IncReportFullInventory()
+	// ObserveReportDuration is called with the total duration of a report.
+	ObserveReportDuration(d time.Duration)
+}
+
+type noopMetricsRecorder struct{}
+
+func (noopMetricsRecorder) IncReportSuccess()                     {}
+func (noopMetricsRecorder) IncReportFailure(codes.Code)           {}
+func (noopMetricsRecorder) IncReportFullInventory()               {}
+func (noopMetricsRecorder) ObserveReportDuration(d time.Duration) {}
+
+func (c *Client) metricsRecorder() MetricsRecorder {
+	if c.metrics == nil {
+		return noopMetricsRecorder{}
+	}
+	return c.metrics
+}
+
+// maxConsecutiveWriteFailures is the number of consecutive failed guest attribute
+// writes after which the next write is skipped.
+const maxConsecutiveWriteFailures = 3
+
+// compressedAttributeSuffix is appended to the attribute path of string fields that were
+// compressed because they exceed the configured threshold.
+const compressedAttributeSuffix = "Compressed"
+
 // ReportInventory writes inventory to guest attributes and reports it to agent endpoint.
+// Failures are logged, use ReportInventoryWithErrors to handle them.
 func (c *Client) ReportInventory(ctx context.Context) {
-	state := c.inventoryProvider.Get(ctx)
+	c.ReportInventoryWithErrors(ctx)
+}
 
+// ReportInventoryWithErrors works as ReportInventory, additionally returning the joined
+// errors of the guest attributes write and of the agent endpoint report.
+func (c *Client) ReportInventoryWithErrors(ctx context.Context) error {
+	attributeURL := ""
 	if agentconfig.GuestAttributesEnabled() && !agentconfig.DisableInventoryWrite() {
-		clog.Infof(ctx, "Writing inventory to guest attributes")
-		write(ctx, state, inventoryURL)
+		attributeURL = inventoryURL
+	}
+	return c.reportInventoryWithErrors(ctx, attributeURL)
+}
+
+// reportInventoryWithErrors collects and filters the inventory, writes it to guest
+// attributes at attributeURL and reports it to the agent endpoint. The write is skipped
+// when attributeURL is empty.
+func (c *Client) reportInventoryWithErrors(ctx context.Context, attributeURL string) error {
+	if d := ReportJitter(c.reportJitter); d > 0 {
+		clog.Debugf(ctx, "Delaying inventory collection by %s.", d)
+		timer := time.NewTimer(d)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+	// Concurrent cycles would race on the write and report state kept in the Client.
+	c.reportMx.Lock()
+	defer c.reportMx.Unlock()
+
+	// Guest attributes and the agent endpoint receive the same filtered inventory.
+	state := c.filterInventory(c.inventoryProvider.Get(ctx))
+	// The fingerprint is computed once per cycle, it lets both the write and the report
+	// skip the work for an inventory that did not change.
+	fingerprint, err := state.Fingerprint()
+	if err != nil {
+		clog.Debugf(ctx, "Unable to compute the fingerprint of the collected inventory: %v", err)
+	}
+
+	var errs []error
+	if attributeURL != "" {
+		if err := c.writeInventoryIfChanged(ctx, state, fingerprint, attributeURL); err != nil {
+			errs = append(errs, fmt.Errorf("writing inventory to guest attributes: %w", err))
+		}
+	}
+
+	if err := c.reportWithFingerprint(ctx, state, fingerprint); err != nil {
+		errs = append(errs, fmt.Errorf("reporting inventory to agent endpoint: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// jitterInt63n returns a pseudo-random number in [0, n), it is replaced in tests.
+var jitterInt63n = rand.Int63n
+
+// ReportJitter returns a random duration in [0, max) for callers to add to a fixed
+// reporting schedule so that the instances of a fleet do not report in sync. It returns
+// 0 when max is not positive.
+func ReportJitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(jitterInt63n(int64(max)))
+}
+
+// SelfCheck verifies that the agent endpoint and, when guest attributes are enabled, the
+// metadata server are reachable without collecting the inventory. The endpoint receives
+// the checksum of an empty inventory, so the next report may be requested in full.
+func (c *Client) SelfCheck(ctx context.Context) error {
+	attributeURL := ""
+	if agentconfig.GuestAttributesEnabled() {
+		attributeURL = inventoryURL + "/Hostname"
+	}
+	return c.selfCheck(ctx, attributeURL)
+}
+
+// selfCheck reports an empty inventory and reads the guest attribute at attributeURL,
+// the read is skipped when attributeURL is empty.
+func (c *Client) selfCheck(ctx context.Context, attributeURL string) error {
+	if _, err := c.reportVMInventory(ctx, &agentendpointpb.VmInventory{}, false); err != nil {
+		return fmt.Errorf("agent endpoint check failed: %w", err)
+	}
+	if attributeURL == "" {
+		return nil
+	}
+	// An attribute that was not written yet still proves the metadata server is reachable.
+	if _, err := attributes.GetAttribute(ctx, attributeURL); err != nil && !errors.Is(err, attributes.ErrNotFound) {
+		return fmt.Errorf("guest attributes check failed: %w", err)
+	}
+	return nil
+}
+
+// unfingerprintedFields are the InstanceInventory fields left out of its Fingerprint, they
+// are written to guest attributes even when the fingerprint did not change.
+var unfingerprintedFields = map[string]bool{"LastUpdated": true, "SourceTimestamps": true}
+
+// writeInventoryIfChanged writes state to guest attributes. When fingerprint, the
+// Fingerprint of state, is the same as the one of the last successful write, only the
+// fields left out of it are written.
+func (c *Client) writeInventoryIfChanged(ctx context.Context, state *inventory.InstanceInventory, fingerprint, url string) error {
+	if c.inventoryUnchanged(fingerprint) {
+		clog.Infof(ctx, "Inventory unchanged since the last write, writing only its collection times to guest attributes")
+		return c.writeInventory(ctx, state, url, func(name string) bool { return unfingerprintedFields[name] })
 	}
 
-	c.report(ctx, state)
+	if err := c.writeInventory(ctx, state, url, nil); err != nil {
+		return err
+	}
+	c.lastWrittenFingerprint = fingerprint
+	return nil
+}
+
+// inventoryUnchanged reports whether fingerprint matches the fingerprint of the last
+// inventory successfully written to guest attributes.
+func (c *Client) inventoryUnchanged(fingerprint string) bool {
+	return fingerprint != "" && fingerprint == c.lastWrittenFingerprint
+}
+
+// writeInventory writes the fields of state selected by include, all of them when include
+// is nil, to guest attributes and returns the error of the write, or of skipping it after
+// too many consecutive failures.
+func (c *Client) writeInventory(ctx context.Context, state *inventory.InstanceInventory, url string, include func(name string) bool) error {
+	if c.writeFailures >= maxConsecutiveWriteFailures {
+		clog.Warningf(ctx, "Skipping writing inventory to guest attributes after %d consecutive failures", c.writeFailures)
+		err := fmt.Errorf("skipped after %d consecutive failures", c.writeFailures)
+		c.writeFailures = 0
+		return err
+	}
+
+	clog.Infof(ctx, "Writing inventory to guest attributes")
+	if err := c.writeFields(ctx, state, url, include); err != nil {
+		c.writeFailures++
+		clog.Errorf(ctx, "Error writing inventory to guest attributes (%d consecutive failures): %v", c.writeFailures, err)
+		return err
+	}
+	c.writeFailures = 0
+	return nil
+}
+
+// write posts every field of state that changed since it was last written as a guest
+// attribute and returns the joined errors of all failed posts.
+func (c *Client) write(ctx context.Context, state *inventory.InstanceInventory, url string) error {
+	return c.writeFields(ctx, state, url, nil)
 }
 
-func write(ctx context.Context, state *inventory.InstanceInventory, url string) {
+// writeFields works as write for the fields of state selected by include, all of them
+// when include is nil.
+func (c *Client) writeFields(ctx context.Context, state *inventory.InstanceInventory, url string, include func(name string) bool) error {
 	clog.Debugf(ctx, "Writing instance inventory to guest attributes.")
 
+	var errs []error
 	e := reflect.ValueOf(state).Elem()
 	t := e.Type()
 	for i := 0; i < e.NumField(); i++ {
+		if err := ctx.Err(); err != nil {
+			clog.Infof(ctx, "Stopped writing instance inventory to guest attributes: %v", err)
+			return errors.Join(append(errs, err)...)
+		}
+
 		f := e.Field(i)
-		u := fmt.Sprintf("%s/%s", url, t.Field(i).Name)
+		name := t.Field(i).Name
+		if include != nil && !include(name) {
+			continue
+		}
+		u := fmt.Sprintf("%s/%s", url, name)
 		switch f.Kind() {
 		case reflect.String:
-			clog.Debugf(ctx, "postAttribute %s: %+v", u, f)
-			if err := attributes.PostAttribute(u, strings.NewReader(f.String())); err != nil {
-				clog.Errorf(ctx, "postAttribute error: %v", err)
+			compressed := c.compressStringThreshold > 0 && f.Len() > c.compressStringThreshold
+			if compressed {
+				u += compressedAttributeSuffix
+			}
+			digest := attributeDigest([]byte(f.String()))
+			if c.attributeUnchanged(u, digest) {
+				continue
+			}
+			_, written := c.writtenAttributes[u]
+			var err error
+			if compressed {
+				err = c.postAttributeCompressed(ctx, name, u, f.String())
+			} else {
+				err = c.postAttribute(ctx, name, u, f.String())
+			}
+			c.recordAttributeWrite(u, digest, err)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				continue
+			}
+			// Drop the attribute the field was written to before it crossed the threshold,
+			// readers of it would keep seeing its stale value.
+			if compressed && !written {
+				c.deleteAttribute(ctx, name, strings.TrimSuffix(u, compressedAttributeSuffix))
+			} else if _, ok := c.writtenAttributes[u+compressedAttributeSuffix]; !compressed && ok {
+				c.deleteAttribute(ctx, name, u+compressedAttributeSuffix)
+			}
+		case reflect.Bool:
+			value := strconv.FormatBool(f.Bool())
+			digest := attributeDigest([]byte(value))
+			if c.attributeUnchanged(u, digest) {
+				continue
+			}
+			err := c.postAttribute(ctx, name, u, value)
+			c.recordAttributeWrite(u, digest, err)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			}
+		case reflect.Slice, reflect.Map:
+			// Slices and maps, e.g. NetworkInterfaces and SourceTimestamps, are written as
+			// JSON, nil when not collected.
+			if f.IsNil() {
+				continue
+			}
+			b, err := json.Marshal(f.Interface())
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				continue
+			}
+			digest := attributeDigest(b)
+			if c.attributeUnchanged(u, digest) {
+				continue
+			}
+			err = c.postAttribute(ctx, name, u, string(b))
+			c.recordAttributeWrite(u, digest, err)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
 			}
 		case reflect.Ptr:
 			switch reflect.Indirect(f).Kind() {
 			case reflect.Struct:
-				clog.Debugf(ctx, "postAttributeCompressed %s", u)
-				if err := attributes.PostAttributeCompressed(u, f.Interface()); err != nil {
-					clog.Errorf(ctx, "postAttributeCompressed error: %v", err)
+				// The value is posted as JSON, so its encoding identifies the content.
+				b, err := json.Marshal(f.Interface())
+				digest := ""
+				if err == nil {
+					digest = attributeDigest(b)
+				}
+				if c.attributeUnchanged(u, digest) {
+					continue
+				}
+				err = c.postAttributeCompressed(ctx, name, u, f.Interface())
+				c.recordAttributeWrite(u, digest, err)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", name, err))
 				}
 			}
 		}
 	}
+	return errors.Join(errs...)
+}
+
+func attributeDigest(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// attributeUnchanged reports whether digest matches the content last successfully
+// written to the guest attribute at url.
+func (c *Client) attributeUnchanged(url, digest string) bool {
+	return digest != "" && c.writtenAttributes[url] == digest
+}
+
+// recordAttributeWrite remembers digest as the content of the guest attribute at url,
+// the attribute is forgotten when the write failed so that it is posted again.
+func (c *Client) recordAttributeWrite(url, digest string, err error) {
+	if err != nil || digest == "" {
+		delete(c.writtenAttributes, url)
+		return
+	}
+	if c.writtenAttributes == nil {
+		c.writtenAttributes = make(map[string]string)
+	}
+	c.writtenAttributes[url] = digest
+}
+
+func (c *Client) postAttribute(ctx context.Context, field, url, value string) error {
+	if c.attributeWriteLogger == nil {
+		clog.Debugf(ctx, "postAttribute %s: %+v", url, value)
+	}
+	err := c.attributeWriter().PostAttribute(url, strings.NewReader(value))
+	if c.attributeWriteLogger != nil {
+		c.attributeWriteLogger.LogAttributeWrite(ctx, newAttributeWriteRecord(field, url, len(value), err))
+	} else if err != nil {
+		clog.Errorf(ctx, "postAttribute error: %v", err)
+	}
+	return err
+}
+
+func (c *Client) postAttributeCompressed(ctx context.Context, field, url string, value any) error {
+	if c.attributeWriteLogger == nil {
+		clog.Debugf(ctx, "postAttributeCompressed %s", url)
+	}
+	err := c.attributeWriter().PostAttributeCompressed(url, value, c.gzipLevel())
+	if c.attributeWriteLogger != nil {
+		// Size is reported for the uncompressed value, it is only computed when somebody consumes it.
+		size := 0
+		if b, jsonErr := json.Marshal(value); jsonErr == nil {
+			size = len(b)
+		}
+		c.attributeWriteLogger.LogAttributeWrite(ctx, newAttributeWriteRecord(field, url, size, err))
+	} else if err != nil {
+		clog.Errorf(ctx, "postAttributeCompressed error: %v", err)
+	}
+	return err
+}
+
+// deleteAttribute deletes the guest attribute field was written to at url, when the
+// AttributeWriter supports it. Failures are logged, the new attribute was written.
+func (c *Client) deleteAttribute(ctx context.Context, field, url string) {
+	d, ok := c.attributeWriter().(AttributeDeleter)
+	if !ok {
+		return
+	}
+	delete(c.writtenAttributes, url)
+	if err := d.DeleteAttribute(url); err != nil {
+		clog.Warningf(ctx, "Error deleting the replaced %s guest attribute %s: %v", field, url, err)
+	}
+}
+
+func (c *Client) gzipLevel() int {
+	if c.compressionLevel == nil {
+		return gzip.DefaultCompression
+	}
+	return *c.compressionLevel
+}
+
+// report works as reportWithFingerprint, computing the Fingerprint of state.
+func (c *Client) report(ctx context.Context, state *inventory.InstanceInventory) error {
+	fingerprint, err := state.Fingerprint()
+	if err != nil {
+		clog.Debugf(ctx, "Unable to compute the fingerprint of the collected inventory: %v", err)
+	}
+	return c.reportWithFingerprint(ctx, state, fingerprint)
 }
 
-func (c *Client) report(ctx context.Context, state *inventory.InstanceInventory) {
+// reportWithFingerprint reports state to the agent endpoint, stateFingerprint is its
+// Fingerprint, empty when it could not be computed.
+func (c *Client) reportWithFingerprint(ctx context.Context, state *inventory.InstanceInventory, stateFingerprint string) error {
+	if !c.breaker.allow() {
+		clog.Debugf(ctx, "Skipping reporting inventory, the agent endpoint failed %d consecutive times.", c.breaker.threshold)
+		return fmt.Errorf("skipped after %d consecutive failures", c.breaker.threshold)
+	}
 	clog.Debugf(ctx, "Reporting instance inventory to agent endpoint.")
-	inventory := formatInventory(ctx, state)
-	vmInventory := formatVMInventory(ctx, state)
+	metrics := c.metricsRecorder()
+	start := time.Now()
+	defer func() { metrics.ObserveReportDuration(time.Since(start)) }()
+
+	var err error
+	var inventory *agentendpointpb.Inventory
+	var vmInventory *agentendpointpb.VmInventory
+	var checksum string
+	// format builds the reported inventories. An inventory collected unchanged since it was
+	// last formatted is only formatted when the full inventory or the legacy API is needed,
+	// as the checksum of its VmInventory is already known.
+	format := func() error {
+		if vmInventory != nil {
+			return nil
+		}
+		if !c.disableLegacyInventory {
+			inventory = c.formatReportedInventory(ctx, state)
+		}
+		vmInventory = c.formatReportedVMInventory(ctx, state)
+		var err error
+		if checksum, err = computeStableFingerprintVMInventory(ctx, vmInventory, c.fingerprintOSInfoFields...); err != nil {
+			return fmt.Errorf("unable to compute hash, err: %w", err)
+		}
+		c.lastReportedStateFingerprint, c.lastReportedFingerprint = stateFingerprint, checksum
+		c.logReportSize(ctx, inventory, vmInventory)
+		return nil
+	}
+	if stateFingerprint != "" && stateFingerprint == c.lastReportedStateFingerprint {
+		clog.Debugf(ctx, "Inventory unchanged since it was last reported, reporting its checksum only.")
+		checksum = c.lastReportedFingerprint
+	} else if err := format(); err != nil {
+		return err
+	}
 
 	reportFull := false
 	var reportInventoryRes *agentendpointpb.ReportInventoryResponse
 	var reportVMInventoryRes *agentendpointpb.ReportVmInventoryResponse
-	var err error
+	// RetryAPICall does not preserve the status of the API error, keep the last one for metrics.
+	var lastCode codes.Code
+	// attempts counts the calls of f by the current retryAPICall, for the error logs.
+	var attempts int
 	f := func() error {
-		reportVMInventoryRes, err = c.reportVMInventory(ctx, vmInventory, reportFull)
-		if shouldFallbackToLegacyAPI(err) {
+		attempts++
+		if reportFull {
+			if err = format(); err != nil {
+				return err
+			}
+		}
+		reportVMInventoryRes, err = c.reportVMInventoryChecksum(ctx, checksum, vmInventory, reportFull)
+		if !c.disableLegacyInventory && shouldFallbackToLegacyAPI(err) {
+			if err = format(); err != nil {
+				return err
+			}
 			reportInventoryRes, err = c.reportInventory(ctx, inventory, reportFull)
 		}
 
 		if err != nil {
+			lastCode = status.Code(err)
 			return err
 		}
 		return nil
 	}
 
-	if err = retryutil.RetryAPICall(ctx, apiRetrySec*time.Second, "ReportInventory", f); err != nil {
-		clog.Errorf(ctx, "Error reporting inventory checksum: %v", err)
-		return
+	retryStart := time.Now()
+	if err = retryAPICall(ctx, apiRetrySec*time.Second, "ReportInventory", f); err != nil {
+		reportErrorf(ctx, "Error reporting inventory checksum, %d attempts in %s: %v", attempts, time.Since(retryStart).Round(time.Millisecond), err)
+		metrics.IncReportFailure(lastCode)
+		c.breaker.recordFailure()
+		return err
 	}
 
 	if shouldReportFullInventory(reportVMInventoryRes, reportInventoryRes) {
+		metrics.IncReportFullInventory()
 		reportFull = true
-		if err = retryutil.RetryAPICall(ctx, apiRetrySec*time.Second, "ReportInventory", f); err != nil {
-			clog.Errorf(ctx, "Error reporting full inventory: %v", err)
-			return
+		attempts, retryStart = 0, time.Now()
+		if err = retryAPICall(ctx, apiRetrySec*time.Second, "ReportInventory", f); err != nil {
+			reportErrorf(ctx, "Error reporting full inventory, %d attempts in %s: %v", attempts, time.Since(retryStart).Round(time.Millisecond), err)
+			metrics.IncReportFailure(lastCode)
+			c.breaker.recordFailure()
+			return err
+		}
+	}
+	metrics.IncReportSuccess()
+	c.breaker.recordSuccess()
+	return nil
+}
+
+// filterInventory returns a copy of state without the packages the Client is configured to exclude.
+func (c *Client) filterInventory(state *inventory.InstanceInventory) *inventory.InstanceInventory {
+	if state == nil || (len(c.excludedPackageTypes) == 0 && c.minZypperPatchSeverity == 0 && !c.securityUpdatesOnly) {
+		return state
+	}
+
+	filtered := *state
+	filtered.InstalledPackages = excludePackageTypes(state.InstalledPackages, c.excludedPackageTypes)
+	filtered.PackageUpdates = excludePackageTypes(state.PackageUpdates, c.excludedPackageTypes)
+	filtered.InstalledPackages = excludeZypperPatchesBelow(filtered.InstalledPackages, c.minZypperPatchSeverity)
+	filtered.PackageUpdates = excludeZypperPatchesBelow(filtered.PackageUpdates, c.minZypperPatchSeverity)
+	if c.securityUpdatesOnly {
+		filtered.PackageUpdates = securityUpdates(filtered.PackageUpdates)
+	}
+	return &filtered
+}
+
+// securityUpdates returns the updates of pkgs without the updates that their package
+// manager does not flag as security updates. Updates of package managers without
+// security metadata, e.g. zypper packages, GooGet and pip, are kept.
+func securityUpdates(pkgs *packages.Packages) *packages.Packages {
+	if pkgs == nil {
+		return nil
+	}
+	filterPkgInfos := func(pkgs []*packages.PkgInfo) []*packages.PkgInfo {
+		var filtered []*packages.PkgInfo
+		for _, pkg := range pkgs {
+			if pkg.Security {
+				filtered = append(filtered, pkg)
+			}
+		}
+		return filtered
+	}
+
+	filtered := *pkgs
+	filtered.Apt = filterPkgInfos(pkgs.Apt)
+	filtered.Deb = filterPkgInfos(pkgs.Deb)
+	filtered.Yum = filterPkgInfos(pkgs.Yum)
+	filtered.ZypperPatches, filtered.WUA = nil, nil
+	for _, patch := range pkgs.ZypperPatches {
+		if strings.EqualFold(patch.Category, "security") {
+			filtered.ZypperPatches = append(filtered.ZypperPatches, patch)
+		}
+	}
+	for _, update := range pkgs.WUA {
+		if update.IsSecurityUpdate() {
+			filtered.WUA = append(filtered.WUA, update)
+		}
+	}
+	return &filtered
+}
+
+// droppedPackages counts the installed and available packages dropped from a VmInventory
+// to stay within maxInventoryItems.
+type droppedPackages struct {
+	installed, available int
+}
+
+// retryAPICall retries the report calls, reportErrorf logs the reports that failed after
+// all the attempts.
+var (
+	retryAPICall = retryutil.RetryAPICall
+	reportErrorf = clog.Errorf
+)
+
+// reportSizeInfof logs the size of the formatted inventory payloads and reportSizeWarningf
+// the payloads larger than the configured limit.
+var (
+	reportSizeInfof    = clog.Infof
+	reportSizeWarningf = clog.Warningf
+)
+
+// PayloadSizes returns the encoded size in bytes of the legacy Inventory and of the
+// VmInventory sent to the agent endpoint, the size of a nil payload is 0.
+func PayloadSizes(inventory *agentendpointpb.Inventory, vmInventory *agentendpointpb.VmInventory) (legacy, vm int) {
+	return proto.Size(inventory), proto.Size(vmInventory)
+}
+
+func (c *Client) logReportSize(ctx context.Context, inventory *agentendpointpb.Inventory, vmInventory *agentendpointpb.VmInventory) {
+	legacy, vm := PayloadSizes(inventory, vmInventory)
+	reportSizeInfof(ctx, "Inventory payload size: VmInventory %d bytes, legacy Inventory %d bytes.", vm, legacy)
+	if c.reportSizeWarning > 0 && max(legacy, vm) > c.reportSizeWarning {
+		reportSizeWarningf(ctx, "Inventory payload of %d bytes exceeds the limit of %d bytes.", max(legacy, vm), c.reportSizeWarning)
+	}
+}
+
+// truncationWarningf logs the packages dropped when truncating the inventory.
+var truncationWarningf = clog.Warningf
+
+// sanitizationWarningf logs, once per formatted collection, that invalid UTF-8 was
+// replaced in metadata values.
+var sanitizationWarningf = clog.Warningf
+
+// formatLegacyInventory builds the Inventory reported to the legacy ReportInventory API.
+var formatLegacyInventory = formatInventory
+
+// formatReportedInventory builds the Inventory the Client reports to the legacy
+// ReportInventory API for the already filtered state: names are normalized, then
+// packages and versions are capped and the packages redacted, like the VmInventory.
+func (c *Client) formatReportedInventory(ctx context.Context, state *inventory.InstanceInventory) *agentendpointpb.Inventory {
+	inventory := c.capInventory(ctx, c.normalizeLegacyNames(formatLegacyInventory(ctx, state)))
+	return c.redactLegacyMetadata(c.capLegacyVersions(ctx, inventory))
+}
+
+// formatReportedVMInventory builds the VmInventory the Client reports for the already
+// filtered state: names are normalized, then packages, versions and metadata are capped
+// and redacted.
+func (c *Client) formatReportedVMInventory(ctx context.Context, state *inventory.InstanceInventory) *agentendpointpb.VmInventory {
+	vmInventory, dropped := c.capVMInventory(ctx, c.normalizeNames(formatVMInventory(ctx, state)))
+	return c.withReportInfo(ctx, state, c.capMetadataFields(c.redactMetadata(c.capVersions(ctx, vmInventory))), dropped)
+}
+
+// newClient returns a Client configured by opts without a connection to the agent endpoint.
+func newClient(opts []ClientOption) *Client {
+	c := &Client{maxVersionLength: defaultMaxVersionLength}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// nonPackageTypes are the VmInventory item types that describe the configuration or the
+// state of the instance rather than installed software. They are not counted against
+// maxInventoryItems and never dropped.
+var nonPackageTypes = map[string]bool{
+	"service":           true,
+	"windows-service":   true,
+	"firmware":          true,
+	"listening-port":    true,
+	"loaded-library":    true,
+	"certificate":       true,
+	"network-interface": true,
+	"repository":        true,
+	reportInfoType:      true,
+}
+
+// languagePackageTypes are the VmInventory item types dropped first when truncating.
+var languagePackageTypes = map[string]bool{
+	"gem":    true,
+	"pypi":   true,
+	"golang": true,
+	"npm":    true,
+	"conda":  true,
+	"cargo":  true,
+}
+
+// capVMInventory keeps at most maxInventoryItems installed and available packages of
+// vmInventory each, items of the nonPackageTypes are all kept.
+func (c *Client) capVMInventory(ctx context.Context, vmInventory *agentendpointpb.VmInventory) (*agentendpointpb.VmInventory, droppedPackages) {
+	var dropped droppedPackages
+	if c.maxInventoryItems <= 0 {
+		return vmInventory, dropped
+	}
+	isLanguagePackage := func(item *agentendpointpb.VmInventory_InventoryItem) bool {
+		return languagePackageTypes[item.GetType()]
+	}
+	for _, list := range []struct {
+		items   *[]*agentendpointpb.VmInventory_InventoryItem
+		dropped *int
+	}{
+		{&vmInventory.InstalledPackages, &dropped.installed},
+		{&vmInventory.AvailablePackages, &dropped.available},
+	} {
+		var pkgs []*agentendpointpb.VmInventory_InventoryItem
+		for _, item := range *list.items {
+			if !nonPackageTypes[item.GetType()] {
+				pkgs = append(pkgs, item)
+			}
+		}
+		kept, n := capItems(pkgs, c.maxInventoryItems, isLanguagePackage)
+		if n == 0 {
+			continue
+		}
+		truncationWarningf(ctx, "Inventory has %d packages, dropped %d to stay within the limit of %d.", len(pkgs), n, c.maxInventoryItems)
+		keep := make(map[*agentendpointpb.VmInventory_InventoryItem]bool, len(kept))
+		for _, item := range kept {
+			keep[item] = true
+		}
+		items := make([]*agentendpointpb.VmInventory_InventoryItem, 0, len(*list.items)-n)
+		for _, item := range *list.items {
+			if nonPackageTypes[item.GetType()] || keep[item] {
+				items = append(items, item)
+			}
+		}
+		*list.items = items
+		*list.dropped = n
+	}
+	return vmInventory, dropped
+}
+
+func (c *Client) capInventory(ctx context.Context, inventory *agentendpointpb.Inventory) *agentendpointpb.Inventory {
+	if c.maxInventoryItems <= 0 {
+		return inventory
+	}
+	// The legacy inventory does not report language packages.
+	isLanguagePackage := func(*agentendpointpb.Inventory_SoftwarePackage) bool { return false }
+	for _, pkgs := range []*[]*agentendpointpb.Inventory_SoftwarePackage{&inventory.InstalledPackages, &inventory.AvailablePackages} {
+		kept, dropped := capItems(*pkgs, c.maxInventoryItems, isLanguagePackage)
+		if dropped == 0 {
+			continue
+		}
+		truncationWarningf(ctx, "Legacy inventory has %d packages, dropped %d to stay within the limit of %d.", len(*pkgs), dropped, c.maxInventoryItems)
+		*pkgs = kept
+	}
+	return inventory
+}
+
+// legacyPackage exposes the fields of a legacy Inventory package the Client limits apply
+// to, with the VmInventory item type of the same package and its free-form fields keyed
+// like the metadata of that item. name and version are nil for packages without them.
+type legacyPackage struct {
+	itemType      string
+	name, version *string
+	metadata      map[string]*string
+}
+
+func legacyPackageFields(pkg *agentendpointpb.Inventory_SoftwarePackage) legacyPackage {
+	versioned := func(itemType string, p *agentendpointpb.Inventory_VersionedPackage) legacyPackage {
+		l := legacyPackage{itemType: itemType, name: &p.PackageName, version: &p.Version}
+		if p.Source != nil {
+			l.metadata = map[string]*string{"SourceName": &p.Source.Name, "SourceVersion": &p.Source.Version}
+		}
+		return l
+	}
+	switch d := pkg.GetDetails().(type) {
+	case *agentendpointpb.Inventory_SoftwarePackage_AptPackage:
+		return versioned("deb", d.AptPackage)
+	case *agentendpointpb.Inventory_SoftwarePackage_YumPackage:
+		return versioned("rpm", d.YumPackage)
+	case *agentendpointpb.Inventory_SoftwarePackage_ZypperPackage:
+		return versioned("rpm", d.ZypperPackage)
+	case *agentendpointpb.Inventory_SoftwarePackage_GoogetPackage:
+		return versioned("googet", d.GoogetPackage)
+	case *agentendpointpb.Inventory_SoftwarePackage_CosPackage:
+		return versioned("cos", d.CosPackage)
+	case *agentendpointpb.Inventory_SoftwarePackage_ZypperPatch:
+		return legacyPackage{itemType: "zypperPatch", name: &d.ZypperPatch.PatchName, metadata: map[string]*string{
+			"Category": &d.ZypperPatch.Category,
+			"Severity": &d.ZypperPatch.Severity,
+			"Summary":  &d.ZypperPatch.Summary,
+		}}
+	case *agentendpointpb.Inventory_SoftwarePackage_WuaPackage:
+		return legacyPackage{itemType: "wuaPackage", name: &d.WuaPackage.Title, version: &d.WuaPackage.UpdateId, metadata: map[string]*string{
+			"Description": &d.WuaPackage.Description,
+			"SupportUrl":  &d.WuaPackage.SupportUrl,
+		}}
+	case *agentendpointpb.Inventory_SoftwarePackage_QfePackage:
+		return legacyPackage{itemType: "qfePackage", name: &d.QfePackage.Caption, version: &d.QfePackage.HotFixId, metadata: map[string]*string{
+			"Description": &d.QfePackage.Description,
+		}}
+	case *agentendpointpb.Inventory_SoftwarePackage_WindowsApplication:
+		return legacyPackage{itemType: "windowsApplication", name: &d.WindowsApplication.DisplayName, version: &d.WindowsApplication.DisplayVersion, metadata: map[string]*string{
+			"Publisher": &d.WindowsApplication.Publisher,
+			"HelpLink":  &d.WindowsApplication.HelpLink,
+		}}
+	}
+	return legacyPackage{}
+}
+
+// legacyPackages returns the fields of the installed and available packages of inventory.
+func legacyPackages(inventory *agentendpointpb.Inventory) []legacyPackage {
+	var pkgs []legacyPackage
+	for _, list := range [][]*agentendpointpb.Inventory_SoftwarePackage{inventory.GetInstalledPackages(), inventory.GetAvailablePackages()} {
+		for _, pkg := range list {
+			pkgs = append(pkgs, legacyPackageFields(pkg))
+		}
+	}
+	return pkgs
+}
+
+// normalizeLegacyNames lowercases the names of the legacy packages of the
+// normalizedNameTypes, the legacy Inventory has no field to keep the original name in.
+func (c *Client) normalizeLegacyNames(inventory *agentendpointpb.Inventory) *agentendpointpb.Inventory {
+	if len(c.normalizedNameTypes) == 0 {
+		return inventory
+	}
+	for _, pkg := range legacyPackages(inventory) {
+		if pkg.name != nil && c.normalizedNameTypes[pkg.itemType] {
+			*pkg.name = strings.ToLower(*pkg.name)
+		}
+	}
+	return inventory
+}
+
+// capLegacyVersions truncates the versions of the legacy packages to maxVersionLength,
+// the legacy Inventory has no field to keep the full version in.
+func (c *Client) capLegacyVersions(ctx context.Context, inventory *agentendpointpb.Inventory) *agentendpointpb.Inventory {
+	if c.maxVersionLength <= 0 {
+		return inventory
+	}
+	var truncated int
+	for _, pkg := range legacyPackages(inventory) {
+		if pkg.version == nil {
+			continue
+		}
+		if version, ok := truncateVersion(*pkg.version, c.maxVersionLength); ok {
+			*pkg.version = version
+			truncated++
+		}
+	}
+	if truncated > 0 {
+		truncationWarningf(ctx, "Truncated the versions of %d legacy packages to the limit of %d characters.", truncated, c.maxVersionLength)
+	}
+	return inventory
+}
+
+// redactLegacyMetadata calls the MetadataRedactor with an item holding the name, type,
+// version and free-form fields of each legacy package, fields the redactor modifies or
+// deletes are modified or cleared in the package.
+func (c *Client) redactLegacyMetadata(inventory *agentendpointpb.Inventory) *agentendpointpb.Inventory {
+	if c.metadataRedactor == nil {
+		return inventory
+	}
+	for _, pkg := range legacyPackages(inventory) {
+		if pkg.itemType == "" {
+			continue
+		}
+		item := &agentendpointpb.VmInventory_InventoryItem{Type: pkg.itemType, Location: []string{}, Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{}}}
+		if pkg.name != nil {
+			item.Name = *pkg.name
+		}
+		if pkg.version != nil {
+			item.Version = *pkg.version
+		}
+		for k, v := range pkg.metadata {
+			item.Metadata.Fields[k] = structpb.NewStringValue(*v)
+		}
+		c.metadataRedactor(item)
+		for k, v := range pkg.metadata {
+			*v = item.GetMetadata().GetFields()[k].GetStringValue()
+		}
+	}
+	return inventory
+}
+
+const (
+	// defaultMaxVersionLength is well above the length of the versions of OS packages.
+	defaultMaxVersionLength = 256
+	// fullVersionMetadataKey holds the version of the items whose version was truncated
+	// to maxVersionLength.
+	fullVersionMetadataKey = "FullVersion"
+)
+
+func (c *Client) capVersions(ctx context.Context, vmInventory *agentendpointpb.VmInventory) *agentendpointpb.VmInventory {
+	if c.maxVersionLength <= 0 {
+		return vmInventory
+	}
+	var truncated int
+	for _, items := range [][]*agentendpointpb.VmInventory_InventoryItem{vmInventory.GetInstalledPackages(), vmInventory.GetAvailablePackages()} {
+		for _, item := range items {
+			version, ok := truncateVersion(item.GetVersion(), c.maxVersionLength)
+			if !ok {
+				continue
+			}
+			if item.Metadata == nil {
+				item.Metadata = &structpb.Struct{}
+			}
+			if item.Metadata.Fields == nil {
+				item.Metadata.Fields = map[string]*structpb.Value{}
+			}
+			item.Metadata.Fields[fullVersionMetadataKey] = structpb.NewStringValue(item.GetVersion())
+			item.Version = version
+			truncated++
+		}
+	}
+	if truncated > 0 {
+		truncationWarningf(ctx, "Truncated the versions of %d packages to the limit of %d characters.", truncated, c.maxVersionLength)
+	}
+	return vmInventory
+}
+
+// truncateVersion shortens version to max characters ending with an ellipsis, it reports
+// false when version is within the limit.
+func truncateVersion(version string, max int) (string, bool) {
+	runes := []rune(version)
+	if len(runes) <= max {
+		return version, false
+	}
+	if max <= 1 {
+		return string(runes[:max]), true
+	}
+	return string(runes[:max-1]) + "…", true
+}
+
+// MetadataRedactor is called with each installed and available package reported to the
+// agent endpoint, it may modify or delete the Location and Metadata fields of item. Legacy
+// Inventory packages are passed as an item holding their free-form fields as metadata,
+// e.g. Publisher and HelpLink of Windows applications.
+type MetadataRedactor func(item *agentendpointpb.VmInventory_InventoryItem)
+
+func (c *Client) redactMetadata(vmInventory *agentendpointpb.VmInventory) *agentendpointpb.VmInventory {
+	if c.metadataRedactor == nil {
+		return vmInventory
+	}
+	for _, items := range [][]*agentendpointpb.VmInventory_InventoryItem{vmInventory.GetInstalledPackages(), vmInventory.GetAvailablePackages()} {
+		for _, item := range items {
+			c.metadataRedactor(item)
+		}
+	}
+	return vmInventory
+}
+
+// defaultNormalizedNameTypes are the VmInventory item types of case-insensitive package
+// names, normalized by WithNormalizedNames when no types are given.
+var defaultNormalizedNameTypes = []string{"windowsApplication", "windows-service", "pypi"}
+
+// displayNameMetadataKey holds the original name of the items whose name was normalized.
+const displayNameMetadataKey = "DisplayName"
+
+func (c *Client) normalizeNames(vmInventory *agentendpointpb.VmInventory) *agentendpointpb.VmInventory {
+	if len(c.normalizedNameTypes) == 0 {
+		return vmInventory
+	}
+	for _, items := range [][]*agentendpointpb.VmInventory_InventoryItem{vmInventory.GetInstalledPackages(), vmInventory.GetAvailablePackages()} {
+		for _, item := range items {
+			if !c.normalizedNameTypes[item.GetType()] {
+				continue
+			}
+			name := strings.ToLower(item.GetName())
+			if name == item.GetName() {
+				continue
+			}
+			if item.Metadata == nil {
+				item.Metadata = &structpb.Struct{}
+			}
+			if item.Metadata.Fields == nil {
+				item.Metadata.Fields = map[string]*structpb.Value{}
+			}
+			item.Metadata.Fields[displayNameMetadataKey] = structpb.NewStringValue(item.GetName())
+			item.Name = name
+		}
+	}
+	return vmInventory
+}
+
+// reportInfoType is the type of the VmInventory item describing the report rather than
+// the instance, as the OsInfo has no field for it: its "Labels" metadata holds the Client
+// labels, "OSInfo" the collected OS information the OsInfo has no field for, and
+// "Truncated", "DroppedInstalledPackages" and "DroppedAvailablePackages" the packages
+// dropped to stay within maxInventoryItems. The item is added after the packages are
+// capped and redacted, so it is neither dropped nor passed to the MetadataRedactor.
+const reportInfoType = "inventory-report"
+
+// withReportInfo appends the reportInfoType item to the installed packages of vmInventory
+// when the Client has labels, state has OS information the OsInfo has no field for or
+// packages were dropped.
+func (c *Client) withReportInfo(ctx context.Context, state *inventory.InstanceInventory, vmInventory *agentendpointpb.VmInventory, dropped droppedPackages) *agentendpointpb.VmInventory {
+	fields := map[string]*structpb.Value{}
+	if len(c.labels) > 0 {
+		labels, sanitized := newMetadataStruct(c.labels)
+		if sanitized {
+			sanitizationWarningf(ctx, "Replaced invalid UTF-8 in the inventory labels.")
+		}
+		fields["Labels"] = structpb.NewStructValue(labels)
+	}
+	if osInfo, sanitized := osInfoMetadata(state); len(osInfo.GetFields()) > 0 {
+		if sanitized {
+			sanitizationWarningf(ctx, "Replaced invalid UTF-8 in the inventory OS information.")
+		}
+		fields["OSInfo"] = structpb.NewStructValue(osInfo)
+	}
+	if dropped != (droppedPackages{}) {
+		fields["Truncated"] = structpb.NewBoolValue(true)
+		fields["DroppedInstalledPackages"] = structpb.NewNumberValue(float64(dropped.installed))
+		fields["DroppedAvailablePackages"] = structpb.NewNumberValue(float64(dropped.available))
+	}
+	if len(fields) == 0 {
+		return vmInventory
+	}
+	vmInventory.InstalledPackages = append(vmInventory.InstalledPackages, &agentendpointpb.VmInventory_InventoryItem{
+		Name:     reportInfoType,
+		Type:     reportInfoType,
+		Location: []string{},
+		Metadata: &structpb.Struct{Fields: fields},
+	})
+	return vmInventory
+}
+
+// osInfoMetadata returns the OS information of state the OsInfo has no field for, unset
+// fields are left out. The bool reports whether invalid UTF-8 was replaced.
+func osInfoMetadata(state *inventory.InstanceInventory) (*structpb.Struct, bool) {
+	if state == nil {
+		return nil, false
+	}
+	values := map[string]string{}
+	for k, v := range map[string]string{
+		"RebootRequiredReason":   state.RebootRequiredReason,
+		"CPE":                    state.CPE,
+		"Variant":                state.Variant,
+		"CPEName":                state.CPEName,
+		"BuildID":                state.BuildID,
+		"InstalledKernelRelease": state.InstalledKernelRelease,
+		"Environment":            state.Environment,
+		"WSLDistro":              state.WSLDistro,
+	} {
+		if v != "" {
+			values[k] = v
+		}
+	}
+	osInfo, sanitized := newMetadataStruct(values)
+	for k, v := range map[string]bool{
+		"RebootRequired": state.RebootRequired,
+		"KernelMismatch": state.KernelMismatch,
+	} {
+		if v {
+			osInfo.Fields[k] = structpb.NewBoolValue(true)
+		}
+	}
+	return osInfo, sanitized
+}
+
+// metadataTruncatedMetadataKey is set on the items whose metadata was truncated to maxMetadataFields.
+const metadataTruncatedMetadataKey = "MetadataTruncated"
+
+func (c *Client) capMetadataFields(vmInventory *agentendpointpb.VmInventory) *agentendpointpb.VmInventory {
+	if c.maxMetadataFields <= 0 {
+		return vmInventory
+	}
+	for _, items := range [][]*agentendpointpb.VmInventory_InventoryItem{vmInventory.GetInstalledPackages(), vmInventory.GetAvailablePackages()} {
+		for _, item := range items {
+			trimMetadata(item.GetMetadata(), c.maxMetadataFields)
+		}
+	}
+	return vmInventory
+}
+
+// trimMetadata keeps the first max fields of metadata in key order and flags it
+// with metadataTruncatedMetadataKey when fields were dropped.
+func trimMetadata(metadata *structpb.Struct, max int) {
+	if len(metadata.GetFields()) <= max {
+		return
+	}
+	keys := make([]string, 0, len(metadata.Fields))
+	for k := range metadata.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys[max:] {
+		delete(metadata.Fields, k)
+	}
+	metadata.Fields[metadataTruncatedMetadataKey] = structpb.NewBoolValue(true)
+}
+
+// capItems keeps at most max items, preferring items that are not language packages,
+// and returns the kept items in their original order with the number of dropped items.
+func capItems[T any](items []T, max int, isLanguagePackage func(T) bool) ([]T, int) {
+	if len(items) <= max {
+		return items, 0
+	}
+	osBudget := max
+	languageBudget := 0
+	var osCount int
+	for _, item := range items {
+		if !isLanguagePackage(item) {
+			osCount++
+		}
+	}
+	if osCount < max {
+		languageBudget = max - osCount
+	}
+
+	kept := make([]T, 0, max)
+	for _, item := range items {
+		if isLanguagePackage(item) {
+			if languageBudget == 0 {
+				continue
+			}
+			languageBudget--
+		} else {
+			if osBudget == 0 {
+				continue
+			}
+			osBudget--
+		}
+		kept = append(kept, item)
+	}
+	return kept, len(items) - len(kept)
+}
+
+// appliedZypperPatchStatuses are the statuses of the zypper patches already installed.
+var appliedZypperPatchStatuses = map[string]bool{"applied": true, "installed": true}
+
+// withoutAppliedZypperPatches returns pkgs without the zypper patches that are already
+// applied, as some zypper queries list them with the available patches. Patches without
+// a status are kept.
+func withoutAppliedZypperPatches(pkgs *packages.Packages) *packages.Packages {
+	if pkgs == nil || !slices.ContainsFunc(pkgs.ZypperPatches, func(patch *packages.ZypperPatch) bool {
+		return appliedZypperPatchStatuses[patch.Status]
+	}) {
+		return pkgs
+	}
+	filtered := *pkgs
+	filtered.ZypperPatches = make([]*packages.ZypperPatch, 0, len(pkgs.ZypperPatches))
+	for _, patch := range pkgs.ZypperPatches {
+		if !appliedZypperPatchStatuses[patch.Status] {
+			filtered.ZypperPatches = append(filtered.ZypperPatches, patch)
+		}
+	}
+	return &filtered
+}
+
+// zypperPatchSeverities orders the zypper patch severities from the least to the most severe.
+var zypperPatchSeverities = []string{"low", "moderate", "important", "critical"}
+
+// zypperPatchSeverityRank returns the 1-based position of severity in zypperPatchSeverities,
+// 0 for unknown severities.
+func zypperPatchSeverityRank(severity string) int {
+	for i, s := range zypperPatchSeverities {
+		if strings.EqualFold(severity, s) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func excludeZypperPatchesBelow(pkgs *packages.Packages, minRank int) *packages.Packages {
+	if pkgs == nil || pkgs.ZypperPatches == nil || minRank == 0 {
+		return pkgs
+	}
+
+	filtered := *pkgs
+	filtered.ZypperPatches = make([]*packages.ZypperPatch, 0, len(pkgs.ZypperPatches))
+	for _, patch := range pkgs.ZypperPatches {
+		if rank := zypperPatchSeverityRank(patch.Severity); rank != 0 && rank < minRank {
+			continue
 		}
+		filtered.ZypperPatches = append(filtered.ZypperPatches, patch)
 	}
+	return &filtered
+}
+
+func excludePackageTypes(pkgs *packages.Packages, excluded map[string]bool) *packages.Packages {
+	if pkgs == nil {
+		return nil
+	}
+
+	filtered := *pkgs
+	filtered.Yum = excludePkgInfoTypes(pkgs.Yum, excluded)
+	filtered.Rpm = excludePkgInfoTypes(pkgs.Rpm, excluded)
+	filtered.Apt = excludePkgInfoTypes(pkgs.Apt, excluded)
+	filtered.Deb = excludePkgInfoTypes(pkgs.Deb, excluded)
+	filtered.Zypper = excludePkgInfoTypes(pkgs.Zypper, excluded)
+	filtered.COS = excludePkgInfoTypes(pkgs.COS, excluded)
+	filtered.Gem = excludePkgInfoTypes(pkgs.Gem, excluded)
+	filtered.Pip = excludePipTypes(pkgs.Pip, excluded)
+	filtered.GooGet = excludePkgInfoTypes(pkgs.GooGet, excluded)
+	filtered.KernelModules = excludePkgInfoTypes(pkgs.KernelModules, excluded)
+	if excluded["zypperPatch"] {
+		filtered.ZypperPatches = nil
+	}
+	if excluded["wuaPackage"] {
+		filtered.WUA = nil
+	}
+	if excluded["qfePackage"] {
+		filtered.QFE = nil
+	}
+	if excluded["windowsApplication"] {
+		filtered.WindowsApplication = nil
+	}
+	if excluded["golang"] {
+		filtered.GoModules = nil
+	}
+	if excluded["npm"] {
+		filtered.Npm = nil
+	}
+	if excluded["cargo"] {
+		filtered.Cargo = nil
+	}
+	if excluded["container-image"] {
+		filtered.ContainerImages = nil
+	}
+	if excluded["pkg"] {
+		filtered.Pkg = nil
+	}
+	if excluded["nix"] {
+		filtered.Nix = nil
+	}
+	if excluded["conda"] {
+		filtered.Conda = nil
+	}
+	if excluded["service"] {
+		filtered.SystemdUnits = nil
+	}
+	if excluded["windows-service"] {
+		filtered.WindowsServices = nil
+	}
+	if excluded["firmware"] {
+		filtered.Firmware = nil
+	}
+	if excluded["listening-port"] {
+		filtered.ListeningPorts = nil
+	}
+	if excluded["loaded-library"] {
+		filtered.LoadedLibraries = nil
+	}
+	if excluded["certificate"] {
+		filtered.Certificates = nil
+	}
+	if excluded["module"] {
+		filtered.EnvironmentModules = nil
+	}
+	if excluded["ide-extension"] {
+		filtered.IDEExtensions = nil
+	}
+	if excluded["repository"] {
+		filtered.GooGetRepositories = nil
+		filtered.Repositories = nil
+	}
+	return &filtered
+}
+
+func excludePkgInfoTypes(pkgs []*packages.PkgInfo, excluded map[string]bool) []*packages.PkgInfo {
+	if pkgs == nil {
+		return nil
+	}
+
+	filtered := make([]*packages.PkgInfo, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		if !excluded[pkg.Type] {
+			filtered = append(filtered, pkg)
+		}
+	}
+	return filtered
+}
+
+func excludePipTypes(pkgs []*packages.PipPackage, excluded map[string]bool) []*packages.PipPackage {
+	if pkgs == nil {
+		return nil
+	}
+
+	filtered := make([]*packages.PipPackage, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		if !excluded[pkg.Type] {
+			filtered = append(filtered, pkg)
+		}
+	}
+	return filtered
 }
 
 func shouldFallbackToLegacyAPI(err error) bool {
@@ -118,7 +1262,20 @@ func shouldReportFullInventory(reportVMInventoryRes *agentendpointpb.ReportVmInv
 	return reportVMInventoryRes.GetReportFullInventory() || reportInventoryRes.GetReportFullInventory()
 }
 
+// FormatVMInventory returns the VmInventory a Client created with opts reports for state,
+// after the same filtering, normalization, capping and redaction, with the Client labels,
+// the OS information the OsInfo has no field for and the truncation in its inventory-report
+// item.
+func FormatVMInventory(ctx context.Context, state *inventory.InstanceInventory, opts ...ClientOption) *agentendpointpb.VmInventory {
+	c := newClient(opts)
+	return c.formatReportedVMInventory(ctx, c.filterInventory(state))
+}
+
 func formatVMInventory(ctx context.Context, state *inventory.InstanceInventory) *agentendpointpb.VmInventory {
+	if state == nil {
+		clog.Warningf(ctx, "No instance inventory to format, reporting an empty VmInventory.")
+		return &agentendpointpb.VmInventory{OsInfo: &agentendpointpb.VmInventory_OsInfo{}}
+	}
 	osInfo := &agentendpointpb.VmInventory_OsInfo{
 		HostName:             state.Hostname,
 		LongName:             state.LongName,
@@ -131,7 +1288,8 @@ func formatVMInventory(ctx context.Context, state *inventory.InstanceInventory)
 	}
 
 	installedPackages := formatPkgsToInventoryItems(ctx, state.InstalledPackages)
-	availablePackages := formatPkgsToInventoryItems(ctx, state.PackageUpdates)
+	installedPackages = append(installedPackages, networkInterfaceToInventoryItem(state.NetworkInterfaces)...)
+	availablePackages := formatPkgsToInventoryItems(ctx, withoutAppliedZypperPatches(state.PackageUpdates))
 
 	return &agentendpointpb.VmInventory{OsInfo: osInfo, InstalledPackages: installedPackages, AvailablePackages: availablePackages}
 }
@@ -142,40 +1300,141 @@ func formatPkgsToInventoryItems(ctx context.Context, pkgs *packages.Packages) []
 		return softwarePackages
 	}
 
+	// keys maps each item to the identity of the package it was converted from.
+	keys := map[*agentendpointpb.VmInventory_InventoryItem]string{}
+	add := func(items []*agentendpointpb.VmInventory_InventoryItem, pkgs any) {
+		list := reflect.ValueOf(pkgs)
+		for i, item := range items {
+			pkg := list.Index(i).Interface()
+			keys[item] = fmt.Sprintf("%s|%s|%s", item.GetType(), inventory.PackageKey(pkg), inventory.PackageVersion(pkg))
+		}
+		softwarePackages = append(softwarePackages, items...)
+	}
+
 	if pkgs.Yum != nil {
-		softwarePackages = append(softwarePackages, yumToInventoryItem(pkgs.Yum)...)
+		add(yumToInventoryItem(pkgs.Yum), pkgs.Yum)
 	}
 	if pkgs.Rpm != nil {
-		softwarePackages = append(softwarePackages, rpmToInventoryItem(pkgs.Rpm)...)
+		add(rpmToInventoryItem(pkgs.Rpm), pkgs.Rpm)
 	}
 	if pkgs.Apt != nil {
-		softwarePackages = append(softwarePackages, aptToInventoryItem(pkgs.Apt)...)
+		add(aptToInventoryItem(pkgs.Apt), pkgs.Apt)
 	}
 	if pkgs.Deb != nil {
-		softwarePackages = append(softwarePackages, debToInventoryItem(pkgs.Deb)...)
+		add(debToInventoryItem(pkgs.Deb), pkgs.Deb)
 	}
 	if pkgs.Zypper != nil {
-		softwarePackages = append(softwarePackages, zypperToInventoryItem(pkgs.Zypper)...)
+		add(zypperToInventoryItem(pkgs.Zypper), pkgs.Zypper)
 	}
 	if pkgs.ZypperPatches != nil {
-		softwarePackages = append(softwarePackages, zypperPatchToInventoryItem(pkgs.ZypperPatches)...)
+		add(zypperPatchToInventoryItem(pkgs.ZypperPatches), pkgs.ZypperPatches)
 	}
 	if pkgs.COS != nil {
-		softwarePackages = append(softwarePackages, cosToInventoryItem(pkgs.COS)...)
+		add(cosToInventoryItem(pkgs.COS), pkgs.COS)
 	}
 	if pkgs.GooGet != nil {
-		softwarePackages = append(softwarePackages, googetToInventoryItem(pkgs.GooGet)...)
+		add(googetToInventoryItem(pkgs.GooGet), pkgs.GooGet)
 	}
 	if pkgs.WUA != nil {
-		softwarePackages = append(softwarePackages, wuaToInventoryItem(pkgs.WUA)...)
+		add(wuaToInventoryItem(ctx, pkgs.WUA), pkgs.WUA)
 	}
 	if pkgs.QFE != nil {
-		softwarePackages = append(softwarePackages, qfeToInventoryItem(ctx, pkgs.QFE)...)
+		add(qfeToInventoryItem(ctx, pkgs.QFE), pkgs.QFE)
 	}
 	if pkgs.WindowsApplication != nil {
-		softwarePackages = append(softwarePackages, windowsApplicationToInventoryItem(pkgs.WindowsApplication)...)
+		add(windowsApplicationToInventoryItem(pkgs.WindowsApplication), pkgs.WindowsApplication)
+	}
+	if pkgs.KernelModules != nil {
+		add(kernelModuleToInventoryItem(pkgs.KernelModules), pkgs.KernelModules)
+	}
+	if pkgs.Pip != nil {
+		add(pipToInventoryItem(pkgs.Pip), pkgs.Pip)
+	}
+	if pkgs.GoModules != nil {
+		add(goModuleToInventoryItem(pkgs.GoModules), pkgs.GoModules)
+	}
+	if pkgs.Npm != nil {
+		add(npmToInventoryItem(pkgs.Npm), pkgs.Npm)
+	}
+	if pkgs.Cargo != nil {
+		add(cargoToInventoryItem(pkgs.Cargo), pkgs.Cargo)
+	}
+	if pkgs.ContainerImages != nil {
+		add(containerImageToInventoryItem(ctx, pkgs.ContainerImages), pkgs.ContainerImages)
+	}
+	if pkgs.Pkg != nil {
+		add(freeBSDPackageToInventoryItem(pkgs.Pkg), pkgs.Pkg)
+	}
+	if pkgs.Nix != nil {
+		add(nixToInventoryItem(pkgs.Nix), pkgs.Nix)
+	}
+	if pkgs.Conda != nil {
+		add(condaToInventoryItem(pkgs.Conda), pkgs.Conda)
+	}
+	if pkgs.SystemdUnits != nil {
+		add(systemdUnitToInventoryItem(pkgs.SystemdUnits), pkgs.SystemdUnits)
+	}
+	if pkgs.WindowsServices != nil {
+		add(windowsServiceToInventoryItem(pkgs.WindowsServices), pkgs.WindowsServices)
+	}
+	if pkgs.Firmware != nil {
+		add(firmwareToInventoryItem(pkgs.Firmware), pkgs.Firmware)
+	}
+	if pkgs.ListeningPorts != nil {
+		add(listeningPortToInventoryItem(pkgs.ListeningPorts), pkgs.ListeningPorts)
+	}
+	if pkgs.LoadedLibraries != nil {
+		add(loadedLibraryToInventoryItem(pkgs.LoadedLibraries), pkgs.LoadedLibraries)
+	}
+	if pkgs.Certificates != nil {
+		add(certificateToInventoryItem(pkgs.Certificates), pkgs.Certificates)
+	}
+	if pkgs.IDEExtensions != nil {
+		add(ideExtensionToInventoryItem(pkgs.IDEExtensions), pkgs.IDEExtensions)
+	}
+	if pkgs.GooGetRepositories != nil {
+		add(googetRepositoryToInventoryItem(pkgs.GooGetRepositories), pkgs.GooGetRepositories)
+	}
+	if pkgs.Repositories != nil {
+		add(packageRepositoryToInventoryItem(pkgs.Repositories), pkgs.Repositories)
+	}
+	if pkgs.EnvironmentModules != nil {
+		add(environmentModuleToInventoryItem(pkgs.EnvironmentModules), pkgs.EnvironmentModules)
+	}
+	return dedupInventoryItems(dropUnnamedInventoryItems(ctx, softwarePackages), keys)
+}
+
+// dropUnnamedInventoryItems drops items without a name, e.g. from malformed rpm database
+// entries, preserving order.
+func dropUnnamedInventoryItems(ctx context.Context, items []*agentendpointpb.VmInventory_InventoryItem) []*agentendpointpb.VmInventory_InventoryItem {
+	named := items[:0]
+	for _, item := range items {
+		// QFE items are named after their optional support URL, the hot fix ID identifies them.
+		if item.GetName() != "" || (item.GetType() == "qfePackage" && item.GetVersion() != "") {
+			named = append(named, item)
+		}
+	}
+	if skipped := len(items) - len(named); skipped > 0 {
+		clog.Debugf(ctx, "Skipped %d inventory items without a name.", skipped)
+	}
+	return named
+}
+
+// dedupInventoryItems drops items that have the same identity as an earlier item, preserving order.
+// keys maps the items to their type and the inventory.PackageKey and PackageVersion of their package, so several
+// installed versions or architectures of a package, e.g. kernels or multilib rpms, are kept.
+func dedupInventoryItems(items []*agentendpointpb.VmInventory_InventoryItem, keys map[*agentendpointpb.VmInventory_InventoryItem]string) []*agentendpointpb.VmInventory_InventoryItem {
+	seen := make(map[string]bool, len(items))
+	deduped := items[:0]
+	for _, item := range items {
+		key := keys[item]
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		deduped = append(deduped, item)
 	}
-	return softwarePackages
+	return deduped
 }
 
 func aptToInventoryItem(packages []*packages.PkgInfo) []*agentendpointpb.VmInventory_InventoryItem {
@@ -187,10 +1446,10 @@ func aptToInventoryItem(packages []*packages.PkgInfo) []*agentendpointpb.VmInven
 			Version:  pkg.Version,
 			Purl:     pkg.Purl,
 			Location: []string{},
-			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+			Metadata: withPkgInfoMetadata(withDebSourceMetadata(&structpb.Struct{Fields: map[string]*structpb.Value{
 				"SourceName":    structpb.NewStringValue(pkg.Source.Name),
 				"SourceVersion": structpb.NewStringValue(pkg.Source.Version),
-			}},
+			}}, pkg), pkg),
 		}
 	}
 	return formattedApt
@@ -205,15 +1464,47 @@ func debToInventoryItem(packages []*packages.PkgInfo) []*agentendpointpb.VmInven
 			Version:  pkg.Version,
 			Purl:     pkg.Purl,
 			Location: []string{},
-			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+			Metadata: withPkgInfoMetadata(withDebSourceMetadata(&structpb.Struct{Fields: map[string]*structpb.Value{
 				"SourceName":    structpb.NewStringValue(pkg.Source.Name),
 				"SourceVersion": structpb.NewStringValue(pkg.Source.Version),
-			}},
+			}}, pkg), pkg),
 		}
 	}
 	return formattedDeb
 }
 
+// debSourceArchitecture is the architecture of Debian source packages.
+const debSourceArchitecture = "source"
+
+// withDebSourceMetadata adds the architecture and the purl of the source package a
+// deb package was built from, so binaries built from the same source can be grouped.
+func withDebSourceMetadata(metadata *structpb.Struct, pkg *packages.PkgInfo) *structpb.Struct {
+	if pkg.Source.Name == "" {
+		return metadata
+	}
+	metadata.Fields["SourceArchitecture"] = structpb.NewStringValue(debSourceArchitecture)
+	metadata.Fields["SourcePurl"] = structpb.NewStringValue(debSourcePurl(pkg))
+	return metadata
+}
+
+// debSourcePurl returns the purl of the source package of pkg, the namespace and distro
+// are taken from the purl of the binary package when it has one.
+func debSourcePurl(pkg *packages.PkgInfo) string {
+	version := pkg.Source.Version
+	if version == "" {
+		version = pkg.Version
+	}
+	var namespace string
+	qualifiersMap := map[string]string{"arch": debSourceArchitecture}
+	if binary, err := packageurl.FromString(pkg.Purl); err == nil {
+		namespace = binary.Namespace
+		if distro, ok := binary.Qualifiers.Map()["distro"]; ok {
+			qualifiersMap["distro"] = distro
+		}
+	}
+	return packages.BuildPurl(packageurl.TypeDebian, namespace, pkg.Source.Name, version, qualifiersMap)
+}
+
 func googetToInventoryItem(packages []*packages.PkgInfo) []*agentendpointpb.VmInventory_InventoryItem {
 	formattedGooGet := make([]*agentendpointpb.VmInventory_InventoryItem, len(packages))
 	for i, pkg := range packages {
@@ -223,7 +1514,7 @@ func googetToInventoryItem(packages []*packages.PkgInfo) []*agentendpointpb.VmIn
 			Version:  pkg.Version,
 			Purl:     pkg.Purl,
 			Location: []string{},
-			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{}},
+			Metadata: withPkgInfoMetadata(&structpb.Struct{Fields: map[string]*structpb.Value{}}, pkg),
 		}
 	}
 	return formattedGooGet
@@ -238,9 +1529,7 @@ func yumToInventoryItem(packages []*packages.PkgInfo) []*agentendpointpb.VmInven
 			Version:  pkg.Version,
 			Purl:     pkg.Purl,
 			Location: []string{},
-			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
-				"SourceRPM": structpb.NewStringValue(pkg.Source.Name),
-			}},
+			Metadata: rpmMetadata(pkg),
 		}
 	}
 	return formattedYum
@@ -255,29 +1544,106 @@ func zypperToInventoryItem(packages []*packages.PkgInfo) []*agentendpointpb.VmIn
 			Version:  pkg.Version,
 			Purl:     pkg.Purl,
 			Location: []string{},
-			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
-				"SourceRPM": structpb.NewStringValue(pkg.Source.Name),
-			}},
+			Metadata: rpmMetadata(pkg),
 		}
 	}
-	return formattedZypper
+	return formattedZypper
+}
+
+func rpmToInventoryItem(packages []*packages.PkgInfo) []*agentendpointpb.VmInventory_InventoryItem {
+	formattedRpm := make([]*agentendpointpb.VmInventory_InventoryItem, len(packages))
+	for i, pkg := range packages {
+		formattedRpm[i] = &agentendpointpb.VmInventory_InventoryItem{
+			Name:     pkg.Name,
+			Type:     pkg.Type,
+			Version:  pkg.Version,
+			Purl:     pkg.Purl,
+			Location: []string{},
+			Metadata: rpmMetadata(pkg),
+		}
+	}
+	return formattedRpm
+}
+
+// withPkgInfoMetadata adds the optional package details to metadata: the architecture
+// reported by the package manager when it differs from the normalized one, the
+// installed size, the vendor or maintainer and the digest when known.
+func withPkgInfoMetadata(metadata *structpb.Struct, pkg *packages.PkgInfo) *structpb.Struct {
+	if pkg.RawArch != "" && pkg.RawArch != pkg.Arch {
+		metadata.Fields["RawArchitecture"] = structpb.NewStringValue(pkg.RawArch)
+	}
+	if pkg.Size > 0 {
+		metadata.Fields["InstalledSize"] = structpb.NewNumberValue(float64(pkg.Size))
+	}
+	if pkg.Vendor != "" {
+		metadata.Fields["Vendor"] = structpb.NewStringValue(pkg.Vendor)
+	}
+	if pkg.Maintainer != "" {
+		metadata.Fields["Maintainer"] = structpb.NewStringValue(pkg.Maintainer)
+	}
+	if pkg.Digest != "" {
+		metadata.Fields["Digest"] = structpb.NewStringValue(pkg.Digest)
+	}
+	if pkg.Repository != "" {
+		metadata.Fields["Repository"] = structpb.NewStringValue(pkg.Repository)
+	}
+	if pkg.Explicit != nil {
+		metadata.Fields["Explicit"] = structpb.NewBoolValue(*pkg.Explicit)
+	}
+	if len(pkg.Files) > 0 {
+		files := make([]*structpb.Value, len(pkg.Files))
+		for i, f := range pkg.Files {
+			files[i] = structpb.NewStringValue(f)
+		}
+		metadata.Fields["Files"] = structpb.NewListValue(&structpb.ListValue{Values: files})
+		if pkg.FilesTruncated {
+			metadata.Fields["FilesTruncated"] = structpb.NewBoolValue(true)
+		}
+	}
+	return metadata
+}
+
+func rpmMetadata(pkg *packages.PkgInfo) *structpb.Struct {
+	metadata := &structpb.Struct{Fields: map[string]*structpb.Value{
+		"SourceRPM": structpb.NewStringValue(pkg.Source.Name),
+	}}
+	epoch, rest := splitRpmEpoch(pkg.Version)
+	if epoch != "" {
+		metadata.Fields["Epoch"] = structpb.NewStringValue(epoch)
+	}
+	if i := strings.LastIndex(rest, "-"); i > 0 && i < len(rest)-1 {
+		release := rest[i+1:]
+		metadata.Fields["Release"] = structpb.NewStringValue(release)
+		if distTag := rpmDistTag(release); distTag != "" {
+			metadata.Fields["DistTag"] = structpb.NewStringValue(distTag)
+		}
+	}
+	return withPkgInfoMetadata(metadata, pkg)
+}
+
+// rpmDistTagRE matches the distribution tag of an rpm release, e.g. el8_6 in 372.9.1.el8_6
+// or fc39 in 1.fc39.
+var rpmDistTagRE = regexp.MustCompile(`(?:^|[.+])([a-z]+[0-9]+(?:_[0-9]+)*)(?:[.+]|$)`)
+
+// rpmDistTag returns the distribution tag of release, empty when it has none.
+func rpmDistTag(release string) string {
+	m := rpmDistTagRE.FindStringSubmatch(release)
+	if m == nil {
+		return ""
+	}
+	return m[1]
 }
 
-func rpmToInventoryItem(packages []*packages.PkgInfo) []*agentendpointpb.VmInventory_InventoryItem {
-	formattedRpm := make([]*agentendpointpb.VmInventory_InventoryItem, len(packages))
-	for i, pkg := range packages {
-		formattedRpm[i] = &agentendpointpb.VmInventory_InventoryItem{
-			Name:     pkg.Name,
-			Type:     pkg.Type,
-			Version:  pkg.Version,
-			Purl:     pkg.Purl,
-			Location: []string{},
-			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
-				"SourceRPM": structpb.NewStringValue(pkg.Source.Name),
-			}},
-		}
+// splitRpmEpoch splits an rpm version of the form [epoch:]version-release into the epoch and the rest.
+func splitRpmEpoch(version string) (epoch, rest string) {
+	i := strings.Index(version, ":")
+	if i <= 0 {
+		return "", version
 	}
-	return formattedRpm
+	if _, err := strconv.ParseUint(version[:i], 10, 32); err != nil {
+		return "", version
+	}
+	return version[:i], version[i+1:]
 }
 
 func cosToInventoryItem(packages []*packages.PkgInfo) []*agentendpointpb.VmInventory_InventoryItem {
@@ -289,12 +1655,24 @@ func cosToInventoryItem(packages []*packages.PkgInfo) []*agentendpointpb.VmInven
 			Version:  pkg.Version,
 			Purl:     pkg.Purl,
 			Location: []string{},
-			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{}},
+			Metadata: withPkgInfoMetadata(cosMetadata(pkg), pkg),
 		}
 	}
 	return formattedCos
 }
 
+// cosMetadata returns the portage category and image build id of a COS package, when known.
+func cosMetadata(pkg *packages.PkgInfo) *structpb.Struct {
+	metadata := &structpb.Struct{Fields: map[string]*structpb.Value{}}
+	if pkg.Category != "" {
+		metadata.Fields["Category"] = structpb.NewStringValue(pkg.Category)
+	}
+	if pkg.BuildID != "" {
+		metadata.Fields["BuildID"] = structpb.NewStringValue(pkg.BuildID)
+	}
+	return metadata
+}
+
 func zypperPatchToInventoryItem(packages []*packages.ZypperPatch) []*agentendpointpb.VmInventory_InventoryItem {
 	zypperPatchFormattedPackages := make([]*agentendpointpb.VmInventory_InventoryItem, len(packages))
 	for i, pkg := range packages {
@@ -314,13 +1692,18 @@ func zypperPatchToInventoryItem(packages []*packages.ZypperPatch) []*agentendpoi
 	return zypperPatchFormattedPackages
 }
 
-func wuaToInventoryItem(packages []*packages.WUAPackage) []*agentendpointpb.VmInventory_InventoryItem {
+func wuaToInventoryItem(ctx context.Context, packages []*packages.WUAPackage) []*agentendpointpb.VmInventory_InventoryItem {
 	wuaFormattedPackages := make([]*agentendpointpb.VmInventory_InventoryItem, len(packages))
+	var sanitized bool
 	for i, pkg := range packages {
-		categoriesList := formatToCategoriesList(pkg.CategoryIDs, pkg.Categories)
-		kbArticleIdsList := formatToStructList(pkg.KBArticleIDs)
-		moreInfoUrls := formatToStructList(pkg.MoreInfoURLs)
-		categoryIds := formatToStructList(pkg.CategoryIDs)
+		categoriesList, ok := formatToCategoriesList(wuaCategoryNames(ctx, pkg))
+		sanitized = sanitized || ok
+		kbArticleIdsList, ok := formatToStructList(dedupStrings(pkg.KBArticleIDs))
+		sanitized = sanitized || ok
+		moreInfoUrls, ok := formatToStructList(dedupStrings(pkg.MoreInfoURLs))
+		sanitized = sanitized || ok
+		categoryIds, ok := formatToStructList(pkg.CategoryIDs)
+		sanitized = sanitized || ok
 		wuaFormattedPackages[i] = &agentendpointpb.VmInventory_InventoryItem{
 			Name:     pkg.Title,
 			Type:     "wuaPackage",
@@ -336,16 +1719,28 @@ func wuaToInventoryItem(packages []*packages.WUAPackage) []*agentendpointpb.VmIn
 				"RevisionNumber":           structpb.NewNumberValue(float64(pkg.RevisionNumber)),
 				"LastDeploymentChangeTime": structpb.NewStringValue(pkg.LastDeploymentChangeTime.UTC().Format(dateTimeFormat)),
 				"SupportUrl":               structpb.NewStringValue(pkg.SupportURL),
+				"MsrcSeverity":             structpb.NewStringValue(pkg.MsrcSeverity),
+				"IsSecurityUpdate":         structpb.NewBoolValue(pkg.IsSecurityUpdate()),
 			}},
 		}
+		if pkg.MaxDownloadSize > 0 {
+			wuaFormattedPackages[i].Metadata.Fields["MaxDownloadSize"] = structpb.NewNumberValue(float64(pkg.MaxDownloadSize))
+		}
+		if pkg.RebootRequired != nil {
+			wuaFormattedPackages[i].Metadata.Fields["RebootRequired"] = structpb.NewBoolValue(*pkg.RebootRequired)
+		}
+	}
+	if sanitized {
+		sanitizationWarningf(ctx, "Replaced invalid UTF-8 in the categories, KB articles or URLs of WUA updates.")
 	}
 	return wuaFormattedPackages
 }
 
 func qfeToInventoryItem(ctx context.Context, packages []*packages.QFEPackage) []*agentendpointpb.VmInventory_InventoryItem {
 	qfeFormattedPackages := make([]*agentendpointpb.VmInventory_InventoryItem, len(packages))
+	locale := qfeDateLocale()
 	for i, pkg := range packages {
-		t, err := parseQFEDate(ctx, pkg.InstalledOn)
+		t, ambiguous, err := parseQFEDateInLocale(pkg.InstalledOn, locale)
 		if err != nil {
 			clog.Warningf(ctx, "Error parsing QFE InstalledOn date: %v", err)
 		}
@@ -361,6 +1756,10 @@ func qfeToInventoryItem(ctx context.Context, packages []*packages.QFEPackage) []
 				"InstalledOn": structpb.NewStringValue(installedOn),
 			}},
 		}
+		// Keep the raw date of guessed and unparsable dates for audit.
+		if ambiguous || err != nil {
+			qfeFormattedPackages[i].Metadata.Fields["InstalledOnRaw"] = structpb.NewStringValue(pkg.InstalledOn)
+		}
 	}
 	return qfeFormattedPackages
 }
@@ -368,53 +1767,536 @@ func qfeToInventoryItem(ctx context.Context, packages []*packages.QFEPackage) []
 func windowsApplicationToInventoryItem(packages []*packages.WindowsApplication) []*agentendpointpb.VmInventory_InventoryItem {
 	windowsApplicationFormattedPackages := make([]*agentendpointpb.VmInventory_InventoryItem, len(packages))
 	for i, pkg := range packages {
+		metadata := map[string]*structpb.Value{
+			"Publisher":   structpb.NewStringValue(pkg.Publisher),
+			"InstallDate": structpb.NewStringValue(pkg.InstallDate.UTC().Format(dateTimeFormat)),
+			"HelpLink":    structpb.NewStringValue(pkg.HelpLink),
+		}
+		if pkg.InstallSource != "" {
+			metadata["InstallSource"] = structpb.NewStringValue(pkg.InstallSource)
+		}
+		if pkg.UninstallString != "" {
+			metadata["UninstallString"] = structpb.NewStringValue(pkg.UninstallString)
+		}
 		windowsApplicationFormattedPackages[i] = &agentendpointpb.VmInventory_InventoryItem{
 			Name:     pkg.DisplayName,
 			Type:     "windowsApplication",
 			Version:  pkg.DisplayVersion,
 			Purl:     pkg.Purl,
 			Location: []string{},
+			Metadata: &structpb.Struct{Fields: metadata},
+		}
+	}
+	return windowsApplicationFormattedPackages
+}
+
+func kernelModuleToInventoryItem(packages []*packages.PkgInfo) []*agentendpointpb.VmInventory_InventoryItem {
+	formattedModules := make([]*agentendpointpb.VmInventory_InventoryItem, len(packages))
+	for i, pkg := range packages {
+		formattedModules[i] = &agentendpointpb.VmInventory_InventoryItem{
+			Name:     pkg.Name,
+			Type:     pkg.Type,
+			Version:  pkg.Version,
+			Purl:     pkg.Purl,
+			Location: []string{},
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{}},
+		}
+	}
+	return formattedModules
+}
+
+func pipToInventoryItem(packages []*packages.PipPackage) []*agentendpointpb.VmInventory_InventoryItem {
+	formattedPip := make([]*agentendpointpb.VmInventory_InventoryItem, len(packages))
+	for i, pkg := range packages {
+		location := []string{}
+		if pkg.Location != "" {
+			location = []string{pkg.Location}
+		}
+		formattedPip[i] = &agentendpointpb.VmInventory_InventoryItem{
+			Name:     pkg.Name,
+			Type:     pkg.Type,
+			Version:  pkg.Version,
+			Purl:     pkg.Purl,
+			Location: location,
 			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
-				"Publisher":   structpb.NewStringValue(pkg.Publisher),
-				"InstallDate": structpb.NewStringValue(pkg.InstallDate.UTC().Format(dateTimeFormat)),
-				"HelpLink":    structpb.NewStringValue(pkg.HelpLink),
+				"Virtualenv": structpb.NewBoolValue(pkg.Virtualenv),
 			}},
 		}
+		if pkg.LocalProject != "" {
+			formattedPip[i].Metadata.Fields["LocalInstall"] = structpb.NewBoolValue(true)
+			formattedPip[i].Metadata.Fields["LocalProject"] = structpb.NewStringValue(pkg.LocalProject)
+			formattedPip[i].Metadata.Fields["Editable"] = structpb.NewBoolValue(pkg.Editable)
+		}
+		if pkg.License != "" {
+			formattedPip[i].Metadata.Fields["License"] = structpb.NewStringValue(pkg.License)
+		}
+		if pkg.Summary != "" {
+			formattedPip[i].Metadata.Fields["Summary"] = structpb.NewStringValue(pkg.Summary)
+		}
 	}
-	return windowsApplicationFormattedPackages
+	return formattedPip
+}
+
+func goModuleToInventoryItem(packages []*packages.GoModule) []*agentendpointpb.VmInventory_InventoryItem {
+	formattedModules := make([]*agentendpointpb.VmInventory_InventoryItem, len(packages))
+	for i, pkg := range packages {
+		location := pkg.Binaries
+		if location == nil {
+			location = []string{}
+		}
+		formattedModules[i] = &agentendpointpb.VmInventory_InventoryItem{
+			Name:     pkg.Path,
+			Type:     "golang",
+			Version:  pkg.Version,
+			Purl:     pkg.Purl,
+			Location: location,
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{}},
+		}
+	}
+	return formattedModules
+}
+
+func npmToInventoryItem(packages []*packages.NpmPackage) []*agentendpointpb.VmInventory_InventoryItem {
+	formattedNpm := make([]*agentendpointpb.VmInventory_InventoryItem, len(packages))
+	for i, pkg := range packages {
+		location := pkg.Location
+		if location == nil {
+			location = []string{}
+		}
+		formattedNpm[i] = &agentendpointpb.VmInventory_InventoryItem{
+			Name:     pkg.Name,
+			Type:     "npm",
+			Version:  pkg.Version,
+			Purl:     pkg.Purl,
+			Location: location,
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{}},
+		}
+	}
+	return formattedNpm
+}
+
+func cargoToInventoryItem(packages []*packages.CargoPackage) []*agentendpointpb.VmInventory_InventoryItem {
+	formattedCargo := make([]*agentendpointpb.VmInventory_InventoryItem, len(packages))
+	for i, pkg := range packages {
+		location := pkg.Location
+		if location == nil {
+			location = []string{}
+		}
+		formattedCargo[i] = &agentendpointpb.VmInventory_InventoryItem{
+			Name:     pkg.Name,
+			Type:     "cargo",
+			Version:  pkg.Version,
+			Purl:     pkg.Purl,
+			Location: location,
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"Source": structpb.NewStringValue(pkg.Source),
+			}},
+		}
+	}
+	return formattedCargo
+}
+
+func containerImageToInventoryItem(ctx context.Context, images []*packages.ContainerImage) []*agentendpointpb.VmInventory_InventoryItem {
+	formattedImages := make([]*agentendpointpb.VmInventory_InventoryItem, len(images))
+	var sanitized bool
+	for i, img := range images {
+		name, version := img.Reference()
+		repoTags, ok := formatToStructList(img.RepoTags)
+		sanitized = sanitized || ok
+		formattedImages[i] = &agentendpointpb.VmInventory_InventoryItem{
+			Name:     name,
+			Type:     "container-image",
+			Version:  version,
+			Purl:     img.Purl,
+			Location: []string{},
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"ImageID":  structpb.NewStringValue(img.ID),
+				"Digest":   structpb.NewStringValue(img.Digest),
+				"RepoTags": structpb.NewListValue(repoTags),
+				"Size":     structpb.NewNumberValue(float64(img.Size)),
+			}},
+		}
+	}
+	if sanitized {
+		sanitizationWarningf(ctx, "Replaced invalid UTF-8 in the tags of container images.")
+	}
+	return formattedImages
+}
+
+func freeBSDPackageToInventoryItem(packages []*packages.FreeBSDPackage) []*agentendpointpb.VmInventory_InventoryItem {
+	formattedPkgs := make([]*agentendpointpb.VmInventory_InventoryItem, len(packages))
+	for i, pkg := range packages {
+		formattedPkgs[i] = &agentendpointpb.VmInventory_InventoryItem{
+			Name:     pkg.Name,
+			Type:     "pkg",
+			Version:  pkg.Version,
+			Purl:     pkg.Purl,
+			Location: []string{},
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"Origin":   structpb.NewStringValue(pkg.Origin),
+				"Category": structpb.NewStringValue(pkg.Category()),
+			}},
+		}
+	}
+	return formattedPkgs
+}
+
+func systemdUnitToInventoryItem(units []*packages.SystemdUnit) []*agentendpointpb.VmInventory_InventoryItem {
+	formattedUnits := make([]*agentendpointpb.VmInventory_InventoryItem, len(units))
+	for i, unit := range units {
+		formattedUnits[i] = &agentendpointpb.VmInventory_InventoryItem{
+			Name:     unit.Name,
+			Type:     "service",
+			Location: []string{},
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"ActiveState":   structpb.NewStringValue(unit.ActiveState),
+				"SubState":      structpb.NewStringValue(unit.SubState),
+				"UnitFileState": structpb.NewStringValue(unit.UnitFileState),
+			}},
+		}
+	}
+	return formattedUnits
+}
+
+func windowsServiceToInventoryItem(services []*packages.WindowsService) []*agentendpointpb.VmInventory_InventoryItem {
+	formattedServices := make([]*agentendpointpb.VmInventory_InventoryItem, len(services))
+	for i, service := range services {
+		formattedServices[i] = &agentendpointpb.VmInventory_InventoryItem{
+			Name:     service.Name,
+			Type:     "windows-service",
+			Location: []string{},
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"DisplayName": structpb.NewStringValue(service.DisplayName),
+				"StartType":   structpb.NewStringValue(service.StartType),
+				"State":       structpb.NewStringValue(service.State),
+			}},
+		}
+	}
+	return formattedServices
+}
+
+func firmwareToInventoryItem(firmware []*packages.Firmware) []*agentendpointpb.VmInventory_InventoryItem {
+	formattedFirmware := make([]*agentendpointpb.VmInventory_InventoryItem, len(firmware))
+	for i, fw := range firmware {
+		metadata := map[string]*structpb.Value{}
+		if fw.ReleaseDate != "" {
+			metadata["ReleaseDate"] = structpb.NewStringValue(fw.ReleaseDate)
+		}
+		formattedFirmware[i] = &agentendpointpb.VmInventory_InventoryItem{
+			Name:     fw.Vendor,
+			Type:     "firmware",
+			Version:  fw.Version,
+			Location: []string{},
+			Metadata: &structpb.Struct{Fields: metadata},
+		}
+	}
+	return formattedFirmware
+}
+
+func listeningPortToInventoryItem(ports []*packages.ListeningPort) []*agentendpointpb.VmInventory_InventoryItem {
+	formattedPorts := make([]*agentendpointpb.VmInventory_InventoryItem, len(ports))
+	for i, port := range ports {
+		metadata := map[string]*structpb.Value{
+			"Protocol": structpb.NewStringValue(port.Protocol),
+			"Address":  structpb.NewStringValue(port.Address),
+			"Port":     structpb.NewNumberValue(float64(port.Port)),
+		}
+		if port.PID != 0 {
+			metadata["PID"] = structpb.NewNumberValue(float64(port.PID))
+		}
+		if port.Process != "" {
+			metadata["Process"] = structpb.NewStringValue(port.Process)
+		}
+		formattedPorts[i] = &agentendpointpb.VmInventory_InventoryItem{
+			Name:     port.Protocol + "/" + net.JoinHostPort(port.Address, strconv.Itoa(port.Port)),
+			Type:     "listening-port",
+			Location: []string{},
+			Metadata: &structpb.Struct{Fields: metadata},
+		}
+	}
+	return formattedPorts
+}
+
+func loadedLibraryToInventoryItem(libs []*packages.LoadedLibrary) []*agentendpointpb.VmInventory_InventoryItem {
+	formattedLibs := make([]*agentendpointpb.VmInventory_InventoryItem, len(libs))
+	for i, lib := range libs {
+		processes := make([]*structpb.Value, len(lib.Processes))
+		for j, p := range lib.Processes {
+			processes[j] = structpb.NewStringValue(p)
+		}
+		formattedLibs[i] = &agentendpointpb.VmInventory_InventoryItem{
+			Name:     lib.Path,
+			Type:     "loaded-library",
+			Version:  lib.Version,
+			Location: []string{},
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"Processes": structpb.NewListValue(&structpb.ListValue{Values: processes}),
+				"Deleted":   structpb.NewBoolValue(lib.Deleted),
+			}},
+		}
+	}
+	return formattedLibs
+}
+
+func environmentModuleToInventoryItem(modules []*packages.EnvironmentModule) []*agentendpointpb.VmInventory_InventoryItem {
+	formattedModules := make([]*agentendpointpb.VmInventory_InventoryItem, len(modules))
+	for i, module := range modules {
+		formattedModules[i] = &agentendpointpb.VmInventory_InventoryItem{
+			Name:     module.Name,
+			Type:     "module",
+			Version:  module.Version,
+			Location: []string{module.Path},
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"ModulePath": structpb.NewStringValue(module.ModulePath),
+				"Format":     structpb.NewStringValue(module.Format),
+				"Default":    structpb.NewBoolValue(module.Default),
+			}},
+		}
+	}
+	return formattedModules
+}
+
+func certificateToInventoryItem(certs []*packages.Certificate) []*agentendpointpb.VmInventory_InventoryItem {
+	formattedCerts := make([]*agentendpointpb.VmInventory_InventoryItem, len(certs))
+	for i, cert := range certs {
+		formattedCerts[i] = &agentendpointpb.VmInventory_InventoryItem{
+			Name:     cert.Subject,
+			Type:     "certificate",
+			Location: []string{cert.Location},
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"Subject":     structpb.NewStringValue(cert.Subject),
+				"Issuer":      structpb.NewStringValue(cert.Issuer),
+				"Fingerprint": structpb.NewStringValue(cert.Fingerprint),
+				"NotAfter":    structpb.NewStringValue(cert.NotAfter.UTC().Format(dateTimeFormat)),
+				"Expired":     structpb.NewBoolValue(cert.Expired),
+			}},
+		}
+	}
+	return formattedCerts
+}
+
+func networkInterfaceToInventoryItem(ifaces []inventory.NetworkInterface) []*agentendpointpb.VmInventory_InventoryItem {
+	stringList := func(values []string) *structpb.Value {
+		list := make([]*structpb.Value, len(values))
+		for i, v := range values {
+			list[i] = structpb.NewStringValue(v)
+		}
+		return structpb.NewListValue(&structpb.ListValue{Values: list})
+	}
+	formattedIfaces := make([]*agentendpointpb.VmInventory_InventoryItem, len(ifaces))
+	for i, iface := range ifaces {
+		metadata := map[string]*structpb.Value{
+			"IPv4": stringList(iface.IPv4),
+			"IPv6": stringList(iface.IPv6),
+		}
+		if iface.MAC != "" {
+			metadata["MAC"] = structpb.NewStringValue(iface.MAC)
+		}
+		formattedIfaces[i] = &agentendpointpb.VmInventory_InventoryItem{
+			Name:     iface.Name,
+			Type:     "network-interface",
+			Location: []string{},
+			Metadata: &structpb.Struct{Fields: metadata},
+		}
+	}
+	return formattedIfaces
+}
+
+func ideExtensionToInventoryItem(exts []*packages.IDEExtension) []*agentendpointpb.VmInventory_InventoryItem {
+	formattedExtensions := make([]*agentendpointpb.VmInventory_InventoryItem, len(exts))
+	for i, ext := range exts {
+		location := []string{}
+		if ext.Location != "" {
+			location = []string{ext.Location}
+		}
+		formattedExtensions[i] = &agentendpointpb.VmInventory_InventoryItem{
+			Name:     ext.Name,
+			Type:     "ide-extension",
+			Version:  ext.Version,
+			Location: location,
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"IDE":       structpb.NewStringValue(ext.IDE),
+				"ID":        structpb.NewStringValue(ext.ID),
+				"Publisher": structpb.NewStringValue(ext.Publisher),
+			}},
+		}
+	}
+	return formattedExtensions
+}
+
+func googetRepositoryToInventoryItem(repos []*packages.GooGetRepository) []*agentendpointpb.VmInventory_InventoryItem {
+	formattedRepos := make([]*agentendpointpb.VmInventory_InventoryItem, len(repos))
+	for i, repo := range repos {
+		location := []string{}
+		if repo.Location != "" {
+			location = []string{repo.Location}
+		}
+		formattedRepos[i] = &agentendpointpb.VmInventory_InventoryItem{
+			Name:     repo.Name,
+			Type:     "repository",
+			Location: location,
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"Manager":  structpb.NewStringValue("googet"),
+				"URL":      structpb.NewStringValue(repo.URL),
+				"UseOAuth": structpb.NewBoolValue(repo.UseOAuth),
+				"Priority": structpb.NewNumberValue(float64(repo.Priority)),
+			}},
+		}
+	}
+	return formattedRepos
+}
+
+func packageRepositoryToInventoryItem(repos []*packages.PackageRepository) []*agentendpointpb.VmInventory_InventoryItem {
+	formattedRepos := make([]*agentendpointpb.VmInventory_InventoryItem, len(repos))
+	for i, repo := range repos {
+		location := []string{}
+		if repo.Location != "" {
+			location = []string{repo.Location}
+		}
+		formattedRepos[i] = &agentendpointpb.VmInventory_InventoryItem{
+			Name:     repo.Name,
+			Type:     "repository",
+			Location: location,
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"Manager":  structpb.NewStringValue(repo.Manager),
+				"URL":      structpb.NewStringValue(repo.URL),
+				"Enabled":  structpb.NewBoolValue(repo.Enabled),
+				"GPGCheck": structpb.NewBoolValue(repo.GPGCheck),
+			}},
+		}
+	}
+	return formattedRepos
+}
+
+func nixToInventoryItem(packages []*packages.NixPackage) []*agentendpointpb.VmInventory_InventoryItem {
+	formattedNix := make([]*agentendpointpb.VmInventory_InventoryItem, len(packages))
+	for i, pkg := range packages {
+		location := pkg.Location
+		if location == nil {
+			location = []string{}
+		}
+		formattedNix[i] = &agentendpointpb.VmInventory_InventoryItem{
+			Name:     pkg.Name,
+			Type:     "nix",
+			Version:  pkg.Version,
+			Location: location,
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{}},
+		}
+	}
+	return formattedNix
+}
+
+func condaToInventoryItem(packages []*packages.CondaPackage) []*agentendpointpb.VmInventory_InventoryItem {
+	formattedConda := make([]*agentendpointpb.VmInventory_InventoryItem, len(packages))
+	for i, pkg := range packages {
+		location := pkg.Location
+		if location == nil {
+			location = []string{}
+		}
+		formattedConda[i] = &agentendpointpb.VmInventory_InventoryItem{
+			Name:     pkg.Name,
+			Type:     "conda",
+			Version:  pkg.Version,
+			Purl:     pkg.Purl,
+			Location: location,
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"Build": structpb.NewStringValue(pkg.Build),
+			}},
+		}
+	}
+	return formattedConda
 }
 
-func formatToStructList(stringArray []string) *structpb.ListValue {
-	var listAny []any
+// formatToStructList returns stringArray as a ListValue. Entries that are not valid UTF-8,
+// which structpb.NewList rejects, have their invalid bytes replaced instead of dropping
+// the list, the bool reports whether that happened.
+func formatToStructList(stringArray []string) (*structpb.ListValue, bool) {
+	structList := &structpb.ListValue{Values: make([]*structpb.Value, 0, len(stringArray))}
+	var sanitized bool
 	for _, entry := range stringArray {
-		listAny = append(listAny, entry)
+		if !utf8.ValidString(entry) {
+			entry, sanitized = strings.ToValidUTF8(entry, "\uFFFD"), true
+		}
+		structList.Values = append(structList.Values, structpb.NewStringValue(entry))
 	}
-	structList, err := structpb.NewList(listAny)
-	if err != nil {
+	return structList, sanitized
+}
+
+// dedupStrings returns ss without repeated entries, preserving the order of first occurrence.
+// Some WUA updates list the same KB article or URL more than once.
+func dedupStrings(ss []string) []string {
+	if len(ss) < 2 {
+		return ss
+	}
+	seen := make(map[string]bool, len(ss))
+	deduped := make([]string, 0, len(ss))
+	for _, s := range ss {
+		if seen[s] {
+			continue
+		}
+		seen[s] = true
+		deduped = append(deduped, s)
+	}
+	return deduped
+}
 
+// wuaCategoryNames returns the category ids of pkg with their names, names missing
+// from a flaky WUA response are left empty and names without an id are dropped.
+func wuaCategoryNames(ctx context.Context, pkg *packages.WUAPackage) ([]string, []string) {
+	if len(pkg.CategoryIDs) == len(pkg.Categories) {
+		return pkg.CategoryIDs, pkg.Categories
 	}
-	return structList
+	clog.Warningf(ctx, "WUA update %q has %d category ids and %d category names.", pkg.UpdateID, len(pkg.CategoryIDs), len(pkg.Categories))
+	names := make([]string, len(pkg.CategoryIDs))
+	copy(names, pkg.Categories)
+	return pkg.CategoryIDs, names
 }
 
-func formatToCategoriesList(categoryIds []string, categoryNames []string) *structpb.ListValue {
+// formatToCategoriesList returns the categories as a list of Id and Name structs, the
+// bool reports whether any entry had to be sanitized.
+func formatToCategoriesList(categoryIds []string, categoryNames []string) (*structpb.ListValue, bool) {
 	categoryList := &structpb.ListValue{}
+	var sanitized bool
 	for i := range categoryIds {
-		entry := map[string]any{
+		entry, ok := newMetadataStruct(map[string]string{
 			"Id":   categoryIds[i],
 			"Name": categoryNames[i],
-		}
+		})
+		sanitized = sanitized || ok
+		categoryList.Values = append(categoryList.Values, structpb.NewStructValue(entry))
+	}
+	return categoryList, sanitized
+}
 
-		v, err := structpb.NewValue(entry)
-		if err != nil {
-			continue
+// newMetadataStruct returns fields as a Struct. Values that are not valid UTF-8, which
+// structpb.NewStruct rejects, have their invalid bytes replaced instead of dropping the
+// metadata, the bool reports whether that happened. Metadata is built from typed package
+// fields, with times already formatted, so strings are the only values it can reject.
+func newMetadataStruct(fields map[string]string) (*structpb.Struct, bool) {
+	s := &structpb.Struct{Fields: make(map[string]*structpb.Value, len(fields))}
+	var sanitized bool
+	for k, v := range fields {
+		if !utf8.ValidString(v) {
+			v, sanitized = strings.ToValidUTF8(v, "\uFFFD"), true
 		}
-
-		categoryList.Values = append(categoryList.Values, v)
+		s.Fields[k] = structpb.NewStringValue(v)
 	}
-	return categoryList
+	return s, sanitized
+}
+
+// FormatInventory returns the legacy Inventory a Client created with opts reports for
+// state, after the same filtering, normalization, capping and redaction. The legacy
+// Inventory has no field for the Client labels nor for the truncation.
+func FormatInventory(ctx context.Context, state *inventory.InstanceInventory, opts ...ClientOption) *agentendpointpb.Inventory {
+	c := newClient(opts)
+	return c.formatReportedInventory(ctx, c.filterInventory(state))
 }
 
 func formatInventory(ctx context.Context, state *inventory.InstanceInventory) *agentendpointpb.Inventory {
+	if state == nil {
+		clog.Warningf(ctx, "No instance inventory to format, reporting an empty Inventory.")
+		return &agentendpointpb.Inventory{OsInfo: &agentendpointpb.Inventory_OsInfo{}}
+	}
 	osInfo := &agentendpointpb.Inventory_OsInfo{
 		Hostname:             state.Hostname,
 		LongName:             state.LongName,
@@ -426,7 +2308,7 @@ func formatInventory(ctx context.Context, state *inventory.InstanceInventory) *a
 		OsconfigAgentVersion: state.OSConfigAgentVersion,
 	}
 	installedPackages := formatPackages(ctx, state.InstalledPackages, state.ShortName)
-	availablePackages := formatPackages(ctx, state.PackageUpdates, state.ShortName)
+	availablePackages := formatPackages(ctx, withoutAppliedZypperPatches(state.PackageUpdates), state.ShortName)
 
 	return &agentendpointpb.Inventory{OsInfo: osInfo, InstalledPackages: installedPackages, AvailablePackages: availablePackages}
 }
@@ -443,7 +2325,7 @@ func formatPackages(ctx context.Context, pkgs *packages.Packages, shortName stri
 				Details: formatAptPackage(pkg),
 			}
 		}
-		softwarePackages = append(softwarePackages, temp...)
+		softwarePackages = append(softwarePackages, sortSoftwarePackages(temp)...)
 	}
 	if pkgs.Deb != nil {
 		temp := make([]*agentendpointpb.Inventory_SoftwarePackage, len(pkgs.Deb))
@@ -452,7 +2334,7 @@ func formatPackages(ctx context.Context, pkgs *packages.Packages, shortName stri
 				Details: formatAptPackage(pkg),
 			}
 		}
-		softwarePackages = append(softwarePackages, temp...)
+		softwarePackages = append(softwarePackages, sortSoftwarePackages(temp)...)
 	}
 	if pkgs.GooGet != nil {
 		temp := make([]*agentendpointpb.Inventory_SoftwarePackage, len(pkgs.GooGet))
@@ -461,7 +2343,7 @@ func formatPackages(ctx context.Context, pkgs *packages.Packages, shortName stri
 				Details: formatGooGetPackage(pkg),
 			}
 		}
-		softwarePackages = append(softwarePackages, temp...)
+		softwarePackages = append(softwarePackages, sortSoftwarePackages(temp)...)
 	}
 	if pkgs.Yum != nil {
 		temp := make([]*agentendpointpb.Inventory_SoftwarePackage, len(pkgs.Yum))
@@ -470,7 +2352,7 @@ func formatPackages(ctx context.Context, pkgs *packages.Packages, shortName stri
 				Details: formatYumPackage(pkg),
 			}
 		}
-		softwarePackages = append(softwarePackages, temp...)
+		softwarePackages = append(softwarePackages, sortSoftwarePackages(temp)...)
 	}
 	if pkgs.Zypper != nil {
 		temp := make([]*agentendpointpb.Inventory_SoftwarePackage, len(pkgs.Zypper))
@@ -479,24 +2361,25 @@ func formatPackages(ctx context.Context, pkgs *packages.Packages, shortName stri
 				Details: formatZypperPackage(pkg),
 			}
 		}
-		softwarePackages = append(softwarePackages, temp...)
+		softwarePackages = append(softwarePackages, sortSoftwarePackages(temp)...)
 	}
 	if pkgs.Rpm != nil {
 		temp := make([]*agentendpointpb.Inventory_SoftwarePackage, len(pkgs.Rpm))
-		if packages.YumExists || !packages.ZypperExists {
+		// The legacy inventory has no dnf packages, dnf is the successor of yum.
+		if pkgs.RPMBackend == packages.RPMBackendZypper {
 			for i, pkg := range pkgs.Rpm {
 				temp[i] = &agentendpointpb.Inventory_SoftwarePackage{
-					Details: formatYumPackage(pkg),
+					Details: formatZypperPackage(pkg),
 				}
 			}
 		} else {
 			for i, pkg := range pkgs.Rpm {
 				temp[i] = &agentendpointpb.Inventory_SoftwarePackage{
-					Details: formatZypperPackage(pkg),
+					Details: formatYumPackage(pkg),
 				}
 			}
 		}
-		softwarePackages = append(softwarePackages, temp...)
+		softwarePackages = append(softwarePackages, sortSoftwarePackages(temp)...)
 	}
 	if pkgs.ZypperPatches != nil {
 		temp := make([]*agentendpointpb.Inventory_SoftwarePackage, len(pkgs.ZypperPatches))
@@ -505,16 +2388,16 @@ func formatPackages(ctx context.Context, pkgs *packages.Packages, shortName stri
 				Details: formatZypperPatch(pkg),
 			}
 		}
-		softwarePackages = append(softwarePackages, temp...)
+		softwarePackages = append(softwarePackages, sortSoftwarePackages(temp)...)
 	}
 	if pkgs.WUA != nil {
 		temp := make([]*agentendpointpb.Inventory_SoftwarePackage, len(pkgs.WUA))
 		for i, pkg := range pkgs.WUA {
 			temp[i] = &agentendpointpb.Inventory_SoftwarePackage{
-				Details: formatWUAPackage(pkg),
+				Details: formatWUAPackage(ctx, pkg),
 			}
 		}
-		softwarePackages = append(softwarePackages, temp...)
+		softwarePackages = append(softwarePackages, sortSoftwarePackages(temp)...)
 	}
 	if pkgs.QFE != nil {
 		temp := make([]*agentendpointpb.Inventory_SoftwarePackage, len(pkgs.QFE))
@@ -523,7 +2406,7 @@ func formatPackages(ctx context.Context, pkgs *packages.Packages, shortName stri
 				Details: formatQFEPackage(ctx, pkg),
 			}
 		}
-		softwarePackages = append(softwarePackages, temp...)
+		softwarePackages = append(softwarePackages, sortSoftwarePackages(temp)...)
 	}
 	if pkgs.COS != nil {
 		temp := make([]*agentendpointpb.Inventory_SoftwarePackage, len(pkgs.COS))
@@ -532,7 +2415,7 @@ func formatPackages(ctx context.Context, pkgs *packages.Packages, shortName stri
 				Details: formatCOSPackage(pkg),
 			}
 		}
-		softwarePackages = append(softwarePackages, temp...)
+		softwarePackages = append(softwarePackages, sortSoftwarePackages(temp)...)
 	}
 	if pkgs.WindowsApplication != nil {
 		temp := make([]*agentendpointpb.Inventory_SoftwarePackage, len(pkgs.WindowsApplication))
@@ -541,18 +2424,48 @@ func formatPackages(ctx context.Context, pkgs *packages.Packages, shortName stri
 				Details: formatWindowsApplication(pkg),
 			}
 		}
-		softwarePackages = append(softwarePackages, temp...)
+		softwarePackages = append(softwarePackages, sortSoftwarePackages(temp)...)
 	}
 	// Ignore Pip and Gem packages.
 
-	return softwarePackages
+	return dedupSoftwarePackages(dropUnnamedSoftwarePackages(ctx, softwarePackages))
+}
+
+// dropUnnamedSoftwarePackages drops packages without a name, e.g. from malformed rpm
+// database entries, preserving order.
+func dropUnnamedSoftwarePackages(ctx context.Context, pkgs []*agentendpointpb.Inventory_SoftwarePackage) []*agentendpointpb.Inventory_SoftwarePackage {
+	named := pkgs[:0]
+	for _, pkg := range pkgs {
+		if softwarePackageSortKey(pkg)[0] != "" {
+			named = append(named, pkg)
+		}
+	}
+	if skipped := len(pkgs) - len(named); skipped > 0 {
+		clog.Debugf(ctx, "Skipped %d software packages without a name.", skipped)
+	}
+	return named
+}
+
+// dedupSoftwarePackages drops packages that have the same identity as an earlier package, preserving order.
+func dedupSoftwarePackages(pkgs []*agentendpointpb.Inventory_SoftwarePackage) []*agentendpointpb.Inventory_SoftwarePackage {
+	seen := make(map[string]bool, len(pkgs))
+	deduped := pkgs[:0]
+	for _, pkg := range pkgs {
+		key := fingerprintForPackage(pkg)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		deduped = append(deduped, pkg)
+	}
+	return deduped
 }
 
 func formatAptPackage(pkg *packages.PkgInfo) *agentendpointpb.Inventory_SoftwarePackage_AptPackage {
 	fPkg := &agentendpointpb.Inventory_SoftwarePackage_AptPackage{
 		AptPackage: &agentendpointpb.Inventory_VersionedPackage{
 			PackageName:  pkg.Name,
-			Architecture: pkg.Arch,
+			Architecture: osinfo.NormalizeArchitecture(pkg.Arch),
 			Version:      pkg.Version,
 		},
 	}
@@ -572,7 +2485,7 @@ func formatCOSPackage(pkg *packages.PkgInfo) *agentendpointpb.Inventory_Software
 	return &agentendpointpb.Inventory_SoftwarePackage_CosPackage{
 		CosPackage: &agentendpointpb.Inventory_VersionedPackage{
 			PackageName:  pkg.Name,
-			Architecture: pkg.Arch,
+			Architecture: osinfo.NormalizeArchitecture(pkg.Arch),
 			Version:      pkg.Version,
 		}}
 }
@@ -581,7 +2494,7 @@ func formatGooGetPackage(pkg *packages.PkgInfo) *agentendpointpb.Inventory_Softw
 	return &agentendpointpb.Inventory_SoftwarePackage_GoogetPackage{
 		GoogetPackage: &agentendpointpb.Inventory_VersionedPackage{
 			PackageName:  pkg.Name,
-			Architecture: pkg.Arch,
+			Architecture: osinfo.NormalizeArchitecture(pkg.Arch),
 			Version:      pkg.Version,
 		}}
 }
@@ -590,7 +2503,7 @@ func formatYumPackage(pkg *packages.PkgInfo) *agentendpointpb.Inventory_Software
 	fPkg := &agentendpointpb.Inventory_SoftwarePackage_YumPackage{
 		YumPackage: &agentendpointpb.Inventory_VersionedPackage{
 			PackageName:  pkg.Name,
-			Architecture: pkg.Arch,
+			Architecture: osinfo.NormalizeArchitecture(pkg.Arch),
 			Version:      pkg.Version,
 		},
 	}
@@ -610,7 +2523,7 @@ func formatZypperPackage(pkg *packages.PkgInfo) *agentendpointpb.Inventory_Softw
 	return &agentendpointpb.Inventory_SoftwarePackage_ZypperPackage{
 		ZypperPackage: &agentendpointpb.Inventory_VersionedPackage{
 			PackageName:  pkg.Name,
-			Architecture: pkg.Arch,
+			Architecture: osinfo.NormalizeArchitecture(pkg.Arch),
 			Version:      pkg.Version}}
 }
 
@@ -624,12 +2537,56 @@ func formatZypperPatch(pkg *packages.ZypperPatch) *agentendpointpb.Inventory_Sof
 		}}
 }
 
-func formatWUAPackage(pkg *packages.WUAPackage) *agentendpointpb.Inventory_SoftwarePackage_WuaPackage {
+// sortSoftwarePackages sorts the packages of a single manager by name, architecture and
+// version so the inventory does not depend on the order the provider listed them in.
+func sortSoftwarePackages(pkgs []*agentendpointpb.Inventory_SoftwarePackage) []*agentendpointpb.Inventory_SoftwarePackage {
+	sort.SliceStable(pkgs, func(i, j int) bool {
+		a, b := softwarePackageSortKey(pkgs[i]), softwarePackageSortKey(pkgs[j])
+		for k := range a {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return false
+	})
+	return pkgs
+}
+
+// softwarePackageSortKey returns the name, architecture and version of pkg.
+func softwarePackageSortKey(pkg *agentendpointpb.Inventory_SoftwarePackage) [3]string {
+	versioned := func(p *agentendpointpb.Inventory_VersionedPackage) [3]string {
+		return [3]string{p.GetPackageName(), p.GetArchitecture(), p.GetVersion()}
+	}
+	switch {
+	case pkg.GetAptPackage() != nil:
+		return versioned(pkg.GetAptPackage())
+	case pkg.GetYumPackage() != nil:
+		return versioned(pkg.GetYumPackage())
+	case pkg.GetZypperPackage() != nil:
+		return versioned(pkg.GetZypperPackage())
+	case pkg.GetGoogetPackage() != nil:
+		return versioned(pkg.GetGoogetPackage())
+	case pkg.GetCosPackage() != nil:
+		return versioned(pkg.GetCosPackage())
+	case pkg.GetZypperPatch() != nil:
+		return [3]string{pkg.GetZypperPatch().GetPatchName(), "", ""}
+	case pkg.GetWuaPackage() != nil:
+		return [3]string{pkg.GetWuaPackage().GetTitle(), "", pkg.GetWuaPackage().GetUpdateId()}
+	case pkg.GetQfePackage() != nil:
+		return [3]string{pkg.GetQfePackage().GetHotFixId(), "", ""}
+	case pkg.GetWindowsApplication() != nil:
+		return [3]string{pkg.GetWindowsApplication().GetDisplayName(), "", pkg.GetWindowsApplication().GetDisplayVersion()}
+	}
+	return [3]string{}
+}
+
+func formatWUAPackage(ctx context.Context, pkg *packages.WUAPackage) *agentendpointpb.Inventory_SoftwarePackage_WuaPackage {
 	var categories []*agentendpointpb.Inventory_WindowsUpdatePackage_WindowsUpdateCategory
-	for idx, category := range pkg.Categories {
+	ids, names := wuaCategoryNames(ctx, pkg)
+	for idx, id := range ids {
 		categories = append(categories, &agentendpointpb.Inventory_WindowsUpdatePackage_WindowsUpdateCategory{
-			Id:   pkg.CategoryIDs[idx],
-			Name: category,
+			Id:   id,
+			Name: names[idx],
 		})
 	}
 
@@ -638,9 +2595,9 @@ func formatWUAPackage(pkg *packages.WUAPackage) *agentendpointpb.Inventory_Softw
 			Title:                    pkg.Title,
 			Description:              pkg.Description,
 			Categories:               categories,
-			KbArticleIds:             pkg.KBArticleIDs,
+			KbArticleIds:             dedupStrings(pkg.KBArticleIDs),
 			SupportUrl:               pkg.SupportURL,
-			MoreInfoUrls:             pkg.MoreInfoURLs,
+			MoreInfoUrls:             dedupStrings(pkg.MoreInfoURLs),
 			UpdateId:                 pkg.UpdateID,
 			RevisionNumber:           pkg.RevisionNumber,
 			LastDeploymentChangeTime: timestamppb.New(pkg.LastDeploymentChangeTime),
@@ -648,7 +2605,7 @@ func formatWUAPackage(pkg *packages.WUAPackage) *agentendpointpb.Inventory_Softw
 }
 
 func formatQFEPackage(ctx context.Context, pkg *packages.QFEPackage) *agentendpointpb.Inventory_SoftwarePackage_QfePackage {
-	installedTime, err := time.Parse("1/2/2006", pkg.InstalledOn)
+	installedTime, err := parseQFEDate(ctx, pkg.InstalledOn)
 	if err != nil {
 		clog.Warningf(ctx, "Error parsing QFE InstalledOn date: %v", err)
 	}
@@ -695,9 +2652,9 @@ func computeFingerprint(ctx context.Context, inventory *agentendpointpb.Inventor
 	return hex.EncodeToString(fingerprint.Sum(nil)), nil
 }
 
-func computeStableFingerprint(ctx context.Context, inventory *agentendpointpb.Inventory) (string, error) {
+func computeStableFingerprint(ctx context.Context, inventory *agentendpointpb.Inventory, osInfoFields ...string) (string, error) {
 	fingerprint := sha256.New()
-	b, err := proto.Marshal(inventory.GetOsInfo())
+	b, err := marshalOSInfoFields(inventory.GetOsInfo(), osInfoFields)
 	if err != nil {
 		return "", err
 	}
@@ -727,9 +2684,9 @@ func computeStableFingerprint(ctx context.Context, inventory *agentendpointpb.In
 	return hex.EncodeToString(fingerprint.Sum(nil)), nil
 }
 
-func computeStableFingerprintVMInventory(ctx context.Context, inventory *agentendpointpb.VmInventory) (string, error) {
+func computeStableFingerprintVMInventory(ctx context.Context, inventory *agentendpointpb.VmInventory, osInfoFields ...string) (string, error) {
 	fingerprint := sha256.New()
-	b, err := proto.Marshal(inventory.GetOsInfo())
+	b, err := marshalOSInfoFields(inventory.GetOsInfo(), osInfoFields)
 	if err != nil {
 		return "", err
 	}
@@ -759,6 +2716,30 @@ func computeStableFingerprintVMInventory(ctx context.Context, inventory *agenten
 	return hex.EncodeToString(fingerprint.Sum(nil)), nil
 }
 
+// marshalOSInfoFields marshals only the osInfo fields named in fields, by proto field name
+// e.g. "kernel_version", unknown names are ignored. All fields are marshaled when fields is empty.
+func marshalOSInfoFields(osInfo proto.Message, fields []string) ([]byte, error) {
+	if len(fields) == 0 {
+		return proto.Marshal(osInfo)
+	}
+	include := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		include[f] = true
+	}
+	m := proto.Clone(osInfo).ProtoReflect()
+	var excluded []protoreflect.FieldDescriptor
+	m.Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		if !include[string(fd.Name())] {
+			excluded = append(excluded, fd)
+		}
+		return true
+	})
+	for _, fd := range excluded {
+		m.Clear(fd)
+	}
+	return proto.Marshal(m.Interface())
+}
+
 func fingerprintForInventoryItem(pkg *agentendpointpb.VmInventory_InventoryItem) string {
 	return pkg.String()
 }
@@ -774,17 +2755,66 @@ func fingerprintForPackage(pkg *agentendpointpb.Inventory_SoftwarePackage) strin
 	return pkg.String()
 }
 
+// qfeDateLocale returns the locale QFE InstalledOn dates are formatted in, it decides
+// how dates that are valid both as month/day and day/month are read.
+var qfeDateLocale = osinfo.Locale
+
+// monthFirstRegions are the regions of locales writing short dates month first, dates of
+// all other locales are read day first.
+var monthFirstRegions = map[string]bool{"US": true, "PH": true, "BZ": true}
+
+// isMonthFirstLocale reports whether locale, a BCP 47 tag such as zh-Hans-CN, writes short
+// dates month first. Unknown locales are month first, like the InstalledOn dates of
+// Win32_QuickFixEngineering. Locales without a region are day first, except English which
+// defaults to en-US.
+func isMonthFirstLocale(locale string) bool {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return true
+	}
+	region, confidence := tag.Region()
+	if confidence != language.Exact {
+		base, _ := tag.Base()
+		return base.String() == "en"
+	}
+	return monthFirstRegions[region.String()]
+}
+
 func parseQFEDate(ctx context.Context, installedOn string) (time.Time, error) {
+	t, _, err := parseQFEDateInLocale(installedOn, qfeDateLocale())
+	return t, err
+}
+
+// parseQFEDateInLocale parses installedOn, reading slash separated dates that are valid
+// both month first and day first in the order of locale. The bool reports that the date
+// was ambiguous.
+func parseQFEDateInLocale(installedOn, locale string) (time.Time, bool, error) {
+	monthFirst, monthErr := time.Parse("1/2/2006", installedOn)
+	dayFirst, dayErr := time.Parse("2/1/2006", installedOn)
+	switch {
+	case monthErr == nil && dayErr == nil && !monthFirst.Equal(dayFirst):
+		if isMonthFirstLocale(locale) {
+			return monthFirst, true, nil
+		}
+		return dayFirst, true, nil
+	case monthErr == nil:
+		return monthFirst, false, nil
+	case dayErr == nil:
+		return dayFirst, false, nil
+	}
+
 	layouts := []string{
-		"1/2/2006",
 		"20060102",
 		"2006-01-02",
 		"02-Jan-2006",
+		"02.01.2006",
+		"2006/01/02",
+		time.RFC3339,
 	}
 	for _, layout := range layouts {
 		if t, err := time.Parse(layout, installedOn); err == nil {
-			return t, nil
+			return t, false, nil
 		}
 	}
-	return time.Time{}, fmt.Errorf("unable to parse date %q with any known layout", installedOn)
+	return time.Time{}, false, fmt.Errorf("unable to parse date %q with any known layout", installedOn)
 }
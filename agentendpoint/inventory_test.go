@@ -20,12 +20,16 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/rand"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -37,7 +41,9 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/googleapis/gax-go/v2"
 	"google.golang.org/grpc/codes"
+	grpcmetadata "google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/testing/protocmp"
 	"google.golang.org/protobuf/types/known/structpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -124,7 +130,7 @@ func generateInventoryState() *inventory.InstanceInventory {
 			Zypper:        []*packages.PkgInfo{{Name: "ZypperInstalledPkg", Arch: "Arch", Version: "Version", Type: "rpm", Purl: "pkg:rpm/ShortName/ZypperInstalledPkg@Version?arch=Arch"}},
 			ZypperPatches: []*packages.ZypperPatch{{Name: "ZypperInstalledPatch", Category: "Category", Severity: "Severity", Summary: "Summary", Purl: "pkg:generic/ShortName/ZypperInstalledPatch"}},
 			Gem:           []*packages.PkgInfo{{Name: "GemInstalledPkg", Arch: "Arch", Version: "Version", Purl: "pkg:gem/GemInstalledPkg@Version"}},
-			Pip:           []*packages.PkgInfo{{Name: "PipInstalledPkg", Arch: "Arch", Version: "Version", Purl: "pkg:pypi/PipInstalledPkg@Version"}},
+			Pip:           []*packages.PipPackage{{PkgInfo: packages.PkgInfo{Name: "PipInstalledPkg", Arch: "Arch", Version: "Version", Type: "pypi", Purl: "pkg:pypi/PipInstalledPkg@Version"}}},
 			GooGet:        []*packages.PkgInfo{{Name: "GooGetInstalledPkg", Arch: "Arch", Version: "Version", Type: "googet", Purl: "pkg:googet/ShortName/GooGetInstalledPkg@Version"}},
 			WUA: []*packages.WUAPackage{{
 				Title:                    "WUAInstalled",
@@ -147,7 +153,7 @@ func generateInventoryState() *inventory.InstanceInventory {
 			Zypper:        []*packages.PkgInfo{{Name: "ZypperPkgUpdate", Arch: "Arch", Version: "Version", Type: "rpm", Purl: "pkg:rpm/ShortName/ZypperPkgUpdate@Version?arch=Arch"}},
 			ZypperPatches: []*packages.ZypperPatch{{Name: "ZypperPatchUpdate", Category: "Category", Severity: "Severity", Summary: "Summary", Purl: "pkg:generic/ShortName/ZypperPatchUpdate"}},
 			Gem:           []*packages.PkgInfo{{Name: "GemPkgUpdate", Arch: "Arch", Version: "Version", Purl: "pkg:gem/GemPkgUpdate@Version"}},
-			Pip:           []*packages.PkgInfo{{Name: "PipPkgUpdate", Arch: "Arch", Version: "Version", Purl: "pkg:pypi/PipPkgUpdate@Version"}},
+			Pip:           []*packages.PipPackage{{PkgInfo: packages.PkgInfo{Name: "PipPkgUpdate", Arch: "Arch", Version: "Version", Type: "pypi", Purl: "pkg:pypi/PipPkgUpdate@Version"}}},
 			GooGet:        []*packages.PkgInfo{{Name: "GooGetPkgUpdate", Arch: "Arch", Version: "Version", Type: "googet", Purl: "pkg:googet/ShortName/GooGetPkgUpdate@Version"}},
 			WUA: []*packages.WUAPackage{{
 				Title:       "WUAUpdate",
@@ -190,13 +196,17 @@ func generateVMInventory() *agentendpointpb.VmInventory {
 				}}},
 			{Name: "AptInstalledPkg", Type: "deb", Version: "Version", Purl: "pkg:deb/ShortName/AptInstalledPkg@Version?arch=Arch",
 				Location: []string{}, Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
-					"SourceName":    structpb.NewStringValue("SourceName"),
-					"SourceVersion": structpb.NewStringValue("SourceVersion"),
+					"SourceName":         structpb.NewStringValue("SourceName"),
+					"SourceVersion":      structpb.NewStringValue("SourceVersion"),
+					"SourceArchitecture": structpb.NewStringValue("source"),
+					"SourcePurl":         structpb.NewStringValue("pkg:deb/shortname/SourceName@SourceVersion?arch=source"),
 				}}},
 			{Name: "DebInstalledPkg", Type: "deb", Version: "Version", Purl: "pkg:deb/ShortName/DebInstalledPkg@Version?arch=Arch",
 				Location: []string{}, Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
-					"SourceName":    structpb.NewStringValue("SourceName"),
-					"SourceVersion": structpb.NewStringValue("SourceVersion"),
+					"SourceName":         structpb.NewStringValue("SourceName"),
+					"SourceVersion":      structpb.NewStringValue("SourceVersion"),
+					"SourceArchitecture": structpb.NewStringValue("source"),
+					"SourcePurl":         structpb.NewStringValue("pkg:deb/shortname/SourceName@SourceVersion?arch=source"),
 				}}},
 			{Name: "ZypperInstalledPkg", Type: "rpm", Version: "Version", Purl: "pkg:rpm/ShortName/ZypperInstalledPkg@Version?arch=Arch",
 				Location: []string{}, Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
@@ -223,11 +233,18 @@ func generateVMInventory() *agentendpointpb.VmInventory {
 				"RevisionNumber":           structpb.NewNumberValue(1),
 				"LastDeploymentChangeTime": structpb.NewStringValue("2020-11-10 23:00:00 +0000 GMT"),
 				"SupportUrl":               structpb.NewStringValue("SupportURL"),
+				"MsrcSeverity":             structpb.NewStringValue(""),
+				"IsSecurityUpdate":         structpb.NewBoolValue(false),
 			}}},
 			{Name: "QFEInstalled", Type: "qfePackage", Version: "HotFixID", Purl: "pkg:generic/ShortName/QFEInstalled@HotFixID",
 				Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
-					"Description": structpb.NewStringValue("Description"),
-					"InstalledOn": structpb.NewStringValue("2020-09-01 00:00:00 +0000 GMT"),
+					"Description":    structpb.NewStringValue("Description"),
+					"InstalledOn":    structpb.NewStringValue("2020-09-01 00:00:00 +0000 GMT"),
+					"InstalledOnRaw": structpb.NewStringValue("9/1/2020"),
+				}}},
+			{Name: "PipInstalledPkg", Type: "pypi", Version: "Version", Purl: "pkg:pypi/PipInstalledPkg@Version",
+				Location: []string{}, Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+					"Virtualenv": structpb.NewBoolValue(false),
 				}}},
 		},
 		AvailablePackages: []*agentendpointpb.VmInventory_InventoryItem{
@@ -265,6 +282,12 @@ func generateVMInventory() *agentendpointpb.VmInventory {
 					"RevisionNumber":           structpb.NewNumberValue(1),
 					"LastDeploymentChangeTime": structpb.NewStringValue("0001-01-01 00:00:00 +0000 GMT"),
 					"SupportUrl":               structpb.NewStringValue("SupportURL"),
+					"MsrcSeverity":             structpb.NewStringValue(""),
+					"IsSecurityUpdate":         structpb.NewBoolValue(false),
+				}}},
+			{Name: "PipPkgUpdate", Type: "pypi", Version: "Version", Purl: "pkg:pypi/PipPkgUpdate@Version",
+				Location: []string{}, Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+					"Virtualenv": structpb.NewBoolValue(false),
 				}}},
 		},
 	}
@@ -446,13 +469,23 @@ func decodePackages(str string) *packages.Packages {
 
 func TestWrite(t *testing.T) {
 	inv := &inventory.InstanceInventory{
-		Hostname:      "Hostname",
-		LongName:      "LongName",
-		ShortName:     "ShortName",
-		Architecture:  "Architecture",
-		KernelVersion: "KernelVersion",
-		KernelRelease: "KernelRelease",
-		Version:       "Version",
+		Hostname:               "Hostname",
+		LongName:               "LongName",
+		ShortName:              "ShortName",
+		Architecture:           "Architecture",
+		KernelVersion:          "KernelVersion",
+		KernelRelease:          "KernelRelease",
+		Variant:                "Variant",
+		CPEName:                "CPEName",
+		BuildID:                "BuildID",
+		CPE:                    "CPE",
+		RebootRequired:         true,
+		RebootRequiredReason:   "RebootRequiredReason",
+		InstalledKernelRelease: "InstalledKernelRelease",
+		KernelMismatch:         true,
+		Environment:            "Environment",
+		WSLDistro:              "WSLDistro",
+		Version:                "Version",
 		InstalledPackages: &packages.Packages{
 			Yum: []*packages.PkgInfo{{Name: "Name", Arch: "Arch", Version: "Version"}},
 			WUA: []*packages.WUAPackage{{Title: "Title"}},
@@ -466,15 +499,25 @@ func TestWrite(t *testing.T) {
 	}
 
 	want := map[string]bool{
-		"Hostname":             false,
-		"LongName":             false,
-		"ShortName":            false,
-		"Architecture":         false,
-		"KernelVersion":        false,
-		"Version":              false,
-		"InstalledPackages":    false,
-		"PackageUpdates":       false,
-		"OSConfigAgentVersion": false,
+		"Hostname":               false,
+		"LongName":               false,
+		"ShortName":              false,
+		"Architecture":           false,
+		"KernelVersion":          false,
+		"Variant":                false,
+		"CPEName":                false,
+		"BuildID":                false,
+		"CPE":                    false,
+		"RebootRequired":         false,
+		"RebootRequiredReason":   false,
+		"InstalledKernelRelease": false,
+		"KernelMismatch":         false,
+		"Environment":            false,
+		"WSLDistro":              false,
+		"Version":                false,
+		"InstalledPackages":      false,
+		"PackageUpdates":         false,
+		"OSConfigAgentVersion":   false,
 	}
 
 	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -516,6 +559,56 @@ func TestWrite(t *testing.T) {
 				t.Errorf("did not get expected KernelRelease, got: %q, want: %q", buf.String(), inv.KernelRelease)
 			}
 			want["KernelRelease"] = true
+		case "/Variant":
+			if buf.String() != inv.Variant {
+				t.Errorf("did not get expected Variant, got: %q, want: %q", buf.String(), inv.Variant)
+			}
+			want["Variant"] = true
+		case "/CPEName":
+			if buf.String() != inv.CPEName {
+				t.Errorf("did not get expected CPEName, got: %q, want: %q", buf.String(), inv.CPEName)
+			}
+			want["CPEName"] = true
+		case "/BuildID":
+			if buf.String() != inv.BuildID {
+				t.Errorf("did not get expected BuildID, got: %q, want: %q", buf.String(), inv.BuildID)
+			}
+			want["BuildID"] = true
+		case "/CPE":
+			if buf.String() != inv.CPE {
+				t.Errorf("did not get expected CPE, got: %q, want: %q", buf.String(), inv.CPE)
+			}
+			want["CPE"] = true
+		case "/RebootRequired":
+			if buf.String() != "true" {
+				t.Errorf("did not get expected RebootRequired, got: %q, want: %q", buf.String(), "true")
+			}
+			want["RebootRequired"] = true
+		case "/RebootRequiredReason":
+			if buf.String() != inv.RebootRequiredReason {
+				t.Errorf("did not get expected RebootRequiredReason, got: %q, want: %q", buf.String(), inv.RebootRequiredReason)
+			}
+			want["RebootRequiredReason"] = true
+		case "/InstalledKernelRelease":
+			if buf.String() != inv.InstalledKernelRelease {
+				t.Errorf("did not get expected InstalledKernelRelease, got: %q, want: %q", buf.String(), inv.InstalledKernelRelease)
+			}
+			want["InstalledKernelRelease"] = true
+		case "/KernelMismatch":
+			if buf.String() != "true" {
+				t.Errorf("did not get expected KernelMismatch, got: %q, want: %q", buf.String(), "true")
+			}
+			want["KernelMismatch"] = true
+		case "/Environment":
+			if buf.String() != inv.Environment {
+				t.Errorf("did not get expected Environment, got: %q, want: %q", buf.String(), inv.Environment)
+			}
+			want["Environment"] = true
+		case "/WSLDistro":
+			if buf.String() != inv.WSLDistro {
+				t.Errorf("did not get expected WSLDistro, got: %q, want: %q", buf.String(), inv.WSLDistro)
+			}
+			want["WSLDistro"] = true
 		case "/Version":
 			if buf.String() != inv.Version {
 				t.Errorf("did not get expected Version, got: %q, want: %q", buf.String(), inv.Version)
@@ -551,7 +644,9 @@ func TestWrite(t *testing.T) {
 	defer svr.Close()
 
 	ctx := context.Background()
-	write(ctx, inv, svr.URL)
+	if err := (&Client{}).write(ctx, inv, svr.URL); err != nil {
+		t.Errorf("unexpected error from write: %v", err)
+	}
 
 	for k, v := range want {
 		if v {
@@ -561,6 +656,559 @@ func TestWrite(t *testing.T) {
 	}
 }
 
+func TestWriteCancelledContext(t *testing.T) {
+	var posts int
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts++
+	}))
+	defer svr.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := (&Client{}).write(ctx, generateInventoryState(), svr.URL)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("write with cancelled context returned %v, want %v", err, context.Canceled)
+	}
+	if posts != 0 {
+		t.Errorf("write with cancelled context posted %d attributes, want 0", posts)
+	}
+}
+
+func TestWriteReportsFailures(t *testing.T) {
+	failing := map[string]bool{"/Hostname": true, "/InstalledPackages": true}
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing[r.URL.String()] {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer svr.Close()
+
+	err := (&Client{}).write(context.Background(), generateInventoryState(), svr.URL)
+	if err == nil {
+		t.Fatal("expected error from write, got nil")
+	}
+	for _, field := range []string{"Hostname", "InstalledPackages"} {
+		if !strings.Contains(err.Error(), field+":") {
+			t.Errorf("write error %q does not mention failed field %q", err, field)
+		}
+	}
+	if strings.Contains(err.Error(), "LongName:") {
+		t.Errorf("write error %q mentions field that was written successfully", err)
+	}
+}
+
+func TestWriteInventorySkipsAfterConsecutiveFailures(t *testing.T) {
+	var posts int
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer svr.Close()
+
+	ctx := context.Background()
+	c := &Client{}
+	for i := 0; i < maxConsecutiveWriteFailures; i++ {
+		c.writeInventory(ctx, generateInventoryState(), svr.URL, nil)
+	}
+	if c.writeFailures != maxConsecutiveWriteFailures {
+		t.Fatalf("writeFailures = %d, want %d", c.writeFailures, maxConsecutiveWriteFailures)
+	}
+
+	posts = 0
+	c.writeInventory(ctx, generateInventoryState(), svr.URL, nil)
+	if posts != 0 {
+		t.Errorf("writeInventory posted %d attributes after %d consecutive failures, want 0", posts, maxConsecutiveWriteFailures)
+	}
+	if c.writeFailures != 0 {
+		t.Errorf("writeFailures = %d after skipped write, want 0", c.writeFailures)
+	}
+}
+
+// writeInventoryIfChanged calls c.writeInventoryIfChanged with the Fingerprint of state.
+func writeInventoryIfChanged(t *testing.T, c *Client, state *inventory.InstanceInventory, url string) error {
+	t.Helper()
+	fingerprint, err := state.Fingerprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c.writeInventoryIfChanged(context.Background(), state, fingerprint, url)
+}
+
+func TestWriteInventoryIfChanged(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []ClientOption
+		change func(*inventory.InstanceInventory)
+		want   []string
+	}{
+		{
+			name:   "Unchanged",
+			change: func(*inventory.InstanceInventory) {},
+		},
+		{
+			name: "InstalledPackages",
+			change: func(state *inventory.InstanceInventory) {
+				state.InstalledPackages.Deb = append(state.InstalledPackages.Deb, &packages.PkgInfo{Name: "NewDeb", Version: "1.0", Type: "deb"})
+			},
+			want: []string{"/InstalledPackages"},
+		},
+		{
+			name: "RebootRequired",
+			change: func(state *inventory.InstanceInventory) {
+				state.RebootRequired, state.RebootRequiredReason = true, "reboot-required file"
+			},
+			want: []string{"/RebootRequired", "/RebootRequiredReason"},
+		},
+		{
+			name:   "LastUpdated",
+			change: func(state *inventory.InstanceInventory) { state.LastUpdated = "2026-01-02T00:00:00Z" },
+			want:   []string{"/LastUpdated"},
+		},
+		{
+			name: "SourceTimestamps",
+			change: func(state *inventory.InstanceInventory) {
+				state.SourceTimestamps = map[string]string{inventory.SourceOSInfo: "2026-01-02T00:00:00Z"}
+			},
+			want: []string{"/SourceTimestamps"},
+		},
+		{
+			name:   "KernelVersionLeftOutOfTheReportFingerprint",
+			opts:   []ClientOption{WithFingerprintOSInfoFields("short_name")},
+			change: func(state *inventory.InstanceInventory) { state.KernelVersion = "NewKernelVersion" },
+			want:   []string{"/KernelVersion"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var posted []string
+			svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				posted = append(posted, r.URL.Path)
+			}))
+			defer svr.Close()
+
+			c := &Client{}
+			for _, opt := range tt.opts {
+				opt(c)
+			}
+			if err := writeInventoryIfChanged(t, c, generateInventoryState(), svr.URL); err != nil {
+				t.Fatalf("writeInventoryIfChanged() unexpected error: %v", err)
+			}
+			if len(posted) == 0 {
+				t.Fatalf("first write did not post any attributes")
+			}
+			if c.lastWrittenFingerprint == "" {
+				t.Fatalf("fingerprint of the written inventory not recorded")
+			}
+
+			posted = nil
+			state := generateInventoryState()
+			tt.change(state)
+			if err := writeInventoryIfChanged(t, c, state, svr.URL); err != nil {
+				t.Fatalf("writeInventoryIfChanged() unexpected error: %v", err)
+			}
+			sort.Strings(posted)
+			utiltest.AssertEquals(t, posted, tt.want)
+		})
+	}
+}
+
+func TestInventoryUnchanged(t *testing.T) {
+	tests := []struct {
+		name        string
+		last        string
+		fingerprint string
+		want        bool
+	}{
+		{name: "NothingWrittenYet", last: "", fingerprint: "abc", want: false},
+		{name: "Unchanged", last: "abc", fingerprint: "abc", want: true},
+		{name: "Changed", last: "abc", fingerprint: "def", want: false},
+		{name: "FingerprintUnavailable", last: "", fingerprint: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{lastWrittenFingerprint: tt.last}
+			utiltest.AssertEquals(t, c.inventoryUnchanged(tt.fingerprint), tt.want)
+		})
+	}
+}
+
+func TestWriteInventoryIfChangedRetriesFailedWrite(t *testing.T) {
+	fail := true
+	var posts int
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts++
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer svr.Close()
+
+	c := &Client{}
+	writeInventoryIfChanged(t, c, generateInventoryState(), svr.URL)
+	if c.lastWrittenFingerprint != "" {
+		t.Fatalf("fingerprint recorded for a failed write")
+	}
+
+	fail = false
+	posts = 0
+	writeInventoryIfChanged(t, c, generateInventoryState(), svr.URL)
+	if posts == 0 {
+		t.Errorf("unchanged inventory was not written again after a failed write")
+	}
+}
+
+func TestWriteSkipsUnchangedAttributes(t *testing.T) {
+	posted := map[string]int{}
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posted[r.URL.String()]++
+	}))
+	defer svr.Close()
+
+	ctx := context.Background()
+	c := &Client{}
+	if err := c.write(ctx, generateInventoryState(), svr.URL); err != nil {
+		t.Fatalf("unexpected error from write: %v", err)
+	}
+	firstWrite := len(posted)
+	if firstWrite == 0 {
+		t.Fatalf("first write did not post any attributes")
+	}
+
+	posted = map[string]int{}
+	if err := c.write(ctx, generateInventoryState(), svr.URL); err != nil {
+		t.Fatalf("unexpected error from write: %v", err)
+	}
+	if len(posted) != 0 {
+		t.Errorf("unchanged inventory posted %v, want no attributes", posted)
+	}
+
+	changed := generateInventoryState()
+	changed.Hostname = "NewHostname"
+	changed.InstalledPackages.Deb = append(changed.InstalledPackages.Deb, &packages.PkgInfo{Name: "NewDeb", Version: "1.0", Type: "deb"})
+	if err := c.write(ctx, changed, svr.URL); err != nil {
+		t.Fatalf("unexpected error from write: %v", err)
+	}
+	want := map[string]int{"/Hostname": 1, "/InstalledPackages": 1}
+	if diff := cmp.Diff(want, posted); diff != "" {
+		t.Errorf("write of changed fields posted unexpected attributes (-want +got):\n%s", diff)
+	}
+}
+
+func TestWriteNetworkInterfaces(t *testing.T) {
+	posted := map[string]string{}
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		posted[r.URL.String()] = string(body)
+	}))
+	defer svr.Close()
+
+	ctx := context.Background()
+	c := &Client{}
+	state := generateInventoryState()
+	if err := c.write(ctx, state, svr.URL); err != nil {
+		t.Fatalf("unexpected error from write: %v", err)
+	}
+	if _, ok := posted["/NetworkInterfaces"]; ok {
+		t.Errorf("write posted NetworkInterfaces that were not collected")
+	}
+
+	state.NetworkInterfaces = []inventory.NetworkInterface{{Name: "ens4", MAC: "42:01:0a:80:00:02", IPv4: []string{"10.128.0.2"}}}
+	if err := c.write(ctx, state, svr.URL); err != nil {
+		t.Fatalf("unexpected error from write: %v", err)
+	}
+	utiltest.AssertEquals(t, posted["/NetworkInterfaces"], `[{"Name":"ens4","MAC":"42:01:0a:80:00:02","IPv4":["10.128.0.2"]}]`)
+}
+
+func TestFormatNetworkInterfaces(t *testing.T) {
+	state := &inventory.InstanceInventory{
+		NetworkInterfaces: []inventory.NetworkInterface{
+			{Name: "ens4", MAC: "42:01:0a:80:00:02", IPv4: []string{"10.128.0.2"}, IPv6: []string{"fd20:1:2::2"}},
+			{Name: "wg0", IPv4: []string{"10.8.0.1"}},
+		},
+	}
+
+	got := formatVMInventory(context.Background(), state).GetInstalledPackages()
+
+	ips := func(values ...string) *structpb.Value {
+		list := make([]*structpb.Value, len(values))
+		for i, v := range values {
+			list[i] = structpb.NewStringValue(v)
+		}
+		return structpb.NewListValue(&structpb.ListValue{Values: list})
+	}
+	want := []*agentendpointpb.VmInventory_InventoryItem{
+		{
+			Name: "ens4", Type: "network-interface", Location: []string{},
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"MAC":  structpb.NewStringValue("42:01:0a:80:00:02"),
+				"IPv4": ips("10.128.0.2"),
+				"IPv6": ips("fd20:1:2::2"),
+			}},
+		},
+		{
+			Name: "wg0", Type: "network-interface", Location: []string{},
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"IPv4": ips("10.8.0.1"),
+				"IPv6": ips(),
+			}},
+		},
+	}
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+		t.Errorf("formatVMInventory() unexpected installed items (-want +got):\n%s", diff)
+	}
+}
+
+func TestWriteRepostsFailedAttributes(t *testing.T) {
+	fail := true
+	posted := map[string]int{}
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posted[r.URL.String()]++
+		if fail && r.URL.String() == "/LongName" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer svr.Close()
+
+	ctx := context.Background()
+	c := &Client{}
+	if err := c.write(ctx, generateInventoryState(), svr.URL); err == nil {
+		t.Fatal("expected error from write, got nil")
+	}
+
+	fail = false
+	posted = map[string]int{}
+	if err := c.write(ctx, generateInventoryState(), svr.URL); err != nil {
+		t.Fatalf("unexpected error from write: %v", err)
+	}
+	want := map[string]int{"/LongName": 1}
+	if diff := cmp.Diff(want, posted); diff != "" {
+		t.Errorf("write after a failed post posted unexpected attributes (-want +got):\n%s", diff)
+	}
+}
+
+type fakeAttributeWriter struct {
+	posts      map[string]string
+	compressed map[string]any
+	levels     map[string]int
+	fail       map[string]bool
+}
+
+func newFakeAttributeWriter() *fakeAttributeWriter {
+	return &fakeAttributeWriter{posts: map[string]string{}, compressed: map[string]any{}, levels: map[string]int{}, fail: map[string]bool{}}
+}
+
+func (w *fakeAttributeWriter) PostAttribute(url string, value io.Reader) error {
+	if w.fail[url] {
+		return errors.New("fake post failure")
+	}
+	b, err := io.ReadAll(value)
+	if err != nil {
+		return err
+	}
+	w.posts[url] = string(b)
+	return nil
+}
+
+func (w *fakeAttributeWriter) PostAttributeCompressed(url string, body any, level int) error {
+	if w.fail[url] {
+		return errors.New("fake post failure")
+	}
+	w.compressed[url] = body
+	w.levels[url] = level
+	return nil
+}
+
+func TestWriteAttributeWriter(t *testing.T) {
+	writer := newFakeAttributeWriter()
+	writer.fail["base/LongName"] = true
+	c := &Client{}
+	WithAttributeWriter(writer)(c)
+	WithCompressionLevel(gzip.BestSpeed)(c)
+
+	inv := generateInventoryState()
+	err := c.write(context.Background(), inv, "base")
+	if err == nil || !strings.Contains(err.Error(), "LongName:") {
+		t.Errorf("write error = %v, want the failed LongName post", err)
+	}
+
+	utiltest.AssertEquals(t, writer.posts["base/Hostname"], inv.Hostname)
+	utiltest.AssertEquals(t, writer.posts["base/OSConfigAgentVersion"], inv.OSConfigAgentVersion)
+	if _, ok := writer.posts["base/LongName"]; ok {
+		t.Errorf("failed post of LongName was recorded")
+	}
+	if got := writer.compressed["base/InstalledPackages"]; got != any(inv.InstalledPackages) {
+		t.Errorf("InstalledPackages posted as %v, want %v", got, inv.InstalledPackages)
+	}
+	if got := writer.compressed["base/PackageUpdates"]; got != any(inv.PackageUpdates) {
+		t.Errorf("PackageUpdates posted as %v, want %v", got, inv.PackageUpdates)
+	}
+	utiltest.AssertEquals(t, writer.levels["base/InstalledPackages"], gzip.BestSpeed)
+}
+
+type capturingAttributeWriteLogger struct {
+	records []AttributeWriteRecord
+}
+
+func (l *capturingAttributeWriteLogger) LogAttributeWrite(_ context.Context, record AttributeWriteRecord) {
+	l.records = append(l.records, record)
+}
+
+func TestWriteAttributeWriteLogger(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.String() == "/LongName" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer svr.Close()
+
+	l := &capturingAttributeWriteLogger{}
+	c := &Client{}
+	WithAttributeWriteLogger(l)(c)
+	state := &inventory.InstanceInventory{
+		Hostname:          "Hostname",
+		LongName:          "LongName",
+		InstalledPackages: &packages.Packages{},
+	}
+	c.write(context.Background(), state, svr.URL)
+
+	records := map[string]AttributeWriteRecord{}
+	for _, r := range l.records {
+		records[r.Field] = r
+	}
+
+	hostname := records["Hostname"]
+	utiltest.AssertEquals(t, hostname.URL, svr.URL+"/Hostname")
+	utiltest.AssertEquals(t, hostname.Size, len("Hostname"))
+	utiltest.AssertEquals(t, hostname.Outcome, "success")
+	utiltest.AssertEquals(t, hostname.Error, "")
+
+	longName := records["LongName"]
+	utiltest.AssertEquals(t, longName.Outcome, "failure")
+	if longName.Error == "" {
+		t.Errorf("expected error message in record for failed write")
+	}
+
+	installed := records["InstalledPackages"]
+	utiltest.AssertEquals(t, installed.Outcome, "success")
+	utiltest.AssertEquals(t, installed.Size, len("{}"))
+
+	if _, ok := records["PackageUpdates"]; ok {
+		t.Errorf("unexpected record for nil PackageUpdates")
+	}
+}
+
+func TestWriteCompressesOversizedStrings(t *testing.T) {
+	longName := strings.Repeat("a", 100)
+	got := map[string]string{}
+	var deleted []string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" {
+			deleted = append(deleted, r.URL.String())
+			return
+		}
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[r.URL.String()] = string(b)
+	}))
+	defer svr.Close()
+
+	c := &Client{}
+	WithStringCompressionThreshold(10)(c)
+	state := &inventory.InstanceInventory{
+		Hostname: "Hostname",
+		LongName: longName,
+	}
+	if err := c.write(context.Background(), state, svr.URL); err != nil {
+		t.Fatalf("write() unexpected error: %v", err)
+	}
+
+	utiltest.AssertEquals(t, got["/Hostname"], "Hostname")
+	if _, ok := got["/LongName"]; ok {
+		t.Errorf("oversized string was written uncompressed to /LongName")
+	}
+
+	compressed, ok := got["/LongNameCompressed"]
+	if !ok {
+		t.Fatalf("oversized string was not written to /LongNameCompressed")
+	}
+	decoded, err := base64.StdEncoding.DecodeString(compressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(decoded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var value string
+	if err := json.NewDecoder(zr).Decode(&value); err != nil {
+		t.Fatal(err)
+	}
+	utiltest.AssertEquals(t, value, longName)
+	// The uncompressed attribute a previous agent may have written is deleted once.
+	utiltest.AssertEquals(t, deleted, []string{"/LongName"})
+
+	state.LongName = longName + "b"
+	if err := c.write(context.Background(), state, svr.URL); err != nil {
+		t.Fatalf("write() unexpected error: %v", err)
+	}
+	utiltest.AssertEquals(t, deleted, []string{"/LongName"})
+
+	// A value back under the threshold deletes the compressed attribute.
+	state.LongName = "LongName"
+	if err := c.write(context.Background(), state, svr.URL); err != nil {
+		t.Fatalf("write() unexpected error: %v", err)
+	}
+	utiltest.AssertEquals(t, got["/LongName"], "LongName")
+	utiltest.AssertEquals(t, deleted, []string{"/LongName", "/LongNameCompressed"})
+}
+
+func TestWriteCompressionLevel(t *testing.T) {
+	pkgs := &packages.Packages{}
+	for i := 0; i < 500; i++ {
+		pkgs.Apt = append(pkgs.Apt, &packages.PkgInfo{Name: fmt.Sprintf("package-%d", i), Version: fmt.Sprintf("1.%d.%d", i%7, i*31%97), Arch: "amd64"})
+	}
+	state := &inventory.InstanceInventory{Hostname: "Hostname", InstalledPackages: pkgs}
+
+	writtenSize := func(opts ...ClientOption) int {
+		var size int
+		svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b, err := io.ReadAll(r.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.URL.Path == "/InstalledPackages" {
+				size = len(b)
+			}
+		}))
+		defer svr.Close()
+
+		c := &Client{}
+		for _, opt := range opts {
+			opt(c)
+		}
+		if err := c.write(context.Background(), state, svr.URL); err != nil {
+			t.Fatalf("write() unexpected error: %v", err)
+		}
+		return size
+	}
+
+	none := writtenSize(WithCompressionLevel(gzip.NoCompression))
+	fast := writtenSize(WithCompressionLevel(gzip.BestSpeed))
+	def := writtenSize()
+	best := writtenSize(WithCompressionLevel(gzip.BestCompression))
+	if none <= fast || fast <= best || fast <= def {
+		t.Errorf("expected NoCompression then BestSpeed output to be the largest, got NoCompression: %d, BestSpeed: %d, default: %d, BestCompression: %d", none, fast, def, best)
+	}
+	if invalid := writtenSize(WithCompressionLevel(gzip.BestCompression + 1)); invalid != def {
+		t.Errorf("expected an invalid level to be ignored, got size %d, default: %d", invalid, def)
+	}
+}
+
 func TestReport(t *testing.T) {
 	ctx := context.Background()
 	ctrl := gomock.NewController(t)
@@ -598,7 +1246,7 @@ func TestReport(t *testing.T) {
 				_ ...gax.CallOption) {
 				actualInventory = req.Inventory
 			}).Return(&agentendpointpb.ReportInventoryResponse{ReportFullInventory: tt.reportFullInventory}, nil)
-			mockClient.EXPECT().ReportVmInventory(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, status.Error(codes.FailedPrecondition, ""))
+			mockClient.EXPECT().ReportVmInventory(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(nil, status.Error(codes.FailedPrecondition, ""))
 
 			tc, err := newMockTestClient(ctx, mockClient)
 			if err != nil {
@@ -614,6 +1262,40 @@ func TestReport(t *testing.T) {
 	}
 }
 
+func TestReportDisableLegacyInventory(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var formatted int
+	oldFormat := formatLegacyInventory
+	formatLegacyInventory = func(ctx context.Context, state *inventory.InstanceInventory) *agentendpointpb.Inventory {
+		formatted++
+		return oldFormat(ctx, state)
+	}
+	defer func() { formatLegacyInventory = oldFormat }()
+
+	mockClient := utilmocks.NewMockAgentEndpointClient(ctrl)
+	mockClient.EXPECT().ReportInventory(gomock.Any(), gomock.Any()).Times(0)
+	mockClient.EXPECT().ReportVmInventory(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, status.Error(codes.FailedPrecondition, "")).MinTimes(1)
+
+	tc, err := newMockTestClient(ctx, mockClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	WithDisableLegacyInventory()(tc.client)
+	WithReportCircuitBreaker(1, time.Hour)(tc.client)
+
+	cancelCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	tc.client.report(cancelCtx, generateInventoryState())
+
+	if formatted != 0 {
+		t.Errorf("legacy inventory was formatted %d times, want 0", formatted)
+	}
+	utiltest.AssertEquals(t, tc.client.ReportBreakerState(), BreakerOpen)
+}
+
 func TestReportVmInventory(t *testing.T) {
 	ctx := context.Background()
 	ctrl := gomock.NewController(t)
@@ -645,7 +1327,7 @@ func TestReportVmInventory(t *testing.T) {
 
 			mockClient := utilmocks.NewMockAgentEndpointClient(ctrl)
 			mockClient.EXPECT().ReportVmInventory(gomock.Any(),
-				gomock.Any()).AnyTimes().Do(func(ctx context.Context,
+				gomock.Any(), gomock.Any()).AnyTimes().Do(func(ctx context.Context,
 				req *agentendpointpb.ReportVmInventoryRequest,
 				_ ...gax.CallOption) {
 				actualInventory = req.VmInventory
@@ -666,15 +1348,2729 @@ func TestReportVmInventory(t *testing.T) {
 
 }
 
-func Test_computeFingerprint_gotExpectedFingerprintFormat(t *testing.T) {
-	ctx := context.Background()
-	fingerprint, err := computeFingerprint(ctx, generateInventory())
-	if err != nil {
-		t.Fatalf("unable to generate fingerprint, err - %s", err)
+func chunkTestInventory(installed, available int) *agentendpointpb.VmInventory {
+	inv := &agentendpointpb.VmInventory{OsInfo: &agentendpointpb.VmInventory_OsInfo{HostName: "Hostname", ShortName: "debian"}}
+	for i := 0; i < installed; i++ {
+		inv.InstalledPackages = append(inv.InstalledPackages, &agentendpointpb.VmInventory_InventoryItem{Name: fmt.Sprintf("installed-%d", i), Type: "deb", Version: "1.0"})
 	}
-
-	if !validFingerprint(fingerprint) {
-		t.Fatalf("got fingerprint %q in unexpected format", fingerprint)
+	for i := 0; i < available; i++ {
+		inv.AvailablePackages = append(inv.AvailablePackages, &agentendpointpb.VmInventory_InventoryItem{Name: fmt.Sprintf("available-%d", i), Type: "deb", Version: "2.0"})
+	}
+	return inv
+}
+
+func TestChunkVMInventory(t *testing.T) {
+	tests := []struct {
+		name          string
+		inventory     *agentendpointpb.VmInventory
+		size          int
+		wantInstalled []int
+		wantAvailable []int
+	}{
+		{
+			name:          "InstalledAndAvailableSpanChunks",
+			inventory:     chunkTestInventory(5, 3),
+			size:          3,
+			wantInstalled: []int{3, 2, 0},
+			wantAvailable: []int{0, 1, 2},
+		},
+		{
+			name:          "ExactFit",
+			inventory:     chunkTestInventory(2, 2),
+			size:          2,
+			wantInstalled: []int{2, 0},
+			wantAvailable: []int{0, 2},
+		},
+		{
+			name:          "SingleChunk",
+			inventory:     chunkTestInventory(2, 1),
+			size:          10,
+			wantInstalled: []int{2},
+			wantAvailable: []int{1},
+		},
+		{
+			name:          "Empty",
+			inventory:     chunkTestInventory(0, 0),
+			size:          10,
+			wantInstalled: []int{0},
+			wantAvailable: []int{0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := chunkVMInventory(tt.inventory, tt.size)
+
+			var gotInstalled, gotAvailable []int
+			reassembled := &agentendpointpb.VmInventory{OsInfo: tt.inventory.GetOsInfo()}
+			for _, chunk := range chunks {
+				if diff := cmp.Diff(tt.inventory.GetOsInfo(), chunk.GetOsInfo(), protocmp.Transform()); diff != "" {
+					t.Errorf("chunk OsInfo mismatch (-want +got):\n%s", diff)
+				}
+				gotInstalled = append(gotInstalled, len(chunk.GetInstalledPackages()))
+				gotAvailable = append(gotAvailable, len(chunk.GetAvailablePackages()))
+				reassembled.InstalledPackages = append(reassembled.InstalledPackages, chunk.GetInstalledPackages()...)
+				reassembled.AvailablePackages = append(reassembled.AvailablePackages, chunk.GetAvailablePackages()...)
+			}
+			utiltest.AssertEquals(t, gotInstalled, tt.wantInstalled)
+			utiltest.AssertEquals(t, gotAvailable, tt.wantAvailable)
+			if diff := cmp.Diff(tt.inventory, reassembled, protocmp.Transform()); diff != "" {
+				t.Errorf("reassembled chunks mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReportVmInventoryChunked(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inv := chunkTestInventory(5, 3)
+	wantChecksum, err := computeStableFingerprintVMInventory(ctx, inv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var positions, checksums []string
+	reassembled := &agentendpointpb.VmInventory{OsInfo: inv.GetOsInfo()}
+	mockClient := utilmocks.NewMockAgentEndpointClient(ctrl)
+	mockClient.EXPECT().ReportVmInventory(gomock.Any(), gomock.Any(), gomock.Any()).Times(3).DoAndReturn(
+		func(ctx context.Context, req *agentendpointpb.ReportVmInventoryRequest, _ ...gax.CallOption) (*agentendpointpb.ReportVmInventoryResponse, error) {
+			md, _ := grpcmetadata.FromOutgoingContext(ctx)
+			positions = append(positions, md.Get(inventoryChunkMetadataKey)...)
+			checksums = append(checksums, req.GetInventoryChecksum())
+			reassembled.InstalledPackages = append(reassembled.InstalledPackages, req.GetVmInventory().GetInstalledPackages()...)
+			reassembled.AvailablePackages = append(reassembled.AvailablePackages, req.GetVmInventory().GetAvailablePackages()...)
+			return &agentendpointpb.ReportVmInventoryResponse{}, nil
+		})
+
+	tc, err := newMockTestClient(ctx, mockClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	WithReportChunkSize(3)(tc.client)
+
+	if _, err := tc.client.reportVMInventory(ctx, inv, true); err != nil {
+		t.Fatalf("reportVMInventory() unexpected error: %v", err)
+	}
+
+	utiltest.AssertEquals(t, positions, []string{"1/3", "2/3", "3/3"})
+	utiltest.AssertEquals(t, checksums, []string{wantChecksum, wantChecksum, wantChecksum})
+	if diff := cmp.Diff(inv, reassembled, protocmp.Transform()); diff != "" {
+		t.Errorf("reassembled inventory mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestReportVmInventoryChunkedFallback(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inv := chunkTestInventory(5, 3)
+	var requests []*agentendpointpb.ReportVmInventoryRequest
+	var chunked []bool
+	mockClient := utilmocks.NewMockAgentEndpointClient(ctrl)
+	mockClient.EXPECT().ReportVmInventory(gomock.Any(), gomock.Any(), gomock.Any()).Times(2).DoAndReturn(
+		func(ctx context.Context, req *agentendpointpb.ReportVmInventoryRequest, _ ...gax.CallOption) (*agentendpointpb.ReportVmInventoryResponse, error) {
+			md, _ := grpcmetadata.FromOutgoingContext(ctx)
+			requests = append(requests, req)
+			chunked = append(chunked, len(md.Get(inventoryChunkMetadataKey)) > 0)
+			if len(requests) == 1 {
+				return nil, status.Error(codes.Unimplemented, "chunked reports are not supported")
+			}
+			return &agentendpointpb.ReportVmInventoryResponse{}, nil
+		})
+
+	tc, err := newMockTestClient(ctx, mockClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	WithReportChunkSize(3)(tc.client)
+
+	if _, err := tc.client.reportVMInventory(ctx, inv, true); err != nil {
+		t.Fatalf("reportVMInventory() unexpected error: %v", err)
+	}
+
+	utiltest.AssertEquals(t, chunked, []bool{true, false})
+	if diff := cmp.Diff(inv, requests[1].GetVmInventory(), protocmp.Transform()); diff != "" {
+		t.Errorf("fallback request inventory mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSelfCheck(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/guestInventory/Hostname":
+			w.Write([]byte("Hostname"))
+		case "/unset/Hostname":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer svr.Close()
+
+	tests := []struct {
+		name         string
+		endpointErr  error
+		attributeURL string
+		wantErr      string
+	}{
+		{
+			name:         "Success",
+			attributeURL: svr.URL + "/guestInventory/Hostname",
+		},
+		{
+			name:         "AttributeNotWrittenYet",
+			attributeURL: svr.URL + "/unset/Hostname",
+		},
+		{
+			name: "GuestAttributesDisabled",
+		},
+		{
+			name:         "EndpointUnreachable",
+			endpointErr:  status.Error(codes.Unavailable, "connection refused"),
+			attributeURL: svr.URL + "/guestInventory/Hostname",
+			wantErr:      "agent endpoint check failed",
+		},
+		{
+			name:         "MetadataServerFailure",
+			attributeURL: svr.URL + "/unavailable/Hostname",
+			wantErr:      "guest attributes check failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *agentendpointpb.ReportVmInventoryRequest
+			mockClient := utilmocks.NewMockAgentEndpointClient(ctrl)
+			mockClient.EXPECT().ReportVmInventory(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).DoAndReturn(
+				func(_ context.Context, req *agentendpointpb.ReportVmInventoryRequest, _ ...gax.CallOption) (*agentendpointpb.ReportVmInventoryResponse, error) {
+					got = req
+					return &agentendpointpb.ReportVmInventoryResponse{}, tt.endpointErr
+				})
+
+			tc, err := newMockTestClient(ctx, mockClient)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = tc.client.selfCheck(ctx, tt.attributeURL)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("selfCheck() unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("selfCheck() error = %v, want an error containing %q", err, tt.wantErr)
+			}
+			if got.GetVmInventory() != nil {
+				t.Errorf("selfCheck() reported an inventory: %v", got.GetVmInventory())
+			}
+		})
+	}
+}
+
+func TestReportJitter(t *testing.T) {
+	utiltest.AssertEquals(t, ReportJitter(0), time.Duration(0))
+	utiltest.AssertEquals(t, ReportJitter(-time.Minute), time.Duration(0))
+
+	max := 30 * time.Second
+	for i := 0; i < 1000; i++ {
+		if d := ReportJitter(max); d < 0 || d >= max {
+			t.Fatalf("ReportJitter(%s) = %s, want a duration in [0, %s)", max, d, max)
+		}
+	}
+
+	// The bounds hold for the extreme values of the random source.
+	for _, n := range []func(int64) int64{func(int64) int64 { return 0 }, func(n int64) int64 { return n - 1 }} {
+		utiltest.OverrideVariable(t, &jitterInt63n, n)
+		if d := ReportJitter(max); d < 0 || d >= max {
+			t.Errorf("ReportJitter(%s) = %s, want a duration in [0, %s)", max, d, max)
+		}
+	}
+}
+
+type countingInventoryProvider struct {
+	calls int
+	state *inventory.InstanceInventory
+}
+
+func (p *countingInventoryProvider) Get(context.Context) *inventory.InstanceInventory {
+	p.calls++
+	if p.state != nil {
+		return p.state
+	}
+	return &inventory.InstanceInventory{}
+}
+
+func (p *countingInventoryProvider) GetWithErrors(ctx context.Context) (*inventory.InstanceInventory, error) {
+	return p.Get(ctx), nil
+}
+
+func TestReportInventoryJitterCancelled(t *testing.T) {
+	provider := &countingInventoryProvider{}
+	c := &Client{inventoryProvider: provider}
+	WithReportJitter(time.Hour)(c)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		c.ReportInventory(ctx)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("ReportInventory() did not return after the context was cancelled while waiting for the jitter")
+	}
+	utiltest.AssertEquals(t, provider.calls, 0)
+}
+
+func TestReportInventoryWithErrors(t *testing.T) {
+	tests := []struct {
+		name            string
+		attributeStatus int
+		reportErr       error
+		wantErrs        []string
+	}{
+		{
+			name:            "Success",
+			attributeStatus: http.StatusOK,
+		},
+		{
+			name:            "WriteFailure",
+			attributeStatus: http.StatusInternalServerError,
+			wantErrs:        []string{"writing inventory to guest attributes"},
+		},
+		{
+			name:            "ReportFailure",
+			attributeStatus: http.StatusOK,
+			reportErr:       status.Error(codes.PermissionDenied, "permission denied"),
+			wantErrs:        []string{"reporting inventory to agent endpoint"},
+		},
+		{
+			name:            "WriteAndReportFailure",
+			attributeStatus: http.StatusInternalServerError,
+			reportErr:       status.Error(codes.PermissionDenied, "permission denied"),
+			wantErrs:        []string{"writing inventory to guest attributes", "reporting inventory to agent endpoint"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.attributeStatus)
+			}))
+			defer svr.Close()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockClient := utilmocks.NewMockAgentEndpointClient(ctrl)
+			mockClient.EXPECT().ReportVmInventory(gomock.Any(), gomock.Any(), gomock.Any()).Return(&agentendpointpb.ReportVmInventoryResponse{}, tt.reportErr)
+
+			tc, err := newMockTestClient(ctx, mockClient)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tc.client.inventoryProvider = &countingInventoryProvider{state: generateInventoryState()}
+			WithDisableLegacyInventory()(tc.client)
+
+			err = tc.client.reportInventoryWithErrors(ctx, svr.URL)
+			if len(tt.wantErrs) == 0 {
+				if err != nil {
+					t.Fatalf("reportInventoryWithErrors() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("reportInventoryWithErrors() = nil, want errors %q", tt.wantErrs)
+			}
+			for _, want := range tt.wantErrs {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("reportInventoryWithErrors() = %q, want it to mention %q", err, want)
+				}
+			}
+			if tt.reportErr == nil && strings.Contains(err.Error(), "agent endpoint") {
+				t.Errorf("reportInventoryWithErrors() = %q, mentions the agent endpoint report that succeeded", err)
+			}
+		})
+	}
+}
+
+func TestReportInventoryWithErrorsFiltersWrite(t *testing.T) {
+	ctx := context.Background()
+	var mu sync.Mutex
+	written := map[string]string{}
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		written[r.URL.Path] = string(body)
+		mu.Unlock()
+	}))
+	defer svr.Close()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	var reported *agentendpointpb.VmInventory
+	mockClient := utilmocks.NewMockAgentEndpointClient(ctrl)
+	mockClient.EXPECT().ReportVmInventory(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(_ context.Context, req *agentendpointpb.ReportVmInventoryRequest, _ ...gax.CallOption) (*agentendpointpb.ReportVmInventoryResponse, error) {
+			if req.GetVmInventory() != nil {
+				reported = req.GetVmInventory()
+			}
+			return &agentendpointpb.ReportVmInventoryResponse{ReportFullInventory: reported == nil}, nil
+		})
+
+	tc, err := newMockTestClient(ctx, mockClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc.client.inventoryProvider = &countingInventoryProvider{state: generateInventoryState()}
+	WithDisableLegacyInventory()(tc.client)
+	WithExcludedPackageTypes("deb")(tc.client)
+
+	if err := tc.client.reportInventoryWithErrors(ctx, svr.URL); err != nil {
+		t.Fatalf("reportInventoryWithErrors() unexpected error: %v", err)
+	}
+
+	installed, ok := written["/InstalledPackages"]
+	if !ok {
+		t.Fatalf("InstalledPackages was not written to guest attributes, written: %v", written)
+	}
+	if pkgs := decodePackages(installed); len(pkgs.Deb) != 0 || len(pkgs.Yum) == 0 {
+		t.Errorf("guest attributes InstalledPackages = %+v, want the packages without the excluded deb packages", pkgs)
+	}
+	for _, item := range reported.GetInstalledPackages() {
+		if item.GetType() == "deb" {
+			t.Errorf("agent endpoint received excluded deb package %q", item.GetName())
+		}
+	}
+	wantFingerprint, err := tc.client.filterInventory(generateInventoryState()).Fingerprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	utiltest.AssertEquals(t, tc.client.lastWrittenFingerprint, wantFingerprint)
+}
+
+func TestReportInventoryWithErrorsConcurrentCycles(t *testing.T) {
+	ctx := context.Background()
+	var mu sync.Mutex
+	writes := map[string]int{}
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		writes[r.URL.Path]++
+		mu.Unlock()
+	}))
+	defer svr.Close()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := utilmocks.NewMockAgentEndpointClient(ctrl)
+	mockClient.EXPECT().ReportVmInventory(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(&agentendpointpb.ReportVmInventoryResponse{}, nil)
+
+	tc, err := newMockTestClient(ctx, mockClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := &countingInventoryProvider{state: generateInventoryState()}
+	tc.client.inventoryProvider = provider
+	WithDisableLegacyInventory()(tc.client)
+
+	const cycles = 5
+	var wg sync.WaitGroup
+	for i := 0; i < cycles; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := tc.client.reportInventoryWithErrors(ctx, svr.URL); err != nil {
+				t.Errorf("reportInventoryWithErrors() unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	utiltest.AssertEquals(t, provider.calls, cycles)
+	// Serialized cycles see the fingerprint of the first write and skip the unchanged inventory.
+	utiltest.AssertEquals(t, writes["/InstalledPackages"], 1)
+}
+
+func TestReportFingerprintFastPath(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	formatted := 0
+	utiltest.OverrideVariable(t, &formatLegacyInventory, func(ctx context.Context, state *inventory.InstanceInventory) *agentendpointpb.Inventory {
+		formatted++
+		return formatInventory(ctx, state)
+	})
+
+	var requests []*agentendpointpb.ReportVmInventoryRequest
+	mockClient := utilmocks.NewMockAgentEndpointClient(ctrl)
+	mockClient.EXPECT().ReportVmInventory(gomock.Any(), gomock.Any(), gomock.Any()).Times(5).DoAndReturn(
+		func(ctx context.Context, req *agentendpointpb.ReportVmInventoryRequest, _ ...gax.CallOption) (*agentendpointpb.ReportVmInventoryResponse, error) {
+			requests = append(requests, req)
+			// The endpoint requests the full inventory on the third report.
+			return &agentendpointpb.ReportVmInventoryResponse{ReportFullInventory: len(requests) == 3}, nil
+		})
+
+	tc, err := newMockTestClient(ctx, mockClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := generateInventoryState()
+	if err := tc.client.report(ctx, state); err != nil {
+		t.Fatalf("report() unexpected error: %v", err)
+	}
+	utiltest.AssertEquals(t, formatted, 1)
+
+	// Collecting the same inventory again takes the fast path.
+	state = generateInventoryState()
+	state.LastUpdated = "2026-01-02T00:00:00Z"
+	if err := tc.client.report(ctx, state); err != nil {
+		t.Fatalf("report() unexpected error: %v", err)
+	}
+	utiltest.AssertEquals(t, formatted, 1)
+
+	// The inventory is formatted once the endpoint requests it in full.
+	if err := tc.client.report(ctx, state); err != nil {
+		t.Fatalf("report() unexpected error: %v", err)
+	}
+	utiltest.AssertEquals(t, formatted, 2)
+
+	// A changed inventory is formatted again.
+	state = generateInventoryState()
+	state.InstalledPackages.Yum[0].Version = "NewVersion"
+	if err := tc.client.report(ctx, state); err != nil {
+		t.Fatalf("report() unexpected error: %v", err)
+	}
+	utiltest.AssertEquals(t, formatted, 3)
+
+	full := requests[0].GetInventoryChecksum()
+	for i, req := range requests[1:4] {
+		if got := req.GetInventoryChecksum(); got != full {
+			t.Errorf("request %d checksum = %q, want the checksum of the full path %q", i+2, got, full)
+		}
+	}
+	if requests[1].GetVmInventory() != nil || requests[2].GetVmInventory() != nil {
+		t.Errorf("checksum only requests carried the inventory")
+	}
+	if requests[3].GetVmInventory() == nil {
+		t.Errorf("full inventory request carried no inventory")
+	}
+	if requests[4].GetInventoryChecksum() == full {
+		t.Errorf("changed inventory reported the checksum of the previous inventory")
+	}
+}
+
+func TestReportLogsAttempts(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Retry each call up to three times without sleeping.
+	utiltest.OverrideVariable(t, &retryAPICall, func(_ context.Context, _ time.Duration, _ string, f func() error) error {
+		var err error
+		for i := 0; i < 3; i++ {
+			if err = f(); err == nil {
+				return nil
+			}
+		}
+		return err
+	})
+	var logs []string
+	utiltest.OverrideVariable(t, &reportErrorf, func(_ context.Context, format string, args ...any) {
+		logs = append(logs, fmt.Sprintf(format, args...))
+	})
+
+	calls := 0
+	mockClient := utilmocks.NewMockAgentEndpointClient(ctrl)
+	mockClient.EXPECT().ReportVmInventory(gomock.Any(), gomock.Any(), gomock.Any()).Times(5).DoAndReturn(
+		func(context.Context, *agentendpointpb.ReportVmInventoryRequest, ...gax.CallOption) (*agentendpointpb.ReportVmInventoryResponse, error) {
+			calls++
+			// The checksum is reported on the second attempt, the full inventory never.
+			if calls == 2 {
+				return &agentendpointpb.ReportVmInventoryResponse{ReportFullInventory: true}, nil
+			}
+			return nil, status.Error(codes.Unavailable, "connection refused")
+		})
+	tc, err := newMockTestClient(ctx, mockClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	WithDisableLegacyInventory()(tc.client)
+
+	if err := tc.client.report(ctx, generateInventoryState()); err == nil {
+		t.Fatalf("report() expected an error")
+	}
+
+	if len(logs) != 1 || !strings.HasPrefix(logs[0], "Error reporting full inventory, 3 attempts in ") {
+		t.Errorf("report() logged unexpected errors: %q", logs)
+	}
+}
+
+func TestReportLogsPayloadSize(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var infos, warnings []string
+	utiltest.OverrideVariable(t, &reportSizeInfof, func(_ context.Context, format string, args ...any) {
+		infos = append(infos, fmt.Sprintf(format, args...))
+	})
+	utiltest.OverrideVariable(t, &reportSizeWarningf, func(_ context.Context, format string, args ...any) {
+		warnings = append(warnings, fmt.Sprintf(format, args...))
+	})
+
+	mockClient := utilmocks.NewMockAgentEndpointClient(ctrl)
+	mockClient.EXPECT().ReportVmInventory(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(&agentendpointpb.ReportVmInventoryResponse{}, nil)
+	tc, err := newMockTestClient(ctx, mockClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	WithReportSizeWarning(10)(tc.client)
+
+	state := generateInventoryState()
+	if err := tc.client.report(ctx, state); err != nil {
+		t.Fatalf("report() unexpected error: %v", err)
+	}
+
+	legacy, vm := PayloadSizes(formatInventory(ctx, state), formatVMInventory(ctx, state))
+	if legacy == 0 || vm == 0 {
+		t.Fatalf("PayloadSizes() = %d, %d, want non-zero sizes", legacy, vm)
+	}
+	want := fmt.Sprintf("Inventory payload size: VmInventory %d bytes, legacy Inventory %d bytes.", vm, legacy)
+	utiltest.AssertEquals(t, infos, []string{want})
+	if len(warnings) != 1 {
+		t.Errorf("expected a warning for a payload above the limit, got: %q", warnings)
+	}
+
+	legacy, vm = PayloadSizes(nil, nil)
+	utiltest.AssertEquals(t, legacy+vm, 0)
+}
+
+type fakeMetricsRecorder struct {
+	success       int
+	failures      []codes.Code
+	fullInventory int
+	durations     int
+}
+
+func (f *fakeMetricsRecorder) IncReportSuccess() { f.success++ }
+func (f *fakeMetricsRecorder) IncReportFailure(code codes.Code) {
+	f.failures = append(f.failures, code)
+}
+func (f *fakeMetricsRecorder) IncReportFullInventory()             { f.fullInventory++ }
+func (f *fakeMetricsRecorder) ObserveReportDuration(time.Duration) { f.durations++ }
+
+func TestReportMetrics(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tests := []struct {
+		name                string
+		vmInventoryErr      error
+		reportFullInventory bool
+		want                *fakeMetricsRecorder
+	}{
+		{
+			name: "Success",
+			want: &fakeMetricsRecorder{success: 1, durations: 1},
+		},
+		{
+			name:                "SuccessWithFullInventory",
+			reportFullInventory: true,
+			want:                &fakeMetricsRecorder{success: 1, fullInventory: 1, durations: 1},
+		},
+		{
+			name:           "FailedPreconditionFallback",
+			vmInventoryErr: status.Error(codes.FailedPrecondition, ""),
+			want:           &fakeMetricsRecorder{success: 1, durations: 1},
+		},
+		{
+			name:           "Failure",
+			vmInventoryErr: status.Error(codes.PermissionDenied, ""),
+			want:           &fakeMetricsRecorder{failures: []codes.Code{codes.PermissionDenied}, durations: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := utilmocks.NewMockAgentEndpointClient(ctrl)
+			reportFull := tt.reportFullInventory
+			if tt.vmInventoryErr != nil {
+				mockClient.EXPECT().ReportVmInventory(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(nil, tt.vmInventoryErr)
+			} else {
+				mockClient.EXPECT().ReportVmInventory(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+					func(context.Context, *agentendpointpb.ReportVmInventoryRequest, ...gax.CallOption) (*agentendpointpb.ReportVmInventoryResponse, error) {
+						resp := &agentendpointpb.ReportVmInventoryResponse{ReportFullInventory: reportFull}
+						reportFull = false
+						return resp, nil
+					})
+			}
+			mockClient.EXPECT().ReportInventory(gomock.Any(), gomock.Any()).AnyTimes().Return(&agentendpointpb.ReportInventoryResponse{}, nil)
+
+			tc, err := newMockTestClient(ctx, mockClient)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := &fakeMetricsRecorder{}
+			WithMetricsRecorder(got)(tc.client)
+
+			tc.client.report(ctx, generateInventoryState())
+
+			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(fakeMetricsRecorder{})); diff != "" {
+				t.Errorf("unexpected metrics (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReportCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	calls := 0
+	var endpointErr error = status.Error(codes.PermissionDenied, "")
+	mockClient := utilmocks.NewMockAgentEndpointClient(ctrl)
+	mockClient.EXPECT().ReportVmInventory(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(context.Context, *agentendpointpb.ReportVmInventoryRequest, ...gax.CallOption) (*agentendpointpb.ReportVmInventoryResponse, error) {
+			calls++
+			if endpointErr != nil {
+				return nil, endpointErr
+			}
+			return &agentendpointpb.ReportVmInventoryResponse{}, nil
+		})
+
+	tc, err := newMockTestClient(ctx, mockClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	WithReportCircuitBreaker(2, time.Minute)(tc.client)
+	tc.client.breaker.now = func() time.Time { return now }
+
+	steps := []struct {
+		name      string
+		advance   time.Duration
+		healthy   bool
+		wantCalls int
+		wantState BreakerState
+	}{
+		{name: "FirstFailure", wantCalls: 1, wantState: BreakerClosed},
+		{name: "ThresholdReached", wantCalls: 2, wantState: BreakerOpen},
+		{name: "SkippedDuringCooldown", advance: 30 * time.Second, wantCalls: 2, wantState: BreakerOpen},
+		{name: "FailedProbeReopens", advance: 30 * time.Second, wantCalls: 3, wantState: BreakerOpen},
+		{name: "SkippedAfterFailedProbe", advance: 30 * time.Second, healthy: true, wantCalls: 3, wantState: BreakerOpen},
+		{name: "SuccessfulProbeCloses", advance: 30 * time.Second, healthy: true, wantCalls: 4, wantState: BreakerClosed},
+	}
+	for _, step := range steps {
+		now = now.Add(step.advance)
+		if step.healthy {
+			endpointErr = nil
+		}
+		tc.client.report(ctx, generateInventoryState())
+		if calls != step.wantCalls {
+			t.Errorf("%s: unexpected number of ReportVmInventory calls, expect %d, got %d", step.name, step.wantCalls, calls)
+		}
+		if got := tc.client.ReportBreakerState(); got != step.wantState {
+			t.Errorf("%s: unexpected breaker state, expect %s, got %s", step.name, step.wantState, got)
+		}
+	}
+}
+
+func TestReportCircuitBreakerDisabled(t *testing.T) {
+	b := &reportBreaker{}
+	for i := 0; i < 10; i++ {
+		b.recordFailure()
+	}
+	if !b.allow() || b.currentState() != BreakerClosed {
+		t.Errorf("breaker without a threshold opened after consecutive failures, state: %s", b.currentState())
+	}
+}
+
+func TestFilterInventoryExcludedPackageTypes(t *testing.T) {
+	ctx := context.Background()
+	c := &Client{}
+	WithExcludedPackageTypes("deb", "wuaPackage")(c)
+
+	original := generateInventoryState()
+	state := c.filterInventory(original)
+
+	vmInventory := formatVMInventory(ctx, state)
+	for _, items := range [][]*agentendpointpb.VmInventory_InventoryItem{vmInventory.GetInstalledPackages(), vmInventory.GetAvailablePackages()} {
+		for _, item := range items {
+			if item.GetType() == "deb" || item.GetType() == "wuaPackage" {
+				t.Errorf("formatVMInventory returned excluded item %q of type %q", item.GetName(), item.GetType())
+			}
+		}
+	}
+	if len(vmInventory.GetInstalledPackages()) == 0 || len(vmInventory.GetAvailablePackages()) == 0 {
+		t.Errorf("formatVMInventory dropped packages of types that are not excluded")
+	}
+
+	inventory := formatInventory(ctx, state)
+	for _, pkgs := range [][]*agentendpointpb.Inventory_SoftwarePackage{inventory.GetInstalledPackages(), inventory.GetAvailablePackages()} {
+		for _, pkg := range pkgs {
+			if pkg.GetAptPackage() != nil || pkg.GetWuaPackage() != nil {
+				t.Errorf("formatInventory returned excluded package %v", pkg)
+			}
+		}
+	}
+
+	if diff := cmp.Diff(generateInventoryState(), original); diff != "" {
+		t.Errorf("filterInventory modified the original state (-want +got):\n%s", diff)
+	}
+}
+
+func TestFilterInventorySecurityUpdatesOnly(t *testing.T) {
+	ctx := context.Background()
+	installed := &packages.Packages{
+		Apt: []*packages.PkgInfo{{Name: "bash", Arch: "x86_64", Version: "5.1", Type: "deb"}},
+	}
+	updates := &packages.Packages{
+		Apt: []*packages.PkgInfo{
+			{Name: "libssl3", Arch: "x86_64", Version: "3.0.2-0ubuntu1.15", Type: "deb", Security: true},
+			{Name: "google-cloud-cli", Arch: "x86_64", Version: "470.0.0-0", Type: "deb"},
+		},
+		Yum: []*packages.PkgInfo{
+			{Name: "kernel", Arch: "x86_64", Version: "5.14.0-427", Type: "rpm", Security: true},
+			{Name: "vim-minimal", Arch: "x86_64", Version: "2:8.2.2637-20", Type: "rpm"},
+		},
+		Pip: []*packages.PipPackage{{PkgInfo: packages.PkgInfo{Name: "requests", Version: "2.32.3", Type: "pip"}}},
+		ZypperPatches: []*packages.ZypperPatch{
+			{Name: "SUSE-2024-1", Category: "security", Severity: "important"},
+			{Name: "SUSE-2024-2", Category: "recommended", Severity: "moderate"},
+		},
+		WUA: []*packages.WUAPackage{
+			{Title: "Cumulative Update", UpdateID: "security", CategoryIDs: []string{"0fa1201d-4330-4fa8-8ae9-b877473b6441"}, Categories: []string{"Security Updates"}},
+			{Title: "Definition Update", UpdateID: "definition", CategoryIDs: []string{"e0789628-ce08-4437-be74-2495b842f43b"}, Categories: []string{"Definition Updates"}},
+		},
+	}
+	state := &inventory.InstanceInventory{InstalledPackages: installed, PackageUpdates: updates}
+
+	c := &Client{}
+	WithSecurityUpdatesOnly()(c)
+	filtered := c.filterInventory(state)
+
+	var got []string
+	for _, item := range formatVMInventory(ctx, filtered).GetAvailablePackages() {
+		got = append(got, item.GetName())
+	}
+	want := []string{"kernel", "libssl3", "SUSE-2024-1", "Cumulative Update", "requests"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("formatVMInventory() unexpected available packages (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(installed, filtered.InstalledPackages); diff != "" {
+		t.Errorf("filterInventory() changed the installed packages (-want +got):\n%s", diff)
+	}
+	if len(state.PackageUpdates.Apt) != 2 {
+		t.Errorf("filterInventory() modified the unfiltered state")
+	}
+}
+
+func TestExportedFormatters(t *testing.T) {
+	ctx := context.Background()
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer svr.Close()
+	opts := []ClientOption{WithExcludedPackageTypes("deb"), WithMaxInventoryItems(3), WithNormalizedNames("rpm"), WithLabels(map[string]string{"env": "prod"})}
+
+	// reportWith reports the inventory with a Client created with opts and returns the
+	// inventories the agent endpoint received, the legacy one only if the endpoint
+	// requires the legacy API.
+	reportWith := func(legacy bool) (*agentendpointpb.VmInventory, *agentendpointpb.Inventory) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		var reportedVM *agentendpointpb.VmInventory
+		var reported *agentendpointpb.Inventory
+		mockClient := utilmocks.NewMockAgentEndpointClient(ctrl)
+		mockClient.EXPECT().ReportVmInventory(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+			func(ctx context.Context, req *agentendpointpb.ReportVmInventoryRequest, _ ...gax.CallOption) (*agentendpointpb.ReportVmInventoryResponse, error) {
+				if legacy {
+					return nil, status.Error(codes.FailedPrecondition, "legacy API required")
+				}
+				if req.GetVmInventory() != nil {
+					reportedVM = req.GetVmInventory()
+				}
+				return &agentendpointpb.ReportVmInventoryResponse{ReportFullInventory: reportedVM == nil}, nil
+			})
+		mockClient.EXPECT().ReportInventory(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+			func(_ context.Context, req *agentendpointpb.ReportInventoryRequest, _ ...gax.CallOption) (*agentendpointpb.ReportInventoryResponse, error) {
+				if req.GetInventory() != nil {
+					reported = req.GetInventory()
+				}
+				return &agentendpointpb.ReportInventoryResponse{ReportFullInventory: reported == nil}, nil
+			})
+
+		c := newClient(append(opts, WithInventoryProvider(&countingInventoryProvider{state: generateInventoryState()})))
+		c.raw = mockClient
+		if err := c.reportInventoryWithErrors(ctx, svr.URL); err != nil {
+			t.Fatalf("reportInventoryWithErrors() unexpected error: %v", err)
+		}
+		return reportedVM, reported
+	}
+
+	reportedVM, _ := reportWith(false)
+	if diff := cmp.Diff(reportedVM, FormatVMInventory(ctx, generateInventoryState(), opts...), protocmp.Transform()); diff != "" {
+		t.Errorf("FormatVMInventory() differs from the reported VmInventory (-want +got):\n%s", diff)
+	}
+	reportInfo := reportedVM.GetInstalledPackages()[len(reportedVM.GetInstalledPackages())-1]
+	utiltest.AssertEquals(t, reportInfo.GetType(), reportInfoType)
+	utiltest.AssertEquals(t, reportInfo.GetMetadata().AsMap(), map[string]any{
+		"Labels":                   map[string]any{"env": "prod"},
+		"Truncated":                true,
+		"DroppedInstalledPackages": float64(6),
+		"DroppedAvailablePackages": float64(3),
+	})
+	_, reported := reportWith(true)
+	if diff := cmp.Diff(reported, FormatInventory(ctx, generateInventoryState(), opts...), protocmp.Transform()); diff != "" {
+		t.Errorf("FormatInventory() differs from the reported Inventory (-want +got):\n%s", diff)
+	}
+	// Without options the formatters apply no filtering nor capping beyond the defaults.
+	if diff := cmp.Diff(formatInventory(ctx, generateInventoryState()), FormatInventory(ctx, generateInventoryState()), protocmp.Transform()); diff != "" {
+		t.Errorf("FormatInventory() without options differs from formatInventory() (-want +got):\n%s", diff)
+	}
+}
+
+func TestFormattersLabels(t *testing.T) {
+	ctx := context.Background()
+	labels := map[string]string{"env": "prod", "team": "payments"}
+	opts := []ClientOption{WithLabels(labels)}
+	// Changing the map after the option is applied does not change the reported labels.
+	c := newClient(opts)
+	labels["env"] = "dev"
+
+	vmInventory := c.formatReportedVMInventory(ctx, generateInventoryState())
+	var reportInfo []*agentendpointpb.VmInventory_InventoryItem
+	for _, item := range vmInventory.GetInstalledPackages() {
+		if item.GetType() == reportInfoType {
+			reportInfo = append(reportInfo, item)
+		}
+	}
+	if len(reportInfo) != 1 {
+		t.Fatalf("formatReportedVMInventory() reported %d %s items, want 1", len(reportInfo), reportInfoType)
+	}
+	utiltest.AssertEquals(t, reportInfo[0].GetMetadata().AsMap(), map[string]any{
+		"Labels": map[string]any{"env": "prod", "team": "payments"},
+	})
+
+	// Without labels there is no item describing the report.
+	if diff := cmp.Diff(formatVMInventory(ctx, generateInventoryState()), FormatVMInventory(ctx, generateInventoryState()), protocmp.Transform()); diff != "" {
+		t.Errorf("FormatVMInventory() without labels differs from formatVMInventory() (-want +got):\n%s", diff)
+	}
+	// The legacy Inventory has no field for the labels.
+	if diff := cmp.Diff(FormatInventory(ctx, generateInventoryState()), FormatInventory(ctx, generateInventoryState(), opts...), protocmp.Transform()); diff != "" {
+		t.Errorf("FormatInventory() with labels differs from the one without (-want +got):\n%s", diff)
+	}
+}
+
+func TestFormatVMInventoryOSInfo(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(*inventory.InstanceInventory)
+		want   map[string]any
+	}{
+		{
+			name: "RebootRequired",
+			change: func(state *inventory.InstanceInventory) {
+				state.RebootRequired, state.RebootRequiredReason = true, "/var/run/reboot-required exists"
+			},
+			want: map[string]any{"RebootRequired": true, "RebootRequiredReason": "/var/run/reboot-required exists"},
+		},
+		{
+			name:   "CPE",
+			change: func(state *inventory.InstanceInventory) { state.CPE = "cpe:2.3:o:debian:debian_linux:12:*:*:*:*:*:*:*" },
+			want:   map[string]any{"CPE": "cpe:2.3:o:debian:debian_linux:12:*:*:*:*:*:*:*"},
+		},
+		{
+			name: "OSRelease",
+			change: func(state *inventory.InstanceInventory) {
+				state.Variant, state.CPEName, state.BuildID = "Server Edition", "cpe:/o:fedoraproject:fedora:40", "20240422"
+			},
+			want: map[string]any{"Variant": "Server Edition", "CPEName": "cpe:/o:fedoraproject:fedora:40", "BuildID": "20240422"},
+		},
+		{
+			name: "KernelMismatch",
+			change: func(state *inventory.InstanceInventory) {
+				state.InstalledKernelRelease, state.KernelMismatch = "6.1.0-18-amd64", true
+			},
+			want: map[string]any{"InstalledKernelRelease": "6.1.0-18-amd64", "KernelMismatch": true},
+		},
+		{
+			name: "Environment",
+			change: func(state *inventory.InstanceInventory) {
+				state.Environment, state.WSLDistro = "wsl2", "Ubuntu"
+			},
+			want: map[string]any{"Environment": "wsl2", "WSLDistro": "Ubuntu"},
+		},
+		{
+			name:   "InvalidUTF8",
+			change: func(state *inventory.InstanceInventory) { state.RebootRequiredReason = "pending\xff" },
+			want:   map[string]any{"RebootRequiredReason": "pending\uFFFD"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			state := generateInventoryState()
+			tt.change(state)
+
+			vmInventory := FormatVMInventory(ctx, state)
+			reportInfo := vmInventory.GetInstalledPackages()[len(vmInventory.GetInstalledPackages())-1]
+			utiltest.AssertEquals(t, reportInfo.GetType(), reportInfoType)
+			utiltest.AssertEquals(t, reportInfo.GetMetadata().AsMap(), map[string]any{"OSInfo": tt.want})
+		})
+	}
+
+	// Without OS information beyond the OsInfo there is no item describing the report.
+	ctx := context.Background()
+	if diff := cmp.Diff(formatVMInventory(ctx, generateInventoryState()), FormatVMInventory(ctx, generateInventoryState()), protocmp.Transform()); diff != "" {
+		t.Errorf("FormatVMInventory() without OS information differs from formatVMInventory() (-want +got):\n%s", diff)
+	}
+}
+
+func TestFormatReportedInventoryLimits(t *testing.T) {
+	var warnings []string
+	utiltest.OverrideVariable(t, &truncationWarningf, func(_ context.Context, format string, args ...any) {
+		warnings = append(warnings, fmt.Sprintf(format, args...))
+	})
+	utiltest.OverrideVariable(t, &formatLegacyInventory, func(context.Context, *inventory.InstanceInventory) *agentendpointpb.Inventory {
+		return &agentendpointpb.Inventory{
+			OsInfo: &agentendpointpb.Inventory_OsInfo{},
+			InstalledPackages: []*agentendpointpb.Inventory_SoftwarePackage{
+				{Details: &agentendpointpb.Inventory_SoftwarePackage_WindowsApplication{WindowsApplication: &agentendpointpb.Inventory_WindowsApplication{
+					DisplayName: "Google Chrome", DisplayVersion: "120.0.6099.130", Publisher: "Google LLC", HelpLink: "https://support.google.com/chrome?user=alice",
+				}}},
+				{Details: &agentendpointpb.Inventory_SoftwarePackage_YumPackage{YumPackage: &agentendpointpb.Inventory_VersionedPackage{
+					PackageName: "kernel", Architecture: "x86_64", Version: "5.14.0-362.el9-" + strings.Repeat("x", 20),
+					Source: &agentendpointpb.Inventory_VersionedPackage_Source{Name: "kernel", Version: "5.14.0"},
+				}}},
+			},
+		}
+	})
+	var redacted []string
+	c := newClient([]ClientOption{
+		WithNormalizedNames(),
+		WithMaxVersionLength(20),
+		WithMetadataRedactor(func(item *agentendpointpb.VmInventory_InventoryItem) {
+			redacted = append(redacted, item.GetType()+"/"+item.GetName())
+			delete(item.GetMetadata().GetFields(), "HelpLink")
+		}),
+	})
+
+	got := c.formatReportedInventory(context.Background(), nil)
+
+	want := []*agentendpointpb.Inventory_SoftwarePackage{
+		{Details: &agentendpointpb.Inventory_SoftwarePackage_WindowsApplication{WindowsApplication: &agentendpointpb.Inventory_WindowsApplication{
+			DisplayName: "google chrome", DisplayVersion: "120.0.6099.130", Publisher: "Google LLC",
+		}}},
+		{Details: &agentendpointpb.Inventory_SoftwarePackage_YumPackage{YumPackage: &agentendpointpb.Inventory_VersionedPackage{
+			PackageName: "kernel", Architecture: "x86_64", Version: "5.14.0-362.el9-xxxx…",
+			Source: &agentendpointpb.Inventory_VersionedPackage_Source{Name: "kernel", Version: "5.14.0"},
+		}}},
+	}
+	if diff := cmp.Diff(want, got.GetInstalledPackages(), protocmp.Transform()); diff != "" {
+		t.Errorf("formatReportedInventory() unexpected packages (-want +got):\n%s", diff)
+	}
+	utiltest.AssertEquals(t, redacted, []string{"windowsApplication/google chrome", "rpm/kernel"})
+	utiltest.AssertEquals(t, warnings, []string{"Truncated the versions of 1 legacy packages to the limit of 20 characters."})
+}
+
+func TestCapVMInventory(t *testing.T) {
+	var warnings []string
+	utiltest.OverrideVariable(t, &truncationWarningf, func(_ context.Context, format string, args ...any) {
+		warnings = append(warnings, fmt.Sprintf(format, args...))
+	})
+	c := &Client{}
+	WithMaxInventoryItems(3)(c)
+	vmInventory := &agentendpointpb.VmInventory{
+		InstalledPackages: []*agentendpointpb.VmInventory_InventoryItem{
+			{Name: "requests", Type: "pypi"},
+			{Name: "ssh", Type: "service"},
+			{Name: "bash", Type: "deb"},
+			{Name: "rake", Type: "gem"},
+			{Name: "root-ca", Type: "certificate"},
+			{Name: "curl", Type: "deb"},
+			{Name: "tcp/0.0.0.0:22", Type: "listening-port"},
+		},
+		AvailablePackages: []*agentendpointpb.VmInventory_InventoryItem{
+			{Name: "bash", Type: "deb"},
+		},
+	}
+
+	got, dropped := c.capVMInventory(context.Background(), vmInventory)
+
+	var names []string
+	for _, item := range got.GetInstalledPackages() {
+		names = append(names, item.GetName())
+		if item.GetMetadata() != nil {
+			t.Errorf("capVMInventory() unexpected metadata on %q: %v", item.GetName(), item.GetMetadata())
+		}
+	}
+	// Items that are not packages are neither counted nor dropped.
+	if diff := cmp.Diff([]string{"requests", "ssh", "bash", "root-ca", "curl", "tcp/0.0.0.0:22"}, names); diff != "" {
+		t.Errorf("capVMInventory() unexpected installed packages (-want +got):\n%s", diff)
+	}
+	utiltest.AssertEquals(t, len(got.GetAvailablePackages()), 1)
+	if dropped != (droppedPackages{installed: 1}) {
+		t.Errorf("capVMInventory() dropped = %+v, want: %+v", dropped, droppedPackages{installed: 1})
+	}
+	utiltest.AssertEquals(t, warnings, []string{"Inventory has 4 packages, dropped 1 to stay within the limit of 3."})
+}
+
+func TestCapMetadataFields(t *testing.T) {
+	fields := map[string]*structpb.Value{}
+	for i := 0; i < 20; i++ {
+		fields[fmt.Sprintf("Key%02d", i)] = structpb.NewNumberValue(float64(i))
+	}
+	vmInventory := &agentendpointpb.VmInventory{
+		InstalledPackages: []*agentendpointpb.VmInventory_InventoryItem{
+			{Name: "big", Metadata: &structpb.Struct{Fields: fields}},
+			{Name: "small", Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{"Key": structpb.NewStringValue("value")}}},
+			{Name: "none"},
+		},
+	}
+
+	c := &Client{}
+	WithMaxMetadataFields(3)(c)
+	got := c.capMetadataFields(vmInventory)
+
+	want := &structpb.Struct{Fields: map[string]*structpb.Value{
+		"Key00":                      structpb.NewNumberValue(0),
+		"Key01":                      structpb.NewNumberValue(1),
+		"Key02":                      structpb.NewNumberValue(2),
+		metadataTruncatedMetadataKey: structpb.NewBoolValue(true),
+	}}
+	if diff := cmp.Diff(want, got.GetInstalledPackages()[0].GetMetadata(), protocmp.Transform()); diff != "" {
+		t.Errorf("capMetadataFields() unexpected metadata (-want +got):\n%s", diff)
+	}
+	if _, ok := got.GetInstalledPackages()[1].GetMetadata().GetFields()[metadataTruncatedMetadataKey]; ok {
+		t.Errorf("capMetadataFields() flagged metadata within the limit as truncated")
+	}
+	if got.GetInstalledPackages()[2].GetMetadata() != nil {
+		t.Errorf("capMetadataFields() unexpected metadata on an item without metadata")
+	}
+}
+
+func TestCapVersions(t *testing.T) {
+	long := "0.0.0-20240102030405-" + strings.Repeat("0123456789abcdef", 20)
+	vmInventory := &agentendpointpb.VmInventory{
+		InstalledPackages: []*agentendpointpb.VmInventory_InventoryItem{
+			{Name: "github.com/example/module", Version: long},
+			{Name: "bash", Version: "5.2.15-2+b2", Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{}}},
+		},
+		AvailablePackages: []*agentendpointpb.VmInventory_InventoryItem{
+			{Name: "pkg", Version: long, Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{"Key": structpb.NewStringValue("value")}}},
+		},
+	}
+	var warnings []string
+	utiltest.OverrideVariable(t, &truncationWarningf, func(_ context.Context, format string, args ...any) {
+		warnings = append(warnings, fmt.Sprintf(format, args...))
+	})
+
+	c := &Client{}
+	WithMaxVersionLength(32)(c)
+	got := c.capVersions(context.Background(), vmInventory)
+
+	wantVersion := long[:31] + "…"
+	for _, item := range []*agentendpointpb.VmInventory_InventoryItem{got.GetInstalledPackages()[0], got.GetAvailablePackages()[0]} {
+		utiltest.AssertEquals(t, item.GetVersion(), wantVersion)
+		utiltest.AssertEquals(t, len([]rune(item.GetVersion())), 32)
+		utiltest.AssertEquals(t, item.GetMetadata().GetFields()[fullVersionMetadataKey].GetStringValue(), long)
+	}
+	utiltest.AssertEquals(t, got.GetAvailablePackages()[0].GetMetadata().GetFields()["Key"].GetStringValue(), "value")
+	utiltest.AssertEquals(t, got.GetInstalledPackages()[1].GetVersion(), "5.2.15-2+b2")
+	if _, ok := got.GetInstalledPackages()[1].GetMetadata().GetFields()[fullVersionMetadataKey]; ok {
+		t.Errorf("capVersions() recorded the full version of a version within the limit")
+	}
+	utiltest.AssertEquals(t, warnings, []string{"Truncated the versions of 2 packages to the limit of 32 characters."})
+
+	// The default limit leaves normal and long but reasonable versions alone.
+	untouched := &agentendpointpb.VmInventory{InstalledPackages: []*agentendpointpb.VmInventory_InventoryItem{{Name: "m", Version: long[:defaultMaxVersionLength]}}}
+	c = &Client{maxVersionLength: defaultMaxVersionLength}
+	utiltest.AssertEquals(t, c.capVersions(context.Background(), untouched).GetInstalledPackages()[0].GetVersion(), long[:defaultMaxVersionLength])
+}
+
+func TestNormalizeNames(t *testing.T) {
+	newInventory := func(appName, pipName, npmName string) *agentendpointpb.VmInventory {
+		return &agentendpointpb.VmInventory{
+			OsInfo: &agentendpointpb.VmInventory_OsInfo{HostName: "host"},
+			InstalledPackages: []*agentendpointpb.VmInventory_InventoryItem{
+				{Name: appName, Type: "windowsApplication", Version: "124.0", Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{}}},
+				{Name: pipName, Type: "pypi", Version: "3.0.2"},
+				{Name: npmName, Type: "npm", Version: "1.0.0"},
+			},
+		}
+	}
+	hostA := func() *agentendpointpb.VmInventory { return newInventory("Google Chrome", "Flask", "JSONStream") }
+	hostB := func() *agentendpointpb.VmInventory { return newInventory("GOOGLE CHROME", "flask", "JSONStream") }
+	fingerprint := func(inv *agentendpointpb.VmInventory) string {
+		t.Helper()
+		f, err := computeStableFingerprintVMInventory(context.Background(), inv)
+		if err != nil {
+			t.Fatalf("computeStableFingerprintVMInventory() unexpected error: %v", err)
+		}
+		return f
+	}
+
+	c := &Client{}
+	if fingerprint(c.normalizeNames(hostA())) == fingerprint(c.normalizeNames(hostB())) {
+		t.Errorf("normalizeNames() without the option normalized the names of the inventory")
+	}
+
+	WithNormalizedNames()(c)
+	gotA, gotB := c.normalizeNames(hostA()), c.normalizeNames(hostB())
+	if fingerprint(gotA) != fingerprint(c.normalizeNames(hostA())) {
+		t.Errorf("normalizeNames() fingerprint is not stable across runs")
+	}
+	for _, got := range []*agentendpointpb.VmInventory{gotA, gotB} {
+		items := got.GetInstalledPackages()
+		utiltest.AssertEquals(t, items[0].GetName(), "google chrome")
+		utiltest.AssertEquals(t, items[1].GetName(), "flask")
+		// npm package names are case-sensitive and are not normalized by default.
+		utiltest.AssertEquals(t, items[2].GetName(), "JSONStream")
+		if _, ok := items[2].GetMetadata().GetFields()[displayNameMetadataKey]; ok {
+			t.Errorf("normalizeNames() set %s on an item that was not normalized", displayNameMetadataKey)
+		}
+	}
+	utiltest.AssertEquals(t, gotA.GetInstalledPackages()[0].GetMetadata().GetFields()[displayNameMetadataKey].GetStringValue(), "Google Chrome")
+	utiltest.AssertEquals(t, gotB.GetInstalledPackages()[0].GetMetadata().GetFields()[displayNameMetadataKey].GetStringValue(), "GOOGLE CHROME")
+	utiltest.AssertEquals(t, gotA.GetInstalledPackages()[1].GetMetadata().GetFields()[displayNameMetadataKey].GetStringValue(), "Flask")
+	if _, ok := gotB.GetInstalledPackages()[1].GetMetadata().GetFields()[displayNameMetadataKey]; ok {
+		t.Errorf("normalizeNames() set %s on a name that was already lowercase", displayNameMetadataKey)
+	}
+
+	// Comparisons ignore the display name, e.g. by the names and versions of the items.
+	key := func(inv *agentendpointpb.VmInventory) []string {
+		var keys []string
+		for _, item := range inv.GetInstalledPackages() {
+			keys = append(keys, item.GetType()+"/"+item.GetName()+"@"+item.GetVersion())
+		}
+		return keys
+	}
+	utiltest.AssertEquals(t, key(gotA), key(gotB))
+
+	c = &Client{}
+	WithNormalizedNames("npm")(c)
+	utiltest.AssertEquals(t, c.normalizeNames(hostA()).GetInstalledPackages()[2].GetName(), "jsonstream")
+	utiltest.AssertEquals(t, c.normalizeNames(hostA()).GetInstalledPackages()[0].GetName(), "Google Chrome")
+}
+
+func TestRedactMetadata(t *testing.T) {
+	newInventory := func() *agentendpointpb.VmInventory {
+		return &agentendpointpb.VmInventory{
+			InstalledPackages: []*agentendpointpb.VmInventory_InventoryItem{
+				{Name: "app", Location: []string{`C:\Program Files\App`}, Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+					"Publisher":     structpb.NewStringValue("Example"),
+					"HelpLink":      structpb.NewStringValue("https://internal.example.com/help"),
+					"InstallSource": structpb.NewStringValue(`\\fileserver\installers`),
+				}}},
+			},
+			AvailablePackages: []*agentendpointpb.VmInventory_InventoryItem{
+				{Name: "update", Location: []string{"/var/cache"}},
+			},
+		}
+	}
+	redactor := func(item *agentendpointpb.VmInventory_InventoryItem) {
+		item.Location = nil
+		delete(item.GetMetadata().GetFields(), "InstallSource")
+	}
+
+	c := &Client{}
+	WithMetadataRedactor(redactor)(c)
+	got := c.redactMetadata(newInventory())
+
+	want := &agentendpointpb.VmInventory{
+		InstalledPackages: []*agentendpointpb.VmInventory_InventoryItem{
+			{Name: "app", Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"Publisher": structpb.NewStringValue("Example"),
+				"HelpLink":  structpb.NewStringValue("https://internal.example.com/help"),
+			}}},
+		},
+		AvailablePackages: []*agentendpointpb.VmInventory_InventoryItem{
+			{Name: "update"},
+		},
+	}
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+		t.Errorf("redactMetadata() unexpected inventory (-want +got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff(newInventory(), (&Client{}).redactMetadata(newInventory()), protocmp.Transform()); diff != "" {
+		t.Errorf("redactMetadata() without a redactor modified the inventory (-want +got):\n%s", diff)
+	}
+}
+
+func TestCapItemsPrefersOSPackages(t *testing.T) {
+	isLanguagePackage := func(s string) bool { return strings.HasPrefix(s, "lang-") }
+	items := []string{"lang-a", "os-a", "lang-b", "os-b", "os-c"}
+
+	tests := []struct {
+		max         int
+		want        []string
+		wantDropped int
+	}{
+		{5, items, 0},
+		{4, []string{"lang-a", "os-a", "os-b", "os-c"}, 1},
+		{3, []string{"os-a", "os-b", "os-c"}, 2},
+		{2, []string{"os-a", "os-b"}, 3},
+	}
+	for _, tt := range tests {
+		got, dropped := capItems(items, tt.max, isLanguagePackage)
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("capItems(%d) unexpected items (-want +got):\n%s", tt.max, diff)
+		}
+		utiltest.AssertEquals(t, dropped, tt.wantDropped)
+	}
+}
+
+func TestCapInventoryDisabled(t *testing.T) {
+	inventory := &agentendpointpb.Inventory{InstalledPackages: []*agentendpointpb.Inventory_SoftwarePackage{{}, {}}}
+	if got := (&Client{}).capInventory(context.Background(), inventory); len(got.GetInstalledPackages()) != 2 {
+		t.Errorf("capInventory() without a limit dropped packages: %v", got)
+	}
+}
+
+func TestFormatPackagesRPMBackend(t *testing.T) {
+	rpm := []*packages.PkgInfo{{Name: "bash", Arch: "x86_64", Version: "5.1", Type: "rpm"}}
+	tests := []struct {
+		backend    packages.RPMBackend
+		wantZypper bool
+	}{
+		{packages.RPMBackendYum, false},
+		{packages.RPMBackendDnf, false},
+		{packages.RPMBackendZypper, true},
+		{packages.RPMBackendUnknown, false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.backend), func(t *testing.T) {
+			got := formatPackages(context.Background(), &packages.Packages{Rpm: rpm, RPMBackend: tt.backend}, "ShortName")
+			if len(got) != 1 {
+				t.Fatalf("formatPackages() unexpected number of packages, expect 1, got %d", len(got))
+			}
+			if isZypper := got[0].GetZypperPackage() != nil; isZypper != tt.wantZypper {
+				t.Errorf("formatPackages() = %v, want zypper package: %v", got[0], tt.wantZypper)
+			}
+		})
+	}
+}
+
+func TestFormatNilAndEmptyInventory(t *testing.T) {
+	ctx := context.Background()
+	c := &Client{}
+	WithExcludedPackageTypes("deb")(c)
+
+	for _, tt := range []struct {
+		name  string
+		state *inventory.InstanceInventory
+	}{
+		{"nil", nil},
+		{"empty", &inventory.InstanceInventory{}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			state := c.filterInventory(tt.state)
+
+			vmInventory := formatVMInventory(ctx, state)
+			if vmInventory.GetOsInfo() == nil || len(vmInventory.GetInstalledPackages()) != 0 || len(vmInventory.GetAvailablePackages()) != 0 {
+				t.Errorf("formatVMInventory() = %v, want an empty VmInventory with OsInfo", vmInventory)
+			}
+			inventory := formatInventory(ctx, state)
+			if inventory.GetOsInfo() == nil || len(inventory.GetInstalledPackages()) != 0 || len(inventory.GetAvailablePackages()) != 0 {
+				t.Errorf("formatInventory() = %v, want an empty Inventory with OsInfo", inventory)
+			}
+		})
+	}
+}
+
+func TestFilterInventoryNoExclusions(t *testing.T) {
+	state := generateInventoryState()
+	if got := (&Client{}).filterInventory(state); got != state {
+		t.Errorf("filterInventory without exclusions returned a different state")
+	}
+}
+
+func TestFormatAppliedZypperPatches(t *testing.T) {
+	ctx := context.Background()
+	patches := []*packages.ZypperPatch{
+		{Name: "applied-patch", Status: "applied"},
+		{Name: "needed-patch", Status: "needed"},
+		{Name: "installed-patch", Status: "installed"},
+		{Name: "not-applied-patch", Status: "not applied"},
+		{Name: "unknown-status-patch"},
+	}
+	state := &inventory.InstanceInventory{
+		InstalledPackages: &packages.Packages{ZypperPatches: patches},
+		PackageUpdates:    &packages.Packages{ZypperPatches: patches},
+	}
+
+	var available []string
+	for _, item := range formatVMInventory(ctx, state).GetAvailablePackages() {
+		available = append(available, item.GetName())
+	}
+	utiltest.AssertEquals(t, available, []string{"needed-patch", "not-applied-patch", "unknown-status-patch"})
+
+	var legacy []string
+	for _, pkg := range formatInventory(ctx, state).GetAvailablePackages() {
+		legacy = append(legacy, pkg.GetZypperPatch().GetPatchName())
+	}
+	utiltest.AssertEquals(t, legacy, []string{"needed-patch", "not-applied-patch", "unknown-status-patch"})
+
+	// Installed patches are reported regardless of their status.
+	utiltest.AssertEquals(t, len(formatVMInventory(ctx, state).GetInstalledPackages()), len(patches))
+	utiltest.AssertEquals(t, len(state.PackageUpdates.ZypperPatches), len(patches))
+}
+
+func TestFilterInventoryMinZypperPatchSeverity(t *testing.T) {
+	ctx := context.Background()
+	patches := []*packages.ZypperPatch{
+		{Name: "low-patch", Severity: "low"},
+		{Name: "moderate-patch", Severity: "moderate"},
+		{Name: "important-patch", Severity: "Important"},
+		{Name: "critical-patch", Severity: "critical"},
+		{Name: "unspecified-patch", Severity: "unspecified"},
+	}
+
+	tests := []struct {
+		threshold string
+		want      []string
+	}{
+		{"", []string{"low-patch", "moderate-patch", "important-patch", "critical-patch", "unspecified-patch"}},
+		{"low", []string{"low-patch", "moderate-patch", "important-patch", "critical-patch", "unspecified-patch"}},
+		{"moderate", []string{"moderate-patch", "important-patch", "critical-patch", "unspecified-patch"}},
+		{"important", []string{"important-patch", "critical-patch", "unspecified-patch"}},
+		{"critical", []string{"critical-patch", "unspecified-patch"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.threshold, func(t *testing.T) {
+			c := &Client{}
+			WithMinZypperPatchSeverity(tt.threshold)(c)
+			state := c.filterInventory(&inventory.InstanceInventory{
+				InstalledPackages: &packages.Packages{ZypperPatches: patches},
+				PackageUpdates:    &packages.Packages{ZypperPatches: patches},
+			})
+
+			var got []string
+			for _, item := range formatVMInventory(ctx, state).GetAvailablePackages() {
+				got = append(got, item.GetName())
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("formatVMInventory() unexpected available zypper patches (-want +got):\n%s", diff)
+			}
+
+			// The legacy inventory is sorted by name.
+			want := append([]string(nil), tt.want...)
+			sort.Strings(want)
+			got = nil
+			for _, pkg := range formatInventory(ctx, state).GetInstalledPackages() {
+				got = append(got, pkg.GetZypperPatch().GetPatchName())
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("formatInventory() unexpected installed zypper patches (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFormatPackagesStableOrder(t *testing.T) {
+	ctx := context.Background()
+	apt := []*packages.PkgInfo{
+		{Name: "zlib1g", Arch: "x86_64", Version: "1.2.13"},
+		{Name: "bash", Arch: "x86_64", Version: "5.2"},
+		{Name: "bash", Arch: "x86_64", Version: "5.1"},
+		{Name: "bash", Arch: "i686", Version: "5.2"},
+	}
+	reversed := make([]*packages.PkgInfo, len(apt))
+	for i, pkg := range apt {
+		reversed[len(apt)-1-i] = pkg
+	}
+	qfe := []*packages.QFEPackage{{HotFixID: "KB2"}, {HotFixID: "KB1"}}
+
+	want := []string{"bash/x86_32/5.2", "bash/x86_64/5.1", "bash/x86_64/5.2", "zlib1g/x86_64/1.2.13", "KB1", "KB2"}
+	for _, input := range [][]*packages.PkgInfo{apt, reversed} {
+		var got []string
+		for _, pkg := range formatPackages(ctx, &packages.Packages{Apt: input, QFE: qfe}, "debian") {
+			if p := pkg.GetAptPackage(); p != nil {
+				got = append(got, fmt.Sprintf("%s/%s/%s", p.GetPackageName(), p.GetArchitecture(), p.GetVersion()))
+			} else {
+				got = append(got, pkg.GetQfePackage().GetHotFixId())
+			}
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("formatPackages() unexpected order (-want +got):\n%s", diff)
+		}
+	}
+}
+
+func TestFormatMultipleInstalledVersions(t *testing.T) {
+	ctx := context.Background()
+	kernels := []*packages.PkgInfo{
+		{Name: "kernel", Arch: "x86_64", Version: "5.14.0-362.8.1.el9_3", Type: "rpm", Purl: "pkg:rpm/rhel/kernel@5.14.0-362.8.1.el9_3?arch=x86_64"},
+		{Name: "kernel", Arch: "x86_64", Version: "5.14.0-427.13.1.el9_4", Type: "rpm", Purl: "pkg:rpm/rhel/kernel@5.14.0-427.13.1.el9_4?arch=x86_64"},
+	}
+	state := &inventory.InstanceInventory{InstalledPackages: &packages.Packages{Rpm: kernels}}
+
+	var gotItems []string
+	for _, item := range formatVMInventory(ctx, state).GetInstalledPackages() {
+		gotItems = append(gotItems, item.GetName()+" "+item.GetVersion())
+	}
+	wantItems := []string{"kernel 5.14.0-362.8.1.el9_3", "kernel 5.14.0-427.13.1.el9_4"}
+	if diff := cmp.Diff(wantItems, gotItems); diff != "" {
+		t.Errorf("formatVMInventory() unexpected installed packages (-want +got):\n%s", diff)
+	}
+
+	gotItems = nil
+	for _, pkg := range formatInventory(ctx, state).GetInstalledPackages() {
+		gotItems = append(gotItems, pkg.GetYumPackage().GetPackageName()+" "+pkg.GetYumPackage().GetVersion())
+	}
+	if diff := cmp.Diff(wantItems, gotItems); diff != "" {
+		t.Errorf("formatInventory() unexpected installed packages (-want +got):\n%s", diff)
+	}
+
+	both, err := computeStableFingerprintVMInventory(ctx, formatVMInventory(ctx, state))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, kernel := range kernels {
+		single := &inventory.InstanceInventory{InstalledPackages: &packages.Packages{Rpm: []*packages.PkgInfo{kernel}}}
+		fingerprint, err := computeStableFingerprintVMInventory(ctx, formatVMInventory(ctx, single))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fingerprint == both {
+			t.Errorf("fingerprint with only kernel %s installed matches the fingerprint with both kernels installed", kernel.Version)
+		}
+	}
+}
+
+func TestFormatDeduplicatesPackages(t *testing.T) {
+	ctx := context.Background()
+	rpm := &packages.PkgInfo{Name: "bash", Arch: "x86_64", Version: "5.1", Type: "rpm", Purl: "pkg:rpm/rhel/bash@5.1?arch=x86_64"}
+	rpmOtherArch := &packages.PkgInfo{Name: "bash", Arch: "i686", Version: "5.1", Type: "rpm", Purl: "pkg:rpm/rhel/bash@5.1?arch=i686"}
+	wua1 := &packages.WUAPackage{Title: "Update", UpdateID: "1", RevisionNumber: 1}
+	wua2 := &packages.WUAPackage{Title: "Update", UpdateID: "2", RevisionNumber: 1}
+	wua1Rev2 := &packages.WUAPackage{Title: "Update", UpdateID: "1", RevisionNumber: 2}
+	pkgs := &packages.Packages{
+		Yum: []*packages.PkgInfo{rpm, rpmOtherArch},
+		Rpm: []*packages.PkgInfo{rpm, rpm},
+		WUA: []*packages.WUAPackage{wua1, wua2, wua1, wua1Rev2},
+	}
+
+	items := formatPkgsToInventoryItems(ctx, pkgs)
+	var gotItems []string
+	for _, item := range items {
+		gotItems = append(gotItems, fmt.Sprintf("%s %s %s %s", item.GetType(), item.GetName(), item.GetVersion(), item.GetPurl()))
+	}
+	wantItems := []string{
+		"rpm bash 5.1 pkg:rpm/rhel/bash@5.1?arch=x86_64",
+		"rpm bash 5.1 pkg:rpm/rhel/bash@5.1?arch=i686",
+		"wuaPackage Update 1 ",
+		"wuaPackage Update 2 ",
+		"wuaPackage Update 1 ",
+	}
+	if diff := cmp.Diff(wantItems, gotItems); diff != "" {
+		t.Errorf("formatPkgsToInventoryItems returned unexpected items (-want +got):\n%s", diff)
+	}
+
+	softwarePackages := formatPackages(ctx, pkgs, "rhel")
+	if len(softwarePackages) != 5 {
+		t.Errorf("formatPackages returned %d packages, want 5: %v", len(softwarePackages), softwarePackages)
+	}
+}
+
+func TestRpmEpochMetadata(t *testing.T) {
+	tests := []struct {
+		name      string
+		version   string
+		wantEpoch string
+	}{
+		{name: "WithEpoch", version: "1:2.3-4.el8", wantEpoch: "1"},
+		{name: "WithoutEpoch", version: "2.3-4.el8"},
+		{name: "NonNumericPrefix", version: "abc:2.3-4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg := &packages.PkgInfo{Name: "pkg", Version: tt.version, Type: "rpm", Source: packages.Source{Name: "pkg.src.rpm"}}
+			for _, items := range [][]*agentendpointpb.VmInventory_InventoryItem{
+				yumToInventoryItem([]*packages.PkgInfo{pkg}),
+				rpmToInventoryItem([]*packages.PkgInfo{pkg}),
+				zypperToInventoryItem([]*packages.PkgInfo{pkg}),
+			} {
+				utiltest.AssertEquals(t, items[0].GetVersion(), tt.version)
+				epoch, ok := items[0].GetMetadata().GetFields()["Epoch"]
+				if tt.wantEpoch == "" {
+					if ok {
+						t.Errorf("unexpected Epoch metadata %v for version %q", epoch, tt.version)
+					}
+					continue
+				}
+				utiltest.AssertEquals(t, epoch.GetStringValue(), tt.wantEpoch)
+			}
+		})
+	}
+}
+
+func TestArchitectureNormalization(t *testing.T) {
+	pkg := &packages.PkgInfo{Name: "foo", Arch: "all", RawArch: "noarch", Version: "1.0", Type: "rpm"}
+	googetPkg := &packages.PkgInfo{Name: "bar", Arch: "amd64", Version: "1.0", Type: "googet"}
+
+	utiltest.AssertEquals(t, formatYumPackage(pkg).YumPackage.GetArchitecture(), "all")
+	utiltest.AssertEquals(t, formatGooGetPackage(googetPkg).GoogetPackage.GetArchitecture(), "x86_64")
+
+	fields := yumToInventoryItem([]*packages.PkgInfo{pkg})[0].GetMetadata().GetFields()
+	utiltest.AssertEquals(t, fields["RawArchitecture"].GetStringValue(), "noarch")
+
+	fields = googetToInventoryItem([]*packages.PkgInfo{googetPkg})[0].GetMetadata().GetFields()
+	if raw, ok := fields["RawArchitecture"]; ok {
+		t.Errorf("unexpected RawArchitecture metadata %v for package without raw architecture", raw)
+	}
+}
+
+func TestFormatKernelModules(t *testing.T) {
+	pkgs := &packages.Packages{
+		KernelModules: []*packages.PkgInfo{
+			{Name: "e1000", Version: "7.3.21-k8-NAPI", Type: "kernel-module"},
+			{Name: "tls", Type: "kernel-module"},
+		},
+	}
+
+	got := formatPkgsToInventoryItems(context.Background(), pkgs)
+
+	want := []*agentendpointpb.VmInventory_InventoryItem{
+		{Name: "e1000", Type: "kernel-module", Version: "7.3.21-k8-NAPI", Location: []string{}, Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{}}},
+		{Name: "tls", Type: "kernel-module", Location: []string{}, Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{}}},
+	}
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+		t.Errorf("formatPkgsToInventoryItems() unexpected diff:\n%s", diff)
+	}
+
+	// Kernel modules have no legacy representation.
+	if legacy := formatPackages(context.Background(), pkgs, ""); len(legacy) != 0 {
+		t.Errorf("formatPackages() unexpected kernel modules in legacy inventory: %v", legacy)
+	}
+}
+
+func TestFormatPipPackages(t *testing.T) {
+	pkgs := &packages.Packages{
+		Pip: []*packages.PipPackage{
+			{PkgInfo: packages.PkgInfo{Name: "requests", Version: "2.22.0", Type: "pypi", Purl: "pkg:pypi/requests@2.22.0"}, Location: "/usr/lib/python3/dist-packages", License: "Apache 2.0", Summary: "Python HTTP for Humans."},
+			{PkgInfo: packages.PkgInfo{Name: "numpy", Version: "1.26.4", Type: "pypi", Purl: "pkg:pypi/numpy@1.26.4"}, Location: "/opt/venv/lib/python3.11/site-packages", Virtualenv: true},
+			{PkgInfo: packages.PkgInfo{Name: "myproject", Version: "0.1.0", Type: "pypi", Purl: "pkg:generic/myproject@0.1.0"}, Location: "/opt/venv/lib/python3.11/site-packages", Virtualenv: true, LocalProject: "/home/user/src/myproject", Editable: true},
+		},
+	}
+
+	got := formatPkgsToInventoryItems(context.Background(), pkgs)
+
+	want := []*agentendpointpb.VmInventory_InventoryItem{
+		{
+			Name: "requests", Type: "pypi", Version: "2.22.0", Purl: "pkg:pypi/requests@2.22.0", Location: []string{"/usr/lib/python3/dist-packages"},
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"Virtualenv": structpb.NewBoolValue(false),
+				"License":    structpb.NewStringValue("Apache 2.0"),
+				"Summary":    structpb.NewStringValue("Python HTTP for Humans."),
+			}},
+		},
+		{
+			Name: "numpy", Type: "pypi", Version: "1.26.4", Purl: "pkg:pypi/numpy@1.26.4", Location: []string{"/opt/venv/lib/python3.11/site-packages"},
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{"Virtualenv": structpb.NewBoolValue(true)}},
+		},
+		{
+			Name: "myproject", Type: "pypi", Version: "0.1.0", Purl: "pkg:generic/myproject@0.1.0", Location: []string{"/opt/venv/lib/python3.11/site-packages"},
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"Virtualenv":   structpb.NewBoolValue(true),
+				"LocalInstall": structpb.NewBoolValue(true),
+				"LocalProject": structpb.NewStringValue("/home/user/src/myproject"),
+				"Editable":     structpb.NewBoolValue(true),
+			}},
+		},
+	}
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+		t.Errorf("formatPkgsToInventoryItems() unexpected diff:\n%s", diff)
+	}
+}
+
+func TestFormatGoModules(t *testing.T) {
+	pkgs := &packages.Packages{
+		GoModules: []*packages.GoModule{
+			{Path: "github.com/google/go-cmp", Version: "v0.6.0", Purl: "pkg:golang/github.com/google/go-cmp@v0.6.0", Binaries: []string{"/usr/bin/app1", "/usr/bin/app2"}},
+		},
+	}
+
+	got := formatPkgsToInventoryItems(context.Background(), pkgs)
+
+	want := []*agentendpointpb.VmInventory_InventoryItem{
+		{
+			Name:     "github.com/google/go-cmp",
+			Type:     "golang",
+			Version:  "v0.6.0",
+			Purl:     "pkg:golang/github.com/google/go-cmp@v0.6.0",
+			Location: []string{"/usr/bin/app1", "/usr/bin/app2"},
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{}},
+		},
+	}
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+		t.Errorf("formatPkgsToInventoryItems() unexpected diff:\n%s", diff)
+	}
+}
+
+func TestFormatNpmPackages(t *testing.T) {
+	pkgs := &packages.Packages{
+		Npm: []*packages.NpmPackage{
+			{Name: "lodash", Version: "4.17.21", Purl: "pkg:npm/lodash@4.17.21", Location: []string{"/srv/app/node_modules/lodash"}},
+		},
+	}
+
+	got := formatPkgsToInventoryItems(context.Background(), pkgs)
+
+	want := []*agentendpointpb.VmInventory_InventoryItem{
+		{
+			Name:     "lodash",
+			Type:     "npm",
+			Version:  "4.17.21",
+			Purl:     "pkg:npm/lodash@4.17.21",
+			Location: []string{"/srv/app/node_modules/lodash"},
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{}},
+		},
+	}
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+		t.Errorf("formatPkgsToInventoryItems() unexpected diff:\n%s", diff)
+	}
+}
+
+func TestFormatCargoPackages(t *testing.T) {
+	pkgs := &packages.Packages{
+		Cargo: []*packages.CargoPackage{
+			{Name: "ripgrep", Version: "14.0.3", Purl: "pkg:cargo/ripgrep@14.0.3", Source: "registry+https://github.com/rust-lang/crates.io-index", Location: []string{"/root/.cargo/bin/rg"}},
+		},
+	}
+
+	got := formatPkgsToInventoryItems(context.Background(), pkgs)
+
+	want := []*agentendpointpb.VmInventory_InventoryItem{
+		{
+			Name:     "ripgrep",
+			Type:     "cargo",
+			Version:  "14.0.3",
+			Purl:     "pkg:cargo/ripgrep@14.0.3",
+			Location: []string{"/root/.cargo/bin/rg"},
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"Source": structpb.NewStringValue("registry+https://github.com/rust-lang/crates.io-index"),
+			}},
+		},
+	}
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+		t.Errorf("formatPkgsToInventoryItems() unexpected diff:\n%s", diff)
+	}
+
+	filtered := (&Client{excludedPackageTypes: map[string]bool{"cargo": true}}).filterInventory(&inventory.InstanceInventory{InstalledPackages: pkgs})
+	if filtered.InstalledPackages.Cargo != nil {
+		t.Errorf("excluded cargo crates were reported: %v", filtered.InstalledPackages.Cargo)
+	}
+}
+
+func TestFormatIDEExtensions(t *testing.T) {
+	pkgs := &packages.Packages{
+		IDEExtensions: []*packages.IDEExtension{
+			{IDE: "vscode", ID: "ms-python.python", Name: "python", Publisher: "ms-python", Version: "2024.2.1", Location: "/home/user/.vscode/extensions/ms-python.python-2024.2.1"},
+			{IDE: "vscode", ID: "other.python", Name: "python", Publisher: "other", Version: "2024.2.1"},
+		},
+	}
+
+	got := formatPkgsToInventoryItems(context.Background(), pkgs)
+
+	want := []*agentendpointpb.VmInventory_InventoryItem{
+		{
+			Name:     "python",
+			Type:     "ide-extension",
+			Version:  "2024.2.1",
+			Location: []string{"/home/user/.vscode/extensions/ms-python.python-2024.2.1"},
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"IDE":       structpb.NewStringValue("vscode"),
+				"ID":        structpb.NewStringValue("ms-python.python"),
+				"Publisher": structpb.NewStringValue("ms-python"),
+			}},
+		},
+		{
+			Name:     "python",
+			Type:     "ide-extension",
+			Version:  "2024.2.1",
+			Location: []string{},
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"IDE":       structpb.NewStringValue("vscode"),
+				"ID":        structpb.NewStringValue("other.python"),
+				"Publisher": structpb.NewStringValue("other"),
+			}},
+		},
+	}
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+		t.Errorf("formatPkgsToInventoryItems() unexpected diff:\n%s", diff)
+	}
+
+	filtered := (&Client{excludedPackageTypes: map[string]bool{"ide-extension": true}}).filterInventory(&inventory.InstanceInventory{InstalledPackages: pkgs})
+	if filtered.InstalledPackages.IDEExtensions != nil {
+		t.Errorf("excluded IDE extensions were reported: %v", filtered.InstalledPackages.IDEExtensions)
+	}
+}
+
+func TestFormatGooGetRepositories(t *testing.T) {
+	pkgs := &packages.Packages{
+		GooGetRepositories: []*packages.GooGetRepository{
+			{Name: "google-compute-engine-stable", URL: "https://packages.cloud.google.com/yuck/repos/google-compute-engine-stable", UseOAuth: true, Priority: 500, Location: "C:/ProgramData/GooGet/repos/google_osconfig.repo"},
+			{Name: "google-compute-engine-stable", URL: "https://example.com/mirror"},
+		},
+	}
+
+	got := formatPkgsToInventoryItems(context.Background(), pkgs)
+
+	want := []*agentendpointpb.VmInventory_InventoryItem{
+		{
+			Name:     "google-compute-engine-stable",
+			Type:     "repository",
+			Location: []string{"C:/ProgramData/GooGet/repos/google_osconfig.repo"},
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"Manager":  structpb.NewStringValue("googet"),
+				"URL":      structpb.NewStringValue("https://packages.cloud.google.com/yuck/repos/google-compute-engine-stable"),
+				"UseOAuth": structpb.NewBoolValue(true),
+				"Priority": structpb.NewNumberValue(500),
+			}},
+		},
+		{
+			Name:     "google-compute-engine-stable",
+			Type:     "repository",
+			Location: []string{},
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"Manager":  structpb.NewStringValue("googet"),
+				"URL":      structpb.NewStringValue("https://example.com/mirror"),
+				"UseOAuth": structpb.NewBoolValue(false),
+				"Priority": structpb.NewNumberValue(0),
+			}},
+		},
+	}
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+		t.Errorf("formatPkgsToInventoryItems() unexpected diff:\n%s", diff)
+	}
+
+	filtered := (&Client{excludedPackageTypes: map[string]bool{"repository": true}}).filterInventory(&inventory.InstanceInventory{InstalledPackages: pkgs})
+	if filtered.InstalledPackages.GooGetRepositories != nil {
+		t.Errorf("excluded GooGet repositories were reported: %v", filtered.InstalledPackages.GooGetRepositories)
+	}
+}
+
+func TestFormatPackageRepositories(t *testing.T) {
+	pkgs := &packages.Packages{
+		Repositories: []*packages.PackageRepository{
+			{Manager: "yum", Name: "google-cloud-sdk", URL: "https://packages.cloud.google.com/yum/repos/cloud-sdk-el9-x86_64", Enabled: true, GPGCheck: true, Location: "/etc/yum.repos.d/google-cloud.repo"},
+			{Manager: "apt", Name: "http://mirror.example.com/debian stable", URL: "http://mirror.example.com/debian"},
+		},
+	}
+
+	got := formatPkgsToInventoryItems(context.Background(), pkgs)
+
+	want := []*agentendpointpb.VmInventory_InventoryItem{
+		{
+			Name:     "google-cloud-sdk",
+			Type:     "repository",
+			Location: []string{"/etc/yum.repos.d/google-cloud.repo"},
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"Manager":  structpb.NewStringValue("yum"),
+				"URL":      structpb.NewStringValue("https://packages.cloud.google.com/yum/repos/cloud-sdk-el9-x86_64"),
+				"Enabled":  structpb.NewBoolValue(true),
+				"GPGCheck": structpb.NewBoolValue(true),
+			}},
+		},
+		{
+			Name:     "http://mirror.example.com/debian stable",
+			Type:     "repository",
+			Location: []string{},
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"Manager":  structpb.NewStringValue("apt"),
+				"URL":      structpb.NewStringValue("http://mirror.example.com/debian"),
+				"Enabled":  structpb.NewBoolValue(false),
+				"GPGCheck": structpb.NewBoolValue(false),
+			}},
+		},
+	}
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+		t.Errorf("formatPkgsToInventoryItems() unexpected diff:\n%s", diff)
+	}
+
+	filtered := (&Client{excludedPackageTypes: map[string]bool{"repository": true}}).filterInventory(&inventory.InstanceInventory{InstalledPackages: pkgs})
+	if filtered.InstalledPackages.Repositories != nil {
+		t.Errorf("excluded repositories were reported: %v", filtered.InstalledPackages.Repositories)
+	}
+}
+
+func TestFormatLoadedLibraries(t *testing.T) {
+	pkgs := &packages.Packages{
+		LoadedLibraries: []*packages.LoadedLibrary{
+			{Path: "/usr/lib/x86_64-linux-gnu/libssl.so.3", Version: "3", Processes: []string{"nginx", "sshd"}, Deleted: true},
+			{Path: "/opt/app/lib/libssl.so.3", Version: "3", Processes: []string{"app"}},
+		},
+	}
+
+	got := formatPkgsToInventoryItems(context.Background(), pkgs)
+
+	want := []*agentendpointpb.VmInventory_InventoryItem{
+		{
+			Name:     "/usr/lib/x86_64-linux-gnu/libssl.so.3",
+			Type:     "loaded-library",
+			Version:  "3",
+			Location: []string{},
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"Processes": structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{structpb.NewStringValue("nginx"), structpb.NewStringValue("sshd")}}),
+				"Deleted":   structpb.NewBoolValue(true),
+			}},
+		},
+		{
+			Name:     "/opt/app/lib/libssl.so.3",
+			Type:     "loaded-library",
+			Version:  "3",
+			Location: []string{},
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"Processes": structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{structpb.NewStringValue("app")}}),
+				"Deleted":   structpb.NewBoolValue(false),
+			}},
+		},
+	}
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+		t.Errorf("formatPkgsToInventoryItems() unexpected diff:\n%s", diff)
+	}
+
+	filtered := (&Client{excludedPackageTypes: map[string]bool{"loaded-library": true}}).filterInventory(&inventory.InstanceInventory{InstalledPackages: pkgs})
+	if filtered.InstalledPackages.LoadedLibraries != nil {
+		t.Errorf("excluded loaded libraries were reported: %v", filtered.InstalledPackages.LoadedLibraries)
+	}
+}
+
+func TestFormatEnvironmentModules(t *testing.T) {
+	pkgs := &packages.Packages{
+		EnvironmentModules: []*packages.EnvironmentModule{
+			{Name: "gcc", Version: "12.2.0", Path: "/opt/apps/modulefiles/Core/gcc/12.2.0.lua", ModulePath: "/opt/apps/modulefiles/Core", Format: "lua", Default: true},
+			{Name: "gcc", Version: "12.2.0", Path: "/etc/modulefiles/gcc/12.2.0", ModulePath: "/etc/modulefiles", Format: "tcl"},
+		},
+	}
+
+	got := formatPkgsToInventoryItems(context.Background(), pkgs)
+
+	want := []*agentendpointpb.VmInventory_InventoryItem{
+		{
+			Name:     "gcc",
+			Type:     "module",
+			Version:  "12.2.0",
+			Location: []string{"/opt/apps/modulefiles/Core/gcc/12.2.0.lua"},
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"ModulePath": structpb.NewStringValue("/opt/apps/modulefiles/Core"),
+				"Format":     structpb.NewStringValue("lua"),
+				"Default":    structpb.NewBoolValue(true),
+			}},
+		},
+		{
+			Name:     "gcc",
+			Type:     "module",
+			Version:  "12.2.0",
+			Location: []string{"/etc/modulefiles/gcc/12.2.0"},
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"ModulePath": structpb.NewStringValue("/etc/modulefiles"),
+				"Format":     structpb.NewStringValue("tcl"),
+				"Default":    structpb.NewBoolValue(false),
+			}},
+		},
+	}
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+		t.Errorf("formatPkgsToInventoryItems() unexpected diff:\n%s", diff)
+	}
+
+	filtered := (&Client{excludedPackageTypes: map[string]bool{"module": true}}).filterInventory(&inventory.InstanceInventory{InstalledPackages: pkgs})
+	if filtered.InstalledPackages.EnvironmentModules != nil {
+		t.Errorf("excluded environment modules were reported: %v", filtered.InstalledPackages.EnvironmentModules)
+	}
+}
+
+func TestFormatContainerImages(t *testing.T) {
+	pkgs := &packages.Packages{
+		ContainerImages: []*packages.ContainerImage{
+			{ID: "sha256:1111", RepoTags: []string{"localhost:5000/app:v1", "app:v1"}, Digest: "sha256:aaaa", Size: 1024, Purl: "pkg:docker/app@sha256%3Aaaaa"},
+			{ID: "sha256:2222"},
+		},
+	}
+
+	got := formatPkgsToInventoryItems(context.Background(), pkgs)
+
+	if len(got) != 2 {
+		t.Fatalf("formatPkgsToInventoryItems() unexpected number of items, expect 2, got %d", len(got))
+	}
+	utiltest.AssertEquals(t, got[0].GetName(), "localhost:5000/app")
+	utiltest.AssertEquals(t, got[0].GetVersion(), "v1")
+	utiltest.AssertEquals(t, got[0].GetType(), "container-image")
+	utiltest.AssertEquals(t, got[0].GetPurl(), "pkg:docker/app@sha256%3Aaaaa")
+	fields := got[0].GetMetadata().GetFields()
+	utiltest.AssertEquals(t, fields["Digest"].GetStringValue(), "sha256:aaaa")
+	utiltest.AssertEquals(t, fields["ImageID"].GetStringValue(), "sha256:1111")
+	utiltest.AssertEquals(t, fields["Size"].GetNumberValue(), float64(1024))
+	utiltest.AssertEquals(t, len(fields["RepoTags"].GetListValue().GetValues()), 2)
+
+	utiltest.AssertEquals(t, got[1].GetName(), "sha256:2222")
+	utiltest.AssertEquals(t, got[1].GetVersion(), "")
+}
+
+func TestFormatFreeBSDPackages(t *testing.T) {
+	pkgs := &packages.Packages{
+		Pkg: []*packages.FreeBSDPackage{
+			{Name: "nginx", Version: "1.26.0_1,3", Origin: "www/nginx", Purl: "pkg:freebsd/nginx@1.26.0_1%2C3"},
+		},
+	}
+
+	got := formatPkgsToInventoryItems(context.Background(), pkgs)
+
+	if len(got) != 1 {
+		t.Fatalf("formatPkgsToInventoryItems() unexpected number of items, expect 1, got %d", len(got))
+	}
+	utiltest.AssertEquals(t, got[0].GetName(), "nginx")
+	utiltest.AssertEquals(t, got[0].GetType(), "pkg")
+	utiltest.AssertEquals(t, got[0].GetVersion(), "1.26.0_1,3")
+	utiltest.AssertEquals(t, got[0].GetPurl(), "pkg:freebsd/nginx@1.26.0_1%2C3")
+	fields := got[0].GetMetadata().GetFields()
+	utiltest.AssertEquals(t, fields["Origin"].GetStringValue(), "www/nginx")
+	utiltest.AssertEquals(t, fields["Category"].GetStringValue(), "www")
+
+	if got := formatPackages(context.Background(), pkgs, "freebsd"); len(got) != 0 {
+		t.Errorf("formatPackages() unexpected packages for pkg-only inventory: %v", got)
+	}
+}
+
+func TestFormatNixPackages(t *testing.T) {
+	pkgs := &packages.Packages{
+		Nix: []*packages.NixPackage{
+			{Name: "bash", Version: "5.2p26", Location: []string{"/nix/store/1b9p07z77phvv2hf6gm9f28syp39f1ag-bash-5.2p26"}},
+		},
+	}
+
+	got := formatPkgsToInventoryItems(context.Background(), pkgs)
+
+	if len(got) != 1 {
+		t.Fatalf("formatPkgsToInventoryItems() unexpected number of items, expect 1, got %d", len(got))
+	}
+	utiltest.AssertEquals(t, got[0].GetName(), "bash")
+	utiltest.AssertEquals(t, got[0].GetType(), "nix")
+	utiltest.AssertEquals(t, got[0].GetVersion(), "5.2p26")
+	utiltest.AssertEquals(t, got[0].GetLocation(), []string{"/nix/store/1b9p07z77phvv2hf6gm9f28syp39f1ag-bash-5.2p26"})
+}
+
+func TestFormatCondaPackages(t *testing.T) {
+	pkgs := &packages.Packages{
+		Conda: []*packages.CondaPackage{
+			{Name: "numpy", Version: "1.26.4", Build: "py311h64a7726_0", Purl: "pkg:conda/numpy@1.26.4?build=py311h64a7726_0", Location: []string{"/opt/conda/envs/ds"}},
+		},
+	}
+
+	got := formatPkgsToInventoryItems(context.Background(), pkgs)
+
+	if len(got) != 1 {
+		t.Fatalf("formatPkgsToInventoryItems() unexpected number of items, expect 1, got %d", len(got))
+	}
+	utiltest.AssertEquals(t, got[0].GetName(), "numpy")
+	utiltest.AssertEquals(t, got[0].GetType(), "conda")
+	utiltest.AssertEquals(t, got[0].GetVersion(), "1.26.4")
+	utiltest.AssertEquals(t, got[0].GetPurl(), "pkg:conda/numpy@1.26.4?build=py311h64a7726_0")
+	utiltest.AssertEquals(t, got[0].GetLocation(), []string{"/opt/conda/envs/ds"})
+	utiltest.AssertEquals(t, got[0].GetMetadata().GetFields()["Build"].GetStringValue(), "py311h64a7726_0")
+}
+
+func TestFormatSystemdUnits(t *testing.T) {
+	pkgs := &packages.Packages{
+		SystemdUnits: []*packages.SystemdUnit{
+			{Name: "sshd.service", ActiveState: "active", SubState: "running", UnitFileState: "enabled"},
+		},
+	}
+
+	got := formatPkgsToInventoryItems(context.Background(), pkgs)
+
+	if len(got) != 1 {
+		t.Fatalf("formatPkgsToInventoryItems() unexpected number of items, expect 1, got %d", len(got))
+	}
+	utiltest.AssertEquals(t, got[0].GetName(), "sshd.service")
+	utiltest.AssertEquals(t, got[0].GetType(), "service")
+	utiltest.AssertEquals(t, got[0].GetMetadata().GetFields()["ActiveState"].GetStringValue(), "active")
+	utiltest.AssertEquals(t, got[0].GetMetadata().GetFields()["SubState"].GetStringValue(), "running")
+	utiltest.AssertEquals(t, got[0].GetMetadata().GetFields()["UnitFileState"].GetStringValue(), "enabled")
+
+	c := &Client{}
+	WithExcludedPackageTypes("service")(c)
+	state := c.filterInventory(&inventory.InstanceInventory{InstalledPackages: pkgs})
+	if got := formatPkgsToInventoryItems(context.Background(), state.InstalledPackages); len(got) != 0 {
+		t.Errorf("formatPkgsToInventoryItems() reported excluded services: %v", got)
+	}
+}
+
+func TestFormatWindowsServices(t *testing.T) {
+	pkgs := &packages.Packages{
+		WindowsServices: []*packages.WindowsService{
+			{Name: "GCEAgent", DisplayName: "Google Compute Engine Agent", StartType: "auto", State: "running"},
+		},
+	}
+
+	got := formatPkgsToInventoryItems(context.Background(), pkgs)
+
+	if len(got) != 1 {
+		t.Fatalf("formatPkgsToInventoryItems() unexpected number of items, expect 1, got %d", len(got))
+	}
+	utiltest.AssertEquals(t, got[0].GetName(), "GCEAgent")
+	utiltest.AssertEquals(t, got[0].GetType(), "windows-service")
+	utiltest.AssertEquals(t, got[0].GetMetadata().GetFields()["DisplayName"].GetStringValue(), "Google Compute Engine Agent")
+	utiltest.AssertEquals(t, got[0].GetMetadata().GetFields()["StartType"].GetStringValue(), "auto")
+	utiltest.AssertEquals(t, got[0].GetMetadata().GetFields()["State"].GetStringValue(), "running")
+}
+
+func TestFormatFirmware(t *testing.T) {
+	pkgs := &packages.Packages{
+		Firmware: []*packages.Firmware{{Vendor: "Google", Version: "Google", ReleaseDate: "06/27/2024"}},
+	}
+
+	got := formatPkgsToInventoryItems(context.Background(), pkgs)
+
+	if len(got) != 1 {
+		t.Fatalf("formatPkgsToInventoryItems() unexpected number of items, expect 1, got %d", len(got))
+	}
+	utiltest.AssertEquals(t, got[0].GetName(), "Google")
+	utiltest.AssertEquals(t, got[0].GetType(), "firmware")
+	utiltest.AssertEquals(t, got[0].GetVersion(), "Google")
+	utiltest.AssertEquals(t, got[0].GetMetadata().GetFields()["ReleaseDate"].GetStringValue(), "06/27/2024")
+
+	filtered := (&Client{excludedPackageTypes: map[string]bool{"firmware": true}}).filterInventory(&inventory.InstanceInventory{InstalledPackages: pkgs})
+	if filtered.InstalledPackages.Firmware != nil {
+		t.Errorf("excluded firmware was reported: %v", filtered.InstalledPackages.Firmware)
+	}
+}
+
+func TestFormatListeningPorts(t *testing.T) {
+	pkgs := &packages.Packages{
+		ListeningPorts: []*packages.ListeningPort{
+			{Protocol: "tcp", Address: "0.0.0.0", Port: 22, PID: 745, Process: "sshd"},
+			{Protocol: "udp6", Address: "::", Port: 546},
+		},
+	}
+
+	got := formatPkgsToInventoryItems(context.Background(), pkgs)
+
+	if len(got) != 2 {
+		t.Fatalf("formatPkgsToInventoryItems() unexpected number of items, expect 2, got %d", len(got))
+	}
+	utiltest.AssertEquals(t, got[0].GetName(), "tcp/0.0.0.0:22")
+	utiltest.AssertEquals(t, got[0].GetType(), "listening-port")
+	fields := got[0].GetMetadata().GetFields()
+	utiltest.AssertEquals(t, fields["Protocol"].GetStringValue(), "tcp")
+	utiltest.AssertEquals(t, fields["Port"].GetNumberValue(), float64(22))
+	utiltest.AssertEquals(t, fields["PID"].GetNumberValue(), float64(745))
+	utiltest.AssertEquals(t, fields["Process"].GetStringValue(), "sshd")
+	utiltest.AssertEquals(t, got[1].GetName(), "udp6/[::]:546")
+	for _, key := range []string{"PID", "Process"} {
+		if _, ok := got[1].GetMetadata().GetFields()[key]; ok {
+			t.Errorf("unexpected %s metadata for a port without a known owner", key)
+		}
+	}
+
+	filtered := (&Client{excludedPackageTypes: map[string]bool{"listening-port": true}}).filterInventory(&inventory.InstanceInventory{InstalledPackages: pkgs})
+	if filtered.InstalledPackages.ListeningPorts != nil {
+		t.Errorf("excluded listening ports were reported: %v", filtered.InstalledPackages.ListeningPorts)
+	}
+}
+
+func TestFormatCertificates(t *testing.T) {
+	pkgs := &packages.Packages{
+		Certificates: []*packages.Certificate{
+			{Subject: "CN=Root CA", Issuer: "CN=Root CA", Fingerprint: "aa", NotAfter: time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), Expired: true, Location: "/etc/ssl/certs/ca-certificates.crt"},
+			{Subject: "CN=Root CA", Issuer: "CN=Root CA", Fingerprint: "bb", NotAfter: time.Date(2099, 12, 31, 23, 59, 59, 0, time.UTC), Location: "/etc/ssl/certs/ca-certificates.crt"},
+		},
+	}
+
+	got := formatPkgsToInventoryItems(context.Background(), pkgs)
+
+	// Both certificates share the subject, they are kept apart by their fingerprint.
+	if len(got) != 2 {
+		t.Fatalf("formatPkgsToInventoryItems() unexpected number of items, expect 2, got %d", len(got))
+	}
+	utiltest.AssertEquals(t, got[0].GetName(), "CN=Root CA")
+	utiltest.AssertEquals(t, got[0].GetType(), "certificate")
+	utiltest.AssertEquals(t, got[0].GetLocation(), []string{"/etc/ssl/certs/ca-certificates.crt"})
+	expired := got[0].GetMetadata().GetFields()
+	utiltest.AssertEquals(t, expired["Issuer"].GetStringValue(), "CN=Root CA")
+	utiltest.AssertEquals(t, expired["Fingerprint"].GetStringValue(), "aa")
+	utiltest.AssertEquals(t, expired["NotAfter"].GetStringValue(), "2001-01-01 00:00:00 +0000 GMT")
+	utiltest.AssertEquals(t, expired["Expired"].GetBoolValue(), true)
+	valid := got[1].GetMetadata().GetFields()
+	utiltest.AssertEquals(t, valid["Fingerprint"].GetStringValue(), "bb")
+	utiltest.AssertEquals(t, valid["Expired"].GetBoolValue(), false)
+
+	filtered := (&Client{excludedPackageTypes: map[string]bool{"certificate": true}}).filterInventory(&inventory.InstanceInventory{InstalledPackages: pkgs})
+	if filtered.InstalledPackages.Certificates != nil {
+		t.Errorf("excluded certificates were reported: %v", filtered.InstalledPackages.Certificates)
+	}
+}
+
+func TestInstalledSizeMetadata(t *testing.T) {
+	pkgs := &packages.Packages{
+		Deb: []*packages.PkgInfo{
+			{Name: "man-db", Version: "2.9.1-1", Type: "deb", Size: 2916352},
+			{Name: "python3-gi", Version: "3.36.0-1", Type: "deb"},
+		},
+		Rpm: []*packages.PkgInfo{
+			{Name: "gcc", Version: "11.4.1-3.el9", Type: "rpm", Size: 86012345},
+		},
+	}
+
+	got := map[string]*structpb.Struct{}
+	for _, item := range formatPkgsToInventoryItems(context.Background(), pkgs) {
+		got[item.GetName()] = item.GetMetadata()
+	}
+
+	utiltest.AssertEquals(t, got["man-db"].GetFields()["InstalledSize"].GetNumberValue(), float64(2916352))
+	utiltest.AssertEquals(t, got["gcc"].GetFields()["InstalledSize"].GetNumberValue(), float64(86012345))
+	if size, ok := got["python3-gi"].GetFields()["InstalledSize"]; ok {
+		t.Errorf("unexpected InstalledSize metadata %v for package with unknown size", size)
+	}
+}
+
+func TestFormatSkipsUnnamedPackages(t *testing.T) {
+	pkgs := &packages.Packages{
+		Rpm: []*packages.PkgInfo{
+			{Name: "gcc", Arch: "x86_64", Version: "11.4.1-3.el9", Type: "rpm"},
+			{Name: "", Arch: "x86_64", Version: "1.0-1", Type: "rpm"},
+			{Name: "", Type: "rpm"},
+		},
+		Deb: []*packages.PkgInfo{
+			{Name: "", Arch: "x86_64", Version: "2.9.1-1", Type: "deb"},
+			{Name: "man-db", Arch: "x86_64", Version: "2.9.1-1", Type: "deb"},
+		},
+		QFE: []*packages.QFEPackage{
+			{Caption: "http://support.microsoft.com/?kbid=4566424", HotFixID: "KB4566424"},
+			{HotFixID: "KB5005112"},
+			{HotFixID: ""},
+		},
+		WindowsApplication: []*packages.WindowsApplication{
+			{DisplayName: "", DisplayVersion: "1.0"},
+		},
+	}
+
+	var gotItems []string
+	for _, item := range formatPkgsToInventoryItems(context.Background(), pkgs) {
+		gotItems = append(gotItems, item.GetName())
+	}
+	utiltest.AssertEquals(t, gotItems, []string{"gcc", "man-db", "http://support.microsoft.com/?kbid=4566424", ""})
+
+	var gotPackages []string
+	for _, pkg := range formatPackages(context.Background(), pkgs, "rhel") {
+		gotPackages = append(gotPackages, softwarePackageSortKey(pkg)[0])
+	}
+	utiltest.AssertEquals(t, gotPackages, []string{"man-db", "gcc", "KB4566424", "KB5005112"})
+}
+
+func TestRepositoryMetadata(t *testing.T) {
+	pkgs := &packages.Packages{
+		Apt: []*packages.PkgInfo{
+			{Name: "libldap-common", Version: "2.4.45+dfsg-1ubuntu1.3", Type: "deb", Repository: "Ubuntu:18.04/bionic-updates, Ubuntu:18.04/bionic-security", Security: true},
+		},
+		Yum: []*packages.PkgInfo{
+			{Name: "kernel", Version: "5.14.0-362.8.1.el9_3", Type: "rpm", Repository: "baseos"},
+		},
+		Zypper: []*packages.PkgInfo{
+			{Name: "at", Version: "3.1.14-8.3.1", Type: "rpm", Repository: "SLES12-SP3-Updates"},
+			{Name: "autoyast2-installation", Version: "3.2.22-2.9.2", Type: "rpm"},
+		},
+	}
+
+	got := map[string]map[string]*structpb.Value{}
+	for _, item := range formatPkgsToInventoryItems(context.Background(), pkgs) {
+		got[item.GetName()] = item.GetMetadata().GetFields()
+	}
+
+	utiltest.AssertEquals(t, got["libldap-common"]["Repository"].GetStringValue(), "Ubuntu:18.04/bionic-updates, Ubuntu:18.04/bionic-security")
+	utiltest.AssertEquals(t, got["kernel"]["Repository"].GetStringValue(), "baseos")
+	utiltest.AssertEquals(t, got["at"]["Repository"].GetStringValue(), "SLES12-SP3-Updates")
+	if v, ok := got["autoyast2-installation"]["Repository"]; ok {
+		t.Errorf("unexpected Repository metadata %v for update with unknown repository", v)
+	}
+}
+
+func TestExplicitMetadata(t *testing.T) {
+	explicit, auto := true, false
+	pkgs := &packages.Packages{
+		Deb: []*packages.PkgInfo{
+			{Name: "vim", Type: "deb", Explicit: &explicit},
+			{Name: "libc6", Type: "deb", Explicit: &auto},
+		},
+		Rpm: []*packages.PkgInfo{
+			{Name: "gpg-pubkey", Type: "rpm"},
+		},
+		Pip: []*packages.PipPackage{
+			{PkgInfo: packages.PkgInfo{Name: "requests", Type: "pypi"}},
+		},
+	}
+
+	got := map[string]map[string]*structpb.Value{}
+	for _, item := range formatPkgsToInventoryItems(context.Background(), pkgs) {
+		got[item.GetName()] = item.GetMetadata().GetFields()
+	}
+
+	utiltest.AssertEquals(t, got["vim"]["Explicit"].GetBoolValue(), true)
+	if v, ok := got["libc6"]["Explicit"]; !ok || v.GetBoolValue() {
+		t.Errorf("libc6 Explicit metadata = %v, want false", v)
+	}
+	for _, name := range []string{"gpg-pubkey", "requests"} {
+		if v, ok := got[name]["Explicit"]; ok {
+			t.Errorf("unexpected Explicit metadata %v for %q", v, name)
+		}
+	}
+}
+
+func TestPackageFilesMetadata(t *testing.T) {
+	pkgs := &packages.Packages{
+		Deb: []*packages.PkgInfo{
+			{Name: "curl", Type: "deb", Files: []string{"/usr/bin/curl", "/usr/share/man/man1/curl.1.gz"}},
+			{Name: "coreutils", Type: "deb", Files: []string{"/usr/bin/cat"}, FilesTruncated: true},
+		},
+		Rpm: []*packages.PkgInfo{
+			{Name: "bash", Type: "rpm"},
+		},
+	}
+
+	got := map[string]map[string]*structpb.Value{}
+	for _, item := range formatPkgsToInventoryItems(context.Background(), pkgs) {
+		got[item.GetName()] = item.GetMetadata().GetFields()
+	}
+
+	utiltest.AssertEquals(t, got["curl"]["Files"].GetListValue().AsSlice(), []any{"/usr/bin/curl", "/usr/share/man/man1/curl.1.gz"})
+	if _, ok := got["curl"]["FilesTruncated"]; ok {
+		t.Errorf("unexpected FilesTruncated metadata for curl")
+	}
+	utiltest.AssertEquals(t, got["coreutils"]["FilesTruncated"].GetBoolValue(), true)
+	if v, ok := got["bash"]["Files"]; ok {
+		t.Errorf("unexpected Files metadata %v for bash", v)
+	}
+}
+
+func TestCOSMetadata(t *testing.T) {
+	pkgs := &packages.Packages{
+		COS: []*packages.PkgInfo{
+			{Name: "app-shells/bash", Type: "cos", Version: "5.2_p15", Category: "app-shells", BuildID: "18244.85.49"},
+			{Name: "dev-libs/popt", Type: "cos", Version: "1.16"},
+		},
+	}
+
+	got := map[string]map[string]*structpb.Value{}
+	for _, item := range formatPkgsToInventoryItems(context.Background(), pkgs) {
+		got[item.GetName()] = item.GetMetadata().GetFields()
+	}
+
+	utiltest.AssertEquals(t, got["app-shells/bash"]["Category"].GetStringValue(), "app-shells")
+	utiltest.AssertEquals(t, got["app-shells/bash"]["BuildID"].GetStringValue(), "18244.85.49")
+	if len(got["dev-libs/popt"]) != 0 {
+		t.Errorf("unexpected metadata %v for dev-libs/popt", got["dev-libs/popt"])
+	}
+}
+
+func TestVendorMaintainerMetadata(t *testing.T) {
+	pkgs := &packages.Packages{
+		Deb: []*packages.PkgInfo{
+			{Name: "man-db", Type: "deb", Maintainer: "Debian Developers <debian-devel@lists.debian.org>"},
+			{Name: "python3-gi", Type: "deb"},
+		},
+		Rpm: []*packages.PkgInfo{
+			{Name: "gcc", Type: "rpm", Vendor: "Rocky Enterprise Software Foundation"},
+			{Name: "gpg-pubkey", Type: "rpm"},
+		},
+	}
+
+	got := map[string]map[string]*structpb.Value{}
+	for _, item := range formatPkgsToInventoryItems(context.Background(), pkgs) {
+		got[item.GetName()] = item.GetMetadata().GetFields()
+	}
+
+	utiltest.AssertEquals(t, got["man-db"]["Maintainer"].GetStringValue(), "Debian Developers <debian-devel@lists.debian.org>")
+	utiltest.AssertEquals(t, got["gcc"]["Vendor"].GetStringValue(), "Rocky Enterprise Software Foundation")
+	for _, name := range []string{"python3-gi", "gpg-pubkey"} {
+		for _, key := range []string{"Vendor", "Maintainer"} {
+			if v, ok := got[name][key]; ok {
+				t.Errorf("unexpected %s metadata %v for %q", key, v, name)
+			}
+		}
+	}
+	if _, ok := got["man-db"]["Vendor"]; ok {
+		t.Errorf("unexpected Vendor metadata for deb package")
+	}
+}
+
+func TestRpmReleaseMetadata(t *testing.T) {
+	tests := []struct {
+		version     string
+		wantRelease string
+		wantDistTag string
+	}{
+		{version: "4.18.0-372.9.1.el8_6", wantRelease: "372.9.1.el8_6", wantDistTag: "el8_6"},
+		{version: "1:1.1.1k-7.el8_6", wantRelease: "7.el8_6", wantDistTag: "el8_6"},
+		{version: "6.5.0-1.fc39", wantRelease: "1.fc39", wantDistTag: "fc39"},
+		{version: "2.40.1-1.amzn2023.0.3", wantRelease: "1.amzn2023.0.3", wantDistTag: "amzn2023"},
+		{version: "8.9-1.module+el8.8.0+18773+ab1d9c4b", wantRelease: "1.module+el8.8.0+18773+ab1d9c4b", wantDistTag: "el8"},
+		{version: "1.2.11-150000.3.48.1", wantRelease: "150000.3.48.1"},
+		{version: "Version"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			pkgs := &packages.Packages{Rpm: []*packages.PkgInfo{{Name: "pkg", Version: tt.version, Type: "rpm"}}}
+			got := formatPkgsToInventoryItems(context.Background(), pkgs)[0]
+
+			utiltest.AssertEquals(t, got.GetVersion(), tt.version)
+			fields := got.GetMetadata().GetFields()
+			if release, ok := fields["Release"]; ok != (tt.wantRelease != "") || release.GetStringValue() != tt.wantRelease {
+				t.Errorf("unexpected Release metadata %v, want: %q", release, tt.wantRelease)
+			}
+			if distTag, ok := fields["DistTag"]; ok != (tt.wantDistTag != "") || distTag.GetStringValue() != tt.wantDistTag {
+				t.Errorf("unexpected DistTag metadata %v, want: %q", distTag, tt.wantDistTag)
+			}
+		})
+	}
+}
+
+func TestDigestMetadata(t *testing.T) {
+	pkgs := &packages.Packages{
+		Deb: []*packages.PkgInfo{
+			{Name: "man-db", Version: "2.9.1-1", Type: "deb", Source: packages.Source{Name: "man-db", Version: "2.9.1-1"}},
+		},
+		Rpm: []*packages.PkgInfo{
+			{Name: "gcc", Version: "11.4.1-3.el9", Type: "rpm", Source: packages.Source{Name: "gcc-11.4.1-3.el9.src.rpm"}, Digest: "sha256:4b6f1f5a3c2f9e0d8a7b6c5d4e3f2a1b0c9d8e7f6a5b4c3d2e1f0a9b8c7d6e5f"},
+			{Name: "gpg-pubkey", Version: "b6792c39-53c4fbdd", Type: "rpm", Source: packages.Source{Name: "gpg-pubkey"}},
+		},
+	}
+
+	got := formatPkgsToInventoryItems(context.Background(), pkgs)
+
+	want := []*agentendpointpb.VmInventory_InventoryItem{
+		{
+			Name: "gcc", Type: "rpm", Version: "11.4.1-3.el9", Location: []string{},
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"SourceRPM": structpb.NewStringValue("gcc-11.4.1-3.el9.src.rpm"),
+				"Release":   structpb.NewStringValue("3.el9"),
+				"DistTag":   structpb.NewStringValue("el9"),
+				"Digest":    structpb.NewStringValue("sha256:4b6f1f5a3c2f9e0d8a7b6c5d4e3f2a1b0c9d8e7f6a5b4c3d2e1f0a9b8c7d6e5f"),
+			}},
+		},
+		{
+			Name: "gpg-pubkey", Type: "rpm", Version: "b6792c39-53c4fbdd", Location: []string{},
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"SourceRPM": structpb.NewStringValue("gpg-pubkey"),
+				"Release":   structpb.NewStringValue("53c4fbdd"),
+			}},
+		},
+		{
+			Name: "man-db", Type: "deb", Version: "2.9.1-1", Location: []string{},
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"SourceName":         structpb.NewStringValue("man-db"),
+				"SourceVersion":      structpb.NewStringValue("2.9.1-1"),
+				"SourceArchitecture": structpb.NewStringValue("source"),
+				"SourcePurl":         structpb.NewStringValue("pkg:deb/man-db@2.9.1-1?arch=source"),
+			}},
+		},
+	}
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+		t.Errorf("formatPkgsToInventoryItems() unexpected diff:\n%s", diff)
+	}
+}
+
+func TestDebSourcePurl(t *testing.T) {
+	tests := []struct {
+		name string
+		pkg  *packages.PkgInfo
+		want string
+	}{
+		{
+			name: "namespace and distro from binary purl",
+			pkg: &packages.PkgInfo{Name: "libssl3", Version: "3.0.11-1~deb12u2", Source: packages.Source{Name: "openssl", Version: "3.0.11-1~deb12u2"},
+				Purl: "pkg:deb/debian/libssl3@3.0.11-1~deb12u2?arch=x86_64&distro=12&source=openssl"},
+			want: "pkg:deb/debian/openssl@3.0.11-1~deb12u2?arch=source&distro=12",
+		},
+		{
+			name: "source version defaults to binary version",
+			pkg:  &packages.PkgInfo{Name: "bash", Version: "5.2.15-2+b2", Source: packages.Source{Name: "bash"}},
+			want: "pkg:deb/bash@5.2.15-2%2Bb2?arch=source",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			utiltest.AssertEquals(t, debSourcePurl(tt.pkg), tt.want)
+		})
+	}
+
+	if got := withDebSourceMetadata(&structpb.Struct{Fields: map[string]*structpb.Value{}}, &packages.PkgInfo{Name: "bash"}); len(got.GetFields()) != 0 {
+		t.Errorf("withDebSourceMetadata() unexpected metadata for package without source: %v", got)
+	}
+}
+
+func TestWUASecurityMetadata(t *testing.T) {
+	pkgs := &packages.Packages{
+		WUA: []*packages.WUAPackage{
+			{
+				Title:        "2024-02 Cumulative Update for Windows Server 2022 (KB5034770)",
+				UpdateID:     "security",
+				Categories:   []string{"Security Updates", "Windows Server 2022"},
+				CategoryIDs:  []string{"0FA1201D-4330-4FA8-8AE9-B877473B6441", "fdfe8200-9d98-44ba-a12a-772282bf60ef"},
+				MsrcSeverity: "Critical",
+			},
+			{
+				Title:       "Update for Windows Defender Antivirus antimalware platform (KB4052623)",
+				UpdateID:    "definition",
+				Categories:  []string{"Definition Updates"},
+				CategoryIDs: []string{"e0789628-ce08-4437-be74-2495b842f43b"},
+			},
+		},
+	}
+
+	got := map[string]*structpb.Struct{}
+	for _, item := range formatPkgsToInventoryItems(context.Background(), pkgs) {
+		got[item.GetVersion()] = item.GetMetadata()
+	}
+
+	utiltest.AssertEquals(t, got["security"].GetFields()["MsrcSeverity"].GetStringValue(), "Critical")
+	utiltest.AssertEquals(t, got["security"].GetFields()["IsSecurityUpdate"].GetBoolValue(), true)
+	utiltest.AssertEquals(t, got["definition"].GetFields()["MsrcSeverity"].GetStringValue(), "")
+	utiltest.AssertEquals(t, got["definition"].GetFields()["IsSecurityUpdate"].GetBoolValue(), false)
+}
+
+func TestWUADownloadSizeAndRebootMetadata(t *testing.T) {
+	rebootRequired := true
+	noReboot := false
+	pkgs := []*packages.WUAPackage{
+		{UpdateID: "cumulative", MaxDownloadSize: 734003200, RebootRequired: &rebootRequired},
+		{UpdateID: "definition", MaxDownloadSize: 1048576, RebootRequired: &noReboot},
+		{UpdateID: "unknown"},
+	}
+
+	got := map[string]*structpb.Struct{}
+	for _, item := range wuaToInventoryItem(context.Background(), pkgs) {
+		got[item.GetVersion()] = item.GetMetadata()
+	}
+
+	utiltest.AssertEquals(t, got["cumulative"].GetFields()["MaxDownloadSize"].GetNumberValue(), float64(734003200))
+	utiltest.AssertEquals(t, got["cumulative"].GetFields()["RebootRequired"].GetBoolValue(), true)
+	utiltest.AssertEquals(t, got["definition"].GetFields()["MaxDownloadSize"].GetNumberValue(), float64(1048576))
+	if v, ok := got["definition"].GetFields()["RebootRequired"]; !ok || v.GetBoolValue() {
+		t.Errorf("unexpected RebootRequired metadata for an update without reboot: %v", v)
+	}
+	for _, key := range []string{"MaxDownloadSize", "RebootRequired"} {
+		if _, ok := got["unknown"].GetFields()[key]; ok {
+			t.Errorf("unexpected %s metadata for an update without it", key)
+		}
+	}
+}
+
+func TestWUADuplicateKBArticleIDs(t *testing.T) {
+	ctx := context.Background()
+	pkg := &packages.WUAPackage{
+		UpdateID:     "update",
+		KBArticleIDs: []string{"5034441", "5034439", "5034441", "5034439"},
+		MoreInfoURLs: []string{"https://support.microsoft.com/kb/5034441", "https://support.microsoft.com/kb/5034441"},
+	}
+	wantKBs := []string{"5034441", "5034439"}
+	wantURLs := []string{"https://support.microsoft.com/kb/5034441"}
+
+	wua := formatWUAPackage(ctx, pkg).WuaPackage
+	utiltest.AssertEquals(t, wua.GetKbArticleIds(), wantKBs)
+	utiltest.AssertEquals(t, wua.GetMoreInfoUrls(), wantURLs)
+
+	metadata := wuaToInventoryItem(ctx, []*packages.WUAPackage{pkg})[0].GetMetadata().GetFields()
+	var gotKBs, gotURLs []string
+	for _, v := range metadata["KbArticleId"].GetListValue().GetValues() {
+		gotKBs = append(gotKBs, v.GetStringValue())
+	}
+	for _, v := range metadata["MoreInfoUrls"].GetListValue().GetValues() {
+		gotURLs = append(gotURLs, v.GetStringValue())
+	}
+	utiltest.AssertEquals(t, gotKBs, wantKBs)
+	utiltest.AssertEquals(t, gotURLs, wantURLs)
+}
+
+func TestWUAMismatchedCategories(t *testing.T) {
+	tests := []struct {
+		name        string
+		categoryIDs []string
+		categories  []string
+		wantIDs     []string
+		wantNames   []string
+	}{
+		{
+			name:        "MissingNames",
+			categoryIDs: []string{"id1", "id2"},
+			categories:  []string{"name1"},
+			wantIDs:     []string{"id1", "id2"},
+			wantNames:   []string{"name1", ""},
+		},
+		{
+			name:        "MissingIDs",
+			categoryIDs: []string{"id1"},
+			categories:  []string{"name1", "name2"},
+			wantIDs:     []string{"id1"},
+			wantNames:   []string{"name1"},
+		},
+		{
+			name:       "NoIDs",
+			categories: []string{"name1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			pkg := &packages.WUAPackage{UpdateID: "update", CategoryIDs: tt.categoryIDs, Categories: tt.categories}
+
+			var gotIDs, gotNames []string
+			for _, category := range formatWUAPackage(ctx, pkg).WuaPackage.GetCategories() {
+				gotIDs = append(gotIDs, category.GetId())
+				gotNames = append(gotNames, category.GetName())
+			}
+			utiltest.AssertEquals(t, gotIDs, tt.wantIDs)
+			utiltest.AssertEquals(t, gotNames, tt.wantNames)
+
+			gotIDs, gotNames = nil, nil
+			items := wuaToInventoryItem(ctx, []*packages.WUAPackage{pkg})
+			for _, category := range items[0].GetMetadata().GetFields()["Categories"].GetListValue().GetValues() {
+				gotIDs = append(gotIDs, category.GetStructValue().GetFields()["Id"].GetStringValue())
+				gotNames = append(gotNames, category.GetStructValue().GetFields()["Name"].GetStringValue())
+			}
+			utiltest.AssertEquals(t, gotIDs, tt.wantIDs)
+			utiltest.AssertEquals(t, gotNames, tt.wantNames)
+		})
+	}
+}
+
+func TestNewMetadataStruct(t *testing.T) {
+	got, sanitized := newMetadataStruct(map[string]string{"Id": "0fa1201d", "Name": "Security Updates"})
+	if sanitized {
+		t.Errorf("newMetadataStruct() sanitized valid values")
+	}
+	utiltest.AssertEquals(t, got.AsMap(), map[string]any{"Id": "0fa1201d", "Name": "Security Updates"})
+
+	got, sanitized = newMetadataStruct(map[string]string{"Id": "0fa1201d", "Name": "Mises \xe0 jour"})
+	if !sanitized {
+		t.Errorf("newMetadataStruct() did not report sanitized values")
+	}
+	utiltest.AssertEquals(t, got.AsMap(), map[string]any{"Id": "0fa1201d", "Name": "Mises \uFFFD jour"})
+	if _, err := proto.Marshal(got); err != nil {
+		t.Errorf("proto.Marshal() of the sanitized metadata: %v", err)
+	}
+}
+
+func TestFormatMetadataSanitizedOncePerCollection(t *testing.T) {
+	ctx := context.Background()
+	var warnings []string
+	utiltest.OverrideVariable(t, &sanitizationWarningf, func(_ context.Context, format string, args ...any) {
+		warnings = append(warnings, fmt.Sprintf(format, args...))
+	})
+
+	// Times and nested values are built from typed package fields, only invalid UTF-8 in
+	// their strings can make the metadata unrepresentable.
+	deployed := time.Date(2024, time.March, 1, 12, 30, 0, 0, time.UTC)
+	updates := []*packages.WUAPackage{
+		{Title: "First", CategoryIDs: []string{"0fa1201d"}, Categories: []string{"Mises \xe0 jour"}, LastDeploymentChangeTime: deployed},
+		{Title: "Second", KBArticleIDs: []string{"KB\xff5034441"}, LastDeploymentChangeTime: deployed},
+	}
+	items := wuaToInventoryItem(ctx, updates)
+	utiltest.AssertEquals(t, len(warnings), 1)
+
+	first := items[0].GetMetadata().AsMap()
+	utiltest.AssertEquals(t, first["LastDeploymentChangeTime"], "2024-03-01 12:30:00 +0000 GMT")
+	utiltest.AssertEquals(t, first["Categories"], []any{map[string]any{"Id": "0fa1201d", "Name": "Mises \uFFFD jour"}})
+	utiltest.AssertEquals(t, items[1].GetMetadata().AsMap()["KbArticleId"], []any{"KB\uFFFD5034441"})
+	if _, err := proto.Marshal(items[1].GetMetadata()); err != nil {
+		t.Errorf("proto.Marshal() of the sanitized metadata: %v", err)
+	}
+
+	// A collection without invalid UTF-8 is not logged.
+	warnings = nil
+	wuaToInventoryItem(ctx, []*packages.WUAPackage{{Title: "Valid", Categories: []string{"Security Updates"}, CategoryIDs: []string{"0fa1201d"}}})
+	utiltest.AssertEquals(t, len(warnings), 0)
+
+	images := []*packages.ContainerImage{
+		{ID: "sha256:1", RepoTags: []string{"app:\xff"}},
+		{ID: "sha256:2", RepoTags: []string{"db:\xfe"}},
+	}
+	containerImageToInventoryItem(ctx, images)
+	utiltest.AssertEquals(t, len(warnings), 1)
+}
+
+func TestFormatToStructList(t *testing.T) {
+	got, sanitized := formatToStructList([]string{"KB5034441", "KB5034439"})
+	if sanitized {
+		t.Errorf("formatToStructList() sanitized valid entries")
+	}
+	utiltest.AssertEquals(t, got.AsSlice(), []any{"KB5034441", "KB5034439"})
+
+	got, sanitized = formatToStructList([]string{"KB5034441", "https://support.example.com/\xe0"})
+	if !sanitized {
+		t.Errorf("formatToStructList() did not report sanitized entries")
+	}
+	utiltest.AssertEquals(t, got.AsSlice(), []any{"KB5034441", "https://support.example.com/\uFFFD"})
+	if _, err := proto.Marshal(got); err != nil {
+		t.Errorf("proto.Marshal() of the sanitized list: %v", err)
+	}
+}
+
+func TestWindowsApplicationInstallSourceMetadata(t *testing.T) {
+	tests := []struct {
+		name string
+		app  *packages.WindowsApplication
+		want map[string]string
+	}{
+		{
+			name: "MSIInstall",
+			app: &packages.WindowsApplication{
+				DisplayName:     "Application",
+				InstallSource:   `C:\Installers\`,
+				UninstallString: "MsiExec.exe /X{00000000-0000-0000-0000-000000000000}",
+			},
+			want: map[string]string{
+				"InstallSource":   `C:\Installers\`,
+				"UninstallString": "MsiExec.exe /X{00000000-0000-0000-0000-000000000000}",
+			},
+		},
+		{
+			name: "BlankValuesOmitted",
+			app:  &packages.WindowsApplication{DisplayName: "Application"},
+			want: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := windowsApplicationToInventoryItem([]*packages.WindowsApplication{tt.app})[0].GetMetadata().GetFields()
+			for _, key := range []string{"InstallSource", "UninstallString"} {
+				got, ok := fields[key]
+				want, wantOK := tt.want[key]
+				if ok != wantOK {
+					t.Errorf("metadata key %q present: %t, want: %t", key, ok, wantOK)
+					continue
+				}
+				if ok {
+					utiltest.AssertEquals(t, got.GetStringValue(), want)
+				}
+			}
+		})
+	}
+}
+
+func Test_computeFingerprint_gotExpectedFingerprintFormat(t *testing.T) {
+	ctx := context.Background()
+	fingerprint, err := computeFingerprint(ctx, generateInventory())
+	if err != nil {
+		t.Fatalf("unable to generate fingerprint, err - %s", err)
+	}
+
+	if !validFingerprint(fingerprint) {
+		t.Fatalf("got fingerprint %q in unexpected format", fingerprint)
 	}
 }
 
@@ -738,6 +4134,77 @@ func Test_computeStableFingerprint_fingerprintChangedAfterChanging(t *testing.T)
 	}
 }
 
+func Test_computeStableFingerprint_osInfoFields(t *testing.T) {
+	ctx := context.Background()
+	withoutKernel := []string{"host_name", "long_name", "short_name", "version", "architecture", "osconfig_agent_version"}
+	tests := []struct {
+		name         string
+		osInfoFields []string
+		wantChanged  bool
+	}{
+		{name: "AllFields", wantChanged: true},
+		{name: "KernelFieldsIncluded", osInfoFields: append(withoutKernel, "kernel_version", "kernel_release"), wantChanged: true},
+		{name: "KernelFieldsExcluded", osInfoFields: withoutKernel, wantChanged: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inventory := generateInventory()
+			before, err := computeStableFingerprint(ctx, inventory, tt.osInfoFields...)
+			if err != nil {
+				t.Fatalf("unable to generate initial fingerprint, err - %s", err)
+			}
+			vmBefore, err := computeStableFingerprintVMInventory(ctx, formatVMInventory(ctx, generateInventoryState()), tt.osInfoFields...)
+			if err != nil {
+				t.Fatalf("unable to generate initial VmInventory fingerprint, err - %s", err)
+			}
+
+			inventory.GetOsInfo().KernelVersion = "NewKernelVersion"
+			inventory.GetOsInfo().KernelRelease = "NewKernelRelease"
+			state := generateInventoryState()
+			state.KernelVersion = "NewKernelVersion"
+			state.KernelRelease = "NewKernelRelease"
+
+			after, err := computeStableFingerprint(ctx, inventory, tt.osInfoFields...)
+			if err != nil {
+				t.Fatalf("unable to generate final fingerprint, err - %s", err)
+			}
+			vmAfter, err := computeStableFingerprintVMInventory(ctx, formatVMInventory(ctx, state), tt.osInfoFields...)
+			if err != nil {
+				t.Fatalf("unable to generate final VmInventory fingerprint, err - %s", err)
+			}
+
+			if got := before != after; got != tt.wantChanged {
+				t.Errorf("Inventory fingerprint changed after a kernel update: %t, want: %t", got, tt.wantChanged)
+			}
+			if got := vmBefore != vmAfter; got != tt.wantChanged {
+				t.Errorf("VmInventory fingerprint changed after a kernel update: %t, want: %t", got, tt.wantChanged)
+			}
+		})
+	}
+}
+
+func Test_computeStableFingerprint_osInfoFieldsChangedAfterChanging(t *testing.T) {
+	ctx := context.Background()
+	inventory := generateInventory()
+	before, err := computeStableFingerprint(ctx, inventory, "short_name", "version")
+	if err != nil {
+		t.Fatalf("unable to generate initial fingerprint, err - %s", err)
+	}
+
+	inventory.GetOsInfo().Version = "NewVersion"
+
+	after, err := computeStableFingerprint(ctx, inventory, "short_name", "version")
+	if err != nil {
+		t.Fatalf("unable to generate final fingerprint, err - %s", err)
+	}
+	if before == after {
+		t.Errorf("stable fingerprint should not be equal if an included OsInfo field is changed")
+	}
+	if got := inventory.GetOsInfo().GetKernelVersion(); got == "" {
+		t.Errorf("computing the fingerprint cleared KernelVersion of the inventory")
+	}
+}
+
 func validFingerprint(fingerprint string) bool {
 	if fingerprint == "" {
 		return false
@@ -834,6 +4301,81 @@ func Benchmark_computeStableFingerprint(b *testing.B) {
 	}
 }
 
+func Benchmark_formatStableFingerprintVMInventory(b *testing.B) {
+	ctx, c, state := context.Background(), &Client{}, generateInventoryState()
+
+	for i := 0; i < b.N; i++ {
+		vmInventory := c.formatReportedVMInventory(ctx, state)
+		if _, err := computeStableFingerprintVMInventory(ctx, vmInventory); err != nil {
+			b.Fatalf("unable to generate fingerprint, err - %s", err)
+		}
+	}
+}
+
+func Benchmark_stateFingerprint(b *testing.B) {
+	state := generateInventoryState()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := state.Fingerprint(); err != nil {
+			b.Fatalf("unable to generate fingerprint, err - %s", err)
+		}
+	}
+}
+
+// alternatingInventoryProvider returns its states in turn.
+type alternatingInventoryProvider struct {
+	calls  int
+	states []*inventory.InstanceInventory
+}
+
+func (p *alternatingInventoryProvider) Get(context.Context) *inventory.InstanceInventory {
+	p.calls++
+	return p.states[p.calls%len(p.states)]
+}
+
+func (p *alternatingInventoryProvider) GetWithErrors(ctx context.Context) (*inventory.InstanceInventory, error) {
+	return p.Get(ctx), nil
+}
+
+func Benchmark_reportInventoryWithErrors(b *testing.B) {
+	changed := generateInventoryState()
+	changed.InstalledPackages.Deb = append(changed.InstalledPackages.Deb, &packages.PkgInfo{Name: "NewDeb", Version: "1.0", Type: "deb"})
+	benchmarks := []struct {
+		name   string
+		states []*inventory.InstanceInventory
+	}{
+		{name: "Unchanged", states: []*inventory.InstanceInventory{generateInventoryState()}},
+		{name: "Changed", states: []*inventory.InstanceInventory{generateInventoryState(), changed}},
+	}
+
+	for _, bb := range benchmarks {
+		b.Run(bb.name, func(b *testing.B) {
+			ctx := context.Background()
+			svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			defer svr.Close()
+
+			ctrl := gomock.NewController(b)
+			defer ctrl.Finish()
+			mockClient := utilmocks.NewMockAgentEndpointClient(ctrl)
+			mockClient.EXPECT().ReportVmInventory(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(&agentendpointpb.ReportVmInventoryResponse{}, nil)
+
+			tc, err := newMockTestClient(ctx, mockClient)
+			if err != nil {
+				b.Fatal(err)
+			}
+			tc.client.inventoryProvider = &alternatingInventoryProvider{states: bb.states}
+			WithDisableLegacyInventory()(tc.client)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := tc.client.reportInventoryWithErrors(ctx, svr.URL); err != nil {
+					b.Fatalf("reportInventoryWithErrors() unexpected error: %v", err)
+				}
+			}
+		})
+	}
+}
+
 func Test_reportVmInventory_parseWindowsApplicationDate(t *testing.T) {
 	apps := []*packages.WindowsApplication{{
 		DisplayName:    "TestApp",
@@ -856,6 +4398,85 @@ func Test_reportVmInventory_parseWindowsApplicationDate(t *testing.T) {
 	utiltest.AssertEquals(t, fields["HelpLink"].GetStringValue(), "TestLink")
 }
 
+func TestFormatQFEPackageInstalledOn(t *testing.T) {
+	tests := []struct {
+		name        string
+		installedOn string
+		want        time.Time
+	}{
+		{name: "US", installedOn: "9/1/2020", want: time.Date(2020, time.September, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "German", installedOn: "01.09.2020", want: time.Date(2020, time.September, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "Japanese", installedOn: "2020/09/01", want: time.Date(2020, time.September, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "ISO", installedOn: "2020-09-01", want: time.Date(2020, time.September, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "Unparsable", installedOn: "bad-date", want: time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := formatQFEPackage(context.Background(), &packages.QFEPackage{HotFixID: "KB1", InstalledOn: tt.installedOn})
+			if got.QfePackage == nil {
+				t.Fatalf("formatQFEPackage() returned nil QfePackage")
+			}
+			utiltest.AssertEquals(t, got.QfePackage.GetInstallTime().AsTime(), tt.want)
+		})
+	}
+}
+
+func TestParseQFEDateInLocale(t *testing.T) {
+	tests := []struct {
+		name          string
+		installedOn   string
+		locale        string
+		want          time.Time
+		wantAmbiguous bool
+	}{
+		{name: "US ambiguous", installedOn: "9/1/2020", locale: "en-US", want: time.Date(2020, time.September, 1, 0, 0, 0, 0, time.UTC), wantAmbiguous: true},
+		{name: "UK ambiguous", installedOn: "9/1/2020", locale: "en-GB", want: time.Date(2020, time.January, 9, 0, 0, 0, 0, time.UTC), wantAmbiguous: true},
+		{name: "French ambiguous", installedOn: "09/01/2020", locale: "fr-FR", want: time.Date(2020, time.January, 9, 0, 0, 0, 0, time.UTC), wantAmbiguous: true},
+		{name: "unknown locale ambiguous", installedOn: "9/1/2020", want: time.Date(2020, time.September, 1, 0, 0, 0, 0, time.UTC), wantAmbiguous: true},
+		{name: "script subtag ambiguous", installedOn: "9/1/2020", locale: "zh-Hans-CN", want: time.Date(2020, time.January, 9, 0, 0, 0, 0, time.UTC), wantAmbiguous: true},
+		{name: "extension ambiguous", installedOn: "9/1/2020", locale: "en-US-u-ca-gregory", want: time.Date(2020, time.September, 1, 0, 0, 0, 0, time.UTC), wantAmbiguous: true},
+		{name: "English without region ambiguous", installedOn: "9/1/2020", locale: "en", want: time.Date(2020, time.September, 1, 0, 0, 0, 0, time.UTC), wantAmbiguous: true},
+		{name: "no region ambiguous", installedOn: "9/1/2020", locale: "fr", want: time.Date(2020, time.January, 9, 0, 0, 0, 0, time.UTC), wantAmbiguous: true},
+		{name: "same day and month", installedOn: "3/3/2020", locale: "en-GB", want: time.Date(2020, time.March, 3, 0, 0, 0, 0, time.UTC)},
+		{name: "UK day after 12", installedOn: "13/1/2020", locale: "en-US", want: time.Date(2020, time.January, 13, 0, 0, 0, 0, time.UTC)},
+		{name: "US day after 12", installedOn: "1/13/2020", locale: "en-GB", want: time.Date(2020, time.January, 13, 0, 0, 0, 0, time.UTC)},
+		{name: "unambiguous layout", installedOn: "2020-09-01", locale: "en-GB", want: time.Date(2020, time.September, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ambiguous, err := parseQFEDateInLocale(tt.installedOn, tt.locale)
+			if err != nil {
+				t.Fatalf("parseQFEDateInLocale(%q, %q) unexpected error: %v", tt.installedOn, tt.locale, err)
+			}
+			utiltest.AssertEquals(t, got, tt.want)
+			utiltest.AssertEquals(t, ambiguous, tt.wantAmbiguous)
+		})
+	}
+}
+
+func TestQFEInstalledOnRawMetadata(t *testing.T) {
+	utiltest.OverrideVariable(t, &qfeDateLocale, func() string { return "de-DE" })
+	pkgs := []*packages.QFEPackage{
+		{Caption: "Ambiguous", HotFixID: "KB1", InstalledOn: "9/1/2020"},
+		{Caption: "Unambiguous", HotFixID: "KB2", InstalledOn: "2020-09-01"},
+		{Caption: "Invalid", HotFixID: "KB3", InstalledOn: "bad-date"},
+	}
+
+	got := map[string]map[string]*structpb.Value{}
+	for _, item := range qfeToInventoryItem(context.Background(), pkgs) {
+		got[item.GetVersion()] = item.GetMetadata().GetFields()
+	}
+
+	utiltest.AssertEquals(t, got["KB1"]["InstalledOn"].GetStringValue(), "2020-01-09 00:00:00 +0000 GMT")
+	utiltest.AssertEquals(t, got["KB1"]["InstalledOnRaw"].GetStringValue(), "9/1/2020")
+	if v, ok := got["KB2"]["InstalledOnRaw"]; ok {
+		t.Errorf("unexpected InstalledOnRaw metadata %v for an unambiguous date", v)
+	}
+	utiltest.AssertEquals(t, got["KB3"]["InstalledOn"].GetStringValue(), "0001-01-01 00:00:00 +0000 GMT")
+	utiltest.AssertEquals(t, got["KB3"]["InstalledOnRaw"].GetStringValue(), "bad-date")
+}
+
 func Test_reportVmInventory_parseQFEDate(t *testing.T) {
 	ctx := context.Background()
 
@@ -889,6 +4510,24 @@ func Test_reportVmInventory_parseQFEDate(t *testing.T) {
 			want:    time.Date(2020, time.September, 1, 0, 0, 0, 0, time.UTC),
 			wantErr: nil,
 		},
+		{
+			name:    "Format DD.MM.YYYY",
+			input:   "01.09.2020",
+			want:    time.Date(2020, time.September, 1, 0, 0, 0, 0, time.UTC),
+			wantErr: nil,
+		},
+		{
+			name:    "Format YYYY/MM/DD",
+			input:   "2020/09/01",
+			want:    time.Date(2020, time.September, 1, 0, 0, 0, 0, time.UTC),
+			wantErr: nil,
+		},
+		{
+			name:    "Format RFC3339",
+			input:   "2020-09-01T10:30:00Z",
+			want:    time.Date(2020, time.September, 1, 10, 30, 0, 0, time.UTC),
+			wantErr: nil,
+		},
 		{
 			name:    "Empty date",
 			input:   "",
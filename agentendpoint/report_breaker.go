@@ -0,0 +1,106 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package agentendpoint
+
+import (
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of the circuit breaker around inventory reports.
+type BreakerState int
+
+const (
+	// BreakerClosed lets every report through.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen skips reports until the cooldown elapsed.
+	BreakerOpen
+	// BreakerHalfOpen lets a single report through to probe the endpoint.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// reportBreaker opens after threshold consecutive failed reports and skips reporting
+// for cooldown, after which a single probe decides whether it closes or opens again.
+// A breaker with a threshold of 0 never opens.
+type reportBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	state    BreakerState
+	failures int
+	openedAt time.Time
+}
+
+// allow reports whether a report should be attempted.
+func (b *reportBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != BreakerOpen {
+		return true
+	}
+	if b.clock().Sub(b.openedAt) < b.cooldown {
+		return false
+	}
+	b.state = BreakerHalfOpen
+	return true
+}
+
+func (b *reportBreaker) recordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.state = BreakerClosed
+}
+
+func (b *reportBreaker) recordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.threshold > 0 && (b.state == BreakerHalfOpen || b.failures >= b.threshold) {
+		b.state = BreakerOpen
+		b.openedAt = b.clock()
+	}
+}
+
+func (b *reportBreaker) currentState() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+func (b *reportBreaker) clock() time.Time {
+	if b.now == nil {
+		return time.Now()
+	}
+	return b.now()
+}
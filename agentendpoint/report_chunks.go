@@ -0,0 +1,70 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package agentendpoint
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/GoogleCloudPlatform/osconfig/clog"
+	grpcmetadata "google.golang.org/grpc/metadata"
+
+	"cloud.google.com/go/osconfig/agentendpoint/apiv1/agentendpointpb"
+)
+
+// inventoryChunkMetadataKey is the request metadata key carrying the position,
+// "<index>/<total>" starting at 1, of a chunk of an inventory reported across
+// multiple ReportVmInventory requests. All chunks share the checksum of the whole
+// inventory so that the endpoint can reassemble them.
+const inventoryChunkMetadataKey = "x-osconfig-inventory-chunk"
+
+// chunkVMInventory splits the installed and then the available packages of inventory
+// into chunks of at most size items, every chunk carries the OsInfo.
+func chunkVMInventory(inventory *agentendpointpb.VmInventory, size int) []*agentendpointpb.VmInventory {
+	installed := inventory.GetInstalledPackages()
+	available := inventory.GetAvailablePackages()
+
+	var chunks []*agentendpointpb.VmInventory
+	for len(chunks) == 0 || len(installed)+len(available) > 0 {
+		chunk := &agentendpointpb.VmInventory{OsInfo: inventory.GetOsInfo()}
+		n := min(size, len(installed))
+		chunk.InstalledPackages, installed = installed[:n], installed[n:]
+		m := min(size-n, len(available))
+		chunk.AvailablePackages, available = available[:m], available[m:]
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// reportVMInventoryChunks reports chunks in order and returns the response to the last
+// one, reporting stops at the first failed chunk.
+func (c *Client) reportVMInventoryChunks(ctx context.Context, token, checksum string, chunks []*agentendpointpb.VmInventory) (*agentendpointpb.ReportVmInventoryResponse, error) {
+	var resp *agentendpointpb.ReportVmInventoryResponse
+	for i, chunk := range chunks {
+		position := fmt.Sprintf("%d/%d", i+1, len(chunks))
+		req := &agentendpointpb.ReportVmInventoryRequest{InventoryChecksum: checksum, VmInventory: chunk}
+		req.InstanceIdToken = "<redacted>"
+		clog.DebugRPC(ctx, "ReportVmInventory chunk "+position, req, nil)
+		req.InstanceIdToken = token
+
+		var err error
+		resp, err = c.raw.ReportVmInventory(grpcmetadata.AppendToOutgoingContext(ctx, inventoryChunkMetadataKey, position), req)
+		clog.DebugRPC(ctx, "ReportVmInventory chunk "+position, nil, resp)
+		if err != nil {
+			return nil, fmt.Errorf("error reporting inventory chunk %s: %w", position, err)
+		}
+	}
+	return resp, nil
+}
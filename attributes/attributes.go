@@ -18,14 +18,49 @@ package attributes
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"time"
 )
 
+// ErrNotFound is returned by GetAttribute when the attribute is not set.
+var ErrNotFound = errors.New("guest attribute not found")
+
+// getClient reads Guest Attributes, the timeout bounds reads from an unresponsive
+// metadata server when ctx has no deadline.
+var getClient = &http.Client{Timeout: 10 * time.Second}
+
+// GetAttribute reads data from Guest Attributes
+func GetAttribute(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Metadata-Flavor", "Google")
+
+	resp, err := getClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf(`received status code %q for request "%s %s"`, resp.Status, req.Method, req.URL.String())
+	case err != nil:
+		return nil, err
+	}
+	return b, nil
+}
+
 // PostAttribute posts data to Guest Attributes
 func PostAttribute(url string, value io.Reader) error {
 	req, err := http.NewRequest("PUT", url, value)
@@ -52,9 +87,18 @@ func PostAttribute(url string, value io.Reader) error {
 
 // PostAttributeCompressed compresses and posts data to Guest Attributes
 func PostAttributeCompressed(url string, body any) error {
+	return PostAttributeCompressedLevel(url, body, gzip.DefaultCompression)
+}
+
+// PostAttributeCompressedLevel compresses data with the given gzip level and posts it
+// to Guest Attributes.
+func PostAttributeCompressedLevel(url string, body any, level int) error {
 	buf := &bytes.Buffer{}
 	b := base64.NewEncoder(base64.StdEncoding, buf)
-	zw := gzip.NewWriter(b)
+	zw, err := gzip.NewWriterLevel(b, level)
+	if err != nil {
+		return err
+	}
 	w := json.NewEncoder(zw)
 	if err := w.Encode(body); err != nil {
 		return err
@@ -69,3 +113,23 @@ func PostAttributeCompressed(url string, body any) error {
 
 	return PostAttribute(url, buf)
 }
+
+// DeleteAttribute deletes the guest attribute at url, deleting an attribute that is not
+// set succeeds.
+func DeleteAttribute(url string) error {
+	req, err := http.NewRequest("DELETE", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Metadata-Flavor", "Google")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf(`received status code %q for request "%s %s"`, resp.Status, req.Method, req.URL.String())
+	}
+	return nil
+}
@@ -17,8 +17,10 @@ package attributes
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -26,6 +28,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/GoogleCloudPlatform/osconfig/packages"
 )
@@ -133,3 +136,110 @@ func getDecompressPackageInfo(encoded string) (*packages.Packages, error) {
 
 	return &pkgs, nil
 }
+
+func TestPostAttributeCompressedLevel(t *testing.T) {
+	var td packages.Packages
+	for i := 0; i < 500; i++ {
+		td.Apt = append(td.Apt, &packages.PkgInfo{Name: fmt.Sprintf("test-package-%d", i), Version: fmt.Sprintf("1.%d.%d", i%7, i*31%97), Arch: "amd64"})
+	}
+
+	var sizes []int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusExpectationFailed)
+			return
+		}
+		pkg, err := getDecompressPackageInfo(string(body))
+		if err != nil || len(pkg.Apt) != len(td.Apt) {
+			w.WriteHeader(http.StatusExpectationFailed)
+			return
+		}
+		sizes = append(sizes, len(body))
+	}))
+	defer ts.Close()
+
+	for _, level := range []int{gzip.BestSpeed, gzip.BestCompression} {
+		if err := PostAttributeCompressedLevel(ts.URL, td, level); err != nil {
+			t.Fatalf("PostAttributeCompressedLevel(%d): unexpected error: %v", level, err)
+		}
+	}
+	if len(sizes) != 2 || sizes[0] <= sizes[1] {
+		t.Errorf("expected BestSpeed output to be larger than BestCompression output, got sizes %v", sizes)
+	}
+
+	if err := PostAttributeCompressedLevel(ts.URL, td, 42); err == nil {
+		t.Errorf("PostAttributeCompressedLevel(42): expected an error for an invalid level")
+	}
+}
+
+func TestGetAttribute(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/set":
+			w.Write([]byte("value"))
+		case "/unset":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	got, err := GetAttribute(context.Background(), ts.URL+"/set")
+	if err != nil || string(got) != "value" {
+		t.Errorf("GetAttribute(/set) = %q, %v, want: \"value\", nil", got, err)
+	}
+	if _, err := GetAttribute(context.Background(), ts.URL+"/unset"); err != ErrNotFound {
+		t.Errorf("GetAttribute(/unset) error = %v, want: %v", err, ErrNotFound)
+	}
+	if _, err := GetAttribute(context.Background(), ts.URL+"/unavailable"); err == nil || err == ErrNotFound {
+		t.Errorf("GetAttribute(/unavailable) error = %v, want a status code error", err)
+	}
+}
+
+func TestGetAttributeCancelled(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := GetAttribute(ctx, ts.URL); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetAttribute() error = %v, want: %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestDeleteAttribute(t *testing.T) {
+	status := http.StatusOK
+	var method string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+
+	if err := DeleteAttribute(ts.URL); err != nil {
+		t.Errorf("DeleteAttribute() unexpected error: %v", err)
+	}
+	if method != "DELETE" {
+		t.Errorf("DeleteAttribute() sent a %s request, want DELETE", method)
+	}
+
+	status = http.StatusNotFound
+	if err := DeleteAttribute(ts.URL); err != nil {
+		t.Errorf("DeleteAttribute() of an unset attribute unexpected error: %v", err)
+	}
+
+	status = http.StatusBadRequest
+	if err := DeleteAttribute(ts.URL); err == nil || !strings.Contains(err.Error(), "400 Bad Request") {
+		t.Errorf("DeleteAttribute() error = %v, want 400 Bad Request", err)
+	}
+}
@@ -0,0 +1,418 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package inventory
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/osconfig/packages"
+)
+
+// InventoryDiff is the difference between two InstanceInventory snapshots, e.g. before
+// and after patching. It can be encoded as JSON.
+type InventoryDiff struct {
+	// OSInfo are the OS and agent fields of the inventory that changed.
+	OSInfo []FieldChange `json:",omitempty"`
+	// InstalledPackages and PackageUpdates map the package types that changed, named
+	// after their JSON field in packages.Packages, e.g. "deb", to their changes.
+	InstalledPackages map[string]*PackageDiff `json:",omitempty"`
+	PackageUpdates    map[string]*PackageDiff `json:",omitempty"`
+	// NetworkInterfaces are the network interfaces that were added, removed or whose
+	// addresses changed.
+	NetworkInterfaces *PackageDiff `json:",omitempty"`
+}
+
+// Empty reports whether the compared inventories have no differences.
+func (d *InventoryDiff) Empty() bool {
+	return len(d.OSInfo) == 0 && len(d.InstalledPackages) == 0 && len(d.PackageUpdates) == 0 && d.NetworkInterfaces == nil
+}
+
+// FieldChange is a field of InstanceInventory that changed from From to To.
+type FieldChange struct {
+	Field, From, To string
+}
+
+// PackageDiff is the difference between the packages of one type.
+type PackageDiff struct {
+	Added   []PackageChange `json:",omitempty"`
+	Removed []PackageChange `json:",omitempty"`
+	// Changed are the packages whose version or other details changed.
+	Changed []PackageChange `json:",omitempty"`
+}
+
+// PackageChange identifies a package of a PackageDiff. OldVersion is its version in the
+// first inventory and Version the one in the second, added packages have no OldVersion
+// and removed packages no Version.
+type PackageChange struct {
+	Name       string
+	Arch       string `json:",omitempty"`
+	OldVersion string `json:",omitempty"`
+	Version    string `json:",omitempty"`
+}
+
+// Diff returns the changes from i to other: packages only in other are added and packages
+// only in i removed. Packages are matched by the identity the agent reports them with, e.g.
+// their name and architecture, so that an upgrade shows as a changed version. A nil
+// inventory is compared as an empty one.
+func (i *InstanceInventory) Diff(other *InstanceInventory) *InventoryDiff {
+	if i == nil {
+		i = &InstanceInventory{}
+	}
+	if other == nil {
+		other = &InstanceInventory{}
+	}
+	return &InventoryDiff{
+		OSInfo:            diffFields(i, other),
+		InstalledPackages: diffPackages(i.InstalledPackages, other.InstalledPackages),
+		PackageUpdates:    diffPackages(i.PackageUpdates, other.PackageUpdates),
+		NetworkInterfaces: diffNetworkInterfaces(i.NetworkInterfaces, other.NetworkInterfaces),
+	}
+}
+
+// diffNetworkInterfaces compares the interfaces by name, an interface whose MAC or
+// addresses differ is changed.
+func diffNetworkInterfaces(a, b []NetworkInterface) *PackageDiff {
+	pointers := func(ifaces []NetworkInterface) []*NetworkInterface {
+		p := make([]*NetworkInterface, len(ifaces))
+		for i := range ifaces {
+			p[i] = &ifaces[i]
+		}
+		return p
+	}
+	return diffPackageList(reflect.ValueOf(pointers(a)), reflect.ValueOf(pointers(b)))
+}
+
+// diffFields compares the string and bool fields of the inventories, LastUpdated changes
+// with every collection and is left out.
+func diffFields(a, b *InstanceInventory) []FieldChange {
+	var changes []FieldChange
+	av := reflect.ValueOf(a).Elem()
+	bv := reflect.ValueOf(b).Elem()
+	t := av.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Name
+		if name == "LastUpdated" {
+			continue
+		}
+		var from, to string
+		switch av.Field(i).Kind() {
+		case reflect.String:
+			from, to = av.Field(i).String(), bv.Field(i).String()
+		case reflect.Bool:
+			from, to = strconv.FormatBool(av.Field(i).Bool()), strconv.FormatBool(bv.Field(i).Bool())
+		default:
+			continue
+		}
+		if from != to {
+			changes = append(changes, FieldChange{Field: name, From: from, To: to})
+		}
+	}
+	return changes
+}
+
+func diffPackages(a, b *packages.Packages) map[string]*PackageDiff {
+	if a == nil {
+		a = &packages.Packages{}
+	}
+	if b == nil {
+		b = &packages.Packages{}
+	}
+	diffs := map[string]*PackageDiff{}
+	av := reflect.ValueOf(a).Elem()
+	bv := reflect.ValueOf(b).Elem()
+	t := av.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Type.Kind() != reflect.Slice {
+			continue
+		}
+		if d := diffPackageList(av.Field(i), bv.Field(i)); d != nil {
+			diffs[packageTypeName(t.Field(i))] = d
+		}
+	}
+	if len(diffs) == 0 {
+		return nil
+	}
+	return diffs
+}
+
+// packageTypeName returns the JSON name of a packages.Packages field, e.g. "deb". Fields
+// left out of the JSON encoding, like WindowsApplication, are named in lower camel case.
+func packageTypeName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		name = strings.ToLower(f.Name[:1]) + f.Name[1:]
+	}
+	return name
+}
+
+type diffEntry struct {
+	key    string
+	change PackageChange
+	pkg    any
+}
+
+// diffPackageList compares two lists of the same package type, nil when they hold the
+// same packages. Packages with the same identity and version are changed when any other
+// detail differs, the remaining packages with the same identity, e.g. an upgraded kernel
+// of which multiple versions are installed, are paired in version order.
+func diffPackageList(a, b reflect.Value) *PackageDiff {
+	before := diffEntries(a)
+	after := diffEntries(b)
+
+	d := &PackageDiff{}
+	// exact maps the identity and version of the packages of before to their indexes.
+	exact := map[string][]int{}
+	for i, e := range before {
+		k := e.key + "|" + e.change.Version
+		exact[k] = append(exact[k], i)
+	}
+	matched := make([]bool, len(before))
+	var unmatched []diffEntry
+	for _, e := range after {
+		k := e.key + "|" + e.change.Version
+		if len(exact[k]) == 0 {
+			unmatched = append(unmatched, e)
+			continue
+		}
+		old := exact[k][0]
+		exact[k] = exact[k][1:]
+		matched[old] = true
+		if !reflect.DeepEqual(before[old].pkg, e.pkg) {
+			d.Changed = append(d.Changed, withOldVersion(e.change, before[old].change.Version))
+		}
+	}
+
+	remaining := map[string][]diffEntry{}
+	for i, e := range before {
+		if !matched[i] {
+			remaining[e.key] = append(remaining[e.key], e)
+		}
+	}
+	for _, e := range unmatched {
+		if olds := remaining[e.key]; len(olds) > 0 {
+			remaining[e.key] = olds[1:]
+			d.Changed = append(d.Changed, withOldVersion(e.change, olds[0].change.Version))
+			continue
+		}
+		d.Added = append(d.Added, e.change)
+	}
+	for _, olds := range remaining {
+		for _, e := range olds {
+			d.Removed = append(d.Removed, withOldVersion(PackageChange{Name: e.change.Name, Arch: e.change.Arch}, e.change.Version))
+		}
+	}
+
+	if len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0 {
+		return nil
+	}
+	sortPackageChanges(d.Added)
+	sortPackageChanges(d.Removed)
+	sortPackageChanges(d.Changed)
+	return d
+}
+
+func withOldVersion(c PackageChange, oldVersion string) PackageChange {
+	c.OldVersion = oldVersion
+	return c
+}
+
+// diffEntries returns the identities of the packages in list sorted by version, so that
+// remaining packages of the same identity are paired oldest first.
+func diffEntries(list reflect.Value) []diffEntry {
+	entries := make([]diffEntry, 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		if list.Index(i).IsNil() {
+			continue
+		}
+		pkg := list.Index(i).Interface()
+		entries = append(entries, diffEntry{key: PackageKey(pkg), change: packageChange(pkg), pkg: pkg})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return compareVersions(entries[i].change.Version, entries[j].change.Version) < 0
+	})
+	return entries
+}
+
+// compareVersions compares two versions in natural order, runs of digits are compared by
+// their numeric value, so that e.g. 5.15.0-9 sorts before 5.15.0-10.
+func compareVersions(a, b string) int {
+	for a != "" && b != "" {
+		ad, bd := isDigit(a[0]), isDigit(b[0])
+		if ad != bd {
+			return strings.Compare(a, b)
+		}
+		if !ad {
+			if a[0] != b[0] {
+				return int(a[0]) - int(b[0])
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+		an, bn := digitRun(a), digitRun(b)
+		// Compare the runs without leading zeros by length first, then lexically.
+		at, bt := strings.TrimLeft(a[:an], "0"), strings.TrimLeft(b[:bn], "0")
+		if len(at) != len(bt) {
+			return len(at) - len(bt)
+		}
+		if c := strings.Compare(at, bt); c != 0 {
+			return c
+		}
+		a, b = a[an:], b[bn:]
+	}
+	return len(a) - len(b)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// digitRun returns the length of the run of digits s starts with.
+func digitRun(s string) int {
+	n := 0
+	for n < len(s) && isDigit(s[n]) {
+		n++
+	}
+	return n
+}
+
+func sortPackageChanges(changes []PackageChange) {
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.Arch != b.Arch {
+			return a.Arch < b.Arch
+		}
+		if c := compareVersions(a.OldVersion, b.OldVersion); c != 0 {
+			return c < 0
+		}
+		return compareVersions(a.Version, b.Version) < 0
+	})
+}
+
+// PackageKey returns the key identifying pkg, an element of a packages.Packages list or a
+// *NetworkInterface, among the packages of its type regardless of its version. Diff matches
+// packages by it and the agent deduplicates the items it reports by it and PackageVersion,
+// so it tells apart e.g. multilib architectures, IDE extensions of different publishers,
+// repositories with different URLs, modules of different module paths and renewed
+// certificates.
+func PackageKey(pkg any) string {
+	switch p := pkg.(type) {
+	case *packages.PkgInfo:
+		return p.Name + "|" + p.Arch
+	case *packages.PipPackage:
+		return PackageKey(&p.PkgInfo)
+	case *packages.ZypperPatch:
+		return p.Name
+	case *packages.WUAPackage:
+		return p.UpdateID
+	case *packages.QFEPackage:
+		return p.HotFixID
+	case *packages.WindowsApplication:
+		return p.DisplayName + "|" + p.Publisher
+	case *packages.GoModule:
+		return p.Path
+	case *packages.NpmPackage:
+		return p.Name
+	case *packages.CargoPackage:
+		return p.Name + "|" + p.Source
+	case *packages.ContainerImage:
+		return p.ID
+	case *packages.FreeBSDPackage:
+		return p.Name
+	case *packages.NixPackage:
+		return p.Name
+	case *packages.CondaPackage:
+		return p.Name
+	case *packages.SystemdUnit:
+		return p.Name
+	case *packages.WindowsService:
+		return p.Name
+	case *packages.Firmware:
+		return p.Vendor
+	case *packages.ListeningPort:
+		return fmt.Sprintf("%s/%s:%d", p.Protocol, p.Address, p.Port)
+	case *packages.Certificate:
+		return p.Fingerprint
+	case *packages.IDEExtension:
+		return p.IDE + "|" + p.ID
+	case *packages.GooGetRepository:
+		return p.Name + "|" + p.URL
+	case *packages.PackageRepository:
+		return p.Manager + "|" + p.Name + "|" + p.URL
+	case *packages.LoadedLibrary:
+		return p.Path
+	case *NetworkInterface:
+		return p.Name
+	case *packages.EnvironmentModule:
+		return p.ModulePath + "|" + p.Name
+	}
+	// Unknown types are identified by their content, a change shows as removed and added.
+	return fmt.Sprintf("%+v", reflect.Indirect(reflect.ValueOf(pkg)).Interface())
+}
+
+// PackageVersion returns the version of pkg Diff compares, e.g. the revision of WUA updates.
+func PackageVersion(pkg any) string {
+	return packageChange(pkg).Version
+}
+
+// packageChange returns the name, architecture and version a PackageDiff reports pkg with.
+func packageChange(pkg any) PackageChange {
+	switch p := pkg.(type) {
+	case *packages.PkgInfo:
+		return PackageChange{Name: p.Name, Arch: p.Arch, Version: p.Version}
+	case *packages.PipPackage:
+		return packageChange(&p.PkgInfo)
+	case *packages.WUAPackage:
+		return PackageChange{Name: p.Title, Version: strconv.Itoa(int(p.RevisionNumber))}
+	case *packages.WindowsApplication:
+		return PackageChange{Name: p.DisplayName, Version: p.DisplayVersion}
+	case *packages.GoModule:
+		return PackageChange{Name: p.Path, Version: p.Version}
+	case *packages.NpmPackage:
+		return PackageChange{Name: p.Name, Version: p.Version}
+	case *packages.CargoPackage:
+		return PackageChange{Name: p.Name, Version: p.Version}
+	case *packages.ContainerImage:
+		return PackageChange{Name: strings.Join(p.RepoTags, ","), Version: p.Digest}
+	case *packages.FreeBSDPackage:
+		return PackageChange{Name: p.Name, Version: p.Version}
+	case *packages.NixPackage:
+		return PackageChange{Name: p.Name, Version: p.Version}
+	case *packages.CondaPackage:
+		return PackageChange{Name: p.Name, Version: p.Version}
+	case *packages.Firmware:
+		return PackageChange{Name: p.Vendor, Version: p.Version}
+	case *packages.Certificate:
+		return PackageChange{Name: p.Subject}
+	case *packages.IDEExtension:
+		return PackageChange{Name: p.Name, Version: p.Version}
+	case *packages.GooGetRepository:
+		return PackageChange{Name: p.Name}
+	case *packages.PackageRepository:
+		return PackageChange{Name: p.Name}
+	case *packages.LoadedLibrary:
+		return PackageChange{Name: p.Path, Version: p.Version}
+	case *packages.EnvironmentModule:
+		return PackageChange{Name: p.Name, Version: p.Version}
+	}
+	// The remaining types are named after their key.
+	return PackageChange{Name: PackageKey(pkg)}
+}
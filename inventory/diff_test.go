@@ -0,0 +1,198 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package inventory
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/osconfig/packages"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiff(t *testing.T) {
+	before := &InstanceInventory{
+		Hostname:      "testhost",
+		KernelRelease: "5.14.0-362.el9.x86_64",
+		LastUpdated:   "2024-03-01T00:00:00Z",
+		InstalledPackages: &packages.Packages{
+			Rpm: []*packages.PkgInfo{
+				{Name: "bash", Arch: "x86_64", Version: "5.1.8-6.el9"},
+				{Name: "openssl", Arch: "x86_64", Version: "3.0.7-24.el9"},
+				{Name: "telnet", Arch: "x86_64", Version: "0.17-85.el9"},
+				{Name: "kernel", Arch: "x86_64", Version: "5.14.0-362.el9"},
+				{Name: "kernel", Arch: "x86_64", Version: "5.14.0-427.el9"},
+			},
+			SystemdUnits: []*packages.SystemdUnit{{Name: "sshd.service", ActiveState: "active"}},
+		},
+		PackageUpdates: &packages.Packages{
+			Yum: []*packages.PkgInfo{{Name: "openssl", Arch: "x86_64", Version: "3.0.7-27.el9"}},
+		},
+		NetworkInterfaces: []NetworkInterface{
+			{Name: "eth0", MAC: "42:01:0a:80:00:02", IPv4: []string{"10.128.0.2"}},
+			{Name: "docker0", MAC: "02:42:ac:11:00:01", IPv4: []string{"172.17.0.1"}},
+		},
+	}
+	after := &InstanceInventory{
+		Hostname:      "testhost",
+		KernelRelease: "5.14.0-503.el9.x86_64",
+		LastUpdated:   "2024-03-02T00:00:00Z",
+		InstalledPackages: &packages.Packages{
+			Rpm: []*packages.PkgInfo{
+				{Name: "bash", Arch: "x86_64", Version: "5.1.8-6.el9"},
+				{Name: "openssl", Arch: "x86_64", Version: "3.0.7-27.el9"},
+				{Name: "curl", Arch: "x86_64", Version: "7.76.1-29.el9"},
+				{Name: "kernel", Arch: "x86_64", Version: "5.14.0-427.el9"},
+				{Name: "kernel", Arch: "x86_64", Version: "5.14.0-503.el9"},
+			},
+			SystemdUnits: []*packages.SystemdUnit{{Name: "sshd.service", ActiveState: "failed"}},
+		},
+		PackageUpdates: &packages.Packages{},
+		NetworkInterfaces: []NetworkInterface{
+			{Name: "eth0", MAC: "42:01:0a:80:00:02", IPv4: []string{"10.128.0.3"}},
+			{Name: "eth1", MAC: "42:01:0a:80:00:03", IPv4: []string{"10.129.0.2"}},
+		},
+	}
+
+	got := before.Diff(after)
+	want := &InventoryDiff{
+		OSInfo: []FieldChange{{Field: "KernelRelease", From: "5.14.0-362.el9.x86_64", To: "5.14.0-503.el9.x86_64"}},
+		InstalledPackages: map[string]*PackageDiff{
+			"rpm": {
+				Added:   []PackageChange{{Name: "curl", Arch: "x86_64", Version: "7.76.1-29.el9"}},
+				Removed: []PackageChange{{Name: "telnet", Arch: "x86_64", OldVersion: "0.17-85.el9"}},
+				Changed: []PackageChange{
+					{Name: "kernel", Arch: "x86_64", OldVersion: "5.14.0-362.el9", Version: "5.14.0-503.el9"},
+					{Name: "openssl", Arch: "x86_64", OldVersion: "3.0.7-24.el9", Version: "3.0.7-27.el9"},
+				},
+			},
+			"systemdUnits": {
+				Changed: []PackageChange{{Name: "sshd.service"}},
+			},
+		},
+		PackageUpdates: map[string]*PackageDiff{
+			"yum": {
+				Removed: []PackageChange{{Name: "openssl", Arch: "x86_64", OldVersion: "3.0.7-27.el9"}},
+			},
+		},
+		NetworkInterfaces: &PackageDiff{
+			Added:   []PackageChange{{Name: "eth1"}},
+			Removed: []PackageChange{{Name: "docker0"}},
+			Changed: []PackageChange{{Name: "eth0"}},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Diff() mismatch (-want +got):\n%s", diff)
+	}
+
+	// The diff is serializable.
+	b, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf("json.Marshal() error: %v", err)
+	}
+	var decoded InventoryDiff
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error: %v", err)
+	}
+	if diff := cmp.Diff(want, &decoded); diff != "" {
+		t.Errorf("decoded Diff() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDiffIdentical(t *testing.T) {
+	inv := &InstanceInventory{
+		Hostname:          "testhost",
+		InstalledPackages: &packages.Packages{Deb: []*packages.PkgInfo{{Name: "bash", Arch: "x86_64", Version: "5.2.15-2"}}},
+	}
+	same := &InstanceInventory{
+		Hostname:          "testhost",
+		LastUpdated:       "2024-03-02T00:00:00Z",
+		InstalledPackages: &packages.Packages{Deb: []*packages.PkgInfo{{Name: "bash", Arch: "x86_64", Version: "5.2.15-2"}}},
+	}
+	if d := inv.Diff(same); !d.Empty() {
+		t.Errorf("Diff() of identical inventories = %+v, want empty", d)
+	}
+}
+
+func TestDiffNil(t *testing.T) {
+	inv := &InstanceInventory{
+		InstalledPackages: &packages.Packages{
+			WindowsApplication: []*packages.WindowsApplication{{DisplayName: "Google Chrome", Publisher: "Google LLC", DisplayVersion: "122.0"}},
+		},
+	}
+
+	got := (*InstanceInventory)(nil).Diff(inv)
+	want := &InventoryDiff{
+		InstalledPackages: map[string]*PackageDiff{
+			"windowsApplication": {Added: []PackageChange{{Name: "Google Chrome", Version: "122.0"}}},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Diff() from nil mismatch (-want +got):\n%s", diff)
+	}
+
+	got = inv.Diff(nil)
+	want = &InventoryDiff{
+		InstalledPackages: map[string]*PackageDiff{
+			"windowsApplication": {Removed: []PackageChange{{Name: "Google Chrome", OldVersion: "122.0"}}},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Diff() to nil mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDiffPairsVersionsInNaturalOrder(t *testing.T) {
+	kernels := func(versions ...string) *InstanceInventory {
+		var pkgs []*packages.PkgInfo
+		for _, v := range versions {
+			pkgs = append(pkgs, &packages.PkgInfo{Name: "linux-image-amd64", Arch: "amd64", Version: v})
+		}
+		return &InstanceInventory{InstalledPackages: &packages.Packages{Deb: pkgs}}
+	}
+
+	got := kernels("5.15.0-10", "5.15.0-9").Diff(kernels("5.15.0-11", "5.15.0-12"))
+	want := &InventoryDiff{
+		InstalledPackages: map[string]*PackageDiff{
+			"deb": {Changed: []PackageChange{
+				{Name: "linux-image-amd64", Arch: "amd64", OldVersion: "5.15.0-9", Version: "5.15.0-11"},
+				{Name: "linux-image-amd64", Arch: "amd64", OldVersion: "5.15.0-10", Version: "5.15.0-12"},
+			}},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Diff() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"5.15.0-9", "5.15.0-10", -1},
+		{"1.10", "1.9", 1},
+		{"1.02", "1.2", 0},
+		{"1.2", "1.2.1", -1},
+		{"1.2a", "1.2b", -1},
+		{"", "", 0},
+	}
+	for _, tt := range tests {
+		got := compareVersions(tt.a, tt.b)
+		if (got < 0) != (tt.want < 0) || (got > 0) != (tt.want > 0) {
+			t.Errorf("compareVersions(%q, %q) = %d, want sign of %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package inventory
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/GoogleCloudPlatform/osconfig/packages"
+)
+
+// exportedPackages adds the packages that are not written to guest attributes to the
+// JSON encoding of packages.Packages.
+type exportedPackages struct {
+	*packages.Packages
+	WindowsApplication []*packages.WindowsApplication `json:"windowsApplication,omitempty"`
+}
+
+// exportedInventory is the JSON encoding of InstanceInventory, it has a field of the same
+// name for each of the InstanceInventory fields.
+type exportedInventory struct {
+	Hostname               string             `json:"hostname"`
+	LongName               string             `json:"longName"`
+	ShortName              string             `json:"shortName"`
+	Version                string             `json:"version"`
+	Architecture           string             `json:"architecture"`
+	KernelVersion          string             `json:"kernelVersion"`
+	KernelRelease          string             `json:"kernelRelease"`
+	Variant                string             `json:"variant,omitempty"`
+	CPEName                string             `json:"cpeName,omitempty"`
+	BuildID                string             `json:"buildId,omitempty"`
+	CPE                    string             `json:"cpe,omitempty"`
+	OSConfigAgentVersion   string             `json:"osconfigAgentVersion"`
+	RebootRequired         bool               `json:"rebootRequired,omitempty"`
+	RebootRequiredReason   string             `json:"rebootRequiredReason,omitempty"`
+	InstalledKernelRelease string             `json:"installedKernelRelease,omitempty"`
+	KernelMismatch         bool               `json:"kernelMismatch,omitempty"`
+	Environment            string             `json:"environment,omitempty"`
+	WSLDistro              string             `json:"wslDistro,omitempty"`
+	NetworkInterfaces      []NetworkInterface `json:"networkInterfaces,omitempty"`
+	InstalledPackages      *exportedPackages  `json:"installedPackages,omitempty"`
+	PackageUpdates         *exportedPackages  `json:"packageUpdates,omitempty"`
+	LastUpdated            string             `json:"lastUpdated"`
+	SourceTimestamps       map[string]string  `json:"sourceTimestamps,omitempty"`
+}
+
+// ExportJSON returns the whole inventory, OS info and all packages, as indented JSON for
+// local diagnostics. Packages of each kind are sorted so that the output of two
+// inventories can be diffed.
+func (i *InstanceInventory) ExportJSON() ([]byte, error) {
+	exported := exportedInventory{
+		Hostname:               i.Hostname,
+		LongName:               i.LongName,
+		ShortName:              i.ShortName,
+		Version:                i.Version,
+		Architecture:           i.Architecture,
+		KernelVersion:          i.KernelVersion,
+		KernelRelease:          i.KernelRelease,
+		Variant:                i.Variant,
+		CPEName:                i.CPEName,
+		BuildID:                i.BuildID,
+		CPE:                    i.CPE,
+		OSConfigAgentVersion:   i.OSConfigAgentVersion,
+		RebootRequired:         i.RebootRequired,
+		RebootRequiredReason:   i.RebootRequiredReason,
+		InstalledKernelRelease: i.InstalledKernelRelease,
+		KernelMismatch:         i.KernelMismatch,
+		Environment:            i.Environment,
+		WSLDistro:              i.WSLDistro,
+		NetworkInterfaces:      i.NetworkInterfaces,
+		LastUpdated:            i.LastUpdated,
+		SourceTimestamps:       i.SourceTimestamps,
+	}
+	var err error
+	if exported.InstalledPackages, err = exportPackages(i.InstalledPackages); err != nil {
+		return nil, err
+	}
+	if exported.PackageUpdates, err = exportPackages(i.PackageUpdates); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(exported, "", "  ")
+}
+
+// exportPackages returns a copy of pkgs with every package list sorted by the JSON
+// encoding of its elements.
+func exportPackages(pkgs *packages.Packages) (*exportedPackages, error) {
+	if pkgs == nil {
+		return nil, nil
+	}
+	sorted := *pkgs
+	v := reflect.ValueOf(&sorted).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		if field.Kind() != reflect.Slice || field.Len() < 2 {
+			continue
+		}
+		keys := make([][]byte, field.Len())
+		for j := range keys {
+			key, err := json.Marshal(field.Index(j).Interface())
+			if err != nil {
+				return nil, err
+			}
+			keys[j] = key
+		}
+		order := make([]int, len(keys))
+		for j := range order {
+			order[j] = j
+		}
+		sort.SliceStable(order, func(a, b int) bool { return bytes.Compare(keys[order[a]], keys[order[b]]) < 0 })
+		copied := reflect.MakeSlice(field.Type(), field.Len(), field.Len())
+		for j, idx := range order {
+			copied.Index(j).Set(field.Index(idx))
+		}
+		field.Set(copied)
+	}
+	return &exportedPackages{Packages: &sorted, WindowsApplication: sorted.WindowsApplication}, nil
+}
@@ -0,0 +1,165 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package inventory
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/osconfig/packages"
+)
+
+// GrafeasOccurrence is a Grafeas PACKAGE occurrence in its JSON form, one installed
+// package of the resource identified by ResourceURI.
+type GrafeasOccurrence struct {
+	ResourceURI string                   `json:"resourceUri"`
+	Kind        string                   `json:"kind"`
+	Package     GrafeasPackageOccurrence `json:"package"`
+}
+
+// GrafeasPackageOccurrence describes an installed package and where it is installed.
+type GrafeasPackageOccurrence struct {
+	Name         string            `json:"name"`
+	PackageType  string            `json:"packageType"`
+	CPEURI       string            `json:"cpeUri,omitempty"`
+	Architecture string            `json:"architecture,omitempty"`
+	Version      GrafeasVersion    `json:"version"`
+	Location     []GrafeasLocation `json:"location"`
+}
+
+// GrafeasVersion is a package version split into its Grafeas components.
+type GrafeasVersion struct {
+	Epoch    int    `json:"epoch,omitempty"`
+	Name     string `json:"name"`
+	Revision string `json:"revision,omitempty"`
+	FullName string `json:"fullName"`
+	Kind     string `json:"kind"`
+}
+
+// GrafeasLocation is a place a package is installed at, Path is empty for packages
+// of the operating system package manager.
+type GrafeasLocation struct {
+	CPEURI  string         `json:"cpeUri,omitempty"`
+	Version GrafeasVersion `json:"version"`
+	Path    string         `json:"path,omitempty"`
+}
+
+// GrafeasOccurrences converts the installed packages of inv into Grafeas package
+// occurrences of resourceURI, e.g. //compute.googleapis.com/projects/p/zones/z/instances/i.
+// Occurrences are sorted by package type, name and version.
+func GrafeasOccurrences(resourceURI string, inv *InstanceInventory) []*GrafeasOccurrence {
+	if inv == nil || inv.InstalledPackages == nil {
+		return nil
+	}
+	pkgs := inv.InstalledPackages
+	var occurrences []*GrafeasOccurrence
+	add := func(packageType, name, arch, version string, splitVersion func(string) GrafeasVersion, paths ...string) {
+		v := splitVersion(version)
+		if len(paths) == 0 {
+			paths = []string{""}
+		}
+		locations := make([]GrafeasLocation, len(paths))
+		for i, p := range paths {
+			locations[i] = GrafeasLocation{CPEURI: inv.CPE, Version: v, Path: p}
+		}
+		occurrences = append(occurrences, &GrafeasOccurrence{
+			ResourceURI: resourceURI,
+			Kind:        "PACKAGE",
+			Package: GrafeasPackageOccurrence{
+				Name:         name,
+				PackageType:  packageType,
+				CPEURI:       inv.CPE,
+				Architecture: arch,
+				Version:      v,
+				Location:     locations,
+			},
+		})
+	}
+	addPkgInfos := func(packageType string, infos []*packages.PkgInfo, splitVersion func(string) GrafeasVersion) {
+		for _, p := range infos {
+			add(packageType, p.Name, p.Arch, p.Version, splitVersion)
+		}
+	}
+
+	addPkgInfos("OS", pkgs.Deb, grafeasOSVersion)
+	addPkgInfos("OS", pkgs.Rpm, grafeasOSVersion)
+	addPkgInfos("OS", pkgs.COS, grafeasVersion)
+	addPkgInfos("OS", pkgs.GooGet, grafeasVersion)
+	for _, p := range pkgs.Pip {
+		var paths []string
+		if p.Location != "" {
+			paths = []string{p.Location}
+		}
+		add("PYPI", p.Name, p.Arch, p.Version, grafeasVersion, paths...)
+	}
+	addPkgInfos("RUBYGEMS", pkgs.Gem, grafeasVersion)
+	for _, p := range pkgs.Pkg {
+		add("OS", p.Name, "", p.Version, grafeasVersion)
+	}
+	for _, p := range pkgs.Npm {
+		add("NPM", p.Name, "", p.Version, grafeasVersion, p.Location...)
+	}
+	for _, p := range pkgs.GoModules {
+		add("GO", p.Path, "", p.Version, grafeasVersion, p.Binaries...)
+	}
+	for _, p := range pkgs.Cargo {
+		add("CARGO", p.Name, "", p.Version, grafeasVersion, p.Location...)
+	}
+	for _, p := range pkgs.Conda {
+		add("CONDA", p.Name, "", p.Version, grafeasVersion, p.Location...)
+	}
+
+	sort.SliceStable(occurrences, func(i, j int) bool {
+		a, b := occurrences[i].Package, occurrences[j].Package
+		if a.PackageType != b.PackageType {
+			return a.PackageType < b.PackageType
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Version.FullName < b.Version.FullName
+	})
+	return occurrences
+}
+
+// grafeasVersion returns version as a Grafeas version without an epoch or revision.
+func grafeasVersion(version string) GrafeasVersion {
+	if version == "" {
+		return GrafeasVersion{Kind: "MINIMUM"}
+	}
+	return GrafeasVersion{Name: version, FullName: version, Kind: "NORMAL"}
+}
+
+// grafeasOSVersion splits a deb or rpm version, [epoch:]version[-revision], into its
+// Grafeas components.
+func grafeasOSVersion(version string) GrafeasVersion {
+	v := grafeasVersion(version)
+	if version == "" {
+		return v
+	}
+	rest := version
+	if epoch, after, ok := strings.Cut(rest, ":"); ok {
+		if n, err := strconv.Atoi(epoch); err == nil {
+			v.Epoch, rest = n, after
+		}
+	}
+	if i := strings.LastIndex(rest, "-"); i > 0 {
+		v.Name, v.Revision = rest[:i], rest[i+1:]
+	} else {
+		v.Name = rest
+	}
+	return v
+}
@@ -18,11 +18,17 @@ package inventory
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/GoogleCloudPlatform/osconfig/agentconfig"
 	"github.com/GoogleCloudPlatform/osconfig/clog"
 	"github.com/GoogleCloudPlatform/osconfig/osinfo"
+	"github.com/GoogleCloudPlatform/osconfig/ospatch"
 	"github.com/GoogleCloudPlatform/osconfig/packages"
 )
 
@@ -35,19 +41,98 @@ type InstanceInventory struct {
 	Architecture         string
 	KernelVersion        string
 	KernelRelease        string
+	Variant              string
+	CPEName              string
+	BuildID              string
+	CPE                  string
 	OSConfigAgentVersion string
 	InstalledPackages    *packages.Packages
 	PackageUpdates       *packages.Packages
 	LastUpdated          string
+	// SourceTimestamps maps each data source to the time, in RFC3339 format, it was
+	// last queried successfully. Failed sources are left out.
+	SourceTimestamps map[string]string
+	// RebootRequired reports a pending system reboot, e.g. to finish installing updates,
+	// RebootRequiredReason describes the signal that requires it.
+	RebootRequired       bool
+	RebootRequiredReason string
+	// InstalledKernelRelease is the release of the newest installed kernel package,
+	// KernelMismatch reports that it differs from the running KernelRelease.
+	InstalledKernelRelease string
+	KernelMismatch         bool
+	// Environment is the container runtime the instance runs in, "host" outside of
+	// containers and empty when unknown. WSL is reported as "wsl1" or "wsl2", with the
+	// distribution name in WSLDistro.
+	Environment string
+	WSLDistro   string
+	// NetworkInterfaces are the non-loopback network interfaces of the instance, only
+	// collected when enabled with WithNetworkInterfaces.
+	NetworkInterfaces []NetworkInterface
 }
 
-type clock interface {
+// Data sources recorded in InstanceInventory.SourceTimestamps, optional package
+// collectors are recorded under their own name.
+const (
+	SourceInstalledPackages = "installed packages"
+	SourcePackageUpdates    = "package updates"
+	SourceOSInfo            = "osinfo"
+	SourceRebootRequired    = "reboot required"
+	SourceNetworkInterfaces = "network interfaces"
+)
+
+// InventoryError is the error of a data source that failed to collect, GetWithErrors joins
+// one for each failed source. Use errors.As to recover it.
+type InventoryError struct {
+	// Source is one of the Source constants or the name of an optional package collector.
+	Source string
+	Err    error
+}
+
+func (e *InventoryError) Error() string {
+	return fmt.Sprintf("%s provider: %v", e.Source, e.Err)
+}
+
+func (e *InventoryError) Unwrap() error {
+	return e.Err
+}
+
+// Fingerprint returns a hash of the collected inventory. LastUpdated and SourceTimestamps
+// are left out as they change with every collection, so inventories collected from an
+// unchanged instance have the same fingerprint. It is cheaper than formatting the
+// inventory, the packages are encoded straight into the hash.
+func (i *InstanceInventory) Fingerprint() (string, error) {
+	if i == nil {
+		return "", nil
+	}
+	stable := *i
+	stable.LastUpdated = ""
+	stable.SourceTimestamps = nil
+
+	h := sha256.New()
+	enc := json.NewEncoder(h)
+	if err := enc.Encode(&stable); err != nil {
+		return "", err
+	}
+	// Windows applications are left out of the JSON encoding of Packages.
+	for _, pkgs := range []*packages.Packages{i.InstalledPackages, i.PackageUpdates} {
+		if pkgs == nil {
+			continue
+		}
+		if err := enc.Encode(pkgs.WindowsApplication); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Clock provides the current time used for InstanceInventory.LastUpdated.
+type Clock interface {
 	Now() time.Time
 }
 
 type defaultClock struct{}
 
-func newDefaultClock() clock {
+func newDefaultClock() Clock {
 	return defaultClock{}
 }
 
@@ -58,6 +143,9 @@ func (dc defaultClock) Now() time.Time {
 // Provider extract all inventormation and returns InstanceInventory aggregate
 type Provider interface {
 	Get(context.Context) *InstanceInventory
+	// GetWithErrors works as Get, additionally returning the aggregated
+	// errors of all the providers that failed to collect their data.
+	GetWithErrors(context.Context) (*InstanceInventory, error)
 }
 
 type defaultInventoryProvider struct {
@@ -66,13 +154,239 @@ type defaultInventoryProvider struct {
 	packageUpdatesProvider    packages.PackageUpdatesProvider
 	installedPackagesProvider packages.InstalledPackagesProvider
 
-	clock clock
+	clock Clock
+
+	// agentVersion returns the OSConfigAgentVersion, agentconfig.Version is used when nil.
+	agentVersion func() string
+
+	// rebootRequired detects a pending system reboot, the detection is skipped when nil.
+	rebootRequired func(context.Context) (bool, string, error)
+
+	// optionalProviders are opt-in collectors whose results are merged into InstalledPackages.
+	optionalProviders []optionalProvider
+
+	// osInfoCacheTTL is how long NewProvider caches the OSInfo, it is not cached when zero.
+	osInfoCacheTTL time.Duration
+	// skipPackageUpdates leaves PackageUpdates empty without querying packageUpdatesProvider.
+	skipPackageUpdates bool
+	// packageFilesLimit is the number of files NewProvider reports for each deb and rpm
+	// package, files are not reported when zero.
+	packageFilesLimit int
+	// networkInterfaces lists the network interfaces, they are not collected when nil.
+	networkInterfaces func(context.Context) ([]NetworkInterface, error)
+}
+
+type optionalProvider struct {
+	name     string
+	provider packages.InstalledPackagesProvider
+}
+
+// Option configures the provider returned by NewProvider.
+type Option func(*defaultInventoryProvider)
+
+// WithAgentVersion overrides the source of InstanceInventory.OSConfigAgentVersion,
+// by default the version set in agentconfig is used.
+func WithAgentVersion(version func() string) Option {
+	return func(p *defaultInventoryProvider) {
+		p.agentVersion = version
+	}
+}
+
+// WithClock overrides the clock used to set InstanceInventory.LastUpdated,
+// by default the wall clock is used.
+func WithClock(clock Clock) Option {
+	return func(p *defaultInventoryProvider) {
+		p.clock = clock
+	}
+}
+
+// WithOSInfoCache caches the OSInfo for ttl across collections, it is refreshed earlier
+// when the running kernel changes. Packages are still collected on every call.
+func WithOSInfoCache(ttl time.Duration) Option {
+	return func(p *defaultInventoryProvider) {
+		p.osInfoCacheTTL = ttl
+	}
+}
+
+// WithoutPackageUpdates skips collecting the available package updates, e.g. on air-gapped
+// hosts where querying the repositories fails, PackageUpdates is reported empty.
+func WithoutPackageUpdates() Option {
+	return func(p *defaultInventoryProvider) {
+		p.skipPackageUpdates = true
+	}
+}
+
+// WithPackageFiles enables reporting of the first maxFiles files owned by each installed
+// deb and rpm package, up to packages.MaxPackageFiles. It runs the package manager once
+// for every package, so it is meant for forensic collections rather than routine ones.
+func WithPackageFiles(maxFiles int) Option {
+	return func(p *defaultInventoryProvider) {
+		p.packageFilesLimit = maxFiles
+	}
+}
+
+// WithNetworkInterfaces enables reporting of the network interfaces of the instance with
+// their MAC and IP addresses in InstanceInventory.NetworkInterfaces.
+func WithNetworkInterfaces() Option {
+	return func(p *defaultInventoryProvider) {
+		p.networkInterfaces = networkInterfaces
+	}
+}
+
+// WithKernelModules enables reporting of the loaded kernel modules.
+func WithKernelModules() Option {
+	return func(p *defaultInventoryProvider) {
+		p.optionalProviders = append(p.optionalProviders, optionalProvider{name: "kernel modules", provider: packages.NewKernelModulesProvider()})
+	}
+}
+
+// WithGoBinaries enables reporting of the modules embedded in the Go binaries
+// found under roots, up to maxDepth directories deep.
+func WithGoBinaries(roots []string, maxDepth int) Option {
+	return func(p *defaultInventoryProvider) {
+		p.optionalProviders = append(p.optionalProviders, optionalProvider{name: "go binaries", provider: packages.NewGoBinariesProvider(roots, maxDepth)})
+	}
+}
+
+// WithNpm enables reporting of the npm packages installed in the node_modules
+// directories found under roots, up to maxDepth directories deep.
+func WithNpm(roots []string, maxDepth int) Option {
+	return func(p *defaultInventoryProvider) {
+		p.optionalProviders = append(p.optionalProviders, optionalProvider{name: "npm", provider: packages.NewNpmProvider(roots, maxDepth)})
+	}
+}
+
+// WithCargo enables reporting of the Rust crates installed in the cargo install roots
+// and locked in the Cargo.lock files found under roots, up to maxDepth directories deep.
+func WithCargo(roots []string, maxDepth int) Option {
+	return func(p *defaultInventoryProvider) {
+		p.optionalProviders = append(p.optionalProviders, optionalProvider{name: "cargo", provider: packages.NewCargoProvider(roots, maxDepth)})
+	}
+}
+
+// WithIDEExtensions enables reporting of the VS Code extensions and JetBrains plugins
+// installed in dirs, which may be glob patterns, e.g. "/home/*/.vscode/extensions".
+func WithIDEExtensions(dirs []string) Option {
+	return func(p *defaultInventoryProvider) {
+		p.optionalProviders = append(p.optionalProviders, optionalProvider{name: "ide extensions", provider: packages.NewIDEExtensionsProvider(dirs)})
+	}
+}
+
+// WithGooGetRepositories enables reporting of the repositories configured in the
+// GooGet repo files, no repositories are reported when GooGet is not installed.
+func WithGooGetRepositories() Option {
+	return func(p *defaultInventoryProvider) {
+		p.optionalProviders = append(p.optionalProviders, optionalProvider{name: "googet repositories", provider: packages.NewGooGetRepositoriesProvider()})
+	}
+}
+
+// WithRepositories enables reporting of the repositories configured for apt, yum and
+// zypper.
+func WithRepositories() Option {
+	return func(p *defaultInventoryProvider) {
+		p.optionalProviders = append(p.optionalProviders, optionalProvider{name: "repositories", provider: packages.NewRepositoriesProvider()})
+	}
+}
+
+// WithContainerImages enables reporting of the images stored by the local container
+// runtimes, it requires access to the runtime sockets.
+func WithContainerImages() Option {
+	return func(p *defaultInventoryProvider) {
+		p.optionalProviders = append(p.optionalProviders, optionalProvider{name: "container images", provider: packages.NewContainerImagesProvider()})
+	}
+}
+
+// WithNix enables reporting of the derivations installed in the given nix profiles,
+// nothing is reported when nix is not installed.
+func WithNix(profiles []string) Option {
+	return func(p *defaultInventoryProvider) {
+		p.optionalProviders = append(p.optionalProviders, optionalProvider{name: "nix", provider: packages.NewNixProvider(profiles)})
+	}
+}
+
+// WithConda enables reporting of the packages installed in the conda environments
+// rooted at prefixes.
+func WithConda(prefixes []string) Option {
+	return func(p *defaultInventoryProvider) {
+		p.optionalProviders = append(p.optionalProviders, optionalProvider{name: "conda", provider: packages.NewCondaProvider(prefixes)})
+	}
+}
+
+// WithSystemdUnits enables reporting of the enabled, running and masked systemd services.
+func WithSystemdUnits() Option {
+	return func(p *defaultInventoryProvider) {
+		p.optionalProviders = append(p.optionalProviders, optionalProvider{name: "systemd units", provider: packages.NewSystemdProvider()})
+	}
+}
+
+// WithWindowsServices enables reporting of the Windows services, it has no effect
+// on other operating systems.
+func WithWindowsServices() Option {
+	return func(p *defaultInventoryProvider) {
+		p.optionalProviders = append(p.optionalProviders, optionalProvider{name: "windows services", provider: packages.NewWindowsServicesProvider()})
+	}
+}
+
+// WithFirmware enables reporting of the system firmware (BIOS or UEFI) vendor, version
+// and release date. Reading it may require elevated access on some systems.
+func WithFirmware() Option {
+	return func(p *defaultInventoryProvider) {
+		p.optionalProviders = append(p.optionalProviders, optionalProvider{name: "firmware", provider: packages.NewFirmwareProvider()})
+	}
+}
+
+// WithListeningPorts enables reporting of the listening TCP and UDP ports with their
+// owning processes, owners are left out when the agent is not allowed to inspect them.
+func WithListeningPorts() Option {
+	return func(p *defaultInventoryProvider) {
+		p.optionalProviders = append(p.optionalProviders, optionalProvider{name: "listening ports", provider: packages.NewListeningPortsProvider()})
+	}
+}
+
+// WithLoadedLibraries enables reporting of the shared libraries mapped by the running
+// processes named in processes, e.g. "sshd". Nothing is reported without process names.
+// It requires permission to read the memory maps of the processes.
+func WithLoadedLibraries(processes []string) Option {
+	return func(p *defaultInventoryProvider) {
+		p.optionalProviders = append(p.optionalProviders, optionalProvider{name: "loaded libraries", provider: packages.NewLoadedLibrariesProvider(processes)})
+	}
+}
+
+// WithEnvironmentModules enables reporting of the Lmod and Environment Modules modulefiles
+// found in modulePaths. Without module paths, the MODULEPATH of the agent or the default
+// locations, e.g. /etc/modulefiles, are searched.
+func WithEnvironmentModules(modulePaths []string) Option {
+	return func(p *defaultInventoryProvider) {
+		p.optionalProviders = append(p.optionalProviders, optionalProvider{name: "environment modules", provider: packages.NewEnvironmentModulesProvider(modulePaths)})
+	}
+}
+
+// WithCertificates enables reporting of the certificates of the system trust store.
+func WithCertificates() Option {
+	return func(p *defaultInventoryProvider) {
+		p.optionalProviders = append(p.optionalProviders, optionalProvider{name: "certificates", provider: packages.NewCertificatesProvider()})
+	}
 }
 
 // NewProvider returns ready to work default provider
-func NewProvider() Provider {
+func NewProvider(opts ...Option) Provider {
+	p := &defaultInventoryProvider{
+		clock:          newDefaultClock(),
+		agentVersion:   agentconfig.Version,
+		rebootRequired: ospatch.SystemRebootRequiredReason,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
 	osInfoProvider := osinfo.NewProvider()
+	if p.osInfoCacheTTL > 0 {
+		osInfoProvider = osinfo.NewCachingProvider(osInfoProvider, p.osInfoCacheTTL)
+	}
 	installedPackagesProvider := packages.NewInstalledPackagesProvider(osInfoProvider)
+	if p.packageFilesLimit > 0 {
+		installedPackagesProvider = packages.PackageFilesProvider(installedPackagesProvider, p.packageFilesLimit)
+	}
 	if agentconfig.TraceGetInventory() {
 		installedPackagesProvider = packages.TracingInstalledPackagesProvider(
 			installedPackagesProvider,
@@ -80,44 +394,139 @@ func NewProvider() Provider {
 		)
 	}
 
-	return &defaultInventoryProvider{
-		osInfoProvider:            osInfoProvider,
-		packageUpdatesProvider:    packages.NewPackageUpdatesProvider(osInfoProvider),
-		installedPackagesProvider: installedPackagesProvider,
-		clock:                     newDefaultClock(),
-	}
+	p.osInfoProvider = osInfoProvider
+	p.packageUpdatesProvider = packages.NewPackageUpdatesProvider(osInfoProvider)
+	p.installedPackagesProvider = installedPackagesProvider
+	return p
 }
 
 // Get extracts all required data from the VM and returns it as InstanceInventory aggregate
 func (p *defaultInventoryProvider) Get(ctx context.Context) *InstanceInventory {
+	inv, _ := p.GetWithErrors(ctx)
+	return inv
+}
+
+// GetWithErrors extracts all required data from the VM and returns it as InstanceInventory aggregate,
+// data of the failed providers is left empty and their errors, each an *InventoryError, are joined
+// in the returned error.
+func (p *defaultInventoryProvider) GetWithErrors(ctx context.Context) (*InstanceInventory, error) {
 	clog.Debugf(ctx, "Gathering instance inventory.")
 
+	var errs []error
+	timestamps := map[string]string{}
+	markQueried := func(source string) {
+		timestamps[source] = p.clock.Now().UTC().Format(time.RFC3339)
+	}
+
 	installedPackages, err := p.installedPackagesProvider.GetInstalledPackages(ctx)
 	if err != nil {
 		clog.Errorf(ctx, "packages.GetInstalledPackages() error: %v", err)
+		errs = append(errs, &InventoryError{Source: SourceInstalledPackages, Err: err})
+	} else {
+		markQueried(SourceInstalledPackages)
 	}
 
-	packageUpdates, err := p.packageUpdatesProvider.GetPackageUpdates(ctx)
-	if err != nil {
-		clog.Errorf(ctx, "packages.GetPackageUpdates() error: %v", err)
+	for _, op := range p.optionalProviders {
+		pkgs, err := op.provider.GetInstalledPackages(ctx)
+		if err != nil {
+			clog.Errorf(ctx, "Error collecting %s: %v", op.name, err)
+			errs = append(errs, &InventoryError{Source: op.name, Err: err})
+			continue
+		}
+		installedPackages.Merge(pkgs)
+		markQueried(op.name)
+	}
+
+	var packageUpdates packages.Packages
+	if !p.skipPackageUpdates {
+		packageUpdates, err = p.packageUpdatesProvider.GetPackageUpdates(ctx)
+		if err != nil {
+			clog.Errorf(ctx, "packages.GetPackageUpdates() error: %v", err)
+			errs = append(errs, &InventoryError{Source: SourcePackageUpdates, Err: err})
+		} else {
+			markQueried(SourcePackageUpdates)
+		}
 	}
 
 	oi, err := p.osInfoProvider.GetOSInfo(ctx)
 	if err != nil {
 		clog.Errorf(ctx, "osinfo.Get() error: %v", err)
+		errs = append(errs, &InventoryError{Source: SourceOSInfo, Err: err})
+	} else if cp, ok := p.osInfoProvider.(*osinfo.CachingProvider); ok {
+		// A cached OSInfo keeps the time it was actually fetched.
+		timestamps[SourceOSInfo] = cp.FetchedAt().UTC().Format(time.RFC3339)
+	} else {
+		markQueried(SourceOSInfo)
 	}
 
+	rebootRequired, rebootRequiredReason := p.getRebootRequired(ctx, &errs, markQueried)
+	networkInterfaces := p.getNetworkInterfaces(ctx, &errs, markQueried)
+	installedKernel := newestInstalledKernel(oi.ShortName, &installedPackages)
+
 	return &InstanceInventory{
-		Hostname:             oi.Hostname,
-		LongName:             oi.LongName,
-		ShortName:            oi.ShortName,
-		Version:              oi.Version,
-		KernelVersion:        oi.KernelVersion,
-		KernelRelease:        oi.KernelRelease,
-		Architecture:         oi.Architecture,
-		OSConfigAgentVersion: agentconfig.Version(),
-		InstalledPackages:    &installedPackages,
-		PackageUpdates:       &packageUpdates,
-		LastUpdated:          p.clock.Now().UTC().Format(time.RFC3339),
+		Hostname:               oi.Hostname,
+		LongName:               oi.LongName,
+		ShortName:              oi.ShortName,
+		Version:                oi.Version,
+		KernelVersion:          oi.KernelVersion,
+		KernelRelease:          oi.KernelRelease,
+		Architecture:           oi.Architecture,
+		Variant:                oi.Variant,
+		CPEName:                oi.CPEName,
+		BuildID:                oi.BuildID,
+		CPE:                    osinfo.CPE(oi),
+		OSConfigAgentVersion:   p.getAgentVersion(),
+		RebootRequired:         rebootRequired,
+		RebootRequiredReason:   rebootRequiredReason,
+		InstalledKernelRelease: installedKernel,
+		KernelMismatch:         installedKernel != "" && oi.KernelRelease != "" && installedKernel != oi.KernelRelease,
+		Environment:            oi.Environment,
+		WSLDistro:              oi.WSLDistro,
+		NetworkInterfaces:      networkInterfaces,
+		InstalledPackages:      &installedPackages,
+		PackageUpdates:         &packageUpdates,
+		LastUpdated:            p.clock.Now().UTC().Format(time.RFC3339),
+		SourceTimestamps:       timestamps,
+	}, errors.Join(errs...)
+}
+
+func (p *defaultInventoryProvider) getAgentVersion() string {
+	if p.agentVersion == nil {
+		return agentconfig.Version()
+	}
+	return p.agentVersion()
+}
+
+func (p *defaultInventoryProvider) getNetworkInterfaces(ctx context.Context, errs *[]error, markQueried func(string)) []NetworkInterface {
+	if p.networkInterfaces == nil {
+		return nil
+	}
+	ifaces, err := p.networkInterfaces(ctx)
+	if err != nil {
+		clog.Errorf(ctx, "Error collecting network interfaces: %v", err)
+		*errs = append(*errs, &InventoryError{Source: SourceNetworkInterfaces, Err: err})
+		return nil
+	}
+	markQueried(SourceNetworkInterfaces)
+	return ifaces
+}
+
+// getRebootRequired detects a pending reboot, hosts where no supported signal exists
+// are reported as not requiring a reboot without failing the inventory.
+func (p *defaultInventoryProvider) getRebootRequired(ctx context.Context, errs *[]error, markQueried func(string)) (bool, string) {
+	if p.rebootRequired == nil {
+		return false, ""
+	}
+	required, reason, err := p.rebootRequired(ctx)
+	if errors.Is(err, ospatch.ErrRebootRequiredUnknown) {
+		clog.Debugf(ctx, "Skipping reboot detection: %v", err)
+		return false, ""
+	}
+	if err != nil {
+		clog.Errorf(ctx, "ospatch.SystemRebootRequiredReason() error: %v", err)
+		*errs = append(*errs, &InventoryError{Source: SourceRebootRequired, Err: err})
+		return false, ""
 	}
+	markQueried(SourceRebootRequired)
+	return required, reason
 }
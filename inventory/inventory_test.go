@@ -2,12 +2,18 @@ package inventory
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/GoogleCloudPlatform/osconfig/osinfo"
+	"github.com/GoogleCloudPlatform/osconfig/ospatch"
 	"github.com/GoogleCloudPlatform/osconfig/packages"
+	"github.com/GoogleCloudPlatform/osconfig/util/utiltest"
 	"github.com/google/go-cmp/cmp"
 )
 
@@ -20,6 +26,7 @@ func TestProvider(t *testing.T) {
 		KernelVersion: "#1 SMP PREEMPT_DYNAMIC Debian 6.1.123-1 (2025-01-02)",
 		KernelRelease: "6.1.0-29-cloud-amd64",
 		Architecture:  "x86_64",
+		Environment:   "docker",
 	}
 
 	updates := packages.Packages{
@@ -52,6 +59,7 @@ func TestProvider(t *testing.T) {
 				InstalledPackages: &packages.Packages{},
 				PackageUpdates:    &packages.Packages{},
 				LastUpdated:       "1970-01-01T10:00:00Z",
+				SourceTimestamps:  map[string]string{},
 			},
 		},
 		{
@@ -74,6 +82,8 @@ func TestProvider(t *testing.T) {
 				Architecture:         "x86_64",
 				KernelVersion:        "#1 SMP PREEMPT_DYNAMIC Debian 6.1.123-1 (2025-01-02)",
 				KernelRelease:        "6.1.0-29-cloud-amd64",
+				CPE:                  "cpe:2.3:o:testshort:testshort:testversion:*:*:*:*:*:*:*",
+				Environment:          "docker",
 				OSConfigAgentVersion: "",
 				InstalledPackages: &packages.Packages{
 					Yum:    []*packages.PkgInfo{{Name: "YumInstalledPkg", Arch: "Arch", Version: "Version", Type: "rpm", Purl: "pkg:rpm/Namespace/YumInstalledPkg@Version?arch=Arch"}},
@@ -84,6 +94,11 @@ func TestProvider(t *testing.T) {
 					Apt: []*packages.PkgInfo{{Name: "AptPkgUpdate", Arch: "Arch", Version: "Version", Type: "deb", Purl: "pkg:deb/Namespace/AptPkgUpdate@Version?arch=Arch"}},
 				},
 				LastUpdated: "1970-01-01T10:00:00Z",
+				SourceTimestamps: map[string]string{
+					SourceInstalledPackages: "1970-01-01T10:00:00Z",
+					SourcePackageUpdates:    "1970-01-01T10:00:00Z",
+					SourceOSInfo:            "1970-01-01T10:00:00Z",
+				},
 			},
 		},
 		{
@@ -106,6 +121,8 @@ func TestProvider(t *testing.T) {
 				Architecture:         "x86_64",
 				KernelVersion:        "#1 SMP PREEMPT_DYNAMIC Debian 6.1.123-1 (2025-01-02)",
 				KernelRelease:        "6.1.0-29-cloud-amd64",
+				CPE:                  "cpe:2.3:o:testshort:testshort:testversion:*:*:*:*:*:*:*",
+				Environment:          "docker",
 				OSConfigAgentVersion: "",
 				PackageUpdates:       &packages.Packages{},
 				InstalledPackages: &packages.Packages{
@@ -113,6 +130,10 @@ func TestProvider(t *testing.T) {
 					GooGet: []*packages.PkgInfo{{Name: "GooGetInstalledPkg", Arch: "Arch", Version: "Version", Type: "googet", Purl: "pkg:googet/Namespace/GooGetInstalledPkg@Version?arch=Arch"}},
 				},
 				LastUpdated: "1970-01-01T10:00:00Z",
+				SourceTimestamps: map[string]string{
+					SourceInstalledPackages: "1970-01-01T10:00:00Z",
+					SourceOSInfo:            "1970-01-01T10:00:00Z",
+				},
 			},
 		},
 	}
@@ -146,12 +167,430 @@ func TestNewProvider(t *testing.T) {
 	}
 }
 
+func TestProviderAgentVersion(t *testing.T) {
+	stub := &stubProvider{
+		osinfo:            func(_ context.Context) (osinfo.OSInfo, error) { return osinfo.OSInfo{}, nil },
+		packageUpdates:    func(_ context.Context) (packages.Packages, error) { return packages.Packages{}, nil },
+		installedPackages: func(_ context.Context) (packages.Packages, error) { return packages.Packages{}, nil },
+	}
+	provider := &defaultInventoryProvider{
+		osInfoProvider:            stub,
+		packageUpdatesProvider:    stub,
+		installedPackagesProvider: stub,
+		clock:                     stubClock{},
+	}
+	WithAgentVersion(func() string { return "20250101.00" })(provider)
+
+	got := provider.Get(context.Background())
+
+	if got.OSConfigAgentVersion != "20250101.00" {
+		t.Errorf("unexpected OSConfigAgentVersion, got: %q, want: %q", got.OSConfigAgentVersion, "20250101.00")
+	}
+}
+
+func TestNewProviderWithClock(t *testing.T) {
+	fixed := time.Date(2025, time.March, 4, 5, 6, 7, 0, time.FixedZone("CET", 3600))
+	provider, ok := NewProvider(WithClock(fixedClock{now: fixed})).(*defaultInventoryProvider)
+	if !ok {
+		t.Fatalf("NewProvider() returned unexpected provider type")
+	}
+
+	stub := &stubProvider{
+		osinfo:            func(_ context.Context) (osinfo.OSInfo, error) { return osinfo.OSInfo{}, nil },
+		packageUpdates:    func(_ context.Context) (packages.Packages, error) { return packages.Packages{}, nil },
+		installedPackages: func(_ context.Context) (packages.Packages, error) { return packages.Packages{}, nil },
+	}
+	provider.osInfoProvider = stub
+	provider.packageUpdatesProvider = stub
+	provider.installedPackagesProvider = stub
+
+	got := provider.Get(context.Background())
+
+	if got.LastUpdated != "2025-03-04T04:06:07Z" {
+		t.Errorf("unexpected LastUpdated, got: %q, want: %q", got.LastUpdated, "2025-03-04T04:06:07Z")
+	}
+}
+
+func TestProviderWithoutPackageUpdates(t *testing.T) {
+	stub := &stubProvider{
+		osinfo: func(_ context.Context) (osinfo.OSInfo, error) { return osinfo.OSInfo{Hostname: "testhost"}, nil },
+		packageUpdates: func(_ context.Context) (packages.Packages, error) {
+			t.Errorf("GetPackageUpdates() called with package updates disabled")
+			return packages.Packages{}, fmt.Errorf("repositories unreachable")
+		},
+		installedPackages: func(_ context.Context) (packages.Packages, error) {
+			return packages.Packages{Deb: []*packages.PkgInfo{{Name: "bash", Version: "5.2"}}}, nil
+		},
+	}
+	provider := &defaultInventoryProvider{
+		osInfoProvider:            stub,
+		packageUpdatesProvider:    stub,
+		installedPackagesProvider: stub,
+		clock:                     stubClock{},
+		agentVersion:              func() string { return "" },
+	}
+	WithoutPackageUpdates()(provider)
+
+	first, err := provider.GetWithErrors(context.Background())
+	if err != nil {
+		t.Fatalf("GetWithErrors() unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(&packages.Packages{}, first.PackageUpdates); diff != "" {
+		t.Errorf("PackageUpdates is expected to be empty (-want +got):\n%s", diff)
+	}
+	if _, ok := first.SourceTimestamps[SourcePackageUpdates]; ok {
+		t.Errorf("skipped package updates were recorded as queried: %v", first.SourceTimestamps)
+	}
+
+	// The fingerprint of the empty updates is stable across collections.
+	second := provider.Get(context.Background())
+	want, err := first.Fingerprint()
+	if err != nil {
+		t.Fatalf("Fingerprint() unexpected error: %v", err)
+	}
+	got, err := second.Fingerprint()
+	if err != nil {
+		t.Fatalf("Fingerprint() unexpected error: %v", err)
+	}
+	utiltest.AssertEquals(t, got, want)
+}
+
+func TestProviderGetWithErrors(t *testing.T) {
+	errOSInfo := fmt.Errorf("osinfo error")
+	errUpdates := fmt.Errorf("updates error")
+
+	tests := []struct {
+		name        string
+		stub        *stubProvider
+		wantErrs    []error
+		wantMessage []string
+	}{
+		{
+			name: "all providers succeeded, no error",
+			stub: &stubProvider{
+				osinfo:            func(_ context.Context) (osinfo.OSInfo, error) { return osinfo.OSInfo{Hostname: "testhost"}, nil },
+				packageUpdates:    func(_ context.Context) (packages.Packages, error) { return packages.Packages{}, nil },
+				installedPackages: func(_ context.Context) (packages.Packages, error) { return packages.Packages{}, nil },
+			},
+		},
+		{
+			name: "some providers failed, error names failing providers",
+			stub: &stubProvider{
+				osinfo:            func(_ context.Context) (osinfo.OSInfo, error) { return osinfo.OSInfo{}, errOSInfo },
+				packageUpdates:    func(_ context.Context) (packages.Packages, error) { return packages.Packages{}, errUpdates },
+				installedPackages: func(_ context.Context) (packages.Packages, error) { return packages.Packages{}, nil },
+			},
+			wantErrs:    []error{errOSInfo, errUpdates},
+			wantMessage: []string{"osinfo provider: osinfo error", "package updates provider: updates error"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := defaultInventoryProvider{
+				osInfoProvider:            tt.stub,
+				packageUpdatesProvider:    tt.stub,
+				installedPackagesProvider: tt.stub,
+				clock:                     stubClock{},
+			}
+
+			got, err := provider.GetWithErrors(context.Background())
+			if got == nil {
+				t.Fatalf("GetWithErrors() returned nil inventory")
+			}
+			if len(tt.wantErrs) == 0 {
+				if err != nil {
+					t.Errorf("GetWithErrors() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("GetWithErrors() expected error, got nil")
+			}
+			for _, want := range tt.wantErrs {
+				if !errors.Is(err, want) {
+					t.Errorf("GetWithErrors() error %v does not wrap %v", err, want)
+				}
+			}
+			for _, want := range tt.wantMessage {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("GetWithErrors() error %q does not contain %q", err, want)
+				}
+			}
+			if strings.Contains(err.Error(), "installed packages provider") {
+				t.Errorf("GetWithErrors() error %q names a provider that succeeded", err)
+			}
+		})
+	}
+}
+
+func TestProviderGetWithErrorsSource(t *testing.T) {
+	errUpdates := fmt.Errorf("updates error")
+	ok := func(_ context.Context) (packages.Packages, error) { return packages.Packages{}, nil }
+	tests := []struct {
+		name       string
+		stub       *stubProvider
+		optional   []optionalProvider
+		wantSource string
+	}{
+		{
+			name: "osinfo",
+			stub: &stubProvider{
+				osinfo:            func(_ context.Context) (osinfo.OSInfo, error) { return osinfo.OSInfo{}, errors.New("osinfo error") },
+				packageUpdates:    ok,
+				installedPackages: ok,
+			},
+			wantSource: SourceOSInfo,
+		},
+		{
+			name: "installed packages",
+			stub: &stubProvider{
+				osinfo:         func(_ context.Context) (osinfo.OSInfo, error) { return osinfo.OSInfo{}, nil },
+				packageUpdates: ok,
+				installedPackages: func(_ context.Context) (packages.Packages, error) {
+					return packages.Packages{}, errors.New("installed error")
+				},
+			},
+			wantSource: SourceInstalledPackages,
+		},
+		{
+			name: "package updates",
+			stub: &stubProvider{
+				osinfo:            func(_ context.Context) (osinfo.OSInfo, error) { return osinfo.OSInfo{}, nil },
+				packageUpdates:    func(_ context.Context) (packages.Packages, error) { return packages.Packages{}, errUpdates },
+				installedPackages: ok,
+			},
+			wantSource: SourcePackageUpdates,
+		},
+		{
+			name: "optional provider",
+			stub: &stubProvider{
+				osinfo:            func(_ context.Context) (osinfo.OSInfo, error) { return osinfo.OSInfo{}, nil },
+				packageUpdates:    ok,
+				installedPackages: ok,
+			},
+			optional: []optionalProvider{{name: "npm", provider: &stubProvider{
+				installedPackages: func(_ context.Context) (packages.Packages, error) {
+					return packages.Packages{}, errors.New("npm error")
+				},
+			}}},
+			wantSource: "npm",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := defaultInventoryProvider{
+				osInfoProvider:            tt.stub,
+				packageUpdatesProvider:    tt.stub,
+				installedPackagesProvider: tt.stub,
+				optionalProviders:         tt.optional,
+				clock:                     stubClock{},
+			}
+
+			_, err := provider.GetWithErrors(context.Background())
+			var invErr *InventoryError
+			if !errors.As(err, &invErr) {
+				t.Fatalf("GetWithErrors() error %v is not an *InventoryError", err)
+			}
+			utiltest.AssertEquals(t, invErr.Source, tt.wantSource)
+			if tt.wantSource == SourcePackageUpdates && !errors.Is(invErr, errUpdates) {
+				t.Errorf("InventoryError %v does not wrap %v", invErr, errUpdates)
+			}
+		})
+	}
+}
+
+func TestProviderOptionalProviders(t *testing.T) {
+	stub := &stubProvider{
+		osinfo:         func(_ context.Context) (osinfo.OSInfo, error) { return osinfo.OSInfo{}, nil },
+		packageUpdates: func(_ context.Context) (packages.Packages, error) { return packages.Packages{}, nil },
+		installedPackages: func(_ context.Context) (packages.Packages, error) {
+			return packages.Packages{Deb: []*packages.PkgInfo{{Name: "DebPkg", Type: "deb"}}}, nil
+		},
+	}
+	modules := &stubProvider{
+		installedPackages: func(_ context.Context) (packages.Packages, error) {
+			return packages.Packages{KernelModules: []*packages.PkgInfo{{Name: "e1000", Version: "7.3.21-k8-NAPI", Type: "kernel-module"}}}, nil
+		},
+	}
+	failing := &stubProvider{
+		installedPackages: func(_ context.Context) (packages.Packages, error) {
+			return packages.Packages{}, fmt.Errorf("unexpected error")
+		},
+	}
+
+	provider := defaultInventoryProvider{
+		osInfoProvider:            stub,
+		packageUpdatesProvider:    stub,
+		installedPackagesProvider: stub,
+		clock:                     stubClock{},
+		optionalProviders: []optionalProvider{
+			{name: "kernel modules", provider: modules},
+			{name: "failing", provider: failing},
+		},
+	}
+
+	got, err := provider.GetWithErrors(context.Background())
+
+	want := &packages.Packages{
+		Deb:           []*packages.PkgInfo{{Name: "DebPkg", Type: "deb"}},
+		KernelModules: []*packages.PkgInfo{{Name: "e1000", Version: "7.3.21-k8-NAPI", Type: "kernel-module"}},
+	}
+	if diff := cmp.Diff(want, got.InstalledPackages); diff != "" {
+		t.Errorf("unexpected InstalledPackages diff:\n%s", diff)
+	}
+	if err == nil || !strings.Contains(err.Error(), "failing provider") {
+		t.Errorf("GetWithErrors() error %v does not name the failing optional provider", err)
+	}
+	if _, ok := got.SourceTimestamps["kernel modules"]; !ok {
+		t.Errorf("GetWithErrors() did not record the timestamp of a succeeded optional provider, got: %v", got.SourceTimestamps)
+	}
+	if _, ok := got.SourceTimestamps["failing"]; ok {
+		t.Errorf("GetWithErrors() recorded the timestamp of a failed optional provider, got: %v", got.SourceTimestamps)
+	}
+}
+
+func TestProviderRebootRequired(t *testing.T) {
+	errRegistry := errors.New("access denied")
+	tests := []struct {
+		name           string
+		rebootRequired func(context.Context) (bool, string, error)
+		wantRequired   bool
+		wantReason     string
+		wantErr        error
+		wantQueried    bool
+	}{
+		{
+			name: "reboot required",
+			rebootRequired: func(context.Context) (bool, string, error) {
+				return true, "/var/run/reboot-required exists", nil
+			},
+			wantRequired: true,
+			wantReason:   "/var/run/reboot-required exists",
+			wantQueried:  true,
+		},
+		{
+			name:           "no reboot required",
+			rebootRequired: func(context.Context) (bool, string, error) { return false, "", nil },
+			wantQueried:    true,
+		},
+		{
+			name: "detection unsupported",
+			rebootRequired: func(context.Context) (bool, string, error) {
+				return false, "", ospatch.ErrRebootRequiredUnknown
+			},
+		},
+		{
+			name:           "detection failed",
+			rebootRequired: func(context.Context) (bool, string, error) { return false, "", errRegistry },
+			wantErr:        errRegistry,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &stubProvider{
+				osinfo:            func(_ context.Context) (osinfo.OSInfo, error) { return osinfo.OSInfo{}, nil },
+				packageUpdates:    func(_ context.Context) (packages.Packages, error) { return packages.Packages{}, nil },
+				installedPackages: func(_ context.Context) (packages.Packages, error) { return packages.Packages{}, nil },
+			}
+			provider := defaultInventoryProvider{
+				osInfoProvider:            stub,
+				packageUpdatesProvider:    stub,
+				installedPackagesProvider: stub,
+				clock:                     stubClock{},
+				rebootRequired:            tt.rebootRequired,
+			}
+
+			got, err := provider.GetWithErrors(context.Background())
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetWithErrors() error = %v, want: %v", err, tt.wantErr)
+			}
+			utiltest.AssertEquals(t, got.RebootRequired, tt.wantRequired)
+			utiltest.AssertEquals(t, got.RebootRequiredReason, tt.wantReason)
+			if _, ok := got.SourceTimestamps[SourceRebootRequired]; ok != tt.wantQueried {
+				t.Errorf("GetWithErrors() recorded reboot required timestamp: %t, want: %t", ok, tt.wantQueried)
+			}
+		})
+	}
+}
+
+func TestWithKernelModules(t *testing.T) {
+	provider := &defaultInventoryProvider{}
+	WithKernelModules()(provider)
+
+	if len(provider.optionalProviders) != 1 || provider.optionalProviders[0].name != "kernel modules" {
+		t.Errorf("WithKernelModules() did not register the kernel modules provider, got: %v", provider.optionalProviders)
+	}
+	if len(NewProvider().(*defaultInventoryProvider).optionalProviders) != 0 {
+		t.Errorf("kernel modules are expected to be disabled by default")
+	}
+}
+
+func TestWithOSInfoCache(t *testing.T) {
+	provider := NewProvider(WithOSInfoCache(time.Hour)).(*defaultInventoryProvider)
+	if _, ok := provider.osInfoProvider.(*osinfo.CachingProvider); !ok {
+		t.Errorf("WithOSInfoCache() did not wrap the osinfo provider, got: %T", provider.osInfoProvider)
+	}
+	if _, ok := NewProvider().(*defaultInventoryProvider).osInfoProvider.(*osinfo.CachingProvider); ok {
+		t.Errorf("osinfo is expected not to be cached by default")
+	}
+}
+
+func TestProviderCachedOSInfoTimestamp(t *testing.T) {
+	var calls int
+	stub := &stubProvider{
+		osinfo: func(_ context.Context) (osinfo.OSInfo, error) {
+			calls++
+			return osinfo.OSInfo{Hostname: "testhost"}, nil
+		},
+		packageUpdates:    func(_ context.Context) (packages.Packages, error) { return packages.Packages{}, nil },
+		installedPackages: func(_ context.Context) (packages.Packages, error) { return packages.Packages{}, nil },
+	}
+	cache := osinfo.NewCachingProvider(stub, time.Hour)
+	provider := &defaultInventoryProvider{
+		osInfoProvider:            cache,
+		packageUpdatesProvider:    stub,
+		installedPackagesProvider: stub,
+		clock:                     stubClock{},
+		agentVersion:              func() string { return "" },
+	}
+
+	first := provider.Get(context.Background())
+	fetched := cache.FetchedAt().UTC().Format(time.RFC3339)
+	if got := first.SourceTimestamps[SourceOSInfo]; got != fetched {
+		t.Errorf("unexpected osinfo timestamp, got: %q, want the fetch time: %q", got, fetched)
+	}
+
+	provider.clock = fixedClock{now: time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)}
+	second := provider.Get(context.Background())
+	if calls != 1 {
+		t.Fatalf("the OSInfo is expected to be cached, got %d calls, want: 1", calls)
+	}
+	if got := second.SourceTimestamps[SourceOSInfo]; got != fetched {
+		t.Errorf("a cache hit refreshed the osinfo timestamp, got: %q, want: %q", got, fetched)
+	}
+	if got := second.SourceTimestamps[SourceInstalledPackages]; got != "2030-01-01T00:00:00Z" {
+		t.Errorf("unexpected installed packages timestamp, got: %q, want: %q", got, "2030-01-01T00:00:00Z")
+	}
+}
+
 type stubClock struct{}
 
 func (sc stubClock) Now() time.Time {
 	return time.UnixMicro(0).Add(10 * time.Hour)
 }
 
+type fixedClock struct {
+	now time.Time
+}
+
+func (fc fixedClock) Now() time.Time {
+	return fc.now
+}
+
 type stubProvider struct {
 	osinfo            func(context.Context) (osinfo.OSInfo, error)
 	packageUpdates    func(context.Context) (packages.Packages, error)
@@ -169,3 +608,135 @@ func (p stubProvider) GetInstalledPackages(ctx context.Context) (packages.Packag
 func (p stubProvider) GetPackageUpdates(ctx context.Context) (packages.Packages, error) {
 	return p.packageUpdates(ctx)
 }
+
+func TestFingerprint(t *testing.T) {
+	newInventory := func() *InstanceInventory {
+		return &InstanceInventory{
+			Hostname: "testhost",
+			InstalledPackages: &packages.Packages{
+				Apt:                []*packages.PkgInfo{{Name: "apt-pkg", Arch: "amd64", Version: "1.0"}},
+				WindowsApplication: []*packages.WindowsApplication{{DisplayName: "app", DisplayVersion: "1.0"}},
+			},
+			LastUpdated:      "2026-01-01T00:00:00Z",
+			SourceTimestamps: map[string]string{SourceOSInfo: "2026-01-01T00:00:00Z"},
+		}
+	}
+	want, err := newInventory().Fingerprint()
+	if err != nil {
+		t.Fatalf("Fingerprint() unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		change    func(*InstanceInventory)
+		wantEqual bool
+	}{
+		{"Unchanged", func(*InstanceInventory) {}, true},
+		{"LastUpdated", func(i *InstanceInventory) { i.LastUpdated = "2026-01-02T00:00:00Z" }, true},
+		{"SourceTimestamps", func(i *InstanceInventory) { i.SourceTimestamps[SourceOSInfo] = "2026-01-02T00:00:00Z" }, true},
+		{"Hostname", func(i *InstanceInventory) { i.Hostname = "otherhost" }, false},
+		{"PackageVersion", func(i *InstanceInventory) { i.InstalledPackages.Apt[0].Version = "1.1" }, false},
+		{"WindowsApplication", func(i *InstanceInventory) { i.InstalledPackages.WindowsApplication[0].DisplayVersion = "1.1" }, false},
+		{"PackageUpdates", func(i *InstanceInventory) { i.PackageUpdates = &packages.Packages{} }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv := newInventory()
+			tt.change(inv)
+			got, err := inv.Fingerprint()
+			if err != nil {
+				t.Fatalf("Fingerprint() unexpected error: %v", err)
+			}
+			if (got == want) != tt.wantEqual {
+				t.Errorf("Fingerprint() = %q, original %q, want equal: %t", got, want, tt.wantEqual)
+			}
+		})
+	}
+}
+
+func TestExportJSON(t *testing.T) {
+	state := &InstanceInventory{
+		Hostname:             "Hostname",
+		LongName:             "LongName",
+		ShortName:            "ShortName",
+		Version:              "Version",
+		Architecture:         "Architecture",
+		KernelVersion:        "KernelVersion",
+		KernelRelease:        "KernelRelease",
+		OSConfigAgentVersion: "OSConfigAgentVersion",
+		InstalledPackages: &packages.Packages{
+			Deb: []*packages.PkgInfo{
+				{Name: "man-db", Arch: "x86_64", Version: "2.9.1-1", Type: "deb"},
+				{Name: "bash", Arch: "x86_64", Version: "5.0-6", Type: "deb", Source: packages.Source{Name: "bash", Version: "5.0-6"}},
+			},
+			WindowsApplication: []*packages.WindowsApplication{
+				{DisplayName: "Google Chrome", DisplayVersion: "120.0.6099.130", Publisher: "Google LLC", InstallDate: time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+		PackageUpdates: &packages.Packages{
+			Apt: []*packages.PkgInfo{{Name: "bash", Arch: "x86_64", Version: "5.0-6ubuntu1.2", Type: "deb"}},
+		},
+		Environment:       "wsl2",
+		WSLDistro:         "Ubuntu",
+		NetworkInterfaces: []NetworkInterface{{Name: "ens4", MAC: "42:01:0a:80:00:02", IPv4: []string{"10.128.0.2"}}},
+		LastUpdated:       "2024-01-02T03:04:05Z",
+		SourceTimestamps:  map[string]string{SourceOSInfo: "2024-01-02T03:04:05Z", SourceInstalledPackages: "2024-01-02T03:04:01Z"},
+	}
+
+	got, err := state.ExportJSON()
+	if err != nil {
+		t.Fatalf("ExportJSON() unexpected error: %v", err)
+	}
+
+	utiltest.AssertFileContents(t, "./testdata/export.golden", string(got))
+	if state.InstalledPackages.Deb[0].Name != "man-db" {
+		t.Errorf("ExportJSON() reordered the packages of the inventory")
+	}
+}
+
+func TestExportJSONFields(t *testing.T) {
+	exported := reflect.TypeOf(exportedInventory{})
+	fields := reflect.TypeOf(InstanceInventory{})
+	for i := 0; i < fields.NumField(); i++ {
+		if _, ok := exported.FieldByName(fields.Field(i).Name); !ok {
+			t.Errorf("exportedInventory has no field for InstanceInventory.%s, ExportJSON() leaves it out", fields.Field(i).Name)
+		}
+	}
+}
+
+func TestGrafeasOccurrences(t *testing.T) {
+	state := &InstanceInventory{
+		ShortName: "debian",
+		Version:   "12",
+		CPE:       "cpe:2.3:o:debian:debian_linux:12:*:*:*:*:*:*:*",
+		InstalledPackages: &packages.Packages{
+			Deb: []*packages.PkgInfo{
+				{Name: "man-db", Arch: "x86_64", Version: "2.11.2-2", Type: "deb"},
+				{Name: "libc6", Arch: "x86_64", Version: "2.36-9+deb12u4", Type: "deb"},
+				{Name: "bind9-host", Arch: "x86_64", Version: "1:9.18.24-1", Type: "deb"},
+			},
+			Pip: []*packages.PipPackage{
+				{PkgInfo: packages.PkgInfo{Name: "requests", Arch: "all", Version: "2.28.1", Type: "pypi"}, Location: "/usr/lib/python3/dist-packages"},
+			},
+			Npm: []*packages.NpmPackage{
+				{Name: "lodash", Version: "4.17.21", Location: []string{"/srv/a/node_modules/lodash", "/srv/b/node_modules/lodash"}},
+			},
+			GoModules: []*packages.GoModule{
+				{Path: "github.com/google/go-cmp", Version: "v0.6.0", Binaries: []string{"/usr/bin/app"}},
+			},
+		},
+		PackageUpdates: &packages.Packages{
+			Apt: []*packages.PkgInfo{{Name: "libc6", Arch: "x86_64", Version: "2.36-9+deb12u7", Type: "deb"}},
+		},
+	}
+
+	got, err := json.MarshalIndent(GrafeasOccurrences("//compute.googleapis.com/projects/p/zones/z/instances/1234", state), "", "  ")
+	if err != nil {
+		t.Fatalf("json.MarshalIndent() unexpected error: %v", err)
+	}
+
+	utiltest.AssertFileContents(t, "./testdata/grafeas.golden", string(got))
+	if occurrences := GrafeasOccurrences("//compute.googleapis.com/projects/p/zones/z/instances/1234", &InstanceInventory{}); occurrences != nil {
+		t.Errorf("GrafeasOccurrences() of an inventory without installed packages = %v, want: nil", occurrences)
+	}
+}
@@ -0,0 +1,145 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package inventory
+
+import (
+	"strings"
+
+	"github.com/GoogleCloudPlatform/osconfig/packages"
+	"github.com/google/osv-scalibr/semantic"
+)
+
+// rpmKernelPackages are the rpm packages, on Red Hat like distributions, that install a
+// kernel whose release is the package version-release.arch.
+var rpmKernelPackages = map[string]bool{
+	"kernel":          true,
+	"kernel-core":     true,
+	"kernel-uek":      true,
+	"kernel-uek-core": true,
+}
+
+// suseKernelFlavors are the flavors of the SUSE kernel-<flavor> packages, their kernel
+// release ends with -<flavor> instead of the architecture.
+var suseKernelFlavors = map[string]bool{
+	"default":  true,
+	"azure":    true,
+	"rt":       true,
+	"preempt":  true,
+	"kvmsmall": true,
+	"64kb":     true,
+}
+
+type kernelCandidate struct {
+	release string
+	version semantic.Version
+}
+
+// newestInstalledKernel returns the release, in the uname -r format, of the newest
+// kernel package in pkgs or an empty string when no kernel package is recognized.
+// shortName selects the naming scheme of the rpm kernel packages.
+func newestInstalledKernel(shortName string, pkgs *packages.Packages) string {
+	if pkgs == nil {
+		return ""
+	}
+
+	var candidates []kernelCandidate
+	for _, pkg := range pkgs.Deb {
+		release := debKernelRelease(pkg.Name)
+		if release == "" {
+			continue
+		}
+		if v, err := semantic.ParseDebianVersion(pkg.Version); err == nil {
+			candidates = append(candidates, kernelCandidate{release: release, version: v})
+		}
+	}
+	suse := strings.HasPrefix(shortName, "sles") || strings.HasPrefix(shortName, "sled") || strings.HasPrefix(shortName, "opensuse")
+	for _, pkg := range pkgs.Rpm {
+		var release string
+		if suse {
+			release = suseKernelRelease(pkg)
+		} else {
+			release = rpmKernelRelease(pkg)
+		}
+		if release == "" {
+			continue
+		}
+		candidates = append(candidates, kernelCandidate{release: release, version: semantic.ParseRedHatVersion(pkg.Version)})
+	}
+
+	var newest *kernelCandidate
+	for i, c := range candidates {
+		if newest == nil {
+			newest = &candidates[i]
+			continue
+		}
+		if cmp, err := c.version.Compare(newest.version); err == nil && cmp > 0 {
+			newest = &candidates[i]
+		}
+	}
+	if newest == nil {
+		return ""
+	}
+	return newest.release
+}
+
+// debKernelRelease returns the kernel release embedded in the name of Debian and Ubuntu
+// kernel image packages, e.g. linux-image-6.1.0-29-cloud-amd64. Meta packages such as
+// linux-image-cloud-amd64 and debug symbols are skipped.
+func debKernelRelease(name string) string {
+	release, ok := strings.CutPrefix(name, "linux-image-")
+	if !ok || strings.HasSuffix(release, "-dbg") || strings.HasSuffix(release, "-dbgsym") {
+		return ""
+	}
+	release = strings.TrimPrefix(release, "unsigned-")
+	release = strings.TrimSuffix(release, "-unsigned")
+	if release == "" || release[0] < '0' || release[0] > '9' {
+		return ""
+	}
+	return release
+}
+
+// rpmKernelRelease returns the kernel release of Red Hat like kernel packages, the
+// package version-release followed by the architecture.
+func rpmKernelRelease(pkg *packages.PkgInfo) string {
+	if !rpmKernelPackages[pkg.Name] {
+		return ""
+	}
+	return stripEpoch(pkg.Version) + "." + pkg.Arch
+}
+
+// suseKernelRelease returns the kernel release of SUSE kernel-<flavor> packages, the
+// package version 5.14.21-150500.55.65.1 of kernel-default runs as 5.14.21-150500.55.65-default.
+func suseKernelRelease(pkg *packages.PkgInfo) string {
+	flavor, ok := strings.CutPrefix(pkg.Name, "kernel-")
+	flavor = strings.TrimSuffix(flavor, "-base")
+	if !ok || !suseKernelFlavors[flavor] {
+		return ""
+	}
+	version, release, ok := strings.Cut(stripEpoch(pkg.Version), "-")
+	if !ok {
+		return ""
+	}
+	if i := strings.LastIndex(release, "."); i >= 0 {
+		release = release[:i]
+	}
+	return version + "-" + release + "-" + flavor
+}
+
+func stripEpoch(version string) string {
+	if _, v, ok := strings.Cut(version, ":"); ok {
+		return v
+	}
+	return version
+}
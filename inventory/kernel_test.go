@@ -0,0 +1,125 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package inventory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/osconfig/osinfo"
+	"github.com/GoogleCloudPlatform/osconfig/packages"
+	"github.com/GoogleCloudPlatform/osconfig/util/utiltest"
+)
+
+func TestNewestInstalledKernel(t *testing.T) {
+	tests := []struct {
+		name      string
+		shortName string
+		pkgs      *packages.Packages
+		want      string
+	}{
+		{
+			name:      "Debian",
+			shortName: "debian",
+			pkgs: &packages.Packages{Deb: []*packages.PkgInfo{
+				{Name: "linux-image-6.1.0-28-cloud-amd64", Version: "6.1.119-1"},
+				{Name: "linux-image-6.1.0-29-cloud-amd64", Version: "6.1.123-1"},
+				{Name: "linux-image-6.1.0-29-cloud-amd64-dbg", Version: "6.1.123-1"},
+				{Name: "linux-image-cloud-amd64", Version: "6.1.123-1"},
+				{Name: "bash", Version: "5.2.15-2+b7"},
+			}},
+			want: "6.1.0-29-cloud-amd64",
+		},
+		{
+			name:      "Ubuntu unsigned",
+			shortName: "ubuntu",
+			pkgs: &packages.Packages{Deb: []*packages.PkgInfo{
+				{Name: "linux-image-unsigned-6.8.0-1015-gcp", Version: "6.8.0-1015.17"},
+				{Name: "linux-image-6.8.0-1013-gcp", Version: "6.8.0-1013.14"},
+			}},
+			want: "6.8.0-1015-gcp",
+		},
+		{
+			name:      "RHEL",
+			shortName: "rhel",
+			pkgs: &packages.Packages{Rpm: []*packages.PkgInfo{
+				{Name: "kernel-core", Arch: "x86_64", Version: "5.14.0-427.13.1.el9_4"},
+				{Name: "kernel-core", Arch: "x86_64", Version: "5.14.0-427.40.1.el9_4"},
+				{Name: "kernel-headers", Arch: "x86_64", Version: "5.14.0-503.11.1.el9_5"},
+			}},
+			want: "5.14.0-427.40.1.el9_4.x86_64",
+		},
+		{
+			name:      "SLES",
+			shortName: "sles",
+			pkgs: &packages.Packages{Rpm: []*packages.PkgInfo{
+				{Name: "kernel-default", Arch: "x86_64", Version: "5.14.21-150500.55.65.1"},
+				{Name: "kernel-default", Arch: "x86_64", Version: "5.14.21-150500.55.49.1"},
+				{Name: "kernel-firmware", Arch: "noarch", Version: "20240712-150600.3.3.1"},
+			}},
+			want: "5.14.21-150500.55.65-default",
+		},
+		{
+			name:      "No kernel packages",
+			shortName: "debian",
+			pkgs:      &packages.Packages{Deb: []*packages.PkgInfo{{Name: "bash", Version: "5.2.15-2+b7"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			utiltest.AssertEquals(t, newestInstalledKernel(tt.shortName, tt.pkgs), tt.want)
+		})
+	}
+}
+
+func TestProviderKernelMismatch(t *testing.T) {
+	installed := packages.Packages{Deb: []*packages.PkgInfo{
+		{Name: "linux-image-6.1.0-28-cloud-amd64", Version: "6.1.119-1"},
+		{Name: "linux-image-6.1.0-29-cloud-amd64", Version: "6.1.123-1"},
+	}}
+
+	tests := []struct {
+		name          string
+		kernelRelease string
+		wantMismatch  bool
+	}{
+		{name: "running the newest kernel", kernelRelease: "6.1.0-29-cloud-amd64"},
+		{name: "running an older kernel", kernelRelease: "6.1.0-28-cloud-amd64", wantMismatch: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &stubProvider{
+				osinfo: func(_ context.Context) (osinfo.OSInfo, error) {
+					return osinfo.OSInfo{ShortName: "debian", KernelRelease: tt.kernelRelease}, nil
+				},
+				packageUpdates:    func(_ context.Context) (packages.Packages, error) { return packages.Packages{}, nil },
+				installedPackages: func(_ context.Context) (packages.Packages, error) { return installed, nil },
+			}
+			provider := defaultInventoryProvider{
+				osInfoProvider:            stub,
+				packageUpdatesProvider:    stub,
+				installedPackagesProvider: stub,
+				clock:                     stubClock{},
+			}
+
+			got := provider.Get(context.Background())
+
+			utiltest.AssertEquals(t, got.InstalledKernelRelease, "6.1.0-29-cloud-amd64")
+			utiltest.AssertEquals(t, got.KernelMismatch, tt.wantMismatch)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"net"
+)
+
+// NetworkInterface is a network interface of the instance with the addresses assigned to
+// it. Dual-stack interfaces report both IPv4 and IPv6 addresses.
+type NetworkInterface struct {
+	Name string
+	MAC  string   `json:",omitempty"`
+	IPv4 []string `json:",omitempty"`
+	IPv6 []string `json:",omitempty"`
+}
+
+// hostInterface is an interface as listed by enumerateInterfaces.
+type hostInterface struct {
+	Name         string
+	HardwareAddr net.HardwareAddr
+	Flags        net.Flags
+	Addrs        []net.Addr
+}
+
+// enumerateInterfaces lists the network interfaces of the host with their addresses.
+var enumerateInterfaces = func() ([]hostInterface, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	hostIfaces := make([]hostInterface, 0, len(ifaces))
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return nil, fmt.Errorf("error listing the addresses of %s: %v", iface.Name, err)
+		}
+		hostIfaces = append(hostIfaces, hostInterface{Name: iface.Name, HardwareAddr: iface.HardwareAddr, Flags: iface.Flags, Addrs: addrs})
+	}
+	return hostIfaces, nil
+}
+
+// networkInterfaces returns the network interfaces of the host. Loopback interfaces and
+// loopback addresses are left out.
+func networkInterfaces(_ context.Context) ([]NetworkInterface, error) {
+	hostIfaces, err := enumerateInterfaces()
+	if err != nil {
+		return nil, err
+	}
+	var ifaces []NetworkInterface
+	for _, hostIface := range hostIfaces {
+		if hostIface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		iface := NetworkInterface{Name: hostIface.Name, MAC: hostIface.HardwareAddr.String()}
+		for _, addr := range hostIface.Addrs {
+			ip := addrIP(addr)
+			if ip == nil || ip.IsLoopback() {
+				continue
+			}
+			if ip.To4() != nil {
+				iface.IPv4 = append(iface.IPv4, ip.String())
+			} else {
+				iface.IPv6 = append(iface.IPv6, ip.String())
+			}
+		}
+		ifaces = append(ifaces, iface)
+	}
+	return ifaces, nil
+}
+
+// addrIP returns the IP of addr, nil when it is not an IP address.
+func addrIP(addr net.Addr) net.IP {
+	switch a := addr.(type) {
+	case *net.IPNet:
+		return a.IP
+	case *net.IPAddr:
+		return a.IP
+	}
+	return nil
+}
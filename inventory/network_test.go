@@ -0,0 +1,116 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package inventory
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/osconfig/osinfo"
+	"github.com/GoogleCloudPlatform/osconfig/packages"
+	"github.com/GoogleCloudPlatform/osconfig/util/utiltest"
+	"github.com/google/go-cmp/cmp"
+)
+
+func ipNet(t *testing.T, cidr string) *net.IPNet {
+	t.Helper()
+	ip, n, err := net.ParseCIDR(cidr)
+	if err != nil {
+		t.Fatalf("net.ParseCIDR(%q) error: %v", cidr, err)
+	}
+	n.IP = ip
+	return n
+}
+
+func TestNetworkInterfaces(t *testing.T) {
+	mac, err := net.ParseMAC("42:01:0a:80:00:02")
+	if err != nil {
+		t.Fatal(err)
+	}
+	utiltest.OverrideVariable(t, &enumerateInterfaces, func() ([]hostInterface, error) {
+		return []hostInterface{
+			{Name: "lo", Flags: net.FlagUp | net.FlagLoopback, Addrs: []net.Addr{ipNet(t, "127.0.0.1/8"), ipNet(t, "::1/128")}},
+			{Name: "ens4", HardwareAddr: mac, Flags: net.FlagUp, Addrs: []net.Addr{
+				ipNet(t, "10.128.0.2/32"),
+				ipNet(t, "2600:1900:4000:1234::2/128"),
+				ipNet(t, "fe80::4001:aff:fe80:2/64"),
+			}},
+			{Name: "docker0", Flags: net.FlagUp, Addrs: []net.Addr{&net.IPAddr{IP: net.ParseIP("172.17.0.1")}, ipNet(t, "127.0.0.2/8")}},
+			{Name: "tun0", Flags: net.FlagUp},
+		}, nil
+	})
+
+	got, err := networkInterfaces(context.Background())
+	if err != nil {
+		t.Fatalf("networkInterfaces() unexpected error: %v", err)
+	}
+	want := []NetworkInterface{
+		{Name: "ens4", MAC: "42:01:0a:80:00:02", IPv4: []string{"10.128.0.2"}, IPv6: []string{"2600:1900:4000:1234::2", "fe80::4001:aff:fe80:2"}},
+		{Name: "docker0", IPv4: []string{"172.17.0.1"}},
+		{Name: "tun0"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("networkInterfaces() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestProviderWithNetworkInterfaces(t *testing.T) {
+	stub := &stubProvider{
+		osinfo: func(_ context.Context) (osinfo.OSInfo, error) { return osinfo.OSInfo{Hostname: "testhost"}, nil },
+		packageUpdates: func(_ context.Context) (packages.Packages, error) {
+			return packages.Packages{}, nil
+		},
+		installedPackages: func(_ context.Context) (packages.Packages, error) {
+			return packages.Packages{}, nil
+		},
+	}
+	newProvider := func() *defaultInventoryProvider {
+		return &defaultInventoryProvider{
+			osInfoProvider:            stub,
+			packageUpdatesProvider:    stub,
+			installedPackagesProvider: stub,
+			clock:                     stubClock{},
+			agentVersion:              func() string { return "" },
+		}
+	}
+
+	if inv := newProvider().Get(context.Background()); inv.NetworkInterfaces != nil {
+		t.Errorf("NetworkInterfaces = %v without WithNetworkInterfaces, want nil", inv.NetworkInterfaces)
+	}
+
+	utiltest.OverrideVariable(t, &enumerateInterfaces, func() ([]hostInterface, error) {
+		return []hostInterface{{Name: "ens4", Addrs: []net.Addr{ipNet(t, "10.128.0.2/32")}}}, nil
+	})
+	provider := newProvider()
+	WithNetworkInterfaces()(provider)
+	inv, err := provider.GetWithErrors(context.Background())
+	if err != nil {
+		t.Fatalf("GetWithErrors() unexpected error: %v", err)
+	}
+	utiltest.AssertEquals(t, inv.NetworkInterfaces, []NetworkInterface{{Name: "ens4", IPv4: []string{"10.128.0.2"}}})
+	if _, ok := inv.SourceTimestamps[SourceNetworkInterfaces]; !ok {
+		t.Errorf("network interfaces were not recorded as queried: %v", inv.SourceTimestamps)
+	}
+
+	errEnumerate := errors.New("enumerate error")
+	utiltest.OverrideVariable(t, &enumerateInterfaces, func() ([]hostInterface, error) { return nil, errEnumerate })
+	_, err = provider.GetWithErrors(context.Background())
+	var invErr *InventoryError
+	if !errors.As(err, &invErr) || invErr.Source != SourceNetworkInterfaces || !errors.Is(err, errEnumerate) {
+		t.Errorf("GetWithErrors() error = %v, want a %q InventoryError", err, SourceNetworkInterfaces)
+	}
+}
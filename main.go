@@ -277,6 +277,11 @@ func runServiceLoop(ctx context.Context) {
 	// Runs functions that need to run on a set interval.
 	ticker := time.NewTicker(agentconfig.SvcPollInterval())
 	defer ticker.Stop()
+	// The inventory client is reused across cycles, tasks enqueued with the tasker run
+	// one at a time.
+	reporter := newInventoryReporter()
+	defer tasker.Enqueue(ctx, "Close OSInventory client", reporter.close)
+
 	// First inventory run will be somewhere between 3 and 5 min.
 	firstInventory := time.After(time.Duration(rand.Intn(120)+180) * time.Second)
 	ranFirstInventory := false
@@ -301,12 +306,7 @@ func runServiceLoop(ctx context.Context) {
 
 			// This should always run after ospackage.SetConfig.
 			tasker.Enqueue(ctx, "Report OSInventory", func() {
-				client, err := agentendpoint.NewClient(ctx)
-				if err != nil {
-					logger.Errorf("%v", err.Error())
-				}
-				client.ReportInventory(ctx)
-				client.Close()
+				reporter.report(ctx, agentconfig.SvcEndpoint(), agentconfig.InventoryCollectors(), currentInventorySettings())
 			})
 		}
 
@@ -0,0 +1,197 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/GoogleCloudPlatform/osconfig/agentconfig"
+	"github.com/GoogleCloudPlatform/osconfig/agentendpoint"
+	"github.com/GoogleCloudPlatform/osconfig/clog"
+	"github.com/GoogleCloudPlatform/osconfig/inventory"
+	"github.com/GoogleCloudPlatform/osconfig/packages"
+)
+
+// inventoryScanDepth is how many directories deep the go-binaries, npm and cargo
+// collectors scan below their roots.
+const inventoryScanDepth = 6
+
+// inventoryOptions returns the inventory provider options enabling the opt-in collectors
+// configured with the osconfig-inventory-collectors metadata attribute. Unknown
+// collectors and collectors missing required arguments are logged and skipped.
+func inventoryOptions(ctx context.Context, collectors []agentconfig.InventoryCollector) []inventory.Option {
+	var opts []inventory.Option
+	for _, c := range collectors {
+		var opt inventory.Option
+		switch c.Name {
+		case "kernel-modules":
+			opt = inventory.WithKernelModules()
+		case "go-binaries":
+			if len(c.Args) > 0 {
+				opt = inventory.WithGoBinaries(c.Args, inventoryScanDepth)
+			}
+		case "npm":
+			if len(c.Args) > 0 {
+				opt = inventory.WithNpm(c.Args, inventoryScanDepth)
+			}
+		case "cargo":
+			if len(c.Args) > 0 {
+				opt = inventory.WithCargo(c.Args, inventoryScanDepth)
+			}
+		case "ide-extensions":
+			if len(c.Args) > 0 {
+				opt = inventory.WithIDEExtensions(c.Args)
+			}
+		case "googet-repositories":
+			opt = inventory.WithGooGetRepositories()
+		case "repositories":
+			opt = inventory.WithRepositories()
+		case "container-images":
+			opt = inventory.WithContainerImages()
+		case "nix":
+			profiles := c.Args
+			if len(profiles) == 0 {
+				profiles = packages.DefaultNixProfiles
+			}
+			opt = inventory.WithNix(profiles)
+		case "conda":
+			if len(c.Args) > 0 {
+				opt = inventory.WithConda(c.Args)
+			}
+		case "systemd-units":
+			opt = inventory.WithSystemdUnits()
+		case "windows-services":
+			opt = inventory.WithWindowsServices()
+		case "firmware":
+			opt = inventory.WithFirmware()
+		case "listening-ports":
+			opt = inventory.WithListeningPorts()
+		case "loaded-libraries":
+			if len(c.Args) > 0 {
+				opt = inventory.WithLoadedLibraries(c.Args)
+			}
+		case "environment-modules":
+			opt = inventory.WithEnvironmentModules(c.Args)
+		case "certificates":
+			opt = inventory.WithCertificates()
+		case "network-interfaces":
+			opt = inventory.WithNetworkInterfaces()
+		case "package-files":
+			maxFiles := packages.MaxPackageFiles
+			if len(c.Args) > 0 {
+				n, err := strconv.Atoi(c.Args[0])
+				if err != nil {
+					clog.Warningf(ctx, "Invalid package-files inventory collector limit %q: %v", c.Args[0], err)
+					continue
+				}
+				maxFiles = n
+			}
+			opt = inventory.WithPackageFiles(maxFiles)
+		default:
+			clog.Warningf(ctx, "Unknown inventory collector %q.", c.Name)
+			continue
+		}
+		if opt == nil {
+			clog.Warningf(ctx, "Inventory collector %q requires arguments, e.g. %s=<path>.", c.Name, c.Name)
+			continue
+		}
+		opts = append(opts, opt)
+	}
+	return opts
+}
+
+// inventorySettings are the inventory settings other than the collectors, configured
+// with their own metadata attributes.
+type inventorySettings struct {
+	osInfoCacheTTL time.Duration
+	packageUpdates bool
+}
+
+func currentInventorySettings() inventorySettings {
+	return inventorySettings{
+		osInfoCacheTTL: agentconfig.InventoryOSInfoCacheTTL(),
+		packageUpdates: agentconfig.InventoryPackageUpdatesEnabled(),
+	}
+}
+
+// options returns the inventory provider options applying s.
+func (s inventorySettings) options() []inventory.Option {
+	var opts []inventory.Option
+	if s.osInfoCacheTTL > 0 {
+		opts = append(opts, inventory.WithOSInfoCache(s.osInfoCacheTTL))
+	}
+	if !s.packageUpdates {
+		opts = append(opts, inventory.WithoutPackageUpdates())
+	}
+	return opts
+}
+
+// inventoryClient is the part of agentendpoint.Client used to report the inventory.
+type inventoryClient interface {
+	ReportInventory(context.Context)
+	Close() error
+}
+
+// inventoryReporter reports the inventory with the same Client across cycles, so that the
+// state the Client keeps between reports, e.g. the fingerprint of the last inventory
+// written to guest attributes, takes effect. The Client is recreated when the endpoint or
+// the inventory collectors or settings configured in metadata change.
+type inventoryReporter struct {
+	newClient func(context.Context, string, []agentconfig.InventoryCollector, inventorySettings) (inventoryClient, error)
+
+	client inventoryClient
+	// config identifies the endpoint, collectors and settings client was created with.
+	config string
+}
+
+func newInventoryReporter() *inventoryReporter {
+	return &inventoryReporter{
+		newClient: func(ctx context.Context, _ string, collectors []agentconfig.InventoryCollector, settings inventorySettings) (inventoryClient, error) {
+			opts := append(inventoryOptions(ctx, collectors), settings.options()...)
+			return agentendpoint.NewClient(ctx, agentendpoint.WithInventoryOptions(opts...))
+		},
+	}
+}
+
+// report reports the inventory, creating the Client on the first call and whenever the
+// configuration changed since the last one.
+func (r *inventoryReporter) report(ctx context.Context, endpoint string, collectors []agentconfig.InventoryCollector, settings inventorySettings) {
+	config := fmt.Sprintf("%s %v %+v", endpoint, collectors, settings)
+	if r.client != nil && r.config != config {
+		r.client.Close()
+		r.client = nil
+	}
+	if r.client == nil {
+		client, err := r.newClient(ctx, endpoint, collectors, settings)
+		if err != nil {
+			clog.Errorf(ctx, "Error creating the inventory client: %v", err)
+			return
+		}
+		r.client = client
+		r.config = config
+	}
+	r.client.ReportInventory(ctx)
+}
+
+// close closes the Client of r, if any.
+func (r *inventoryReporter) close() {
+	if r.client != nil {
+		r.client.Close()
+		r.client = nil
+	}
+}
@@ -0,0 +1,100 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/osconfig/agentconfig"
+)
+
+type fakeInventoryClient struct {
+	reports int
+	closed  bool
+}
+
+func (c *fakeInventoryClient) ReportInventory(context.Context) { c.reports++ }
+
+func (c *fakeInventoryClient) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestInventoryReporterReusesClient(t *testing.T) {
+	ctx := context.Background()
+	var clients []*fakeInventoryClient
+	var fail bool
+	r := &inventoryReporter{
+		newClient: func(context.Context, string, []agentconfig.InventoryCollector, inventorySettings) (inventoryClient, error) {
+			if fail {
+				return nil, errors.New("dial error")
+			}
+			c := &fakeInventoryClient{}
+			clients = append(clients, c)
+			return c, nil
+		},
+	}
+	collectors := []agentconfig.InventoryCollector{{Name: "nix"}}
+	settings := inventorySettings{packageUpdates: true}
+
+	r.report(ctx, "endpoint", collectors, settings)
+	r.report(ctx, "endpoint", collectors, settings)
+	if len(clients) != 1 {
+		t.Fatalf("got %d clients after two cycles, want 1", len(clients))
+	}
+	if clients[0].reports != 2 || clients[0].closed {
+		t.Errorf("got %d reports, closed %t on the first client, want 2 reports, not closed", clients[0].reports, clients[0].closed)
+	}
+
+	r.report(ctx, "endpoint", []agentconfig.InventoryCollector{{Name: "conda", Args: []string{"/opt/conda"}}}, settings)
+	if len(clients) != 2 || !clients[0].closed || clients[1].reports != 1 {
+		t.Errorf("changing the collectors did not replace the client: %d clients, first closed %t", len(clients), clients[0].closed)
+	}
+
+	r.report(ctx, "endpoint", []agentconfig.InventoryCollector{{Name: "conda", Args: []string{"/opt/conda"}}}, inventorySettings{osInfoCacheTTL: time.Hour, packageUpdates: true})
+	if len(clients) != 3 || !clients[1].closed || clients[2].reports != 1 {
+		t.Errorf("changing the inventory settings did not replace the client: %d clients, second closed %t", len(clients), clients[1].closed)
+	}
+
+	fail = true
+	r.report(ctx, "other-endpoint", nil, settings)
+	if !clients[2].closed || r.client != nil {
+		t.Errorf("a failed client creation kept a stale client")
+	}
+	r.close()
+}
+
+func TestInventorySettingsOptions(t *testing.T) {
+	if opts := (inventorySettings{packageUpdates: true}).options(); len(opts) != 0 {
+		t.Errorf("default settings returned %d options, want none", len(opts))
+	}
+	if opts := (inventorySettings{osInfoCacheTTL: time.Hour}).options(); len(opts) != 2 {
+		t.Errorf("cached OS info without package updates returned %d options, want 2", len(opts))
+	}
+}
+
+func TestInventoryOptionsSkipsUnknownCollectors(t *testing.T) {
+	opts := inventoryOptions(context.Background(), []agentconfig.InventoryCollector{
+		{Name: "kernel-modules"},
+		{Name: "osinfo-cache", Args: []string{"1h"}},
+		{Name: "no-package-updates"},
+	})
+	if len(opts) != 1 {
+		t.Errorf("got %d options, want only the kernel-modules one", len(opts))
+	}
+}
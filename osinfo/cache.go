@@ -0,0 +1,105 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package osinfo
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CachingProvider is a Provider that reuses the OSInfo of a wrapped provider for a
+// fixed time, the OSInfo is refreshed once it expires, when the kernel release of the
+// running system changes or after Invalidate is called. Failed lookups are not cached.
+type CachingProvider struct {
+	provider Provider
+	ttl      time.Duration
+
+	// now returns the current time, time.Now is used when nil.
+	now func() time.Time
+	// kernelRelease returns the release of the running kernel, a change invalidates
+	// the cached OSInfo. The release is not checked when nil.
+	kernelRelease func() string
+
+	mu       sync.Mutex
+	cached   *OSInfo
+	fetched  time.Time
+	expires  time.Time
+	cachedKR string
+}
+
+var _ Provider = &CachingProvider{}
+
+// NewCachingProvider returns a provider caching the OSInfo returned by provider for ttl.
+func NewCachingProvider(provider Provider, ttl time.Duration) *CachingProvider {
+	return &CachingProvider{
+		provider:      provider,
+		ttl:           ttl,
+		now:           time.Now,
+		kernelRelease: runningKernelRelease,
+	}
+}
+
+// GetOSInfo returns the cached OSInfo when it is still valid, otherwise it queries
+// the wrapped provider.
+func (p *CachingProvider) GetOSInfo(ctx context.Context) (OSInfo, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.currentTime()
+	var kr string
+	if p.kernelRelease != nil {
+		kr = p.kernelRelease()
+	}
+	if p.cached != nil && now.Before(p.expires) && kr == p.cachedKR {
+		return *p.cached, nil
+	}
+
+	oi, err := p.provider.GetOSInfo(ctx)
+	if err != nil {
+		p.cached = nil
+		return oi, err
+	}
+	p.cached = &oi
+	p.fetched = now
+	p.expires = now.Add(p.ttl)
+	p.cachedKR = kr
+	return oi, nil
+}
+
+// FetchedAt returns when the cached OSInfo was queried from the wrapped provider, the
+// zero time when nothing is cached.
+func (p *CachingProvider) FetchedAt() time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cached == nil {
+		return time.Time{}
+	}
+	return p.fetched
+}
+
+// Invalidate drops the cached OSInfo, the next GetOSInfo call queries the wrapped provider.
+func (p *CachingProvider) Invalidate() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cached = nil
+}
+
+func (p *CachingProvider) currentTime() time.Time {
+	if p.now == nil {
+		return time.Now()
+	}
+	return p.now()
+}
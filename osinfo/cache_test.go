@@ -0,0 +1,98 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package osinfo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type countingProvider struct {
+	calls int
+	err   error
+}
+
+func (p *countingProvider) GetOSInfo(context.Context) (OSInfo, error) {
+	p.calls++
+	return OSInfo{Hostname: "host", KernelRelease: "6.1.0"}, p.err
+}
+
+func TestCachingProvider(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, time.January, 2, 3, 4, 5, 0, time.UTC)
+	release := "6.1.0"
+	stub := &countingProvider{}
+	provider := NewCachingProvider(stub, time.Minute)
+	provider.now = func() time.Time { return now }
+	provider.kernelRelease = func() string { return release }
+
+	get := func(wantCalls int) {
+		t.Helper()
+		oi, err := provider.GetOSInfo(ctx)
+		if err != nil {
+			t.Fatalf("GetOSInfo() unexpected error: %v", err)
+		}
+		if oi.Hostname != "host" {
+			t.Errorf("GetOSInfo() returned unexpected OSInfo: %+v", oi)
+		}
+		if stub.calls != wantCalls {
+			t.Errorf("unexpected number of wrapped provider calls, got: %d, want: %d", stub.calls, wantCalls)
+		}
+	}
+
+	if !provider.FetchedAt().IsZero() {
+		t.Errorf("FetchedAt() = %v before the first lookup, want the zero time", provider.FetchedAt())
+	}
+	get(1) // Miss, nothing cached yet.
+	fetched := now
+	now = now.Add(time.Second)
+	get(1) // Hit.
+	if got := provider.FetchedAt(); !got.Equal(fetched) {
+		t.Errorf("a cache hit changed FetchedAt(), got: %v, want: %v", got, fetched)
+	}
+
+	now = now.Add(58 * time.Second)
+	get(1) // Hit, still within the TTL.
+
+	now = now.Add(time.Second)
+	get(2) // Miss, the TTL expired.
+
+	release = "6.2.0"
+	get(3) // Miss, the kernel changed.
+	get(3) // Hit.
+
+	provider.Invalidate()
+	get(4) // Miss, invalidated.
+}
+
+func TestCachingProviderDoesNotCacheErrors(t *testing.T) {
+	ctx := context.Background()
+	stub := &countingProvider{err: errors.New("uname failed")}
+	provider := NewCachingProvider(stub, time.Hour)
+	provider.kernelRelease = nil
+
+	if _, err := provider.GetOSInfo(ctx); err == nil {
+		t.Fatalf("GetOSInfo() expected an error")
+	}
+	stub.err = nil
+	if _, err := provider.GetOSInfo(ctx); err != nil {
+		t.Fatalf("GetOSInfo() unexpected error: %v", err)
+	}
+	if stub.calls != 2 {
+		t.Errorf("failed lookups are expected not to be cached, got %d calls, want: 2", stub.calls)
+	}
+}
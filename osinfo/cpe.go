@@ -0,0 +1,111 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package osinfo
+
+import (
+	"strings"
+	"unicode"
+)
+
+// cpeProducts maps os-release IDs to the CPE vendor and product used for them in the
+// NVD dictionary.
+var cpeProducts = map[string]struct{ vendor, product string }{
+	"debian": {"debian", "debian_linux"},
+	"ubuntu": {"canonical", "ubuntu_linux"},
+	"rhel":   {"redhat", "enterprise_linux"},
+	"centos": {"centos", "centos"},
+	"rocky":  {"rocky", "rocky_linux"},
+	"ol":     {"oracle", "linux"},
+	"sles":   {"suse", "linux_enterprise_server"},
+	"sled":   {"suse", "linux_enterprise_desktop"},
+}
+
+// CPE returns a CPE 2.3 formatted string, cpe:2.3:o:vendor:product:version:..., for
+// the operating system described by oi. Distributions without a known CPE product
+// fall back to the short name as both vendor and product. An empty string is
+// returned when oi has no short name.
+func CPE(oi OSInfo) string {
+	if oi.ShortName == "" {
+		return ""
+	}
+
+	vendor, product, version, update := oi.ShortName, oi.ShortName, oi.Version, ""
+	switch oi.ShortName {
+	case DefaultShortNameWindows:
+		vendor, product = "microsoft", windowsCPEProduct(oi.LongName)
+	case "rhel", "centos", "rocky", "ol":
+		// The NVD dictionary only lists the major version of these distributions.
+		version, _, _ = strings.Cut(oi.Version, ".")
+	case "sles", "sled":
+		// Service packs are recorded as the update, 15.5 becomes 15:sp5.
+		var sp string
+		version, sp, _ = strings.Cut(oi.Version, ".")
+		if sp != "" && sp != "0" {
+			update = "sp" + sp
+		}
+	}
+	if p, ok := cpeProducts[oi.ShortName]; ok {
+		vendor, product = p.vendor, p.product
+	}
+
+	return strings.Join([]string{
+		"cpe", "2.3", "o",
+		cpeComponent(vendor),
+		cpeComponent(product),
+		cpeComponent(version),
+		cpeComponent(update),
+		"*", "*", "*", "*", "*", "*",
+	}, ":")
+}
+
+// windowsCPEProduct derives the product from a Windows caption, "Microsoft Windows
+// Server 2019 Datacenter" becomes windows_server_2019.
+func windowsCPEProduct(longName string) string {
+	var words []string
+	for _, w := range strings.Fields(strings.ToLower(longName)) {
+		if w == "microsoft" {
+			continue
+		}
+		words = append(words, w)
+		if strings.IndexFunc(w, unicode.IsDigit) >= 0 {
+			break
+		}
+	}
+	if len(words) == 0 || words[0] != "windows" {
+		return "windows"
+	}
+	return strings.Join(words, "_")
+}
+
+// cpeComponent formats a single CPE attribute value: lower case, spaces replaced
+// by underscores and other special characters escaped. Empty values match any.
+func cpeComponent(s string) string {
+	if s == "" {
+		return "*"
+	}
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r == ' ':
+			b.WriteRune('_')
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '_', r == '-', r == '.':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
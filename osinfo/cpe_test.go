@@ -0,0 +1,94 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package osinfo
+
+import "testing"
+
+func TestCPE(t *testing.T) {
+	tests := []struct {
+		name string
+		oi   OSInfo
+		want string
+	}{
+		{
+			name: "debian",
+			oi:   OSInfo{ShortName: "debian", Version: "12"},
+			want: "cpe:2.3:o:debian:debian_linux:12:*:*:*:*:*:*:*",
+		},
+		{
+			name: "ubuntu",
+			oi:   OSInfo{ShortName: "ubuntu", Version: "22.04"},
+			want: "cpe:2.3:o:canonical:ubuntu_linux:22.04:*:*:*:*:*:*:*",
+		},
+		{
+			name: "rhel",
+			oi:   OSInfo{ShortName: "rhel", Version: "9.4"},
+			want: "cpe:2.3:o:redhat:enterprise_linux:9:*:*:*:*:*:*:*",
+		},
+		{
+			name: "centos",
+			oi:   OSInfo{ShortName: "centos", Version: "7"},
+			want: "cpe:2.3:o:centos:centos:7:*:*:*:*:*:*:*",
+		},
+		{
+			name: "sles with service pack",
+			oi:   OSInfo{ShortName: "sles", Version: "15.5"},
+			want: "cpe:2.3:o:suse:linux_enterprise_server:15:sp5:*:*:*:*:*:*",
+		},
+		{
+			name: "sles without service pack",
+			oi:   OSInfo{ShortName: "sles", Version: "15"},
+			want: "cpe:2.3:o:suse:linux_enterprise_server:15:*:*:*:*:*:*:*",
+		},
+		{
+			name: "windows server",
+			oi:   OSInfo{ShortName: "windows", LongName: "Microsoft Windows Server 2019 Datacenter", Version: "10.0.17763"},
+			want: "cpe:2.3:o:microsoft:windows_server_2019:10.0.17763:*:*:*:*:*:*:*",
+		},
+		{
+			name: "windows client",
+			oi:   OSInfo{ShortName: "windows", LongName: "Microsoft Windows 10 Pro", Version: "10.0.19045"},
+			want: "cpe:2.3:o:microsoft:windows_10:10.0.19045:*:*:*:*:*:*:*",
+		},
+		{
+			name: "windows unknown caption",
+			oi:   OSInfo{ShortName: "windows", Version: "10.0.20348"},
+			want: "cpe:2.3:o:microsoft:windows:10.0.20348:*:*:*:*:*:*:*",
+		},
+		{
+			name: "unknown distribution falls back to the short name",
+			oi:   OSInfo{ShortName: "Arch Linux", Version: "rolling+1"},
+			want: "cpe:2.3:o:arch_linux:arch_linux:rolling\\+1:*:*:*:*:*:*:*",
+		},
+		{
+			name: "missing version",
+			oi:   OSInfo{ShortName: "debian"},
+			want: "cpe:2.3:o:debian:debian_linux:*:*:*:*:*:*:*:*",
+		},
+		{
+			name: "no short name",
+			oi:   OSInfo{Version: "1"},
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CPE(tt.oi); got != tt.want {
+				t.Errorf("CPE(%+v) = %q, want: %q", tt.oi, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package osinfo
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/osconfig/util"
+)
+
+// environmentRoot is the root of the filesystem the container signals are read from.
+var environmentRoot = "/"
+
+// cgroupRuntimes maps markers of the cgroup paths of the init process to the container
+// runtime that created them, in the order they are checked.
+var cgroupRuntimes = []struct{ marker, runtime string }{
+	{"kubepods", "kubernetes"},
+	{"libpod", "podman"},
+	{"docker", "docker"},
+	{"lxc", "lxc"},
+	{"machine.slice/machine-", "systemd-nspawn"},
+}
+
+func readEnvironment() string {
+	return detectEnvironment(environmentRoot)
+}
+
+// detectEnvironment returns the container runtime the system under root runs in, e.g.
+// "docker", EnvironmentHost when it runs outside of a container and "" when it cannot
+// tell. Signals written in the root of the container are checked first, so that the
+// innermost runtime is reported for nested containers.
+func detectEnvironment(root string) string {
+	// Runtimes create these files in the root filesystem of the container.
+	if util.Exists(filepath.Join(root, ".dockerenv")) {
+		return "docker"
+	}
+	if util.Exists(filepath.Join(root, "run", ".containerenv")) {
+		return "podman"
+	}
+	// systemd records the container manager it was started by, e.g. systemd-nspawn.
+	if b, err := os.ReadFile(filepath.Join(root, "run", "systemd", "container")); err == nil {
+		if v := strings.TrimSpace(string(b)); v != "" {
+			return strings.ToLower(v)
+		}
+	}
+	// Container managers set the container variable in the environment of the init process.
+	if b, err := os.ReadFile(filepath.Join(root, "proc", "1", "environ")); err == nil {
+		for _, kv := range bytes.Split(b, []byte{0}) {
+			if v, ok := bytes.CutPrefix(kv, []byte("container=")); ok && len(v) > 0 {
+				return strings.ToLower(string(v))
+			}
+		}
+	}
+
+	if wsl := detectWSL(root); wsl != "" {
+		return wsl
+	}
+
+	b, err := os.ReadFile(filepath.Join(root, "proc", "1", "cgroup"))
+	if err != nil {
+		return ""
+	}
+	return cgroupEnvironment(b)
+}
+
+// wslInteropMarkers are the binfmt_misc entries WSL registers to run Windows binaries,
+// WSLInterop-late is used by the releases that start systemd.
+var wslInteropMarkers = []string{"WSLInterop", "WSLInterop-late"}
+
+// detectWSL returns EnvironmentWSL1 or EnvironmentWSL2 when the system under root runs
+// in the Windows Subsystem for Linux and "" otherwise.
+func detectWSL(root string) string {
+	release, err := os.ReadFile(filepath.Join(root, "proc", "sys", "kernel", "osrelease"))
+	if err != nil {
+		release, _ = os.ReadFile(filepath.Join(root, "proc", "version"))
+	}
+	// WSL1 emulates the kernel and reports e.g. "4.4.0-19041-Microsoft", the WSL2 kernels
+	// are real and report e.g. "5.15.153.1-microsoft-standard-WSL2".
+	if bytes.Contains(release, []byte("-Microsoft")) {
+		return EnvironmentWSL1
+	}
+	if bytes.Contains(bytes.ToLower(release), []byte("microsoft")) {
+		return EnvironmentWSL2
+	}
+	// Custom WSL2 kernels do not carry the microsoft suffix, but the interop handler
+	// is still registered. WSL1 always reports its own kernel release.
+	for _, marker := range wslInteropMarkers {
+		if util.Exists(filepath.Join(root, "proc", "sys", "fs", "binfmt_misc", marker)) {
+			return EnvironmentWSL2
+		}
+	}
+	return ""
+}
+
+func readWSLDistro() string {
+	return wslDistro(environmentRoot)
+}
+
+// wslDistro returns the name of the WSL distribution the system under root runs as,
+// e.g. "Ubuntu-22.04", it is empty outside of WSL or when the name is not known.
+// WSL sets WSL_DISTRO_NAME in the environment of the processes it starts, which
+// does not include init when it is systemd, so the own environment is checked too.
+func wslDistro(root string) string {
+	if detectWSL(root) == "" {
+		return ""
+	}
+	for _, pid := range []string{"1", "self"} {
+		b, err := os.ReadFile(filepath.Join(root, "proc", pid, "environ"))
+		if err != nil {
+			continue
+		}
+		for _, kv := range bytes.Split(b, []byte{0}) {
+			if v, ok := bytes.CutPrefix(kv, []byte("WSL_DISTRO_NAME=")); ok && len(v) > 0 {
+				return string(v)
+			}
+		}
+	}
+	return ""
+}
+
+// cgroupEnvironment returns the container runtime found in the /proc/1/cgroup content b,
+// EnvironmentHost when the init process is in host cgroups and "" when the cgroup
+// namespace hides the paths.
+func cgroupEnvironment(b []byte) string {
+	var paths []string
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		// hierarchy-ID:controller-list:cgroup-path
+		fields := strings.SplitN(scanner.Text(), ":", 3)
+		if len(fields) == 3 {
+			paths = append(paths, fields[2])
+		}
+	}
+	for _, r := range cgroupRuntimes {
+		for _, p := range paths {
+			if strings.Contains(p, r.marker) {
+				return r.runtime
+			}
+		}
+	}
+	// A private cgroup namespace, as used by containers on cgroup v2, shows the init
+	// process in the root cgroup, while the host places it in init.scope.
+	if len(paths) == 0 || (len(paths) == 1 && paths[0] == "/") {
+		return ""
+	}
+	return EnvironmentHost
+}
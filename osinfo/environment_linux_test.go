@@ -0,0 +1,201 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package osinfo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/osconfig/util/utiltest"
+)
+
+func TestDetectEnvironment(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name: "docker",
+			files: map[string]string{
+				".dockerenv":    "",
+				"proc/1/cgroup": "12:memory:/docker/3f4b2c1d\n0::/docker/3f4b2c1d\n",
+			},
+			want: "docker",
+		},
+		{
+			name:  "docker cgroup v1 without dockerenv",
+			files: map[string]string{"proc/1/cgroup": "12:memory:/docker/3f4b2c1d\n11:cpu,cpuacct:/docker/3f4b2c1d\n"},
+			want:  "docker",
+		},
+		{
+			name:  "podman",
+			files: map[string]string{"run/.containerenv": "engine=\"podman-4.9.3\"\n"},
+			want:  "podman",
+		},
+		{
+			name:  "systemd-nspawn",
+			files: map[string]string{"run/systemd/container": "systemd-nspawn\n", "proc/1/cgroup": "0::/init.scope\n"},
+			want:  "systemd-nspawn",
+		},
+		{
+			name:  "systemd-nspawn init environment",
+			files: map[string]string{"proc/1/environ": "PATH=/usr/bin\x00container=systemd-nspawn\x00container_uuid=0f1e\x00"},
+			want:  "systemd-nspawn",
+		},
+		{
+			name:  "kubernetes",
+			files: map[string]string{"proc/1/cgroup": "0::/kubepods.slice/kubepods-burstable.slice/cri-containerd-9a1b.scope\n"},
+			want:  "kubernetes",
+		},
+		{
+			name: "docker nested in lxc",
+			files: map[string]string{
+				".dockerenv":     "",
+				"proc/1/environ": "container=lxc\x00",
+				"proc/1/cgroup":  "0::/lxc.payload.outer/docker/3f4b2c1d\n",
+			},
+			want: "docker",
+		},
+		{
+			name:  "bare metal cgroup v2",
+			files: map[string]string{"proc/1/cgroup": "0::/init.scope\n"},
+			want:  EnvironmentHost,
+		},
+		{
+			name:  "bare metal cgroup v1",
+			files: map[string]string{"proc/1/cgroup": "12:memory:/\n11:cpu,cpuacct:/\n1:name=systemd:/init.scope\n", "proc/1/environ": "HOME=/\x00TERM=linux\x00"},
+			want:  EnvironmentHost,
+		},
+		{
+			name: "wsl1",
+			files: map[string]string{
+				"proc/sys/kernel/osrelease": "4.4.0-19041-Microsoft\n",
+				"proc/version":              "Linux version 4.4.0-19041-Microsoft (Microsoft@Microsoft.com) (gcc version 5.4.0 (GCC) ) #1237-Microsoft Sat Sep 11 14:32:00 PST 2021\n",
+			},
+			want: EnvironmentWSL1,
+		},
+		{
+			name: "wsl2",
+			files: map[string]string{
+				"proc/sys/kernel/osrelease": "5.15.153.1-microsoft-standard-WSL2\n",
+				"proc/1/cgroup":             "0::/init.scope\n",
+			},
+			want: EnvironmentWSL2,
+		},
+		{
+			name:  "wsl2 legacy kernel version",
+			files: map[string]string{"proc/version": "Linux version 4.19.104-microsoft-standard (oe-user@oe-host) (gcc version 8.2.0 (GCC)) #1 SMP Wed Feb 19 06:37:35 UTC 2020\n"},
+			want:  EnvironmentWSL2,
+		},
+		{
+			name: "wsl2 custom kernel",
+			files: map[string]string{
+				"proc/sys/kernel/osrelease":          "6.6.36-custom\n",
+				"proc/sys/fs/binfmt_misc/WSLInterop": "enabled\ninterpreter /init\n",
+			},
+			want: EnvironmentWSL2,
+		},
+		{
+			name: "docker in wsl2",
+			files: map[string]string{
+				".dockerenv":                "",
+				"proc/sys/kernel/osrelease": "5.15.153.1-microsoft-standard-WSL2\n",
+			},
+			want: "docker",
+		},
+		{
+			name:  "private cgroup namespace",
+			files: map[string]string{"proc/1/cgroup": "0::/\n"},
+			want:  "",
+		},
+		{
+			name:  "unknown",
+			files: map[string]string{},
+			want:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for name, content := range tt.files {
+				path := filepath.Join(root, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			utiltest.AssertEquals(t, detectEnvironment(root), tt.want)
+		})
+	}
+}
+
+func TestWSLDistro(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name: "init environment",
+			files: map[string]string{
+				"proc/sys/kernel/osrelease": "5.15.153.1-microsoft-standard-WSL2\n",
+				"proc/1/environ":            "PATH=/bin\x00WSL_DISTRO_NAME=Ubuntu-22.04\x00",
+			},
+			want: "Ubuntu-22.04",
+		},
+		{
+			name: "own environment with systemd init",
+			files: map[string]string{
+				"proc/sys/kernel/osrelease": "5.15.153.1-microsoft-standard-WSL2\n",
+				"proc/1/environ":            "container=\x00",
+				"proc/self/environ":         "HOME=/root\x00WSL_DISTRO_NAME=Debian\x00WSL_INTEROP=/run/WSL/1_interop\x00",
+			},
+			want: "Debian",
+		},
+		{
+			name:  "wsl without name",
+			files: map[string]string{"proc/sys/kernel/osrelease": "4.4.0-19041-Microsoft\n"},
+			want:  "",
+		},
+		{
+			name:  "not wsl",
+			files: map[string]string{"proc/sys/kernel/osrelease": "6.1.0-29-cloud-amd64\n", "proc/1/environ": "WSL_DISTRO_NAME=Ubuntu\x00"},
+			want:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for name, content := range tt.files {
+				path := filepath.Join(root, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			utiltest.AssertEquals(t, wslDistro(root), tt.want)
+		})
+	}
+}
@@ -0,0 +1,35 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package osinfo
+
+import "strings"
+
+// Locale returns the locale of the system as a BCP 47 tag, e.g. "en-US" or "de-DE",
+// empty when it is unknown.
+func Locale() string {
+	return normalizeLocale(systemLocale())
+}
+
+// normalizeLocale converts POSIX locale names like "en_US.UTF-8" or "de_DE@euro" to BCP 47
+// tags. The "C" and "POSIX" locales do not name a language and are reported as unknown.
+func normalizeLocale(locale string) string {
+	if i := strings.IndexAny(locale, ".@"); i >= 0 {
+		locale = locale[:i]
+	}
+	if locale == "C" || locale == "POSIX" {
+		return ""
+	}
+	return strings.ReplaceAll(locale, "_", "-")
+}
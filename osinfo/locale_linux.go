@@ -0,0 +1,28 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package osinfo
+
+import "os"
+
+// systemLocale returns the locale dates are formatted in, LC_ALL overrides LC_TIME which
+// overrides LANG.
+func systemLocale() string {
+	for _, env := range []string{"LC_ALL", "LC_TIME", "LANG"} {
+		if l := os.Getenv(env); l != "" {
+			return l
+		}
+	}
+	return ""
+}
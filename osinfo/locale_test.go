@@ -0,0 +1,37 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package osinfo
+
+import "testing"
+
+func TestNormalizeLocale(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{locale: "en-US", want: "en-US"},
+		{locale: "en_US.UTF-8", want: "en-US"},
+		{locale: "de_DE@euro", want: "de-DE"},
+		{locale: "en_GB", want: "en-GB"},
+		{locale: "C.UTF-8", want: ""},
+		{locale: "POSIX", want: ""},
+		{locale: "", want: ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeLocale(tt.locale); got != tt.want {
+			t.Errorf("normalizeLocale(%q) = %q, want %q", tt.locale, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package osinfo
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	kernel32                       = windows.NewLazySystemDLL("kernel32.dll")
+	procGetSystemDefaultLocaleName = kernel32.NewProc("GetSystemDefaultLocaleName")
+)
+
+// localeNameMaxLength is LOCALE_NAME_MAX_LENGTH, the size of a locale name including
+// the terminating null.
+const localeNameMaxLength = 85
+
+// systemLocale returns the system default locale, e.g. "en-US".
+// https://learn.microsoft.com/en-us/windows/win32/api/winnls/nf-winnls-getsystemdefaultlocalename
+func systemLocale() string {
+	buf := make([]uint16, localeNameMaxLength)
+	if ret, _, _ := procGetSystemDefaultLocaleName.Call(
+		uintptr(unsafe.Pointer(&buf[0])),
+		uintptr(len(buf))); ret == 0 {
+		return ""
+	}
+	return windows.UTF16ToString(buf)
+}
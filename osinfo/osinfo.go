@@ -17,6 +17,7 @@ package osinfo
 
 import (
 	"context"
+	"strings"
 )
 
 const (
@@ -24,6 +25,14 @@ const (
 	DefaultShortNameLinux = "linux"
 	// DefaultShortNameWindows is the default shortname used for Windows system.
 	DefaultShortNameWindows = "windows"
+	// EnvironmentHost is the Environment of systems that do not run in a container, on
+	// bare metal or in a VM.
+	EnvironmentHost = "host"
+	// EnvironmentWSL1 and EnvironmentWSL2 are the Environment of systems that run in the
+	// Windows Subsystem for Linux, WSL1 translates system calls while WSL2 runs a kernel in
+	// a lightweight VM.
+	EnvironmentWSL1 = "wsl1"
+	EnvironmentWSL2 = "wsl2"
 )
 
 // Provider is an interface for OSInfo extraction on different systems.
@@ -46,19 +55,55 @@ func (defaultProvider) GetOSInfo(ctx context.Context) (OSInfo, error) {
 // OSInfo describes an operating system.
 type OSInfo struct {
 	Hostname, LongName, ShortName, Version, KernelVersion, KernelRelease, Architecture string
+
+	// Variant, CPEName and BuildID are the VARIANT, CPE_NAME and BUILD_ID fields of
+	// /etc/os-release, empty when the release file does not set them.
+	Variant, CPEName, BuildID string
+
+	// Environment is the container runtime the system runs in, e.g. "docker" or
+	// "systemd-nspawn", EnvironmentHost outside of containers and empty when unknown.
+	Environment string
+
+	// WSLDistro is the name of the WSL distribution, e.g. "Ubuntu-22.04", when the
+	// Environment is EnvironmentWSL1 or EnvironmentWSL2.
+	WSLDistro string
 }
 
-// NormalizeArchitecture attempts to standardize architecture naming.
+// architectureAliases maps architecture names reported by package managers and
+// operating systems to the canonical names used across the inventory.
+var architectureAliases = map[string]string{
+	"x86_64": "x86_64",
+	"amd64":  "x86_64",
+	"x64":    "x86_64",
+	"x86-64": "x86_64",
+	"64-bit": "x86_64",
+
+	"x86_32": "x86_32",
+	"i386":   "x86_32",
+	"i486":   "x86_32",
+	"i586":   "x86_32",
+	"i686":   "x86_32",
+	"386":    "x86_32",
+	"x86":    "x86_32",
+	"32-bit": "x86_32",
+
+	"aarch64": "aarch64",
+	"arm64":   "aarch64",
+
+	"all":    "all",
+	"noarch": "all",
+	"any":    "all",
+}
+
+// NormalizeArchitecture attempts to standardize architecture naming,
+// unknown architectures are returned unchanged.
 func NormalizeArchitecture(arch string) string {
-	switch arch {
-	case "amd64", "64-bit":
-		arch = "x86_64"
-	case "i386", "i686", "32-bit":
-		arch = "x86_32"
-	case "noarch":
-		arch = "all"
+	if normalized, ok := architectureAliases[strings.ToLower(arch)]; ok {
+		return normalized
 	}
 	return arch
 }
 
 type osNameAndVersionProvider func() (shortName string, longName string, version string)
+
+type osReleaseFieldsProvider func() (variant string, cpeName string, buildID string)
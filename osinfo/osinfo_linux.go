@@ -61,7 +61,13 @@ func Get() (OSInfo, error) {
 // LinuxOsInfoProvider is a provider of OSInfo for the linux based systems.
 type LinuxOsInfoProvider struct {
 	nameAndVersionProvider osNameAndVersionProvider
-	uts                    unix.Utsname
+	// releaseFieldsProvider returns the extended os-release fields, they are left empty when nil.
+	releaseFieldsProvider osReleaseFieldsProvider
+	// environmentProvider returns the container environment, it is left empty when nil.
+	environmentProvider func() string
+	// wslDistroProvider returns the WSL distribution name, it is left empty when nil.
+	wslDistroProvider func() string
+	uts               unix.Utsname
 }
 
 // NewLinuxOsInfoProvider is a constructor function for LinuxOsInfoProvider.
@@ -73,6 +79,9 @@ func NewLinuxOsInfoProvider(nameAndVersionProvider osNameAndVersionProvider) (*L
 
 	return &LinuxOsInfoProvider{
 		nameAndVersionProvider: nameAndVersionProvider,
+		releaseFieldsProvider:  readOsReleaseFields,
+		environmentProvider:    readEnvironment,
+		wslDistroProvider:      readWSLDistro,
 		uts:                    uts,
 	}, nil
 }
@@ -80,12 +89,31 @@ func NewLinuxOsInfoProvider(nameAndVersionProvider osNameAndVersionProvider) (*L
 // GetOSInfo gather all required information and returns OSInfo.
 func (oip *LinuxOsInfoProvider) GetOSInfo(ctx context.Context) (OSInfo, error) {
 	short, long, version := oip.nameAndVersionProvider()
+	var variant, cpeName, buildID string
+	if oip.releaseFieldsProvider != nil {
+		variant, cpeName, buildID = oip.releaseFieldsProvider()
+	}
+	var environment string
+	if oip.environmentProvider != nil {
+		environment = oip.environmentProvider()
+	}
+	var wslDistro string
+	if oip.wslDistroProvider != nil {
+		wslDistro = oip.wslDistroProvider()
+	}
 
 	return OSInfo{
 		ShortName: short,
 		LongName:  long,
 		Version:   version,
 
+		Variant: variant,
+		CPEName: cpeName,
+		BuildID: buildID,
+
+		Environment: environment,
+		WSLDistro:   wslDistro,
+
 		Hostname:      oip.hostName(),
 		Architecture:  oip.architecture(),
 		KernelRelease: oip.kernelRelease(),
@@ -108,6 +136,16 @@ func (oip *LinuxOsInfoProvider) kernelVersion() string {
 	return stringFromUtsField(oip.uts.Version)
 }
 
+// runningKernelRelease returns the release of the running kernel, it is empty when
+// uname fails.
+func runningKernelRelease() string {
+	var uts unix.Utsname
+	if err := unix.Uname(&uts); err != nil {
+		return ""
+	}
+	return stringFromUtsField(uts.Release)
+}
+
 func stringFromUtsField(field [65]byte) string {
 	// unix.Utsname Fields are [65]byte so we need to trim any trailing null characters.
 	return string(bytes.TrimRight(field[:], "\x00"))
@@ -176,6 +214,38 @@ func parseOsRelease(releaseDetails string) (shortName, longName, version string)
 	return shortName, longName, version
 }
 
+// readOsReleaseFields returns the extended fields of /etc/os-release, they are empty
+// when the file does not exist.
+func readOsReleaseFields() (variant, cpeName, buildID string) {
+	b, err := ioutil.ReadFile(defaultReleaseFilepath)
+	if err != nil {
+		return "", "", ""
+	}
+	return parseOsReleaseFields(string(b))
+}
+
+func parseOsReleaseFields(releaseDetails string) (variant, cpeName, buildID string) {
+	scanner := bufio.NewScanner(strings.NewReader(releaseDetails))
+
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(value, `"'`)
+		switch key {
+		case "VARIANT":
+			variant = value
+		case "CPE_NAME":
+			cpeName = value
+		case "BUILD_ID":
+			buildID = value
+		}
+	}
+
+	return variant, cpeName, buildID
+}
+
 func parseEnterpriseRelease(releaseDetails string) (shortName string, longName string, version string) {
 	shortName = DefaultShortNameLinux
 
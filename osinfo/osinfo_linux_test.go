@@ -181,6 +181,75 @@ func Test_parseOsRelease(t *testing.T) {
 
 }
 
+const rhelReleaseFileContent = `NAME="Red Hat Enterprise Linux"
+VERSION="9.4 (Plow)"
+ID="rhel"
+VARIANT="Server"
+VERSION_ID="9.4"
+PRETTY_NAME="Red Hat Enterprise Linux 9.4 (Plow)"
+CPE_NAME="cpe:/o:redhat:enterprise_linux:9::baseos"
+BUILD_ID=20240419.0
+`
+
+func Test_parseOsReleaseFields(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		wantVariant string
+		wantCPEName string
+		wantBuildID string
+	}{
+		{
+			name:        "RHEL 9",
+			input:       rhelReleaseFileContent,
+			wantVariant: "Server",
+			wantCPEName: "cpe:/o:redhat:enterprise_linux:9::baseos",
+			wantBuildID: "20240419.0",
+		},
+		{
+			name:  "Debian 10, fields not set",
+			input: debianReleaseFileContent,
+		},
+		{
+			name:        "Single quoted values",
+			input:       "VARIANT='Cloud Edition'\nCPE_NAME='cpe:/o:fedoraproject:fedora:40'\n",
+			wantVariant: "Cloud Edition",
+			wantCPEName: "cpe:/o:fedoraproject:fedora:40",
+		},
+		{
+			name:  "Empty content",
+			input: ``,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			variant, cpeName, buildID := parseOsReleaseFields(tt.input)
+			if variant != tt.wantVariant || cpeName != tt.wantCPEName || buildID != tt.wantBuildID {
+				t.Errorf("parseOsReleaseFields() = (%q, %q, %q), want: (%q, %q, %q)", variant, cpeName, buildID, tt.wantVariant, tt.wantCPEName, tt.wantBuildID)
+			}
+		})
+	}
+}
+
+func Test_readOsReleaseFields(t *testing.T) {
+	releaseFile := filepath.Join(t.TempDir(), "os-release")
+	if err := os.WriteFile(releaseFile, []byte(rhelReleaseFileContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	overrideDefaultReleaseFilepath(t, releaseFile)
+
+	variant, cpeName, buildID := readOsReleaseFields()
+	if variant != "Server" || cpeName != "cpe:/o:redhat:enterprise_linux:9::baseos" || buildID != "20240419.0" {
+		t.Errorf("readOsReleaseFields() = (%q, %q, %q), want the fields of %s", variant, cpeName, buildID, releaseFile)
+	}
+
+	overrideDefaultReleaseFilepath(t, filepath.Join(t.TempDir(), "missing"))
+	if variant, cpeName, buildID := readOsReleaseFields(); variant != "" || cpeName != "" || buildID != "" {
+		t.Errorf("readOsReleaseFields() without a release file = (%q, %q, %q), want empty fields", variant, cpeName, buildID)
+	}
+}
+
 func Test_parseEnterpriseRelease(t *testing.T) {
 	tests := []struct {
 		name string
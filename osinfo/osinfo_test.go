@@ -0,0 +1,56 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package osinfo
+
+import "testing"
+
+func TestNormalizeArchitecture(t *testing.T) {
+	tests := []struct {
+		arch string
+		want string
+	}{
+		{arch: "x86_64", want: "x86_64"},
+		{arch: "amd64", want: "x86_64"},
+		{arch: "AMD64", want: "x86_64"},
+		{arch: "x64", want: "x86_64"},
+		{arch: "64-bit", want: "x86_64"},
+		{arch: "i386", want: "x86_32"},
+		{arch: "i686", want: "x86_32"},
+		{arch: "386", want: "x86_32"},
+		{arch: "32-bit", want: "x86_32"},
+		{arch: "aarch64", want: "aarch64"},
+		{arch: "arm64", want: "aarch64"},
+		{arch: "noarch", want: "all"},
+		{arch: "all", want: "all"},
+		{arch: "any", want: "all"},
+		{arch: "s390x", want: "s390x"},
+		{arch: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.arch, func(t *testing.T) {
+			if got := NormalizeArchitecture(tt.arch); got != tt.want {
+				t.Errorf("NormalizeArchitecture(%q) = %q, want: %q", tt.arch, got, tt.want)
+			}
+		})
+	}
+
+	// Canonical names have to map to themselves, so normalizing twice is safe.
+	for alias, canonical := range architectureAliases {
+		if got := NormalizeArchitecture(canonical); got != canonical {
+			t.Errorf("canonical architecture %q (alias of %q) is normalized to %q", canonical, alias, got)
+		}
+	}
+}
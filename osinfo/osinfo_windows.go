@@ -133,6 +133,10 @@ func getKernelInfo() (string, string, error) {
 	return getVersion(info, langCodePage)
 }
 
+// runningKernelRelease is not checked on Windows, kernel updates there require a reboot
+// which also restarts the agent.
+var runningKernelRelease func() string
+
 type win32OperatingSystem struct {
 	Caption, Version string
 }
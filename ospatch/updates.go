@@ -28,6 +28,10 @@ import (
 	"github.com/GoogleCloudPlatform/osconfig/util"
 )
 
+// ErrRebootRequiredUnknown is returned when no supported source can tell whether a
+// system reboot is required.
+var ErrRebootRequiredUnknown = errors.New("no recognized package manager installed, can't determine if reboot is required")
+
 var (
 	rpmquery     = "/usr/bin/rpmquery"
 	procStatPath = "/proc/stat"
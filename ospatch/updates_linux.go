@@ -19,9 +19,9 @@ package ospatch
 
 import (
 	"context"
-	"errors"
 	"io/ioutil"
 	"os"
+	"strings"
 
 	"github.com/GoogleCloudPlatform/osconfig/clog"
 	"github.com/GoogleCloudPlatform/osconfig/packages"
@@ -32,25 +32,58 @@ var rebootRequiredFile = "/var/run/reboot-required"
 
 // SystemRebootRequired checks whether a system reboot is required.
 func SystemRebootRequired(ctx context.Context) (bool, error) {
+	required, _, err := SystemRebootRequiredReason(ctx)
+	return required, err
+}
+
+// SystemRebootRequiredReason checks whether a system reboot is required and describes
+// the signal that requires it.
+func SystemRebootRequiredReason(ctx context.Context) (bool, string, error) {
 	if packages.AptExists {
 		clog.Debugf(ctx, "Checking if reboot required by looking at %s.", rebootRequiredFile)
 		data, err := ioutil.ReadFile(rebootRequiredFile)
 		if os.IsNotExist(err) {
 			clog.Debugf(ctx, "/var/run/reboot-required does not exist, indicating no reboot is required.")
-			return false, nil
+			return false, "", nil
 		}
 		if err != nil {
-			return false, err
+			return false, "", err
 		}
 		clog.Debugf(ctx, "/var/run/reboot-required exists indicating a reboot is required, content:\n%s", string(data))
-		return true, nil
+		return true, aptRebootReason(), nil
 	}
 	if ok := util.Exists(rpmquery); ok {
 		clog.Debugf(ctx, "Checking if reboot required by querying rpm database.")
-		return rpmReboot(ctx)
+		required, err := rpmReboot(ctx)
+		if err != nil || !required {
+			return false, "", err
+		}
+		return true, "core system packages were updated after the last boot", nil
 	}
 
-	return false, errors.New("no recognized package manager installed, can't determine if reboot is required")
+	return false, "", ErrRebootRequiredUnknown
+}
+
+// aptRebootReason names the packages listed in the .pkgs companion of the
+// reboot-required file, when it exists.
+func aptRebootReason() string {
+	reason := rebootRequiredFile + " exists"
+	data, err := ioutil.ReadFile(rebootRequiredFile + ".pkgs")
+	if err != nil {
+		return reason
+	}
+	var pkgs []string
+	seen := map[string]bool{}
+	for _, pkg := range strings.Fields(string(data)) {
+		if !seen[pkg] {
+			seen[pkg] = true
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	if len(pkgs) == 0 {
+		return reason
+	}
+	return reason + ", required by " + strings.Join(pkgs, ", ")
 }
 
 // InstallWUAUpdates is the linux stub for InstallWUAUpdates.
@@ -18,6 +18,8 @@ import (
 	"context"
 	"errors"
 	"os"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"testing"
 
@@ -156,6 +158,111 @@ func TestSystemRebootRequiredRpm(t *testing.T) {
 	}
 }
 
+func TestSystemRebootRequiredReason(t *testing.T) {
+	ctx := context.Background()
+	originalRunner := runner
+	originalProcStatPath := procStatPath
+	mockCtrl := gomock.NewController(t)
+	t.Cleanup(func() {
+		runner = originalRunner
+		procStatPath = originalProcStatPath
+		mockCtrl.Finish()
+	})
+
+	tests := []struct {
+		desc       string
+		setup      func(t *testing.T)
+		wantReboot bool
+		// wantReason is compared without the reboot-required file path prefix.
+		wantReason string
+		wantErr    error
+	}{
+		{
+			desc: "apt reboot-required file",
+			setup: func(t *testing.T) {
+				setAptExists(t, true)
+				setRebootRequiredFile(t, filepath.Join(t.TempDir(), "reboot-required"))
+				writeFile(t, rebootRequiredFile, "*** System restart required ***\n")
+			},
+			wantReboot: true,
+			wantReason: " exists",
+		},
+		{
+			desc: "apt reboot-required file with packages",
+			setup: func(t *testing.T) {
+				setAptExists(t, true)
+				setRebootRequiredFile(t, filepath.Join(t.TempDir(), "reboot-required"))
+				writeFile(t, rebootRequiredFile, "*** System restart required ***\n")
+				writeFile(t, rebootRequiredFile+".pkgs", "linux-image-6.1.0-29-cloud-amd64\nlibc6\nlibc6\n")
+			},
+			wantReboot: true,
+			wantReason: " exists, required by linux-image-6.1.0-29-cloud-amd64, libc6",
+		},
+		{
+			desc: "apt without reboot-required file",
+			setup: func(t *testing.T) {
+				setAptExists(t, true)
+				setRebootRequiredFile(t, filepath.Join(t.TempDir(), "reboot-required"))
+			},
+		},
+		{
+			desc: "rpm core package installed after boot",
+			setup: func(t *testing.T) {
+				setAptExists(t, false)
+				setRpmquery(t, createTempFile(t))
+				procStatPath = filepath.Join(t.TempDir(), "stat")
+				writeFile(t, procStatPath, "cpu  1 2 3\nbtime 1000\n")
+
+				mockCommandRunner := utilmocks.NewMockCommandRunner(mockCtrl)
+				runner = mockCommandRunner
+				mockCommandRunner.EXPECT().Run(ctx, gomock.Any()).Return([]byte("900\n2000\n"), nil, nil).Times(1)
+			},
+			wantReboot: true,
+			wantReason: "core system packages were updated after the last boot",
+		},
+		{
+			desc: "rpm packages installed before boot",
+			setup: func(t *testing.T) {
+				setAptExists(t, false)
+				setRpmquery(t, createTempFile(t))
+				procStatPath = filepath.Join(t.TempDir(), "stat")
+				writeFile(t, procStatPath, "btime 1000\n")
+
+				mockCommandRunner := utilmocks.NewMockCommandRunner(mockCtrl)
+				runner = mockCommandRunner
+				mockCommandRunner.EXPECT().Run(ctx, gomock.Any()).Return([]byte("900\n"), nil, nil).Times(1)
+			},
+		},
+		{
+			desc: "unsupported package manager",
+			setup: func(t *testing.T) {
+				setAptExists(t, false)
+				setRpmquery(t, "/non_existing_file")
+			},
+			wantErr: ErrRebootRequiredUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			tt.setup(t)
+
+			gotReboot, gotReason, gotErr := SystemRebootRequiredReason(ctx)
+
+			utiltest.AssertEquals(t, gotReboot, tt.wantReboot)
+			utiltest.AssertEquals(t, strings.TrimPrefix(gotReason, rebootRequiredFile), tt.wantReason)
+			utiltest.AssertErrorMatch(t, gotErr, tt.wantErr)
+		})
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
 func TestInstallWUAUpdates(t *testing.T) {
 	if err := InstallWUAUpdates(context.Background()); err != nil {
 		t.Errorf("InstallWUAUpdates() on linux stub should not return an error, got: %v", err)
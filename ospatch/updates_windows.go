@@ -29,6 +29,13 @@ import (
 
 // SystemRebootRequired checks whether a system reboot is required.
 func SystemRebootRequired(ctx context.Context) (bool, error) {
+	required, _, err := SystemRebootRequiredReason(ctx)
+	return required, err
+}
+
+// SystemRebootRequiredReason checks whether a system reboot is required and describes
+// the signal that requires it.
+func SystemRebootRequiredReason(ctx context.Context) (bool, string, error) {
 	// https://docs.microsoft.com/en-us/windows/win32/api/winbase/nf-winbase-movefileexw#remarks
 	clog.Debugf(ctx, "Checking for PendingFileRenameOperations")
 	k, err := registry.OpenKey(registry.LOCAL_MACHINE, `SYSTEM\CurrentControlSet\Control\Session Manager`, registry.QUERY_VALUE)
@@ -39,13 +46,13 @@ func SystemRebootRequired(ctx context.Context) (bool, error) {
 
 			if len(val) > 0 {
 				clog.Infof(ctx, "PendingFileRenameOperations indicate a reboot is required: %q", val)
-				return true, nil
+				return true, "PendingFileRenameOperations are pending", nil
 			}
 		} else if err != registry.ErrNotExist {
-			return false, err
+			return false, "", err
 		}
 	} else if err != registry.ErrNotExist {
-		return false, err
+		return false, "", err
 	}
 
 	regKeys := []string{
@@ -61,13 +68,13 @@ func SystemRebootRequired(ctx context.Context) (bool, error) {
 		if err == nil {
 			k.Close()
 			clog.Infof(ctx, "%s exists indicating a reboot is required.", key)
-			return true, nil
+			return true, key + " exists", nil
 		} else if err != registry.ErrNotExist {
-			return false, err
+			return false, "", err
 		}
 	}
 
-	return false, nil
+	return false, "", nil
 }
 
 func checkFilters(ctx context.Context, updt *packages.IUpdate, kbExcludes, classFilter, exclusive_patches []string) (ok bool, err error) {
@@ -17,7 +17,6 @@ package packages
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
@@ -43,9 +42,14 @@ var (
 		"status":         "${db:Status-Status}",
 		"source_name":    "${source:Package}",
 		"source_version": "${source:Version}",
+		"installed_size": "${Installed-Size}",
 	}
 
-	dpkgQueryArgs     = []string{"-W", "-f", formatFieldsMappingToFormattingString(dpkgPackageFieldsMapping)}
+	dpkgFreeTextFields = []freeTextField{
+		{selector: "${Maintainer}", set: func(pm *packageMetadata, v string) { pm.Maintainer = v }},
+	}
+
+	dpkgQueryArgs     = []string{"-W", "-f", formatFieldsMappingToFormattingString(dpkgPackageFieldsMapping, dpkgFreeTextFields)}
 	dpkgRepairArgs    = []string{"--configure", "-a"}
 	aptGetInstallArgs = []string{"install", "-y"}
 	aptGetRemoveArgs  = []string{"remove", "-y"}
@@ -58,6 +62,9 @@ var (
 	allowDowngradesArg   = "--allow-downgrades"
 
 	dpkgErr = []byte("dpkg --configure -a")
+
+	// aptExtendedStates records the packages apt installed automatically as dependencies.
+	aptExtendedStates = "/var/lib/apt/extended_states"
 )
 
 func init() {
@@ -296,7 +303,10 @@ func parseAptUpdates(ctx context.Context, data []byte, showNew bool) []*PkgInfo
 		}
 		ver := bytes.Trim(pkg[1], "(")             // (246.0.0-0 => 246.0.0-0
 		arch := bytes.Trim(pkg[len(pkg)-1], "[])") // [all]) => all
-		pkgs = append(pkgs, &PkgInfo{Name: string(pkg[0]), Arch: osinfo.NormalizeArchitecture(string(arch)), Version: string(ver), Type: typeDebian})
+		// Ubuntu:18.04/bionic-updates, Ubuntu:18.04/bionic-security or Debian-Security:9/stable
+		repository := bytes.Join(pkg[2:len(pkg)-1], []byte(" "))
+		security := bytes.Contains(bytes.ToLower(repository), []byte("security"))
+		pkgs = append(pkgs, &PkgInfo{Name: string(pkg[0]), Arch: osinfo.NormalizeArchitecture(string(arch)), Version: string(ver), Type: typeDebian, Repository: string(repository), Security: security})
 	}
 	return pkgs
 }
@@ -375,8 +385,8 @@ func parseInstalledDebPackages(ctx context.Context, data []byte) []*PkgInfo {
 
 	var result []*PkgInfo
 	for _, entry := range entries {
-		var dpkg packageMetadata
-		if err := json.Unmarshal(entry, &dpkg); err != nil {
+		dpkg, err := parsePackageMetadata(entry, dpkgFreeTextFields)
+		if err != nil {
 			clog.Debugf(ctx, "unable to parse dpkg package info, err %s, raw - %s", err, string(entry))
 			continue
 		}
@@ -392,6 +402,61 @@ func parseInstalledDebPackages(ctx context.Context, data []byte) []*PkgInfo {
 	return result
 }
 
+// setExplicitDebPackages sets Explicit on pkgs, packages are explicit unless the apt
+// extended states mark them as automatically installed. Nothing is set without apt.
+func setExplicitDebPackages(ctx context.Context, pkgs []*PkgInfo) {
+	if !AptExists {
+		return
+	}
+	data, err := os.ReadFile(aptExtendedStates)
+	// apt only creates the file once it installs a package automatically.
+	if err != nil && !os.IsNotExist(err) {
+		clog.Debugf(ctx, "Unable to read apt extended states: %v", err)
+		return
+	}
+	auto := parseAptExtendedStates(data)
+	for _, pkg := range pkgs {
+		explicit := !auto[pkg.Name+":"+pkg.Arch] && !(pkg.Arch == "all" && auto[pkg.Name])
+		pkg.Explicit = &explicit
+	}
+}
+
+// parseAptExtendedStates returns the automatically installed packages keyed by
+// "name:arch", with the normalized architecture, and by name.
+func parseAptExtendedStates(data []byte) map[string]bool {
+	/*
+	   Package: libc6
+	   Architecture: amd64
+	   Auto-Installed: 1
+	*/
+	auto := map[string]bool{}
+	for _, paragraph := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n\n") {
+		var name, arch string
+		var isAuto bool
+		for _, ln := range strings.Split(paragraph, "\n") {
+			key, value, ok := strings.Cut(ln, ":")
+			if !ok {
+				continue
+			}
+			value = strings.TrimSpace(value)
+			switch key {
+			case "Package":
+				name = value
+			case "Architecture":
+				arch = osinfo.NormalizeArchitecture(value)
+			case "Auto-Installed":
+				isAuto = value == "1"
+			}
+		}
+		if name == "" || !isAuto {
+			continue
+		}
+		auto[name] = true
+		auto[name+":"+arch] = true
+	}
+	return auto
+}
+
 // DpkgInstall installs a deb package.
 func DpkgInstall(ctx context.Context, path string) error {
 	_, err := run(ctx, dpkg, append(dpkgInstallArgs, path))
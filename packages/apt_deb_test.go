@@ -16,7 +16,9 @@ package packages
 import (
 	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"reflect"
 	"slices"
 	"testing"
@@ -239,7 +241,7 @@ func TestAptUpdates(t *testing.T) {
 					err:    nil,
 				},
 			},
-			expectedResults: []*PkgInfo{{Name: "google-cloud-sdk", Arch: "x86_64", Version: "246.0.0-0", Type: "deb"}},
+			expectedResults: []*PkgInfo{{Name: "google-cloud-sdk", Arch: "x86_64", Version: "246.0.0-0", Type: "deb", Repository: "cloud-sdk-stretch:cloud-sdk-stretch"}},
 			expectedError:   nil,
 		},
 		{
@@ -261,7 +263,7 @@ func TestAptUpdates(t *testing.T) {
 					err:    nil,
 				},
 			},
-			expectedResults: []*PkgInfo{{Name: "google-cloud-sdk", Arch: "x86_64", Version: "246.0.0-0", Type: "deb"}},
+			expectedResults: []*PkgInfo{{Name: "google-cloud-sdk", Arch: "x86_64", Version: "246.0.0-0", Type: "deb", Repository: "cloud-sdk-stretch:cloud-sdk-stretch"}},
 			expectedError:   nil,
 		},
 		{
@@ -283,7 +285,7 @@ func TestAptUpdates(t *testing.T) {
 					err:    nil,
 				},
 			},
-			expectedResults: []*PkgInfo{{Name: "google-cloud-sdk", Arch: "x86_64", Version: "246.0.0-0", Type: "deb"}},
+			expectedResults: []*PkgInfo{{Name: "google-cloud-sdk", Arch: "x86_64", Version: "246.0.0-0", Type: "deb", Repository: "cloud-sdk-stretch:cloud-sdk-stretch"}},
 			expectedError:   nil,
 		},
 		{
@@ -440,8 +442,8 @@ func TestAptUpdates(t *testing.T) {
 				},
 			},
 			expectedResults: []*PkgInfo{
-				{Name: "google-cloud-sdk", Arch: "x86_64", Version: "246.0.0-0", Type: "deb"},
-				{Name: "firmware-linux-free", Arch: "all", Version: "3.4", Type: "deb"},
+				{Name: "google-cloud-sdk", Arch: "x86_64", Version: "246.0.0-0", Type: "deb", Repository: "cloud-sdk-stretch:cloud-sdk-stretch"},
+				{Name: "firmware-linux-free", Arch: "all", Version: "3.4", Type: "deb", Repository: "Debian:9.9/stable"},
 			},
 			expectedError: nil,
 		},
@@ -467,7 +469,7 @@ func TestAptUpdates(t *testing.T) {
 				},
 			},
 			expectedResults: []*PkgInfo{
-				{Name: "google-cloud-sdk", Arch: "x86_64", Version: "246.0.0-0", Type: typeDebian},
+				{Name: "google-cloud-sdk", Arch: "x86_64", Version: "246.0.0-0", Type: typeDebian, Repository: "cloud-sdk-stretch:cloud-sdk-stretch"},
 			},
 			expectedError: nil,
 		},
@@ -498,7 +500,7 @@ func TestAptUpdates(t *testing.T) {
 				},
 			},
 			expectedResults: []*PkgInfo{
-				{Name: "google-cloud-sdk", Arch: "x86_64", Version: "246.0.0-0", Type: "deb"},
+				{Name: "google-cloud-sdk", Arch: "x86_64", Version: "246.0.0-0", Type: "deb", Repository: "cloud-sdk-stretch:cloud-sdk-stretch"},
 			},
 			expectedError: nil,
 		},
@@ -811,6 +813,21 @@ func TestParseInstalledDebpackages(t *testing.T) {
 				`{"package":"python3-gi","architecture":"amd64","version":"3.36.0-1","status":"installed","source_name":"pygobject","source_version":"3.36.0-1"}`),
 			want: []*PkgInfo{{Name: "python3-gi", Arch: "x86_64", Version: "3.36.0-1", Source: Source{Name: "pygobject", Version: "3.36.0-1"}, Type: "deb"}},
 		},
+		{
+			name:  "Installed size reported in KiB",
+			input: []byte(`{"package":"man-db","architecture":"amd64","version":"2.9.1-1","status":"installed","source_name":"man-db","source_version":"2.9.1-1","installed_size":"2848"}`),
+			want:  []*PkgInfo{{Name: "man-db", Arch: "x86_64", Version: "2.9.1-1", Source: Source{Name: "man-db", Version: "2.9.1-1"}, Type: "deb", Size: 2848 * 1024}},
+		},
+		{
+			name:  "Maintainer reported",
+			input: []byte(`{"package":"man-db","architecture":"amd64","version":"2.9.1-1","status":"installed","source_name":"man-db","source_version":"2.9.1-1"}` + "\x1fDebian Developers <debian-devel@lists.debian.org>"),
+			want:  []*PkgInfo{{Name: "man-db", Arch: "x86_64", Version: "2.9.1-1", Source: Source{Name: "man-db", Version: "2.9.1-1"}, Type: "deb", Maintainer: "Debian Developers <debian-devel@lists.debian.org>"}},
+		},
+		{
+			name:  "Maintainer with quotes and backslashes",
+			input: []byte(`{"package":"man-db","architecture":"amd64","version":"2.9.1-1","status":"installed","source_name":"man-db","source_version":"2.9.1-1"}` + "\x1f" + `Jane "JD" Doe \ Team <jd@example.com>`),
+			want:  []*PkgInfo{{Name: "man-db", Arch: "x86_64", Version: "2.9.1-1", Source: Source{Name: "man-db", Version: "2.9.1-1"}, Type: "deb", Maintainer: `Jane "JD" Doe \ Team <jd@example.com>`}},
+		},
 		{
 			name: "Skip entries that have status other than 'installed'",
 			input: []byte("" +
@@ -829,6 +846,46 @@ func TestParseInstalledDebpackages(t *testing.T) {
 	}
 }
 
+func TestSetExplicitDebPackages(t *testing.T) {
+	states := filepath.Join(t.TempDir(), "extended_states")
+	data := "Package: libc6\nArchitecture: amd64\nAuto-Installed: 1\n\n" +
+		"Package: tzdata\nArchitecture: amd64\nAuto-Installed: 1\n\n" +
+		"Package: vim\nArchitecture: amd64\nAuto-Installed: 0\n"
+	if err := os.WriteFile(states, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	utiltest.OverrideVariable(t, &aptExtendedStates, states)
+	utiltest.OverrideVariable(t, &AptExists, true)
+
+	pkgs := []*PkgInfo{
+		{Name: "libc6", Arch: "x86_64"},
+		{Name: "libc6", Arch: "x86_32"},
+		{Name: "tzdata", Arch: "all"},
+		{Name: "vim", Arch: "x86_64"},
+		{Name: "git", Arch: "x86_64"},
+	}
+	setExplicitDebPackages(testCtx, pkgs)
+
+	want := map[string]bool{"libc6:x86_64": false, "libc6:x86_32": true, "tzdata:all": false, "vim:x86_64": true, "git:x86_64": true}
+	for _, pkg := range pkgs {
+		if pkg.Explicit == nil {
+			t.Errorf("%s:%s Explicit not set", pkg.Name, pkg.Arch)
+			continue
+		}
+		utiltest.AssertEquals(t, *pkg.Explicit, want[pkg.Name+":"+pkg.Arch])
+	}
+}
+
+func TestSetExplicitDebPackagesWithoutApt(t *testing.T) {
+	utiltest.OverrideVariable(t, &AptExists, false)
+
+	pkgs := []*PkgInfo{{Name: "libc6", Arch: "x86_64"}}
+	setExplicitDebPackages(testCtx, pkgs)
+	if pkgs[0].Explicit != nil {
+		t.Errorf("Explicit = %v, want unset without apt", *pkgs[0].Explicit)
+	}
+}
+
 func TestParseAptUpdates(t *testing.T) {
 	normalCase := `
 Inst libldap-common [2.4.45+dfsg-1ubuntu1.2] (2.4.45+dfsg-1ubuntu1.3 Ubuntu:18.04/bionic-updates, Ubuntu:18.04/bionic-security [all])
@@ -848,8 +905,8 @@ Conf firmware-linux-free (3.4 Debian:9.9/stable [all])
 			input:   []byte(normalCase),
 			showNew: false,
 			want: []*PkgInfo{
-				{Name: "libldap-common", Arch: "all", Version: "2.4.45+dfsg-1ubuntu1.3", Type: "deb"},
-				{Name: "google-cloud-sdk", Arch: "x86_64", Version: "246.0.0-0", Type: "deb"},
+				{Name: "libldap-common", Arch: "all", Version: "2.4.45+dfsg-1ubuntu1.3", Type: "deb", Repository: "Ubuntu:18.04/bionic-updates, Ubuntu:18.04/bionic-security", Security: true},
+				{Name: "google-cloud-sdk", Arch: "x86_64", Version: "246.0.0-0", Type: "deb", Repository: "cloud-sdk-stretch:cloud-sdk-stretch"},
 			},
 		},
 		{
@@ -857,9 +914,9 @@ Conf firmware-linux-free (3.4 Debian:9.9/stable [all])
 			input:   []byte(normalCase),
 			showNew: true,
 			want: []*PkgInfo{
-				{Name: "libldap-common", Arch: "all", Version: "2.4.45+dfsg-1ubuntu1.3", Type: "deb"},
-				{Name: "google-cloud-sdk", Arch: "x86_64", Version: "246.0.0-0", Type: "deb"},
-				{Name: "firmware-linux-free", Arch: "all", Version: "3.4", Type: "deb"},
+				{Name: "libldap-common", Arch: "all", Version: "2.4.45+dfsg-1ubuntu1.3", Type: "deb", Repository: "Ubuntu:18.04/bionic-updates, Ubuntu:18.04/bionic-security", Security: true},
+				{Name: "google-cloud-sdk", Arch: "x86_64", Version: "246.0.0-0", Type: "deb", Repository: "cloud-sdk-stretch:cloud-sdk-stretch"},
+				{Name: "firmware-linux-free", Arch: "all", Version: "3.4", Type: "deb", Repository: "Debian:9.9/stable"},
 			},
 		},
 		{
@@ -879,7 +936,7 @@ Conf firmware-linux-free (3.4 Debian:9.9/stable [all])
 			input:   []byte("Inst something [we dont understand\n Inst google-cloud-sdk [245.0.0-0] (246.0.0-0 cloud-sdk-stretch:cloud-sdk-stretch [amd64])"),
 			showNew: false,
 			want: []*PkgInfo{
-				{Name: "google-cloud-sdk", Arch: "x86_64", Version: "246.0.0-0", Type: "deb"},
+				{Name: "google-cloud-sdk", Arch: "x86_64", Version: "246.0.0-0", Type: "deb", Repository: "cloud-sdk-stretch:cloud-sdk-stretch"},
 			},
 		},
 	}
@@ -0,0 +1,208 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/osconfig/clog"
+	"github.com/package-url/packageurl-go"
+)
+
+const (
+	cargoLock = "Cargo.lock"
+	// cargoInstalls is the list of crates installed by cargo install in an install root,
+	// e.g. ~/.cargo.
+	cargoInstalls = ".crates2.json"
+)
+
+// cratesIOIndexes are the git and sparse indexes of crates.io.
+var cratesIOIndexes = map[string]bool{
+	"https://github.com/rust-lang/crates.io-index": true,
+	"https://index.crates.io/":                     true,
+}
+
+type cargoProvider struct {
+	roots    []string
+	maxDepth int
+}
+
+// NewCargoProvider returns a provider that reports the crates installed in the cargo
+// install roots and locked in the Cargo.lock files found under roots as Packages.Cargo.
+// Directories nested deeper than maxDepth levels below a root are not scanned.
+func NewCargoProvider(roots []string, maxDepth int) InstalledPackagesProvider {
+	return cargoProvider{roots: roots, maxDepth: maxDepth}
+}
+
+func (p cargoProvider) GetInstalledPackages(ctx context.Context) (Packages, error) {
+	pkgs, err := InstalledCargoPackages(ctx, p.roots, p.maxDepth)
+	if err != nil {
+		return Packages{}, err
+	}
+	return Packages{Cargo: pkgs}, nil
+}
+
+type cargoInstallsJSON struct {
+	Installs map[string]struct {
+		Bins []string `json:"bins"`
+	} `json:"installs"`
+}
+
+// InstalledCargoPackages walks roots up to maxDepth directories deep for cargo install
+// roots and Cargo.lock files. Crates of path dependencies and workspace members have no
+// source and are not reported, a crate found in multiple places is reported once with
+// all the locations.
+func InstalledCargoPackages(ctx context.Context, roots []string, maxDepth int) ([]*CargoPackage, error) {
+	var pkgs []*CargoPackage
+	seen := map[string]*CargoPackage{}
+	add := func(name, version, source string, locations ...string) {
+		if name == "" || source == "" {
+			return
+		}
+		key := name + "@" + version + "@" + source
+		if pkg, ok := seen[key]; ok {
+			pkg.Location = append(pkg.Location, locations...)
+			return
+		}
+		pkg := &CargoPackage{Name: name, Version: version, Purl: cargoPurl(name, version, source), Source: source, Location: locations}
+		seen[key] = pkg
+		pkgs = append(pkgs, pkg)
+	}
+
+	for _, root := range roots {
+		err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				clog.Debugf(ctx, "Error walking %q: %v", p, err)
+				return nil
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if d.IsDir() {
+				if p != root && depth(root, p) > maxDepth {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			switch d.Name() {
+			case cargoLock:
+				b, err := os.ReadFile(p)
+				if err != nil {
+					clog.Debugf(ctx, "Error reading %q: %v", p, err)
+					return nil
+				}
+				for _, c := range parseCargoLock(b) {
+					add(c.name, c.version, c.source, p)
+				}
+			case cargoInstalls:
+				b, err := os.ReadFile(p)
+				if err != nil {
+					clog.Debugf(ctx, "Error reading %q: %v", p, err)
+					return nil
+				}
+				var installs cargoInstallsJSON
+				if err := json.Unmarshal(b, &installs); err != nil {
+					clog.Debugf(ctx, "Error parsing %q: %v", p, err)
+					return nil
+				}
+				ids := make([]string, 0, len(installs.Installs))
+				for id := range installs.Installs {
+					ids = append(ids, id)
+				}
+				sort.Strings(ids)
+				for _, id := range ids {
+					// Installs are keyed by package id, "name version (source)".
+					fields := strings.Fields(id)
+					if len(fields) != 3 {
+						continue
+					}
+					var bins []string
+					for _, bin := range installs.Installs[id].Bins {
+						bins = append(bins, filepath.Join(filepath.Dir(p), "bin", bin))
+					}
+					add(fields[0], fields[1], strings.Trim(fields[2], "()"), bins...)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return pkgs, nil
+}
+
+type cargoLockPackage struct {
+	name, version, source string
+}
+
+// parseCargoLock returns the [[package]] entries of a Cargo.lock file. The file is
+// generated by cargo so only the simple key = "value" form is handled.
+func parseCargoLock(b []byte) []cargoLockPackage {
+	var pkgs []cargoLockPackage
+	var current *cargoLockPackage
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			current = nil
+			if line == "[[package]]" {
+				pkgs = append(pkgs, cargoLockPackage{})
+				current = &pkgs[len(pkgs)-1]
+			}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+		switch strings.TrimSpace(key) {
+		case "name":
+			current.name = value
+		case "version":
+			current.version = value
+		case "source":
+			current.source = value
+		}
+	}
+	return pkgs
+}
+
+// cargoPurl returns the purl of a crate, crates not published to crates.io are
+// qualified with the registry or the git repository they come from.
+func cargoPurl(name, version, source string) string {
+	qualifiers := map[string]string{}
+	kind, url, _ := strings.Cut(source, "+")
+	switch {
+	case kind == "git":
+		qualifiers["vcs_url"] = source
+	case !cratesIOIndexes[url]:
+		qualifiers["repository_url"] = url
+	}
+	return BuildPurl(packageurl.TypeCargo, "", name, version, qualifiers)
+}
@@ -0,0 +1,113 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// newCargoFixture creates the following tree:
+//
+//	.cargo/.crates2.json              ripgrep@14.0.3 and cargo-edit@0.12.2 installs
+//	src/app/Cargo.lock                testdata/cargo/Cargo.lock
+//	src/tool/Cargo.lock               regex@1.10.2
+//	src/deep/a/b/Cargo.lock           (nested deeper than the max depth)
+func newCargoFixture(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	lock, err := os.ReadFile(filepath.Join("testdata", "cargo", cargoLock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for p, content := range map[string]string{
+		filepath.Join(".cargo", cargoInstalls): `{"installs": {
+			"ripgrep 14.0.3 (registry+https://github.com/rust-lang/crates.io-index)": {"version_req": null, "bins": ["rg"], "features": [], "profile": "release"},
+			"cargo-edit 0.12.2 (registry+https://github.com/rust-lang/crates.io-index)": {"bins": ["cargo-add", "cargo-rm"]}
+		}}`,
+		filepath.Join("src", "app", cargoLock):            string(lock),
+		filepath.Join("src", "tool", cargoLock):           "version = 3\n\n[[package]]\nname = \"regex\"\nversion = \"1.10.2\"\nsource = \"registry+https://github.com/rust-lang/crates.io-index\"\n",
+		filepath.Join("src", "deep", "a", "b", cargoLock): "[[package]]\nname = \"left-pad\"\nversion = \"1.0.0\"\nsource = \"registry+https://github.com/rust-lang/crates.io-index\"\n",
+	} {
+		p = filepath.Join(root, p)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestInstalledCargoPackages(t *testing.T) {
+	root := newCargoFixture(t)
+	appLock := filepath.Join(root, "src", "app", cargoLock)
+	cratesIO := "registry+https://github.com/rust-lang/crates.io-index"
+
+	got, err := InstalledCargoPackages(context.Background(), []string{root}, 3)
+	if err != nil {
+		t.Fatalf("InstalledCargoPackages() error = %v", err)
+	}
+
+	want := []*CargoPackage{
+		{
+			Name: "cargo-edit", Version: "0.12.2", Purl: "pkg:cargo/cargo-edit@0.12.2", Source: cratesIO,
+			Location: []string{filepath.Join(root, ".cargo", "bin", "cargo-add"), filepath.Join(root, ".cargo", "bin", "cargo-rm")},
+		},
+		{Name: "ripgrep", Version: "14.0.3", Purl: "pkg:cargo/ripgrep@14.0.3", Source: cratesIO, Location: []string{filepath.Join(root, ".cargo", "bin", "rg")}},
+		{
+			Name: "private-crate", Version: "1.2.3", Source: "sparse+https://crates.example.com/index/",
+			Purl:     "pkg:cargo/private-crate@1.2.3?repository_url=https%3A%2F%2Fcrates.example.com%2Findex%2F",
+			Location: []string{appLock},
+		},
+		{Name: "regex", Version: "1.10.2", Purl: "pkg:cargo/regex@1.10.2", Source: cratesIO, Location: []string{appLock, filepath.Join(root, "src", "tool", cargoLock)}},
+		{Name: "serde", Version: "1.0.193", Purl: "pkg:cargo/serde@1.0.193", Source: "sparse+https://index.crates.io/", Location: []string{appLock}},
+		{
+			Name: "tokio-util", Version: "0.7.10", Source: "git+https://github.com/tokio-rs/tokio?branch=master#0f1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d",
+			Purl:     "pkg:cargo/tokio-util@0.7.10?vcs_url=git%2Bhttps%3A%2F%2Fgithub.com%2Ftokio-rs%2Ftokio%3Fbranch%3Dmaster%230f1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d",
+			Location: []string{appLock},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("InstalledCargoPackages() unexpected diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestInstalledCargoPackagesMissingRoot(t *testing.T) {
+	got, err := InstalledCargoPackages(context.Background(), []string{filepath.Join(t.TempDir(), "missing")}, 3)
+	if err != nil || got != nil {
+		t.Errorf("InstalledCargoPackages() = %v, %v, want: nil, nil", got, err)
+	}
+}
+
+func TestCargoProvider(t *testing.T) {
+	root := newCargoFixture(t)
+	got, err := NewCargoProvider([]string{filepath.Join(root, "src", "tool")}, 1).GetInstalledPackages(context.Background())
+	if err != nil {
+		t.Fatalf("GetInstalledPackages() error = %v", err)
+	}
+	want := Packages{Cargo: []*CargoPackage{{
+		Name: "regex", Version: "1.10.2", Purl: "pkg:cargo/regex@1.10.2", Source: "registry+https://github.com/rust-lang/crates.io-index",
+		Location: []string{filepath.Join(root, "src", "tool", cargoLock)},
+	}}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("GetInstalledPackages() unexpected diff (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,93 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"sort"
+	"time"
+)
+
+// certificatesQuery reads the certificates of the system trust store, it is nil on
+// operating systems without a supported trust store.
+var certificatesQuery func(ctx context.Context) ([]*Certificate, error)
+
+type certificatesProvider struct {
+	// now returns the time the certificates are checked for expiry against.
+	now func() time.Time
+}
+
+// NewCertificatesProvider returns a provider that reports the certificates of the
+// system trust store as Packages.Certificates.
+func NewCertificatesProvider() InstalledPackagesProvider {
+	return certificatesProvider{now: time.Now}
+}
+
+func (p certificatesProvider) GetInstalledPackages(ctx context.Context) (Packages, error) {
+	if certificatesQuery == nil {
+		return Packages{}, nil
+	}
+	certs, err := certificatesQuery(ctx)
+	if err != nil {
+		return Packages{}, err
+	}
+	now := p.now()
+	for _, c := range certs {
+		c.Expired = now.After(c.NotAfter)
+	}
+	sort.Slice(certs, func(i, j int) bool {
+		if certs[i].Subject != certs[j].Subject {
+			return certs[i].Subject < certs[j].Subject
+		}
+		return certs[i].Fingerprint < certs[j].Fingerprint
+	})
+	return Packages{Certificates: certs}, nil
+}
+
+func newCertificate(c *x509.Certificate, location string) *Certificate {
+	sum := sha256.Sum256(c.Raw)
+	return &Certificate{
+		Subject:     c.Subject.String(),
+		Issuer:      c.Issuer.String(),
+		Fingerprint: hex.EncodeToString(sum[:]),
+		NotAfter:    c.NotAfter.UTC(),
+		Location:    location,
+	}
+}
+
+// parsePEMCertificates returns the certificates of the PEM blocks of b, blocks that
+// are not valid certificates are skipped.
+func parsePEMCertificates(b []byte, location string) []*Certificate {
+	var certs []*Certificate
+	for {
+		var block *pem.Block
+		block, b = pem.Decode(b)
+		if block == nil {
+			return certs
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		c, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+		certs = append(certs, newCertificate(c, location))
+	}
+}
@@ -0,0 +1,79 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+var (
+	// certBundleFiles are the trust store bundles of the supported distributions,
+	// the first one found is read.
+	certBundleFiles = []string{
+		"/etc/ssl/certs/ca-certificates.crt",                // Debian, Ubuntu, Arch
+		"/etc/pki/ca-trust/extracted/pem/tls-ca-bundle.pem", // RHEL 7 and later, Fedora
+		"/etc/pki/tls/certs/ca-bundle.crt",                  // CentOS, RHEL 6
+		"/etc/ssl/ca-bundle.pem",                            // SUSE
+	}
+	// certDir is read when no bundle is found.
+	certDir = "/etc/ssl/certs"
+)
+
+func init() {
+	certificatesQuery = trustStoreCertificates
+}
+
+func trustStoreCertificates(_ context.Context) ([]*Certificate, error) {
+	for _, file := range certBundleFiles {
+		b, err := os.ReadFile(file)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading trust store %s: %v", file, err)
+		}
+		return parsePEMCertificates(b, file), nil
+	}
+
+	entries, err := os.ReadDir(certDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading trust store %s: %v", certDir, err)
+	}
+	var certs []*Certificate
+	seen := map[string]bool{}
+	for _, entry := range entries {
+		// The directory holds each certificate under its own name and its hash symlink.
+		file := filepath.Join(certDir, entry.Name())
+		b, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		for _, c := range parsePEMCertificates(b, file) {
+			if seen[c.Fingerprint] {
+				continue
+			}
+			seen[c.Fingerprint] = true
+			certs = append(certs, c)
+		}
+	}
+	return certs, nil
+}
@@ -0,0 +1,85 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTrustStoreCertificates(t *testing.T) {
+	oldBundleFiles, oldCertDir := certBundleFiles, certDir
+	defer func() { certBundleFiles, certDir = oldBundleFiles, oldCertDir }()
+
+	valid, expired := readCertFixture(t, "valid.pem"), readCertFixture(t, "expired.pem")
+
+	t.Run("Bundle", func(t *testing.T) {
+		dir := t.TempDir()
+		bundle := filepath.Join(dir, "ca-bundle.crt")
+		if err := os.WriteFile(bundle, append(valid, expired...), 0644); err != nil {
+			t.Fatal(err)
+		}
+		certBundleFiles = []string{filepath.Join(dir, "missing.crt"), bundle}
+		certDir = filepath.Join(dir, "certs")
+
+		got, err := trustStoreCertificates(context.Background())
+		if err != nil {
+			t.Fatalf("trustStoreCertificates() error = %v", err)
+		}
+		if want := wantFixtureCertificates(bundle); !reflect.DeepEqual(got, want) {
+			t.Errorf("trustStoreCertificates() = %+v, want: %+v", got, want)
+		}
+	})
+
+	t.Run("Directory", func(t *testing.T) {
+		dir := t.TempDir()
+		certBundleFiles = []string{filepath.Join(dir, "missing.crt")}
+		certDir = dir
+		for name, content := range map[string][]byte{"a-valid.pem": valid, "b-expired.pem": expired, "README": []byte("not a certificate\n")} {
+			if err := os.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// Hash links point to certificates that are already in the directory.
+		if err := os.Symlink("a-valid.pem", filepath.Join(dir, "0a1b2c3d.0")); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := trustStoreCertificates(context.Background())
+		if err != nil {
+			t.Fatalf("trustStoreCertificates() error = %v", err)
+		}
+		want := wantFixtureCertificates("")
+		want[0].Location = filepath.Join(dir, "0a1b2c3d.0")
+		want[1].Location = filepath.Join(dir, "b-expired.pem")
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("trustStoreCertificates() = %+v, want: %+v", got, want)
+		}
+	})
+
+	t.Run("NoTrustStore", func(t *testing.T) {
+		dir := t.TempDir()
+		certBundleFiles = []string{filepath.Join(dir, "missing.crt")}
+		certDir = filepath.Join(dir, "missing")
+
+		got, err := trustStoreCertificates(context.Background())
+		if err != nil || got != nil {
+			t.Errorf("trustStoreCertificates() = %+v, %v, want: nil, nil", got, err)
+		}
+	})
+}
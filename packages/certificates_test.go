@@ -0,0 +1,117 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const (
+	validCertFingerprint   = "5ba25bb03a1e6da0ec7882d2bfec7a60ada51c6177e95493bdfd95b46ab77831"
+	expiredCertFingerprint = "6fe8effbde2770187c36fcbb9cff22cfe34032c696ff6f085a57f678b67ab9f9"
+)
+
+func readCertFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("testdata", "certs", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func wantFixtureCertificates(location string) []*Certificate {
+	return []*Certificate{
+		{
+			Subject:     "CN=Valid Test Root CA,O=OSConfig Test",
+			Issuer:      "CN=Valid Test Root CA,O=OSConfig Test",
+			Fingerprint: validCertFingerprint,
+			NotAfter:    time.Date(2099, 12, 31, 23, 59, 59, 0, time.UTC),
+			Location:    location,
+		},
+		{
+			Subject:     "CN=Expired Test Root CA,O=OSConfig Test",
+			Issuer:      "CN=Expired Test Root CA,O=OSConfig Test",
+			Fingerprint: expiredCertFingerprint,
+			NotAfter:    time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC),
+			Location:    location,
+		},
+	}
+}
+
+func TestParsePEMCertificates(t *testing.T) {
+	bundle := append(readCertFixture(t, "valid.pem"), "# comment between certificates\n"...)
+	bundle = append(bundle, "-----BEGIN PUBLIC KEY-----\nAAAA\n-----END PUBLIC KEY-----\n"...)
+	bundle = append(bundle, "-----BEGIN CERTIFICATE-----\nbm90IGEgY2VydGlmaWNhdGU=\n-----END CERTIFICATE-----\n"...)
+	bundle = append(bundle, readCertFixture(t, "expired.pem")...)
+
+	got := parsePEMCertificates(bundle, "bundle.crt")
+	if want := wantFixtureCertificates("bundle.crt"); !reflect.DeepEqual(got, want) {
+		t.Errorf("parsePEMCertificates() = %+v, want: %+v", got, want)
+	}
+}
+
+func TestCertificatesProvider(t *testing.T) {
+	oldQuery := certificatesQuery
+	defer func() { certificatesQuery = oldQuery }()
+
+	tests := []struct {
+		name    string
+		query   func(context.Context) ([]*Certificate, error)
+		want    Packages
+		wantErr bool
+	}{
+		{
+			name: "Certificates",
+			query: func(context.Context) ([]*Certificate, error) {
+				return wantFixtureCertificates("ROOT"), nil
+			},
+			want: func() Packages {
+				certs := wantFixtureCertificates("ROOT")
+				certs[1].Expired = true
+				return Packages{Certificates: []*Certificate{certs[1], certs[0]}}
+			}(),
+		},
+		{
+			name: "Error",
+			query: func(context.Context) ([]*Certificate, error) {
+				return nil, errors.New("access denied")
+			},
+			wantErr: true,
+		},
+		{
+			name: "Unsupported",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			certificatesQuery = tt.query
+			provider := certificatesProvider{now: func() time.Time { return time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC) }}
+			got, err := provider.GetInstalledPackages(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetInstalledPackages() error = %v, wantErr: %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetInstalledPackages() = %+v, want: %+v", got, tt.want)
+			}
+		})
+	}
+}
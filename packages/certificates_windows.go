@@ -0,0 +1,83 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// certStores are the system certificate stores holding the trust anchors.
+var certStores = []string{"ROOT", "AuthRoot"}
+
+func init() {
+	certificatesQuery = systemStoreCertificates
+}
+
+func systemStoreCertificates(_ context.Context) ([]*Certificate, error) {
+	var certs []*Certificate
+	seen := map[string]bool{}
+	for _, name := range certStores {
+		storeCerts, err := readCertStore(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range storeCerts {
+			if seen[c.Fingerprint] {
+				continue
+			}
+			seen[c.Fingerprint] = true
+			certs = append(certs, c)
+		}
+	}
+	return certs, nil
+}
+
+func readCertStore(name string) ([]*Certificate, error) {
+	namePtr, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+	store, err := windows.CertOpenSystemStore(0, namePtr)
+	if err != nil {
+		return nil, fmt.Errorf("CertOpenSystemStore(%q) error: %v", name, err)
+	}
+	defer windows.CertCloseStore(store, 0)
+
+	var certs []*Certificate
+	var ctx *windows.CertContext
+	for {
+		ctx, err = windows.CertEnumCertificatesInStore(store, ctx)
+		if errors.Is(err, windows.Errno(windows.CRYPT_E_NOT_FOUND)) || ctx == nil {
+			return certs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("CertEnumCertificatesInStore(%q) error: %v", name, err)
+		}
+		// The encoded certificate is owned by the store, it is copied before parsing.
+		der := make([]byte, ctx.Length)
+		copy(der, unsafe.Slice(ctx.EncodedCert, ctx.Length))
+		c, err := x509.ParseCertificate(der)
+		if err != nil {
+			continue
+		}
+		certs = append(certs, newCertificate(c, name))
+	}
+}
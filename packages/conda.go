@@ -0,0 +1,101 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/osconfig/clog"
+)
+
+const condaMeta = "conda-meta"
+
+type condaProvider struct {
+	prefixes []string
+}
+
+// NewCondaProvider returns a provider that reports the packages installed in the
+// conda environments rooted at prefixes as Packages.Conda.
+func NewCondaProvider(prefixes []string) InstalledPackagesProvider {
+	return condaProvider{prefixes: prefixes}
+}
+
+func (p condaProvider) GetInstalledPackages(ctx context.Context) (Packages, error) {
+	pkgs, err := InstalledCondaPackages(ctx, p.prefixes)
+	if err != nil {
+		return Packages{}, err
+	}
+	return Packages{Conda: pkgs}, nil
+}
+
+type condaMetaJSON struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Build   string `json:"build"`
+	Subdir  string `json:"subdir"`
+}
+
+// InstalledCondaPackages reads the conda-meta records of each environment prefix,
+// prefixes without a conda-meta directory are skipped.
+func InstalledCondaPackages(ctx context.Context, prefixes []string) ([]*CondaPackage, error) {
+	var pkgs []*CondaPackage
+	for _, prefix := range prefixes {
+		matches, err := filepath.Glob(filepath.Join(prefix, condaMeta, "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range matches {
+			pkg, err := readCondaMeta(path)
+			if err != nil {
+				clog.Debugf(ctx, "Unable to read conda package record %q: %v", path, err)
+				continue
+			}
+			if pkg.Name == "" {
+				continue
+			}
+			pkg.Location = []string{prefix}
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	return pkgs, nil
+}
+
+func readCondaMeta(path string) (*CondaPackage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var meta condaMetaJSON
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, err
+	}
+	return &CondaPackage{
+		Name:    meta.Name,
+		Version: meta.Version,
+		Build:   meta.Build,
+		Purl:    condaPurl(meta),
+	}, nil
+}
+
+func condaPurl(meta condaMetaJSON) string {
+	return BuildPurl("conda", "", strings.ToLower(meta.Name), meta.Version, map[string]string{
+		"build":  meta.Build,
+		"subdir": meta.Subdir,
+	})
+}
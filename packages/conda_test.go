@@ -0,0 +1,45 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestInstalledCondaPackages(t *testing.T) {
+	prefix := filepath.Join("testdata", "conda", "envs", "ds")
+	missing := filepath.Join("testdata", "conda", "envs", "missing")
+
+	got, err := NewCondaProvider([]string{prefix, missing}).GetInstalledPackages(testCtx)
+	if err != nil {
+		t.Fatalf("GetInstalledPackages() unexpected error: %v", err)
+	}
+
+	want := []*CondaPackage{
+		{
+			Name:     "numpy",
+			Version:  "1.26.4",
+			Build:    "py311h64a7726_0",
+			Purl:     "pkg:conda/numpy@1.26.4?build=py311h64a7726_0&subdir=linux-64",
+			Location: []string{prefix},
+		},
+	}
+	if diff := cmp.Diff(want, got.Conda); diff != "" {
+		t.Errorf("GetInstalledPackages() unexpected diff:\n%s", diff)
+	}
+}
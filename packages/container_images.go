@@ -0,0 +1,260 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/osconfig/clog"
+	"github.com/GoogleCloudPlatform/osconfig/util"
+	"github.com/package-url/packageurl-go"
+)
+
+var (
+	dockerSocket = "/var/run/docker.sock"
+	crictl       = "/usr/bin/crictl"
+
+	containerRuntimeTimeout = 30 * time.Second
+)
+
+// ContainerImage describes an image stored by a local container runtime.
+type ContainerImage struct {
+	ID       string
+	RepoTags []string
+	Digest   string
+	Size     int64
+	Purl     string
+}
+
+// Reference returns the repository and tag of the first repo tag of the image,
+// untagged images are identified by their id.
+func (img *ContainerImage) Reference() (repository, tag string) {
+	if len(img.RepoTags) == 0 {
+		return img.ID, ""
+	}
+	return splitImageReference(img.RepoTags[0])
+}
+
+// ContainerRuntime lists the images of a local container runtime.
+type ContainerRuntime interface {
+	// Name identifies the runtime in logs and errors.
+	Name() string
+	// Available reports whether the runtime is present on the host.
+	Available() bool
+	// Images lists all the images stored by the runtime.
+	Images(ctx context.Context) ([]*ContainerImage, error)
+}
+
+type containerImagesProvider struct {
+	runtimes []ContainerRuntime
+}
+
+// NewContainerImagesProvider returns a provider that reports the images of the given
+// container runtimes as Packages.ContainerImages, when no runtime is given Docker and
+// CRI runtimes (containerd) are queried. Runtimes not present on the host are skipped.
+func NewContainerImagesProvider(runtimes ...ContainerRuntime) InstalledPackagesProvider {
+	if len(runtimes) == 0 {
+		runtimes = []ContainerRuntime{dockerRuntime{socket: dockerSocket}, criRuntime{}}
+	}
+	return containerImagesProvider{runtimes: runtimes}
+}
+
+func (p containerImagesProvider) GetInstalledPackages(ctx context.Context) (Packages, error) {
+	var images []*ContainerImage
+	var errs []error
+	for _, r := range p.runtimes {
+		if !r.Available() {
+			clog.Debugf(ctx, "Container runtime %s not available, skipping.", r.Name())
+			continue
+		}
+		imgs, err := r.Images(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error listing %s images: %w", r.Name(), err))
+			continue
+		}
+		for _, img := range imgs {
+			img.Purl = containerImagePurl(img)
+		}
+		images = append(images, imgs...)
+	}
+	return Packages{ContainerImages: images}, errors.Join(errs...)
+}
+
+func containerImagePurl(img *ContainerImage) string {
+	if len(img.RepoTags) == 0 {
+		return ""
+	}
+	name, tag := img.Reference()
+	namespace := ""
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		namespace, name = name[:i], name[i+1:]
+	}
+	version := img.Digest
+	if version == "" {
+		version = tag
+	}
+	return BuildPurl(packageurl.TypeDocker, namespace, name, version, nil)
+}
+
+// splitImageReference splits "registry:5000/repo:tag" into repository and tag.
+func splitImageReference(ref string) (repository, tag string) {
+	i := strings.LastIndex(ref, ":")
+	if i < 0 || strings.Contains(ref[i:], "/") {
+		return ref, ""
+	}
+	return ref[:i], ref[i+1:]
+}
+
+func digestFromRepoDigests(repoDigests []string) string {
+	for _, d := range repoDigests {
+		if _, digest, ok := strings.Cut(d, "@"); ok {
+			return digest
+		}
+	}
+	return ""
+}
+
+type dockerRuntime struct {
+	socket string
+}
+
+func (dockerRuntime) Name() string {
+	return "docker"
+}
+
+func (r dockerRuntime) Available() bool {
+	return util.Exists(r.socket)
+}
+
+func (r dockerRuntime) Images(ctx context.Context) ([]*ContainerImage, error) {
+	ctx, cancel := context.WithTimeout(ctx, containerRuntimeTimeout)
+	defer cancel()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", r.socket)
+		},
+	}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://docker/images/json", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received status code %q: %s", resp.Status, body)
+	}
+	return parseDockerImages(body)
+}
+
+type dockerImage struct {
+	ID          string   `json:"Id"`
+	RepoTags    []string `json:"RepoTags"`
+	RepoDigests []string `json:"RepoDigests"`
+	Size        int64    `json:"Size"`
+}
+
+func parseDockerImages(data []byte) ([]*ContainerImage, error) {
+	var images []dockerImage
+	if err := json.Unmarshal(data, &images); err != nil {
+		return nil, err
+	}
+
+	var result []*ContainerImage
+	for _, img := range images {
+		result = append(result, &ContainerImage{
+			ID:       img.ID,
+			RepoTags: withoutDanglingTags(img.RepoTags),
+			Digest:   digestFromRepoDigests(img.RepoDigests),
+			Size:     img.Size,
+		})
+	}
+	return result, nil
+}
+
+// withoutDanglingTags drops the "<none>:<none>" placeholder reported for untagged images.
+func withoutDanglingTags(tags []string) []string {
+	var result []string
+	for _, t := range tags {
+		if t != "<none>:<none>" {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+type criRuntime struct{}
+
+func (criRuntime) Name() string {
+	return "cri"
+}
+
+func (criRuntime) Available() bool {
+	return util.Exists(crictl)
+}
+
+func (criRuntime) Images(ctx context.Context) ([]*ContainerImage, error) {
+	stdout, err := runWithDeadline(ctx, containerRuntimeTimeout, crictl, []string{"images", "--output", "json"})
+	if err != nil {
+		return nil, err
+	}
+	return parseCRIImages(stdout)
+}
+
+type criImages struct {
+	Images []struct {
+		ID          string   `json:"id"`
+		RepoTags    []string `json:"repoTags"`
+		RepoDigests []string `json:"repoDigests"`
+		// Size is serialized as a string by crictl.
+		Size string `json:"size"`
+	} `json:"images"`
+}
+
+func parseCRIImages(data []byte) ([]*ContainerImage, error) {
+	var images criImages
+	if err := json.Unmarshal(data, &images); err != nil {
+		return nil, err
+	}
+
+	var result []*ContainerImage
+	for _, img := range images.Images {
+		size, _ := strconv.ParseInt(img.Size, 10, 64)
+		result = append(result, &ContainerImage{
+			ID:       img.ID,
+			RepoTags: img.RepoTags,
+			Digest:   digestFromRepoDigests(img.RepoDigests),
+			Size:     size,
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,151 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type fakeContainerRuntime struct {
+	name      string
+	available bool
+	images    []*ContainerImage
+	err       error
+}
+
+func (f fakeContainerRuntime) Name() string {
+	return f.name
+}
+
+func (f fakeContainerRuntime) Available() bool {
+	return f.available
+}
+
+func (f fakeContainerRuntime) Images(_ context.Context) ([]*ContainerImage, error) {
+	return f.images, f.err
+}
+
+func TestContainerImagesProvider(t *testing.T) {
+	errRuntime := errors.New("permission denied")
+
+	tests := []struct {
+		name     string
+		runtimes []ContainerRuntime
+		want     []*ContainerImage
+		wantErr  error
+	}{
+		{
+			name: "no runtime present, empty result",
+			runtimes: []ContainerRuntime{
+				fakeContainerRuntime{name: "docker", images: []*ContainerImage{{ID: "sha256:unexpected"}}},
+			},
+		},
+		{
+			name: "images of available runtime",
+			runtimes: []ContainerRuntime{
+				fakeContainerRuntime{name: "docker", available: true, images: []*ContainerImage{
+					{ID: "sha256:1111", RepoTags: []string{"gcr.io/project/app:v1"}, Digest: "sha256:aaaa", Size: 1024},
+					{ID: "sha256:2222", RepoTags: []string{"nginx:latest"}, Size: 2048},
+					{ID: "sha256:3333"},
+				}},
+			},
+			want: []*ContainerImage{
+				{ID: "sha256:1111", RepoTags: []string{"gcr.io/project/app:v1"}, Digest: "sha256:aaaa", Size: 1024, Purl: "pkg:docker/gcr.io/project/app@sha256%3Aaaaa"},
+				{ID: "sha256:2222", RepoTags: []string{"nginx:latest"}, Size: 2048, Purl: "pkg:docker/nginx@latest"},
+				{ID: "sha256:3333"},
+			},
+		},
+		{
+			name: "failing runtime does not hide other runtimes",
+			runtimes: []ContainerRuntime{
+				fakeContainerRuntime{name: "docker", available: true, err: errRuntime},
+				fakeContainerRuntime{name: "cri", available: true, images: []*ContainerImage{{ID: "sha256:4444"}}},
+			},
+			want:    []*ContainerImage{{ID: "sha256:4444"}},
+			wantErr: errRuntime,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewContainerImagesProvider(tt.runtimes...).GetInstalledPackages(context.Background())
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetInstalledPackages() unexpected error, expect %v, got %v", tt.wantErr, err)
+			}
+			if diff := cmp.Diff(tt.want, got.ContainerImages); diff != "" {
+				t.Errorf("GetInstalledPackages() unexpected diff:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseDockerImages(t *testing.T) {
+	data := []byte(`[
+  {"Id": "sha256:1111", "RepoTags": ["nginx:latest"], "RepoDigests": ["nginx@sha256:aaaa"], "Size": 187000000},
+  {"Id": "sha256:2222", "RepoTags": ["<none>:<none>"], "RepoDigests": [], "Size": 1000}
+]`)
+
+	got, err := parseDockerImages(data)
+	if err != nil {
+		t.Fatalf("parseDockerImages() unexpected error: %v", err)
+	}
+
+	want := []*ContainerImage{
+		{ID: "sha256:1111", RepoTags: []string{"nginx:latest"}, Digest: "sha256:aaaa", Size: 187000000},
+		{ID: "sha256:2222", Size: 1000},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("parseDockerImages() unexpected diff:\n%s", diff)
+	}
+}
+
+func TestParseCRIImages(t *testing.T) {
+	data := []byte(`{"images": [
+  {"id": "sha256:1111", "repoTags": ["registry.k8s.io/pause:3.9"], "repoDigests": ["registry.k8s.io/pause@sha256:bbbb"], "size": "321520"}
+]}`)
+
+	got, err := parseCRIImages(data)
+	if err != nil {
+		t.Fatalf("parseCRIImages() unexpected error: %v", err)
+	}
+
+	want := []*ContainerImage{
+		{ID: "sha256:1111", RepoTags: []string{"registry.k8s.io/pause:3.9"}, Digest: "sha256:bbbb", Size: 321520},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("parseCRIImages() unexpected diff:\n%s", diff)
+	}
+}
+
+func TestSplitImageReference(t *testing.T) {
+	tests := []struct {
+		ref, wantRepository, wantTag string
+	}{
+		{ref: "nginx:latest", wantRepository: "nginx", wantTag: "latest"},
+		{ref: "localhost:5000/app", wantRepository: "localhost:5000/app"},
+		{ref: "localhost:5000/app:v1", wantRepository: "localhost:5000/app", wantTag: "v1"},
+	}
+
+	for _, tt := range tests {
+		repository, tag := splitImageReference(tt.ref)
+		if repository != tt.wantRepository || tag != tt.wantTag {
+			t.Errorf("splitImageReference(%q) = (%q, %q), want: (%q, %q)", tt.ref, repository, tag, tt.wantRepository, tt.wantTag)
+		}
+	}
+}
@@ -39,17 +39,27 @@ var readMachineArch = func() (string, error) {
 	return oi.Architecture, nil
 }
 
+// readCOSBuildID returns the BUILD_ID of the running COS image, empty when unknown.
+var readCOSBuildID = func() string {
+	oi, err := osinfo.Get()
+	if err != nil {
+		return ""
+	}
+	return oi.BuildID
+}
+
 func parseInstalledCOSPackages(cosPkgInfo *cos.PackageInfo) ([]*PkgInfo, error) {
 	arch, err := readMachineArch()
 	if err != nil {
 		return nil, fmt.Errorf("error from readMachineArch: %v", err)
 	}
 
+	buildID := readCOSBuildID()
 	var pkgs = make([]*PkgInfo, len(cosPkgInfo.InstalledPackages))
 	for i, pkg := range cosPkgInfo.InstalledPackages {
 		name := pkg.Category + "/" + pkg.Name
 		version := pkg.Version
-		pkgs[i] = &PkgInfo{Name: name, Arch: arch, Version: version, Type: typeCos}
+		pkgs[i] = &PkgInfo{Name: name, Arch: arch, Version: version, Type: typeCos, Category: pkg.Category, BuildID: buildID}
 	}
 	return pkgs, nil
 }
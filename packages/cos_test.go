@@ -26,9 +26,11 @@ import (
 	"testing"
 
 	"cos.googlesource.com/cos/tools.git/src/pkg/cos"
+	"github.com/GoogleCloudPlatform/osconfig/util/utiltest"
 )
 
 func TestParseInstalledCOSPackages(t *testing.T) {
+	utiltest.OverrideVariable(t, &readCOSBuildID, func() string { return "18244.85.49" })
 	readMachineArch = func() (string, error) {
 		return "", errors.New("failed to obtain machine architecture")
 	}
@@ -41,9 +43,9 @@ func TestParseInstalledCOSPackages(t *testing.T) {
 	}
 
 	pkg0 := cos.Package{Category: "dev-util", Name: "foo-x", Version: "1.2.3", EbuildVersion: "someversion"}
-	expect0 := &PkgInfo{Name: "dev-util/foo-x", Arch: "x86_64", Version: "1.2.3", Type: "cos"}
+	expect0 := &PkgInfo{Name: "dev-util/foo-x", Arch: "x86_64", Version: "1.2.3", Type: "cos", Category: "dev-util", BuildID: "18244.85.49"}
 	pkg1 := cos.Package{Category: "app-admin", Name: "bar", Version: "0.1"}
-	expect1 := &PkgInfo{Name: "app-admin/bar", Arch: "x86_64", Version: "0.1", Type: "cos"}
+	expect1 := &PkgInfo{Name: "app-admin/bar", Arch: "x86_64", Version: "0.1", Type: "cos", Category: "app-admin", BuildID: "18244.85.49"}
 
 	pkgInfo := &cos.PackageInfo{InstalledPackages: []cos.Package{pkg0, pkg1}}
 	parsed, err := parseInstalledCOSPackages(pkgInfo)
@@ -59,6 +61,7 @@ func TestParseInstalledCOSPackages(t *testing.T) {
 }
 
 func TestInstalledCOSPackages(t *testing.T) {
+	utiltest.OverrideVariable(t, &readCOSBuildID, func() string { return "" })
 	testDataJSON := `{
     "installedPackages": [
         {
@@ -145,19 +148,19 @@ func TestInstalledCOSPackages(t *testing.T) {
 	}
 
 	expected := []*PkgInfo{
-		{Name: "app-arch/gzip", Arch: "x86_64", Version: "1.9", Type: "cos"},
-		{Name: "dev-libs/popt", Arch: "x86_64", Version: "1.16", Type: "cos"},
-		{Name: "app-emulation/docker-credential-helpers", Arch: "x86_64", Version: "0.6.3", Type: "cos"},
-		{Name: "_not.real-category1+/_not-real_package1", Arch: "x86_64", Version: "12.34.56.78", Type: "cos"},
-		{Name: "_not.real-category1+/_not-real_package2", Arch: "x86_64", Version: "12.34.56.78", Type: "cos"},
-		{Name: "_not.real-category1+/_not-real_package3", Arch: "x86_64", Version: "12.34.56.78_rc3", Type: "cos"},
-		{Name: "_not.real-category1+/_not-real_package4", Arch: "x86_64", Version: "12.34.56.78_rc3", Type: "cos"},
-		{Name: "_not.real-category1+/_not-real_package5", Arch: "x86_64", Version: "12.34.56.78_pre2_rc3", Type: "cos"},
-		{Name: "_not.real-category2+/_not-real_package1", Arch: "x86_64", Version: "12.34.56.78q", Type: "cos"},
-		{Name: "_not.real-category2+/_not-real_package2", Arch: "x86_64", Version: "12.34.56.78q", Type: "cos"},
-		{Name: "_not.real-category2+/_not-real_package3", Arch: "x86_64", Version: "12.34.56.78q_rc3", Type: "cos"},
-		{Name: "_not.real-category2+/_not-real_package4", Arch: "x86_64", Version: "12.34.56.78q_rc3", Type: "cos"},
-		{Name: "_not.real-category2+/_not-real_package5", Arch: "x86_64", Version: "12.34.56.78q_pre2_rc3", Type: "cos"},
+		{Name: "app-arch/gzip", Arch: "x86_64", Version: "1.9", Type: "cos", Category: "app-arch"},
+		{Name: "dev-libs/popt", Arch: "x86_64", Version: "1.16", Type: "cos", Category: "dev-libs"},
+		{Name: "app-emulation/docker-credential-helpers", Arch: "x86_64", Version: "0.6.3", Type: "cos", Category: "app-emulation"},
+		{Name: "_not.real-category1+/_not-real_package1", Arch: "x86_64", Version: "12.34.56.78", Type: "cos", Category: "_not.real-category1+"},
+		{Name: "_not.real-category1+/_not-real_package2", Arch: "x86_64", Version: "12.34.56.78", Type: "cos", Category: "_not.real-category1+"},
+		{Name: "_not.real-category1+/_not-real_package3", Arch: "x86_64", Version: "12.34.56.78_rc3", Type: "cos", Category: "_not.real-category1+"},
+		{Name: "_not.real-category1+/_not-real_package4", Arch: "x86_64", Version: "12.34.56.78_rc3", Type: "cos", Category: "_not.real-category1+"},
+		{Name: "_not.real-category1+/_not-real_package5", Arch: "x86_64", Version: "12.34.56.78_pre2_rc3", Type: "cos", Category: "_not.real-category1+"},
+		{Name: "_not.real-category2+/_not-real_package1", Arch: "x86_64", Version: "12.34.56.78q", Type: "cos", Category: "_not.real-category2+"},
+		{Name: "_not.real-category2+/_not-real_package2", Arch: "x86_64", Version: "12.34.56.78q", Type: "cos", Category: "_not.real-category2+"},
+		{Name: "_not.real-category2+/_not-real_package3", Arch: "x86_64", Version: "12.34.56.78q_rc3", Type: "cos", Category: "_not.real-category2+"},
+		{Name: "_not.real-category2+/_not-real_package4", Arch: "x86_64", Version: "12.34.56.78q_rc3", Type: "cos", Category: "_not.real-category2+"},
+		{Name: "_not.real-category2+/_not-real_package5", Arch: "x86_64", Version: "12.34.56.78q_pre2_rc3", Type: "cos", Category: "_not.real-category2+"},
 	}
 
 	readMachineArch = func() (string, error) {
@@ -0,0 +1,177 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/osconfig/clog"
+)
+
+// tclModuleMagic starts every Tcl modulefile, Lmod modulefiles are Lua files instead.
+const tclModuleMagic = "#%Module"
+
+// defaultModulePaths are searched when no module paths are configured and MODULEPATH is
+// not set in the environment of the agent.
+var defaultModulePaths = []string{
+	"/etc/modulefiles",
+	"/usr/share/modulefiles",
+	"/usr/share/Modules/modulefiles",
+	"/opt/modulefiles",
+}
+
+type environmentModulesProvider struct {
+	modulePaths []string
+}
+
+// NewEnvironmentModulesProvider returns a provider that reports the Lmod and Environment
+// Modules modulefiles found in modulePaths as Packages.EnvironmentModules. Without module
+// paths, the MODULEPATH of the agent or the default locations are searched.
+func NewEnvironmentModulesProvider(modulePaths []string) InstalledPackagesProvider {
+	return environmentModulesProvider{modulePaths: modulePaths}
+}
+
+func (p environmentModulesProvider) GetInstalledPackages(ctx context.Context) (Packages, error) {
+	modulePaths := p.modulePaths
+	if len(modulePaths) == 0 {
+		modulePaths = defaultModulePaths
+		if env := os.Getenv("MODULEPATH"); env != "" {
+			modulePaths = filepath.SplitList(env)
+		}
+	}
+	return Packages{EnvironmentModules: EnvironmentModules(ctx, modulePaths)}, nil
+}
+
+// EnvironmentModules walks each module path and returns the modulefiles found in it.
+// A module is named after the directories below the module path and versioned after
+// the file, e.g. mpi/openmpi/4.1.6.lua is version 4.1.6 of mpi/openmpi. Module paths
+// that do not exist are skipped.
+func EnvironmentModules(ctx context.Context, modulePaths []string) []*EnvironmentModule {
+	var modules []*EnvironmentModule
+	for _, modulePath := range modulePaths {
+		if modulePath == "" {
+			continue
+		}
+		defaults := map[string]string{}
+		var found []*EnvironmentModule
+		err := filepath.WalkDir(modulePath, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if path == modulePath {
+					return err
+				}
+				clog.Debugf(ctx, "Unable to read module directory %q: %v", path, err)
+				return nil
+			}
+			if d.IsDir() {
+				return nil
+			}
+			rel, err := filepath.Rel(modulePath, path)
+			if err != nil {
+				return nil
+			}
+			dir, file := filepath.Split(filepath.ToSlash(rel))
+			name := strings.TrimSuffix(dir, "/")
+			if v := defaultModuleVersion(path, file); v != "" {
+				defaults[name] = v
+				return nil
+			}
+			module, ok := readModuleFile(path, file)
+			if !ok {
+				return nil
+			}
+			module.Name = name
+			// Modules directly in the module path are not versioned.
+			if name == "" {
+				module.Name, module.Version = module.Version, ""
+			}
+			module.ModulePath = modulePath
+			found = append(found, module)
+			return nil
+		})
+		if err != nil {
+			if !os.IsNotExist(err) {
+				clog.Debugf(ctx, "Unable to read module path %q: %v", modulePath, err)
+			}
+			continue
+		}
+		for _, module := range found {
+			if v, ok := defaults[module.Name]; ok && v == module.Version {
+				module.Default = true
+			}
+		}
+		modules = append(modules, found...)
+	}
+	sort.SliceStable(modules, func(i, j int) bool {
+		if modules[i].Name != modules[j].Name {
+			return modules[i].Name < modules[j].Name
+		}
+		return modules[i].Version < modules[j].Version
+	})
+	return modules
+}
+
+// readModuleFile returns the module of the modulefile at path named file, it reports
+// false for files that are not modulefiles, e.g. README files or .modulerc.
+func readModuleFile(path, file string) (*EnvironmentModule, bool) {
+	if strings.HasPrefix(file, ".") {
+		return nil, false
+	}
+	if version, ok := strings.CutSuffix(file, ".lua"); ok {
+		return &EnvironmentModule{Version: version, Path: path, Format: "lua"}, true
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+	magic := make([]byte, len(tclModuleMagic))
+	if _, err := f.Read(magic); err != nil || string(magic) != tclModuleMagic {
+		return nil, false
+	}
+	return &EnvironmentModule{Version: file, Path: path, Format: "tcl"}, true
+}
+
+// defaultModuleVersion returns the version selected by a "default" symlink or by a
+// .version file, e.g. set ModulesVersion "4.1.6", and "" for other files.
+func defaultModuleVersion(path, file string) string {
+	switch file {
+	case "default":
+		target, err := os.Readlink(path)
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSuffix(filepath.Base(target), ".lua")
+	case ".version":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return ""
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) == 3 && fields[0] == "set" && fields[1] == "ModulesVersion" {
+				return strings.Trim(fields[2], `"`)
+			}
+		}
+	}
+	return ""
+}
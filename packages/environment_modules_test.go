@@ -0,0 +1,61 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/osconfig/util/utiltest"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEnvironmentModules(t *testing.T) {
+	core := filepath.Join("testdata", "modules", "core")
+	apps := filepath.Join("testdata", "modules", "apps")
+	missing := filepath.Join("testdata", "modules", "missing")
+
+	got, err := NewEnvironmentModulesProvider([]string{core, apps, missing}).GetInstalledPackages(testCtx)
+	if err != nil {
+		t.Fatalf("GetInstalledPackages() unexpected error: %v", err)
+	}
+
+	want := []*EnvironmentModule{
+		{Name: "cmake", Path: filepath.Join(apps, "cmake.lua"), ModulePath: apps, Format: "lua"},
+		{Name: "gcc", Version: "12.2.0", Path: filepath.Join(core, "gcc", "12.2.0.lua"), ModulePath: core, Format: "lua", Default: true},
+		{Name: "gcc", Version: "13.2.0", Path: filepath.Join(core, "gcc", "13.2.0.lua"), ModulePath: core, Format: "lua"},
+		{Name: "mpi/openmpi", Version: "4.1.6", Path: filepath.Join(apps, "mpi", "openmpi", "4.1.6"), ModulePath: apps, Format: "tcl"},
+		{Name: "python", Version: "3.11.9", Path: filepath.Join(core, "python", "3.11.9"), ModulePath: core, Format: "tcl"},
+		{Name: "python", Version: "3.12.4", Path: filepath.Join(core, "python", "3.12.4"), ModulePath: core, Format: "tcl", Default: true},
+	}
+	if diff := cmp.Diff(want, got.EnvironmentModules); diff != "" {
+		t.Errorf("GetInstalledPackages() unexpected diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestEnvironmentModulesModulePathEnv(t *testing.T) {
+	apps := filepath.Join("testdata", "modules", "apps")
+	t.Setenv("MODULEPATH", filepath.Join("testdata", "modules", "missing")+string(filepath.ListSeparator)+apps)
+
+	got, err := NewEnvironmentModulesProvider(nil).GetInstalledPackages(testCtx)
+	if err != nil {
+		t.Fatalf("GetInstalledPackages() unexpected error: %v", err)
+	}
+	var names []string
+	for _, m := range got.EnvironmentModules {
+		names = append(names, m.Name)
+	}
+	utiltest.AssertEquals(t, names, []string{"cmake", "mpi/openmpi"})
+}
@@ -0,0 +1,43 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import "context"
+
+// firmwareQuery reads the firmware information, it is nil on operating systems
+// without a supported source.
+var firmwareQuery func(ctx context.Context) (*Firmware, error)
+
+type firmwareProvider struct{}
+
+// NewFirmwareProvider returns a provider that reports the system firmware as
+// Packages.Firmware. Reading it may require elevated access on some systems.
+func NewFirmwareProvider() InstalledPackagesProvider {
+	return firmwareProvider{}
+}
+
+func (firmwareProvider) GetInstalledPackages(ctx context.Context) (Packages, error) {
+	if firmwareQuery == nil {
+		return Packages{}, nil
+	}
+	fw, err := firmwareQuery(ctx)
+	if err != nil {
+		return Packages{}, err
+	}
+	if fw == nil {
+		return Packages{}, nil
+	}
+	return Packages{Firmware: []*Firmware{fw}}, nil
+}
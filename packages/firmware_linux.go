@@ -0,0 +1,61 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const dmiDir = "/sys/class/dmi/id"
+
+// readDMIFile returns the content of the file name in the DMI sysfs directory.
+var readDMIFile = func(name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(dmiDir, name))
+}
+
+func init() {
+	firmwareQuery = dmiFirmware
+}
+
+// dmiFirmware reads the BIOS information exported by the kernel from SMBIOS, nil is
+// returned on systems without DMI, e.g. some arm64 machines.
+func dmiFirmware(_ context.Context) (*Firmware, error) {
+	var fw Firmware
+	found := false
+	for file, dst := range map[string]*string{
+		"bios_vendor":  &fw.Vendor,
+		"bios_version": &fw.Version,
+		"bios_date":    &fw.ReleaseDate,
+	} {
+		b, err := readDMIFile(file)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading DMI %s: %v", file, err)
+		}
+		*dst = strings.TrimSpace(string(b))
+		found = true
+	}
+	if !found {
+		return nil, nil
+	}
+	return &fw, nil
+}
@@ -0,0 +1,80 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestFirmwareProviderDMI(t *testing.T) {
+	oldReadDMIFile := readDMIFile
+	defer func() { readDMIFile = oldReadDMIFile }()
+
+	tests := []struct {
+		name    string
+		files   map[string]string
+		readErr error
+		want    Packages
+		wantErr bool
+	}{
+		{
+			name: "BIOS",
+			files: map[string]string{
+				"bios_vendor":  "Google\n",
+				"bios_version": "Google\n",
+				"bios_date":    "06/27/2024\n",
+			},
+			want: Packages{Firmware: []*Firmware{{Vendor: "Google", Version: "Google", ReleaseDate: "06/27/2024"}}},
+		},
+		{
+			name:  "MissingDate",
+			files: map[string]string{"bios_vendor": "SeaBIOS\n", "bios_version": "1.16.3\n"},
+			want:  Packages{Firmware: []*Firmware{{Vendor: "SeaBIOS", Version: "1.16.3"}}},
+		},
+		{
+			name:  "NoDMI",
+			files: map[string]string{},
+		},
+		{
+			name:    "PermissionDenied",
+			readErr: os.ErrPermission,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			readDMIFile = func(name string) ([]byte, error) {
+				if tt.readErr != nil {
+					return nil, tt.readErr
+				}
+				content, ok := tt.files[name]
+				if !ok {
+					return nil, os.ErrNotExist
+				}
+				return []byte(content), nil
+			}
+			got, err := NewFirmwareProvider().GetInstalledPackages(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetInstalledPackages() error = %v, wantErr: %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetInstalledPackages() = %+v, want: %+v", got, tt.want)
+			}
+		})
+	}
+}
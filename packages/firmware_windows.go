@@ -0,0 +1,51 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/GoogleCloudPlatform/osconfig/clog"
+	"github.com/StackExchange/wmi"
+)
+
+type win32BIOS struct {
+	Manufacturer, SMBIOSBIOSVersion string
+	ReleaseDate                     time.Time
+}
+
+func init() {
+	firmwareQuery = wmiFirmware
+}
+
+// wmiFirmware queries the wmi object Win32_BIOS for the system firmware.
+func wmiFirmware(ctx context.Context) (*Firmware, error) {
+	var bios []win32BIOS
+	query := "SELECT Manufacturer, SMBIOSBIOSVersion, ReleaseDate FROM Win32_BIOS"
+	clog.Debugf(ctx, "Querying WMI for the system firmware, query=%q.", query)
+	if err := wmi.Query(query, &bios); err != nil {
+		return nil, fmt.Errorf("wmi.Query(%q) error: %v", query, err)
+	}
+	if len(bios) == 0 {
+		return nil, nil
+	}
+	fw := &Firmware{Vendor: bios[0].Manufacturer, Version: bios[0].SMBIOSBIOSVersion}
+	if !bios[0].ReleaseDate.IsZero() {
+		fw.ReleaseDate = bios[0].ReleaseDate.Format("01/02/2006")
+	}
+	return fw, nil
+}
@@ -0,0 +1,68 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+
+	"github.com/GoogleCloudPlatform/osconfig/clog"
+	"github.com/GoogleCloudPlatform/osconfig/util"
+)
+
+var (
+	pkgCmd = "/usr/sbin/pkg"
+
+	pkgQueryArgs = []string{"query", "-a", `%n\t%v\t%o`}
+)
+
+func init() {
+	if runtime.GOOS == "freebsd" {
+		PkgExists = util.Exists(pkgCmd)
+	}
+}
+
+// InstalledFreeBSDPackages queries for all packages installed by FreeBSD pkg.
+func InstalledFreeBSDPackages(ctx context.Context) ([]*FreeBSDPackage, error) {
+	out, err := run(ctx, pkgCmd, pkgQueryArgs)
+	if err != nil {
+		return nil, err
+	}
+	return parseFreeBSDPackages(ctx, out), nil
+}
+
+func parseFreeBSDPackages(ctx context.Context, data []byte) []*FreeBSDPackage {
+	/*
+	   curl	8.7.1	ftp/curl
+	   nginx	1.26.0_1,3	www/nginx
+	*/
+	var pkgs []*FreeBSDPackage
+	for _, ln := range bytes.Split(bytes.TrimSpace(data), []byte("\n")) {
+		fields := bytes.Split(ln, []byte("\t"))
+		if len(fields) != 3 {
+			clog.Debugf(ctx, "%q does not represent a pkg package", ln)
+			continue
+		}
+		name, version := string(fields[0]), string(fields[1])
+		pkgs = append(pkgs, &FreeBSDPackage{
+			Name:    name,
+			Version: version,
+			Origin:  string(fields[2]),
+			Purl:    BuildPurl("freebsd", "", name, version, nil),
+		})
+	}
+	return pkgs
+}
@@ -0,0 +1,40 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseFreeBSDPackages(t *testing.T) {
+	data := []byte("curl\t8.7.1\tftp/curl\n" +
+		"nginx\t1.26.0_1,3\twww/nginx\n" +
+		"something we dont understand\n")
+
+	got := parseFreeBSDPackages(testCtx, data)
+
+	want := []*FreeBSDPackage{
+		{Name: "curl", Version: "8.7.1", Origin: "ftp/curl", Purl: "pkg:freebsd/curl@8.7.1"},
+		{Name: "nginx", Version: "1.26.0_1,3", Origin: "www/nginx", Purl: "pkg:freebsd/nginx@1.26.0_1%2C3"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("parseFreeBSDPackages() unexpected diff:\n%s", diff)
+	}
+	if category := got[1].Category(); category != "www" {
+		t.Errorf("Category() = %q, want: %q", category, "www")
+	}
+}
@@ -0,0 +1,130 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"context"
+	"debug/buildinfo"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/osconfig/clog"
+	"github.com/package-url/packageurl-go"
+)
+
+type goBinariesProvider struct {
+	roots    []string
+	maxDepth int
+}
+
+// NewGoBinariesProvider returns a provider that reports the modules embedded in the Go
+// binaries found under roots as Packages.GoModules. Directories nested deeper than
+// maxDepth levels below a root are not scanned.
+func NewGoBinariesProvider(roots []string, maxDepth int) InstalledPackagesProvider {
+	return goBinariesProvider{roots: roots, maxDepth: maxDepth}
+}
+
+func (p goBinariesProvider) GetInstalledPackages(ctx context.Context) (Packages, error) {
+	modules, err := InstalledGoModules(ctx, p.roots, p.maxDepth)
+	if err != nil {
+		return Packages{}, err
+	}
+	return Packages{GoModules: modules}, nil
+}
+
+// InstalledGoModules walks roots up to maxDepth directories deep and returns the main
+// module and dependencies of every Go binary found, modules used by multiple binaries
+// are reported once with the paths of all the binaries.
+func InstalledGoModules(ctx context.Context, roots []string, maxDepth int) ([]*GoModule, error) {
+	var pkgs []*GoModule
+	seen := map[string]*GoModule{}
+	add := func(m *debug.Module, location string) {
+		if m == nil || m.Path == "" {
+			return
+		}
+		if m.Replace != nil {
+			m = m.Replace
+		}
+		version := m.Version
+		if version == "(devel)" {
+			version = ""
+		}
+		key := m.Path + "@" + version
+		if pkg, ok := seen[key]; ok {
+			pkg.Binaries = append(pkg.Binaries, location)
+			return
+		}
+		pkg := &GoModule{
+			Path:     m.Path,
+			Version:  version,
+			Purl:     goModulePurl(m.Path, version),
+			Binaries: []string{location},
+		}
+		seen[key] = pkg
+		pkgs = append(pkgs, pkg)
+	}
+
+	for _, root := range roots {
+		err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				clog.Debugf(ctx, "Error walking %q: %v", p, err)
+				return nil
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if d.IsDir() {
+				if p != root && depth(root, p) > maxDepth {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+
+			bi, err := buildinfo.ReadFile(p)
+			if err != nil {
+				// Not a Go binary.
+				return nil
+			}
+			add(&bi.Main, p)
+			for _, dep := range bi.Deps {
+				add(dep, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return pkgs, nil
+}
+
+func depth(root, p string) int {
+	rel, err := filepath.Rel(root, p)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return strings.Count(rel, string(filepath.Separator)) + 1
+}
+
+func goModulePurl(module, version string) string {
+	namespace, name := path.Split(module)
+	return BuildPurl(packageurl.TypeGolang, strings.TrimSuffix(namespace, "/"), name, version, nil)
+}
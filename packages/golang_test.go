@@ -0,0 +1,99 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// copyTestBinary copies the running test binary, which is a Go binary with embedded
+// build information, to dst and uses it as a fixture.
+func copyTestBinary(t *testing.T, dst string) {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dst, data, 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInstalledGoModules(t *testing.T) {
+	root := t.TempDir()
+	binary := filepath.Join(root, "bin", "app")
+	copyTestBinary(t, binary)
+	copyTestBinary(t, filepath.Join(root, "a", "b", "c", "deep"))
+	if err := os.WriteFile(filepath.Join(root, "bin", "script.sh"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	pkgs, err := InstalledGoModules(context.Background(), []string{root}, 1)
+	if err != nil {
+		t.Fatalf("InstalledGoModules() unexpected error: %v", err)
+	}
+
+	found := map[string]*GoModule{}
+	for _, pkg := range pkgs {
+		if !strings.HasPrefix(pkg.Purl, "pkg:golang/") {
+			t.Errorf("unexpected purl %q of %q", pkg.Purl, pkg.Path)
+		}
+		for _, location := range pkg.Binaries {
+			if location != binary {
+				t.Errorf("unexpected binary %q of %q, binaries nested deeper than max depth should be skipped", location, pkg.Path)
+			}
+		}
+		found[pkg.Path] = pkg
+	}
+
+	main, ok := found["github.com/GoogleCloudPlatform/osconfig"]
+	if !ok {
+		t.Fatalf("main module of the fixture binary not found in %v", pkgs)
+	}
+	if main.Purl != "pkg:golang/github.com/GoogleCloudPlatform/osconfig" {
+		t.Errorf("unexpected main module purl %q", main.Purl)
+	}
+	if _, ok := found["github.com/golang/mock"]; !ok {
+		t.Errorf("dependency github.com/golang/mock of the fixture binary not found")
+	}
+}
+
+func TestInstalledGoModulesMultipleBinaries(t *testing.T) {
+	root := t.TempDir()
+	copyTestBinary(t, filepath.Join(root, "app1"))
+	copyTestBinary(t, filepath.Join(root, "app2"))
+
+	pkgs, err := NewGoBinariesProvider([]string{root}, 0).GetInstalledPackages(context.Background())
+	if err != nil {
+		t.Fatalf("GetInstalledPackages() unexpected error: %v", err)
+	}
+
+	for _, pkg := range pkgs.GoModules {
+		if len(pkg.Binaries) != 2 {
+			t.Errorf("module %q expected to be reported once with both binaries, got: %v", pkg.Path, pkg.Binaries)
+		}
+	}
+}
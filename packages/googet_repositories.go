@@ -0,0 +1,146 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/osconfig/agentconfig"
+	"github.com/GoogleCloudPlatform/osconfig/clog"
+)
+
+// googetRepoFiles returns the contents of the GooGet repo files keyed by path, it is
+// replaced in tests.
+var googetRepoFiles = readGooGetRepoFiles
+
+type googetRepositoriesProvider struct{}
+
+// NewGooGetRepositoriesProvider returns a provider that reports the repositories
+// configured in the GooGet repo files as Packages.GooGetRepositories.
+func NewGooGetRepositoriesProvider() InstalledPackagesProvider {
+	return googetRepositoriesProvider{}
+}
+
+func (p googetRepositoriesProvider) GetInstalledPackages(ctx context.Context) (Packages, error) {
+	repos, err := GooGetRepositories(ctx)
+	if err != nil {
+		return Packages{}, err
+	}
+	return Packages{GooGetRepositories: repos}, nil
+}
+
+// GooGetRepositories reads the repositories configured in the GooGet repo files. It
+// returns no repositories when GooGet is not installed.
+func GooGetRepositories(ctx context.Context) ([]*GooGetRepository, error) {
+	if !GooGetExists {
+		return nil, nil
+	}
+	files, err := googetRepoFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var repos []*GooGetRepository
+	for _, path := range paths {
+		parsed := parseGooGetRepoFile(files[path])
+		if len(parsed) == 0 {
+			clog.Debugf(ctx, "No GooGet repositories found in %q.", path)
+			continue
+		}
+		for _, repo := range parsed {
+			repo.Location = path
+		}
+		repos = append(repos, parsed...)
+	}
+	return repos, nil
+}
+
+func readGooGetRepoFiles() (map[string][]byte, error) {
+	paths, err := filepath.Glob(filepath.Join(agentconfig.GooGetRepoDir(), "*.repo"))
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string][]byte, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		files[path] = data
+	}
+	return files, nil
+}
+
+// parseGooGetRepoFile parses a GooGet repo file, which holds either a list of
+// repositories or a single one. Repositories without a URL are skipped.
+func parseGooGetRepoFile(data []byte) []*GooGetRepository {
+	/*
+	   - name: google-compute-engine-stable
+	     url: https://packages.cloud.google.com/yuck/repos/google-compute-engine-stable
+	     useoauth: true
+	     priority: 500
+	*/
+	var repos []*GooGetRepository
+	var repo *GooGetRepository
+	for _, ln := range strings.Split(string(data), "\n") {
+		ln = strings.TrimSpace(ln)
+		if ln == "" || strings.HasPrefix(ln, "#") {
+			continue
+		}
+		if ln == "-" || strings.HasPrefix(ln, "- ") {
+			repo = &GooGetRepository{}
+			repos = append(repos, repo)
+			ln = strings.TrimSpace(strings.TrimPrefix(ln, "-"))
+		}
+		key, value, ok := strings.Cut(ln, ":")
+		if !ok {
+			continue
+		}
+		if repo == nil {
+			repo = &GooGetRepository{}
+			repos = append(repos, repo)
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "name":
+			repo.Name = value
+		case "url":
+			repo.URL = value
+		case "useoauth":
+			repo.UseOAuth, _ = strconv.ParseBool(value)
+		case "priority":
+			repo.Priority, _ = strconv.Atoi(value)
+		}
+	}
+
+	var valid []*GooGetRepository
+	for _, repo := range repos {
+		if repo.URL != "" {
+			valid = append(valid, repo)
+		}
+	}
+	return valid
+}
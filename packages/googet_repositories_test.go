@@ -0,0 +1,118 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/osconfig/util/utiltest"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseGooGetRepoFile(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []*GooGetRepository
+	}{
+		{
+			name: "List",
+			data: "# Repo file managed by Google OSConfig agent\n" +
+				"- name: google-compute-engine-stable\n" +
+				"  url: https://packages.cloud.google.com/yuck/repos/google-compute-engine-stable\n" +
+				"  useoauth: true\n" +
+				"  priority: 500\n" +
+				"- name: \"internal\"\n" +
+				"  url: 'https://example.com/googet'\n",
+			want: []*GooGetRepository{
+				{Name: "google-compute-engine-stable", URL: "https://packages.cloud.google.com/yuck/repos/google-compute-engine-stable", UseOAuth: true, Priority: 500},
+				{Name: "internal", URL: "https://example.com/googet"},
+			},
+		},
+		{
+			name: "SingleRepository",
+			data: "name: single\r\nurl: https://example.com/single\r\n",
+			want: []*GooGetRepository{{Name: "single", URL: "https://example.com/single"}},
+		},
+		{
+			name: "SkipsRepositoriesWithoutURL",
+			data: "- name: no-url\n- name: with-url\n  url: https://example.com\n",
+			want: []*GooGetRepository{{Name: "with-url", URL: "https://example.com"}},
+		},
+		{
+			name: "Empty",
+			data: "",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseGooGetRepoFile([]byte(tt.data))
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("parseGooGetRepoFile() unexpected diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGooGetRepositories(t *testing.T) {
+	files := map[string][]byte{
+		"C:/ProgramData/GooGet/repos/z.repo":     []byte("- name: z\n  url: https://example.com/z\n"),
+		"C:/ProgramData/GooGet/repos/a.repo":     []byte("- name: a\n  url: https://example.com/a\n"),
+		"C:/ProgramData/GooGet/repos/empty.repo": []byte("\n"),
+	}
+	utiltest.OverrideVariable(t, &googetRepoFiles, func() (map[string][]byte, error) { return files, nil })
+	utiltest.OverrideVariable(t, &GooGetExists, true)
+
+	got, err := GooGetRepositories(context.Background())
+	if err != nil {
+		t.Fatalf("GooGetRepositories() unexpected error: %v", err)
+	}
+	want := []*GooGetRepository{
+		{Name: "a", URL: "https://example.com/a", Location: "C:/ProgramData/GooGet/repos/a.repo"},
+		{Name: "z", URL: "https://example.com/z", Location: "C:/ProgramData/GooGet/repos/z.repo"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("GooGetRepositories() unexpected diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestGooGetRepositoriesWithoutGooGet(t *testing.T) {
+	utiltest.OverrideVariable(t, &googetRepoFiles, func() (map[string][]byte, error) {
+		t.Error("repo files read while GooGet is not installed")
+		return nil, nil
+	})
+	utiltest.OverrideVariable(t, &GooGetExists, false)
+
+	got, err := NewGooGetRepositoriesProvider().GetInstalledPackages(context.Background())
+	if err != nil {
+		t.Fatalf("GetInstalledPackages() unexpected error: %v", err)
+	}
+	if got.GooGetRepositories != nil {
+		t.Errorf("GetInstalledPackages() = %v, want no repositories", got.GooGetRepositories)
+	}
+}
+
+func TestGooGetRepositoriesReadError(t *testing.T) {
+	utiltest.OverrideVariable(t, &googetRepoFiles, func() (map[string][]byte, error) { return nil, errors.New("access denied") })
+	utiltest.OverrideVariable(t, &GooGetExists, true)
+
+	if _, err := GooGetRepositories(context.Background()); err == nil {
+		t.Error("GooGetRepositories() expected an error")
+	}
+}
@@ -0,0 +1,187 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/osconfig/clog"
+	"github.com/GoogleCloudPlatform/osconfig/util"
+)
+
+// IDEs reported in IDEExtension.IDE.
+const (
+	ideVSCode    = "vscode"
+	ideJetBrains = "jetbrains"
+)
+
+type ideExtensionsProvider struct {
+	dirs []string
+}
+
+// NewIDEExtensionsProvider returns a provider that reports the VS Code extensions and the
+// JetBrains plugins installed in dirs as Packages.IDEExtensions. Dirs may be glob patterns,
+// e.g. "/home/*/.vscode/extensions" or "/home/*/.local/share/JetBrains/*".
+func NewIDEExtensionsProvider(dirs []string) InstalledPackagesProvider {
+	return ideExtensionsProvider{dirs: dirs}
+}
+
+func (p ideExtensionsProvider) GetInstalledPackages(ctx context.Context) (Packages, error) {
+	pkgs, err := InstalledIDEExtensions(ctx, p.dirs)
+	if err != nil {
+		return Packages{}, err
+	}
+	return Packages{IDEExtensions: pkgs}, nil
+}
+
+// vscodePackageJSON holds the fields of the package.json manifest of a VS Code extension.
+type vscodePackageJSON struct {
+	Name      string `json:"name"`
+	Publisher string `json:"publisher"`
+	Version   string `json:"version"`
+}
+
+// jetBrainsPluginXML holds the fields of the META-INF/plugin.xml descriptor of a
+// JetBrains plugin.
+type jetBrainsPluginXML struct {
+	ID      string `xml:"id"`
+	Name    string `xml:"name"`
+	Version string `xml:"version"`
+	Vendor  string `xml:"vendor"`
+}
+
+// InstalledIDEExtensions reads the extensions installed in the directories matching
+// dirs. Each entry of a directory is a VS Code extension when it holds a package.json
+// manifest, and a JetBrains plugin when it is, or holds in its lib directory, a jar with
+// a META-INF/plugin.xml descriptor. Other entries are skipped.
+func InstalledIDEExtensions(ctx context.Context, dirs []string) ([]*IDEExtension, error) {
+	var exts []*IDEExtension
+	for _, pattern := range dirs {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, dir := range matches {
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				clog.Debugf(ctx, "Unable to read IDE extensions directory %q: %v", dir, err)
+				continue
+			}
+			for _, entry := range entries {
+				path := filepath.Join(dir, entry.Name())
+				var ext *IDEExtension
+				switch {
+				case entry.IsDir() && util.Exists(filepath.Join(path, "package.json")):
+					ext, err = readVSCodeExtension(path)
+				case entry.IsDir():
+					ext, err = readJetBrainsPluginDir(path)
+				case strings.HasSuffix(entry.Name(), ".jar"):
+					ext, err = readJetBrainsPluginJar(path)
+				default:
+					continue
+				}
+				if err != nil {
+					clog.Debugf(ctx, "Unable to read IDE extension %q: %v", path, err)
+					continue
+				}
+				if ext == nil || ext.Name == "" {
+					continue
+				}
+				ext.Location = path
+				exts = append(exts, ext)
+			}
+		}
+	}
+	return exts, nil
+}
+
+func readVSCodeExtension(dir string) (*IDEExtension, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return nil, err
+	}
+	var manifest vscodePackageJSON
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	ext := &IDEExtension{IDE: ideVSCode, Name: manifest.Name, Publisher: manifest.Publisher, Version: manifest.Version}
+	if manifest.Publisher != "" && manifest.Name != "" {
+		// Extensions are identified as publisher.name on the marketplace.
+		ext.ID = manifest.Publisher + "." + manifest.Name
+	}
+	return ext, nil
+}
+
+// readJetBrainsPluginDir reads the descriptor of a plugin installed as a directory,
+// it is held by one of the jars of its lib directory. It returns nil when none has one.
+func readJetBrainsPluginDir(dir string) (*IDEExtension, error) {
+	jars, err := filepath.Glob(filepath.Join(dir, "lib", "*.jar"))
+	if err != nil {
+		return nil, err
+	}
+	for _, jar := range jars {
+		ext, err := readJetBrainsPluginJar(jar)
+		if err != nil {
+			return nil, err
+		}
+		if ext != nil {
+			return ext, nil
+		}
+	}
+	return nil, nil
+}
+
+// readJetBrainsPluginJar reads the META-INF/plugin.xml descriptor of jar, it returns nil
+// when jar has no descriptor.
+func readJetBrainsPluginJar(jar string) (*IDEExtension, error) {
+	r, err := zip.OpenReader(jar)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	f, err := r.Open("META-INF/plugin.xml")
+	if err != nil {
+		return nil, nil
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	var descriptor jetBrainsPluginXML
+	if err := xml.Unmarshal(data, &descriptor); err != nil {
+		return nil, err
+	}
+	id := strings.TrimSpace(descriptor.ID)
+	name := strings.TrimSpace(descriptor.Name)
+	// The id defaults to the name when the descriptor does not set it.
+	if id == "" {
+		id = name
+	}
+	return &IDEExtension{
+		IDE:       ideJetBrains,
+		ID:        id,
+		Name:      name,
+		Publisher: strings.TrimSpace(descriptor.Vendor),
+		Version:   strings.TrimSpace(descriptor.Version),
+	}, nil
+}
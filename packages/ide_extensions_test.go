@@ -0,0 +1,96 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// writeJar writes a jar at path holding the given files.
+func writeJar(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w := zip.NewWriter(f)
+	for name, content := range files {
+		fw, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInstalledIDEExtensions(t *testing.T) {
+	vscode := filepath.Join("testdata", "ide", "vscode", "extensions")
+
+	plugins := filepath.Join(t.TempDir(), "JetBrains", "IntelliJIdea2024.1")
+	writeJar(t, filepath.Join(plugins, "intellij-rust", "lib", "intellij-rust-deps.jar"), map[string]string{
+		"META-INF/MANIFEST.MF": "Manifest-Version: 1.0\n",
+	})
+	writeJar(t, filepath.Join(plugins, "intellij-rust", "lib", "intellij-rust.jar"), map[string]string{
+		"META-INF/plugin.xml": `<idea-plugin>
+  <id>org.rust.lang</id>
+  <name>Rust</name>
+  <version>0.4.201.5424-232</version>
+  <vendor url="https://www.jetbrains.com">JetBrains</vendor>
+</idea-plugin>`,
+	})
+	writeJar(t, filepath.Join(plugins, "CheckStyle-IDEA.jar"), map[string]string{
+		"META-INF/plugin.xml": `<idea-plugin><name>CheckStyle-IDEA</name><version>5.85.1</version><vendor>Jamie Shiell</vendor></idea-plugin>`,
+	})
+	writeJar(t, filepath.Join(plugins, "library.jar"), map[string]string{"lib.txt": "not a plugin"})
+	if err := os.WriteFile(filepath.Join(plugins, "broken.jar"), []byte("not a zip"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := InstalledIDEExtensions(context.Background(), []string{vscode, filepath.Join(filepath.Dir(plugins), "*"), filepath.Join(t.TempDir(), "missing")})
+	if err != nil {
+		t.Fatalf("InstalledIDEExtensions() unexpected error: %v", err)
+	}
+
+	want := []*IDEExtension{
+		{IDE: "vscode", ID: "golang.go", Name: "go", Publisher: "golang", Version: "0.41.0", Location: filepath.Join(vscode, "golang.go-0.41.0")},
+		{IDE: "vscode", ID: "ms-python.python", Name: "python", Publisher: "ms-python", Version: "2024.2.1", Location: filepath.Join(vscode, "ms-python.python-2024.2.1")},
+		{IDE: "jetbrains", ID: "CheckStyle-IDEA", Name: "CheckStyle-IDEA", Publisher: "Jamie Shiell", Version: "5.85.1", Location: filepath.Join(plugins, "CheckStyle-IDEA.jar")},
+		{IDE: "jetbrains", ID: "org.rust.lang", Name: "Rust", Publisher: "JetBrains", Version: "0.4.201.5424-232", Location: filepath.Join(plugins, "intellij-rust")},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("InstalledIDEExtensions() unexpected diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestInstalledIDEExtensionsBadPattern(t *testing.T) {
+	if _, err := InstalledIDEExtensions(context.Background(), []string{"[-]"}); err == nil {
+		t.Errorf("InstalledIDEExtensions() with a malformed pattern returned no error")
+	}
+}
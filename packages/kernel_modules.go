@@ -0,0 +1,82 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/osconfig/clog"
+)
+
+var (
+	procModules = "/proc/modules"
+	modinfo     = "/sbin/modinfo"
+
+	modinfoTimeout = 5 * time.Second
+)
+
+type kernelModulesProvider struct{}
+
+// NewKernelModulesProvider returns a provider that reports the loaded kernel modules
+// as Packages.KernelModules.
+func NewKernelModulesProvider() InstalledPackagesProvider {
+	return kernelModulesProvider{}
+}
+
+func (kernelModulesProvider) GetInstalledPackages(ctx context.Context) (Packages, error) {
+	modules, err := InstalledKernelModules(ctx)
+	if err != nil {
+		return Packages{}, err
+	}
+	return Packages{KernelModules: modules}, nil
+}
+
+// InstalledKernelModules queries for all loaded kernel modules, the version
+// is taken from modinfo when the module declares one.
+func InstalledKernelModules(ctx context.Context) ([]*PkgInfo, error) {
+	data, err := os.ReadFile(procModules)
+	if err != nil {
+		return nil, err
+	}
+
+	/*
+	   tls 155648 0 - Live 0x0000000000000000
+	   nf_tables 356352 0 - Live 0x0000000000000000
+	   ...
+	*/
+	var pkgs []*PkgInfo
+	for _, ln := range bytes.Split(bytes.TrimSpace(data), []byte("\n")) {
+		fields := bytes.Fields(ln)
+		if len(fields) == 0 {
+			continue
+		}
+		name := string(fields[0])
+		pkgs = append(pkgs, &PkgInfo{Name: name, Arch: noarch, Version: kernelModuleVersion(ctx, name), Type: typeKernelModule})
+	}
+	return pkgs, nil
+}
+
+func kernelModuleVersion(ctx context.Context, name string) string {
+	stdout, err := runWithDeadline(ctx, modinfoTimeout, modinfo, []string{"-F", "version", name})
+	if err != nil {
+		clog.Debugf(ctx, "Unable to get version of kernel module %q: %v", name, err)
+		return ""
+	}
+	return strings.TrimSpace(string(stdout))
+}
@@ -0,0 +1,73 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	utilmocks "github.com/GoogleCloudPlatform/osconfig/util/mocks"
+	"github.com/golang/mock/gomock"
+)
+
+func TestInstalledKernelModules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "modules")
+	content := "tls 155648 0 - Live 0x0000000000000000\n" +
+		"e1000 159744 0 - Live 0x0000000000000000\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	oldProcModules := procModules
+	procModules = path
+	defer func() { procModules = oldProcModules }()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockCommandRunner := utilmocks.NewMockCommandRunner(mockCtrl)
+	runner = mockCommandRunner
+	setExpectations(mockCommandRunner, []expectedCommand{
+		{cmd: exec.Command(modinfo, "-F", "version", "tls"), stdout: []byte("\n")},
+		{cmd: exec.Command(modinfo, "-F", "version", "e1000"), stdout: []byte("7.3.21-k8-NAPI\n")},
+	})
+
+	got, err := InstalledKernelModules(context.Background())
+	if err != nil {
+		t.Fatalf("InstalledKernelModules() unexpected error: %v", err)
+	}
+
+	want := []*PkgInfo{
+		{Name: "tls", Arch: noarch, Version: "", Type: "kernel-module"},
+		{Name: "e1000", Arch: noarch, Version: "7.3.21-k8-NAPI", Type: "kernel-module"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("InstalledKernelModules() unexpected result, expect %v, got %v", want, got)
+	}
+}
+
+func TestInstalledKernelModulesMissingProcModules(t *testing.T) {
+	oldProcModules := procModules
+	procModules = filepath.Join(t.TempDir(), "missing")
+	defer func() { procModules = oldProcModules }()
+
+	_, err := NewKernelModulesProvider().GetInstalledPackages(context.Background())
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("GetInstalledPackages() unexpected error, expect %v, got %v", os.ErrNotExist, err)
+	}
+}
@@ -0,0 +1,53 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"context"
+	"sort"
+)
+
+// listeningPortsQuery enumerates the listening sockets, it is nil on operating systems
+// without a supported source.
+var listeningPortsQuery func(ctx context.Context) ([]*ListeningPort, error)
+
+type listeningPortsProvider struct{}
+
+// NewListeningPortsProvider returns a provider that reports the listening TCP and UDP
+// sockets as Packages.ListeningPorts. The owning processes are only reported when the
+// agent is allowed to inspect them.
+func NewListeningPortsProvider() InstalledPackagesProvider {
+	return listeningPortsProvider{}
+}
+
+func (listeningPortsProvider) GetInstalledPackages(ctx context.Context) (Packages, error) {
+	if listeningPortsQuery == nil {
+		return Packages{}, nil
+	}
+	ports, err := listeningPortsQuery(ctx)
+	if err != nil {
+		return Packages{}, err
+	}
+	sort.Slice(ports, func(i, j int) bool {
+		if ports[i].Protocol != ports[j].Protocol {
+			return ports[i].Protocol < ports[j].Protocol
+		}
+		if ports[i].Port != ports[j].Port {
+			return ports[i].Port < ports[j].Port
+		}
+		return ports[i].Address < ports[j].Address
+	})
+	return Packages{ListeningPorts: ports}, nil
+}
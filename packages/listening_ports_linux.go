@@ -0,0 +1,154 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// tcpListen and udpUnconnected are the socket states in /proc/net of listening
+	// TCP sockets and of bound UDP sockets.
+	tcpListen       = "0A"
+	udpUnconnected  = "07"
+	procNetInodeIdx = 9
+)
+
+// procRoot is the mount point of procfs, it is a variable to allow testing.
+var procRoot = "/proc"
+
+func init() {
+	listeningPortsQuery = procListeningPorts
+}
+
+func procListeningPorts(_ context.Context) ([]*ListeningPort, error) {
+	var ports []*ListeningPort
+	inodes := map[string]*ListeningPort{}
+	for _, proto := range []string{"tcp", "tcp6", "udp", "udp6"} {
+		b, err := os.ReadFile(filepath.Join(procRoot, "net", proto))
+		if errors.Is(err, os.ErrNotExist) {
+			// IPv6 is disabled.
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading listening sockets: %v", err)
+		}
+		for inode, port := range parseProcNet(proto, b) {
+			ports = append(ports, port)
+			inodes[inode] = port
+		}
+	}
+	setSocketOwners(inodes)
+	return ports, nil
+}
+
+// parseProcNet returns the listening sockets of a /proc/net/{tcp,tcp6,udp,udp6} file
+// by socket inode.
+func parseProcNet(proto string, b []byte) map[string]*ListeningPort {
+	state := tcpListen
+	if strings.HasPrefix(proto, "udp") {
+		state = udpUnconnected
+	}
+	ports := map[string]*ListeningPort{}
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	// Skip the header line.
+	scanner.Scan()
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) <= procNetInodeIdx || fields[3] != state {
+			continue
+		}
+		// Connected UDP sockets have a remote address, they are not listening.
+		if _, remotePort, _ := parseProcNetAddress(fields[2]); remotePort != 0 {
+			continue
+		}
+		addr, port, err := parseProcNetAddress(fields[1])
+		if err != nil {
+			continue
+		}
+		ports[fields[procNetInodeIdx]] = &ListeningPort{Protocol: proto, Address: addr, Port: port}
+	}
+	return ports
+}
+
+// parseProcNetAddress parses an address of /proc/net, e.g. 0100007F:0016 for 127.0.0.1:22.
+// Addresses are written as 32 bit words in host byte order, little endian on the
+// supported architectures.
+func parseProcNetAddress(s string) (string, int, error) {
+	hexIP, hexPort, ok := strings.Cut(s, ":")
+	if !ok {
+		return "", 0, fmt.Errorf("invalid address %q", s)
+	}
+	ip, err := hex.DecodeString(hexIP)
+	if err != nil || (len(ip) != net.IPv4len && len(ip) != net.IPv6len) {
+		return "", 0, fmt.Errorf("invalid address %q", s)
+	}
+	for i := 0; i < len(ip); i += 4 {
+		ip[i], ip[i+1], ip[i+2], ip[i+3] = ip[i+3], ip[i+2], ip[i+1], ip[i]
+	}
+	port, err := strconv.ParseUint(hexPort, 16, 16)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid address %q: %v", s, err)
+	}
+	return net.IP(ip).String(), int(port), nil
+}
+
+// setSocketOwners sets the owning process of the sockets by inode from the file
+// descriptors of the processes, processes the agent is not allowed to inspect are skipped.
+func setSocketOwners(inodes map[string]*ListeningPort) {
+	if len(inodes) == 0 {
+		return
+	}
+	procs, err := os.ReadDir(procRoot)
+	if err != nil {
+		return
+	}
+	for _, proc := range procs {
+		pid, err := strconv.Atoi(proc.Name())
+		if err != nil {
+			continue
+		}
+		fds, err := os.ReadDir(filepath.Join(procRoot, proc.Name(), "fd"))
+		if err != nil {
+			continue
+		}
+		var name string
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(procRoot, proc.Name(), "fd", fd.Name()))
+			if err != nil || !strings.HasPrefix(link, "socket:[") {
+				continue
+			}
+			port, ok := inodes[strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]")]
+			if !ok {
+				continue
+			}
+			if name == "" {
+				comm, _ := os.ReadFile(filepath.Join(procRoot, proc.Name(), "comm"))
+				name = strings.TrimSpace(string(comm))
+			}
+			port.PID, port.Process = pid, name
+		}
+	}
+}
@@ -0,0 +1,113 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+const (
+	procNetHeader = "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n"
+	procNetTCP    = procNetHeader +
+		"   0: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1001 1 0000000000000000 100 0 0 10 0\n" +
+		"   1: 0100007F:0277 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1002 1 0000000000000000 100 0 0 10 0\n" +
+		"   2: 0A00000A:0016 0B00000A:D431 01 00000000:00000000 02:00082D1F 00000000     0        0 1003 4 0000000000000000 20 4 29 10 -1\n"
+	procNetTCP6 = procNetHeader +
+		"   0: 00000000000000000000000000000000:0016 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1004 1 0000000000000000 100 0 0 10 0\n"
+	procNetUDP = "   sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops\n" +
+		"  100: 00000000:0044 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 1005 2 0000000000000000 0\n" +
+		"  101: 0A00000A:A1B2 08080808:0035 01 00000000:00000000 00:00000000 00000000     0        0 1006 2 0000000000000000 0\n"
+)
+
+func TestProcListeningPorts(t *testing.T) {
+	oldProcRoot := procRoot
+	defer func() { procRoot = oldProcRoot }()
+	procRoot = t.TempDir()
+
+	writeProcFile := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(procRoot, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeProcFile("net/tcp", procNetTCP)
+	writeProcFile("net/tcp6", procNetTCP6)
+	writeProcFile("net/udp", procNetUDP)
+	// udp6 is missing as on hosts with IPv6 disabled.
+
+	// sshd owns both port 22 sockets, the owner of the other sockets is unknown.
+	writeProcFile("745/comm", "sshd\n")
+	if err := os.MkdirAll(filepath.Join(procRoot, "745", "fd"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for fd, target := range map[string]string{"0": "/dev/null", "3": "socket:[1001]", "4": "socket:[1004]", "5": "socket:[99]"} {
+		if err := os.Symlink(target, filepath.Join(procRoot, "745", "fd", fd)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeProcFile("self/comm", "agent\n")
+
+	got, err := procListeningPorts(context.Background())
+	if err != nil {
+		t.Fatalf("procListeningPorts() error = %v", err)
+	}
+	sort.Slice(got, func(i, j int) bool { return got[i].Protocol+got[i].Address < got[j].Protocol+got[j].Address })
+
+	want := []*ListeningPort{
+		{Protocol: "tcp", Address: "0.0.0.0", Port: 22, PID: 745, Process: "sshd"},
+		{Protocol: "tcp", Address: "127.0.0.1", Port: 631},
+		{Protocol: "tcp6", Address: "::", Port: 22, PID: 745, Process: "sshd"},
+		{Protocol: "udp", Address: "0.0.0.0", Port: 68},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("procListeningPorts() = %+v, want: %+v", got, want)
+	}
+}
+
+func TestParseProcNetAddress(t *testing.T) {
+	tests := []struct {
+		in       string
+		wantAddr string
+		wantPort int
+		wantErr  bool
+	}{
+		{in: "0100007F:0016", wantAddr: "127.0.0.1", wantPort: 22},
+		{in: "00000000000000000000000001000000:1F90", wantAddr: "::1", wantPort: 8080},
+		{in: "0000000000000000FFFF00000100007F:0035", wantAddr: "127.0.0.1", wantPort: 53},
+		{in: "0100007F", wantErr: true},
+		{in: "01007F:0016", wantErr: true},
+		{in: "0100007F:XYZ", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			addr, port, err := parseProcNetAddress(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseProcNetAddress(%q) error = %v, wantErr: %v", tt.in, err, tt.wantErr)
+			}
+			if addr != tt.wantAddr || port != tt.wantPort {
+				t.Errorf("parseProcNetAddress(%q) = %q, %d, want: %q, %d", tt.in, addr, port, tt.wantAddr, tt.wantPort)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestListeningPortsProvider(t *testing.T) {
+	oldQuery := listeningPortsQuery
+	defer func() { listeningPortsQuery = oldQuery }()
+
+	tests := []struct {
+		name    string
+		query   func(context.Context) ([]*ListeningPort, error)
+		want    Packages
+		wantErr bool
+	}{
+		{
+			name: "Ports",
+			query: func(context.Context) ([]*ListeningPort, error) {
+				return []*ListeningPort{
+					{Protocol: "udp", Address: "0.0.0.0", Port: 68},
+					{Protocol: "tcp", Address: "127.0.0.1", Port: 631, PID: 812, Process: "cupsd"},
+					{Protocol: "tcp", Address: "0.0.0.0", Port: 22, PID: 745, Process: "sshd"},
+				}, nil
+			},
+			want: Packages{ListeningPorts: []*ListeningPort{
+				{Protocol: "tcp", Address: "0.0.0.0", Port: 22, PID: 745, Process: "sshd"},
+				{Protocol: "tcp", Address: "127.0.0.1", Port: 631, PID: 812, Process: "cupsd"},
+				{Protocol: "udp", Address: "0.0.0.0", Port: 68},
+			}},
+		},
+		{
+			name: "Error",
+			query: func(context.Context) ([]*ListeningPort, error) {
+				return nil, errors.New("access denied")
+			},
+			wantErr: true,
+		},
+		{
+			name: "Unsupported",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listeningPortsQuery = tt.query
+			got, err := NewListeningPortsProvider().GetInstalledPackages(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetInstalledPackages() error = %v, wantErr: %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetInstalledPackages() = %+v, want: %+v", got, tt.want)
+			}
+		})
+	}
+}
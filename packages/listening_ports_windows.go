@@ -0,0 +1,81 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/osconfig/clog"
+	"github.com/StackExchange/wmi"
+)
+
+// netTCPIPNamespace is the WMI namespace of the classes behind Get-NetTCPConnection
+// and Get-NetUDPEndpoint.
+const netTCPIPNamespace = `root\StandardCimv2`
+
+type msftNetEndpoint struct {
+	LocalAddress  string
+	LocalPort     uint16
+	OwningProcess uint32
+}
+
+type win32Process struct {
+	ProcessId uint32
+	Name      string
+}
+
+func init() {
+	listeningPortsQuery = wmiListeningPorts
+}
+
+func wmiListeningPorts(ctx context.Context) ([]*ListeningPort, error) {
+	var ports []*ListeningPort
+	for proto, query := range map[string]string{
+		// State 2 is Listen.
+		"tcp": "SELECT LocalAddress, LocalPort, OwningProcess FROM MSFT_NetTCPConnection WHERE State = 2",
+		"udp": "SELECT LocalAddress, LocalPort, OwningProcess FROM MSFT_NetUDPEndpoint",
+	} {
+		var endpoints []msftNetEndpoint
+		clog.Debugf(ctx, "Querying WMI for listening sockets, query=%q.", query)
+		if err := wmi.QueryNamespace(query, &endpoints, netTCPIPNamespace); err != nil {
+			return nil, fmt.Errorf("wmi.QueryNamespace(%q) error: %v", query, err)
+		}
+		for _, e := range endpoints {
+			p := proto
+			if strings.Contains(e.LocalAddress, ":") {
+				p += "6"
+			}
+			ports = append(ports, &ListeningPort{Protocol: p, Address: e.LocalAddress, Port: int(e.LocalPort), PID: int(e.OwningProcess)})
+		}
+	}
+
+	// Process names are best effort, the ports are reported without them.
+	var procs []win32Process
+	query := "SELECT ProcessId, Name FROM Win32_Process"
+	if err := wmi.Query(query, &procs); err != nil {
+		clog.Debugf(ctx, "wmi.Query(%q) error: %v", query, err)
+		return ports, nil
+	}
+	names := make(map[int]string, len(procs))
+	for _, proc := range procs {
+		names[int(proc.ProcessId)] = proc.Name
+	}
+	for _, p := range ports {
+		p.Process = names[p.PID]
+	}
+	return ports, nil
+}
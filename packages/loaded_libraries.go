@@ -0,0 +1,66 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"context"
+	"regexp"
+	"sort"
+)
+
+// loadedLibrariesQuery returns the shared libraries mapped by the processes with the
+// given names, it is nil on operating systems without a supported source.
+var loadedLibrariesQuery func(ctx context.Context, processes []string) ([]*LoadedLibrary, error)
+
+var (
+	// sonameVersionRE matches the version suffix of a library, e.g. libssl.so.3.
+	sonameVersionRE = regexp.MustCompile(`\.so\.([0-9][0-9.]*)$`)
+	// nameVersionRE matches a version in the name of a library, e.g. libc-2.31.so.
+	nameVersionRE = regexp.MustCompile(`-([0-9][0-9.]*)\.so$`)
+)
+
+type loadedLibrariesProvider struct {
+	processes []string
+}
+
+// NewLoadedLibrariesProvider returns a provider that reports the shared libraries mapped
+// by the running processes named in processes as Packages.LoadedLibraries. Nothing is
+// reported without process names.
+func NewLoadedLibrariesProvider(processes []string) InstalledPackagesProvider {
+	return loadedLibrariesProvider{processes: processes}
+}
+
+func (p loadedLibrariesProvider) GetInstalledPackages(ctx context.Context) (Packages, error) {
+	if loadedLibrariesQuery == nil || len(p.processes) == 0 {
+		return Packages{}, nil
+	}
+	libs, err := loadedLibrariesQuery(ctx, p.processes)
+	if err != nil {
+		return Packages{}, err
+	}
+	sort.Slice(libs, func(i, j int) bool { return libs[i].Path < libs[j].Path })
+	return Packages{LoadedLibraries: libs}, nil
+}
+
+// libraryVersion returns the version in the file name of a shared library.
+func libraryVersion(name string) string {
+	if m := sonameVersionRE.FindStringSubmatch(name); m != nil {
+		return m[1]
+	}
+	if m := nameVersionRE.FindStringSubmatch(name); m != nil {
+		return m[1]
+	}
+	return ""
+}
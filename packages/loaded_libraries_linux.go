@@ -0,0 +1,118 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// deletedMappingSuffix is appended by the kernel to mappings of removed files.
+const deletedMappingSuffix = " (deleted)"
+
+func init() {
+	loadedLibrariesQuery = procLoadedLibraries
+}
+
+// procLoadedLibraries reads the mappings of the processes named in processes, processes
+// the agent is not allowed to inspect are skipped.
+func procLoadedLibraries(_ context.Context, processes []string) ([]*LoadedLibrary, error) {
+	procs, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, err
+	}
+	libs := map[string]*LoadedLibrary{}
+	for _, proc := range procs {
+		if _, err := strconv.Atoi(proc.Name()); err != nil {
+			continue
+		}
+		comm, err := os.ReadFile(filepath.Join(procRoot, proc.Name(), "comm"))
+		if err != nil {
+			continue
+		}
+		name := matchProcessName(strings.TrimSpace(string(comm)), processes)
+		if name == "" {
+			continue
+		}
+		maps, err := os.ReadFile(filepath.Join(procRoot, proc.Name(), "maps"))
+		if err != nil {
+			continue
+		}
+		for _, lib := range parseProcMaps(maps) {
+			if existing, ok := libs[lib.Path]; ok {
+				existing.Deleted = existing.Deleted || lib.Deleted
+				lib = existing
+			} else {
+				libs[lib.Path] = lib
+			}
+			if !slices.Contains(lib.Processes, name) {
+				lib.Processes = append(lib.Processes, name)
+				sort.Strings(lib.Processes)
+			}
+		}
+	}
+
+	var result []*LoadedLibrary
+	for _, lib := range libs {
+		result = append(result, lib)
+	}
+	return result, nil
+}
+
+// matchProcessName returns the name of processes that comm, the possibly truncated name
+// of a process, belongs to. The kernel truncates comm to 15 characters.
+func matchProcessName(comm string, processes []string) string {
+	for _, name := range processes {
+		if comm == name || (len(comm) == 15 && strings.HasPrefix(name, comm)) {
+			return name
+		}
+	}
+	return ""
+}
+
+// parseProcMaps returns the shared libraries of a /proc/<pid>/maps file, each library
+// once. Anonymous mappings and other files are skipped.
+func parseProcMaps(b []byte) []*LoadedLibrary {
+	/*
+	   7f2a4c000000-7f2a4c028000 r--p 00000000 08:01 1835      /usr/lib/x86_64-linux-gnu/libc.so.6
+	   7f2a4c5e0000-7f2a4c5e2000 rw-p 00000000 00:00 0
+	*/
+	var libs []*LoadedLibrary
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		fields := strings.SplitN(strings.TrimSpace(scanner.Text()), " ", 6)
+		if len(fields) < 6 {
+			continue
+		}
+		path := strings.TrimSpace(fields[5])
+		deleted := strings.HasSuffix(path, deletedMappingSuffix)
+		path = strings.TrimSuffix(path, deletedMappingSuffix)
+		name := filepath.Base(path)
+		if !strings.HasPrefix(path, "/") || !(strings.HasSuffix(name, ".so") || strings.Contains(name, ".so.")) || seen[path] {
+			continue
+		}
+		seen[path] = true
+		libs = append(libs, &LoadedLibrary{Path: path, Version: libraryVersion(name), Deleted: deleted})
+	}
+	return libs
+}
@@ -0,0 +1,50 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/osconfig/util/utiltest"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoadedLibraries(t *testing.T) {
+	utiltest.OverrideVariable(t, &procRoot, filepath.Join("testdata", "proc"))
+
+	got, err := NewLoadedLibrariesProvider([]string{"sshd", "nginx"}).GetInstalledPackages(context.Background())
+	if err != nil {
+		t.Fatalf("GetInstalledPackages() unexpected error: %v", err)
+	}
+	want := []*LoadedLibrary{
+		{Path: "/usr/lib/x86_64-linux-gnu/ld-linux-x86-64.so.2", Version: "2", Processes: []string{"sshd"}},
+		{Path: "/usr/lib/x86_64-linux-gnu/libc.so.6", Version: "6", Processes: []string{"nginx", "sshd"}},
+		{Path: "/usr/lib/x86_64-linux-gnu/libpcre-8.45.so", Version: "8.45", Processes: []string{"nginx"}},
+		{Path: "/usr/lib/x86_64-linux-gnu/libssl.so.3", Version: "3", Processes: []string{"nginx", "sshd"}, Deleted: true},
+	}
+	if diff := cmp.Diff(want, got.LoadedLibraries); diff != "" {
+		t.Errorf("GetInstalledPackages() unexpected diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestMatchProcessName(t *testing.T) {
+	processes := []string{"sshd", "google_osconfig_agent"}
+	utiltest.AssertEquals(t, matchProcessName("sshd", processes), "sshd")
+	utiltest.AssertEquals(t, matchProcessName("google_osconfig", processes), "google_osconfig_agent")
+	utiltest.AssertEquals(t, matchProcessName("google_os", processes), "")
+	utiltest.AssertEquals(t, matchProcessName("bash", processes), "")
+}
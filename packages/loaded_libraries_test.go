@@ -0,0 +1,51 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"context"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/osconfig/util/utiltest"
+)
+
+func TestLibraryVersion(t *testing.T) {
+	tests := map[string]string{
+		"libssl.so.3":              "3",
+		"libstdc++.so.6.0.30":      "6.0.30",
+		"libc-2.31.so":             "2.31",
+		"ld-linux-x86-64.so.2":     "2",
+		"libpam.so":                "",
+		"libnss_files-2.28.so.bak": "",
+	}
+	for name, want := range tests {
+		utiltest.AssertEquals(t, libraryVersion(name), want)
+	}
+}
+
+func TestLoadedLibrariesProviderWithoutProcesses(t *testing.T) {
+	utiltest.OverrideVariable(t, &loadedLibrariesQuery, func(context.Context, []string) ([]*LoadedLibrary, error) {
+		t.Error("libraries queried without an allowlist of processes")
+		return nil, nil
+	})
+
+	got, err := NewLoadedLibrariesProvider(nil).GetInstalledPackages(context.Background())
+	if err != nil {
+		t.Fatalf("GetInstalledPackages() unexpected error: %v", err)
+	}
+	if got.LoadedLibraries != nil {
+		t.Errorf("GetInstalledPackages() = %v, want no libraries", got.LoadedLibraries)
+	}
+}
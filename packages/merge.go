@@ -0,0 +1,91 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"reflect"
+)
+
+// Merge appends the packages of other to the corresponding lists of p, including the
+// Windows only lists. The merged lists do not share their backing arrays with other, so
+// appending to one does not modify the other, the packages themselves are shared. The
+// RPMBackend of other is used when the one of p is unknown.
+func (p *Packages) Merge(other Packages) {
+	dst := reflect.ValueOf(p).Elem()
+	src := reflect.ValueOf(other)
+	for i := 0; i < src.NumField(); i++ {
+		field := src.Field(i)
+		if field.Kind() != reflect.Slice || field.Len() == 0 {
+			continue
+		}
+		d := dst.Field(i)
+		merged := reflect.MakeSlice(d.Type(), 0, d.Len()+field.Len())
+		merged = reflect.AppendSlice(merged, d)
+		d.Set(reflect.AppendSlice(merged, field))
+	}
+	if p.RPMBackend == RPMBackendUnknown {
+		p.RPMBackend = other.RPMBackend
+	}
+}
+
+// Clone returns a deep copy of p, modifying the packages of the copy does not modify
+// the ones of p. Nil lists stay nil.
+func (p Packages) Clone() Packages {
+	return deepCopy(reflect.ValueOf(p)).Interface().(Packages)
+}
+
+// deepCopy returns a copy of v that shares no pointers, slices or maps with it.
+// Unexported struct fields, e.g. the ones of time.Time, are copied by value.
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(deepCopy(v.Elem()))
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+		}
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(deepCopy(v.Field(i)))
+			}
+		}
+		return c
+	default:
+		return v
+	}
+}
@@ -0,0 +1,157 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/osconfig/util/utiltest"
+	"github.com/google/go-cmp/cmp"
+)
+
+// allLists returns Packages with n new elements in each of its lists.
+func allLists(t *testing.T, n int) Packages {
+	t.Helper()
+	var pkgs Packages
+	v := reflect.ValueOf(&pkgs).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		if field.Kind() != reflect.Slice {
+			continue
+		}
+		if field.Type().Elem().Kind() != reflect.Pointer {
+			t.Fatalf("Packages.%s is expected to be a list of pointers", v.Type().Field(i).Name)
+		}
+		for j := 0; j < n; j++ {
+			field.Set(reflect.Append(field, reflect.New(field.Type().Elem().Elem())))
+		}
+	}
+	return pkgs
+}
+
+func TestPackagesMerge(t *testing.T) {
+	vim := &PkgInfo{Name: "vim", Type: "deb"}
+	curl := &PkgInfo{Name: "curl", Type: "deb"}
+	bash := &PkgInfo{Name: "bash", Type: "rpm"}
+	kb := &QFEPackage{HotFixID: "KB5034441"}
+
+	tests := []struct {
+		name  string
+		dst   Packages
+		other Packages
+		want  Packages
+	}{
+		{
+			name:  "disjoint managers",
+			dst:   Packages{Deb: []*PkgInfo{vim}},
+			other: Packages{Rpm: []*PkgInfo{bash}, QFE: []*QFEPackage{kb}, RPMBackend: RPMBackendDnf},
+			want:  Packages{Deb: []*PkgInfo{vim}, Rpm: []*PkgInfo{bash}, QFE: []*QFEPackage{kb}, RPMBackend: RPMBackendDnf},
+		},
+		{
+			name:  "overlapping managers",
+			dst:   Packages{Deb: []*PkgInfo{vim}, RPMBackend: RPMBackendYum},
+			other: Packages{Deb: []*PkgInfo{curl, vim}, RPMBackend: RPMBackendDnf},
+			want:  Packages{Deb: []*PkgInfo{vim, curl, vim}, RPMBackend: RPMBackendYum},
+		},
+		{
+			name:  "empty other",
+			dst:   Packages{Deb: []*PkgInfo{vim}},
+			other: Packages{},
+			want:  Packages{Deb: []*PkgInfo{vim}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.dst.Merge(tt.other)
+			if diff := cmp.Diff(tt.want, tt.dst); diff != "" {
+				t.Errorf("Merge() returned unexpected packages (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPackagesMergeAllLists(t *testing.T) {
+	pkgs := allLists(t, 1)
+	pkgs.Merge(allLists(t, 2))
+
+	v := reflect.ValueOf(pkgs)
+	for i := 0; i < v.NumField(); i++ {
+		if v.Field(i).Kind() == reflect.Slice && v.Field(i).Len() != 3 {
+			t.Errorf("Merge() did not merge Packages.%s, got %d packages, want: 3", v.Type().Field(i).Name, v.Field(i).Len())
+		}
+	}
+}
+
+func TestPackagesMergeDoesNotAlias(t *testing.T) {
+	other := Packages{Deb: make([]*PkgInfo, 1, 10)}
+	other.Deb[0] = &PkgInfo{Name: "vim"}
+	var pkgs Packages
+	pkgs.Merge(other)
+
+	pkgs.Deb = append(pkgs.Deb, &PkgInfo{Name: "curl"})
+	if got := other.Deb[:2][1]; got != nil {
+		t.Errorf("appending to the merged list modified the backing array of other, got: %+v", got)
+	}
+}
+
+func TestPackagesClone(t *testing.T) {
+	explicit := true
+	installed := time.Date(2025, time.May, 6, 7, 8, 9, 0, time.UTC)
+	pkgs := Packages{
+		Deb:                []*PkgInfo{{Name: "vim", Type: "deb", Explicit: &explicit, Files: []string{"/usr/bin/vim"}}},
+		WUA:                []*WUAPackage{{Title: "Update", Categories: []string{"Security Updates"}, LastDeploymentChangeTime: installed}},
+		WindowsApplication: []*WindowsApplication{{DisplayName: "7-Zip", InstallDate: installed}},
+		LoadedLibraries:    []*LoadedLibrary{{Path: "/usr/lib/libssl.so.3", Processes: []string{"sshd"}}},
+		RPMBackend:         RPMBackendDnf,
+	}
+
+	clone := pkgs.Clone()
+	if diff := cmp.Diff(pkgs, clone); diff != "" {
+		t.Fatalf("Clone() returned unexpected packages (-want +got):\n%s", diff)
+	}
+	utiltest.AssertEquals(t, clone.Rpm == nil, true)
+
+	clone.Deb[0].Name = "emacs"
+	*clone.Deb[0].Explicit = false
+	clone.Deb[0].Files[0] = "/usr/bin/emacs"
+	clone.WUA[0].Categories[0] = "Drivers"
+	clone.WindowsApplication[0].DisplayName = "WinRAR"
+	clone.LoadedLibraries[0].Processes[0] = "nginx"
+
+	utiltest.AssertEquals(t, pkgs.Deb[0].Name, "vim")
+	utiltest.AssertEquals(t, *pkgs.Deb[0].Explicit, true)
+	utiltest.AssertEquals(t, pkgs.Deb[0].Files[0], "/usr/bin/vim")
+	utiltest.AssertEquals(t, pkgs.WUA[0].Categories[0], "Security Updates")
+	utiltest.AssertEquals(t, pkgs.WindowsApplication[0].DisplayName, "7-Zip")
+	utiltest.AssertEquals(t, pkgs.LoadedLibraries[0].Processes[0], "sshd")
+}
+
+func TestPackagesCloneAllLists(t *testing.T) {
+	pkgs := allLists(t, 1)
+	clone := pkgs.Clone()
+
+	v, c := reflect.ValueOf(pkgs), reflect.ValueOf(clone)
+	for i := 0; i < v.NumField(); i++ {
+		if v.Field(i).Kind() != reflect.Slice {
+			continue
+		}
+		if c.Field(i).Len() != 1 || c.Field(i).Index(0).Pointer() == v.Field(i).Index(0).Pointer() {
+			t.Errorf("Clone() did not copy the packages of Packages.%s", v.Type().Field(i).Name)
+		}
+	}
+}
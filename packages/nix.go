@@ -0,0 +1,140 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/osconfig/clog"
+	"github.com/GoogleCloudPlatform/osconfig/util"
+)
+
+var (
+	nixStore string
+
+	// DefaultNixProfiles are the profiles holding the system wide nix packages.
+	DefaultNixProfiles = []string{"/run/current-system/sw", "/nix/var/nix/profiles/default"}
+
+	// nixQuery returns the store paths of the closure of profile, one per line.
+	nixQuery = func(ctx context.Context, profile string) ([]byte, error) {
+		return run(ctx, nixStore, []string{"--query", "--requisites", profile})
+	}
+)
+
+func init() {
+	for _, p := range []string{"/run/current-system/sw/bin/nix-store", "/nix/var/nix/profiles/default/bin/nix-store"} {
+		if util.Exists(p) {
+			nixStore = p
+			break
+		}
+	}
+	NixExists = nixStore != ""
+}
+
+type nixProvider struct {
+	profiles []string
+}
+
+// NewNixProvider returns a provider that reports the derivations installed in the
+// given nix profiles as Packages.Nix, nothing is reported when nix is not installed.
+func NewNixProvider(profiles []string) InstalledPackagesProvider {
+	return nixProvider{profiles: profiles}
+}
+
+func (p nixProvider) GetInstalledPackages(ctx context.Context) (Packages, error) {
+	if !NixExists {
+		return Packages{}, nil
+	}
+	pkgs, err := InstalledNixPackages(ctx, p.profiles)
+	if err != nil {
+		return Packages{}, err
+	}
+	return Packages{Nix: pkgs}, nil
+}
+
+// InstalledNixPackages queries for the derivations in the closure of each profile.
+// Store paths without a version, like sources and build scripts, are skipped and a
+// store path referenced by multiple profiles is reported once. Profiles that fail to be
+// queried are skipped, an error is only returned if all of them fail.
+func InstalledNixPackages(ctx context.Context, profiles []string) ([]*NixPackage, error) {
+	var pkgs []*NixPackage
+	var errs []error
+	seen := map[string]bool{}
+	for _, profile := range profiles {
+		out, err := nixQuery(ctx, profile)
+		if err != nil {
+			clog.Warningf(ctx, "Error querying nix profile %q, skipping it: %v", profile, err)
+			errs = append(errs, fmt.Errorf("nix profile %q: %w", profile, err))
+			continue
+		}
+		for _, pkg := range parseNixStorePaths(ctx, out) {
+			if seen[pkg.Location[0]] {
+				continue
+			}
+			seen[pkg.Location[0]] = true
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	if len(errs) != 0 && len(errs) == len(profiles) {
+		return nil, errors.Join(errs...)
+	}
+	return pkgs, nil
+}
+
+func parseNixStorePaths(ctx context.Context, data []byte) []*NixPackage {
+	/*
+	   /nix/store/1b9p07z77phvv2hf6gm9f28syp39f1ag-bash-5.2p26
+	   /nix/store/5ldnhk1jdbq0xcqx2yg2rqkfbfnvxq0b-openssl-3.0.13-bin
+	   /nix/store/9zpm3pk0pk0b0gmi2kgsbvd4r0pfb16y-source
+	*/
+	var pkgs []*NixPackage
+	for _, ln := range bytes.Split(bytes.TrimSpace(data), []byte("\n")) {
+		storePath := string(bytes.TrimSpace(ln))
+		if storePath == "" {
+			continue
+		}
+		_, drvName, ok := strings.Cut(path.Base(storePath), "-")
+		if !ok {
+			clog.Debugf(ctx, "%q does not represent a nix store path", storePath)
+			continue
+		}
+		name, version := parseNixDrvName(drvName)
+		if version == "" {
+			continue
+		}
+		pkgs = append(pkgs, &NixPackage{Name: name, Version: version, Location: []string{storePath}})
+	}
+	return pkgs
+}
+
+// parseNixDrvName splits a derivation name the way builtins.parseDrvName does:
+// the version starts at the first dash followed by a character that is not a letter.
+func parseNixDrvName(drvName string) (name, version string) {
+	for i := 0; i < len(drvName)-1; i++ {
+		if drvName[i] == '-' && !isASCIILetter(drvName[i+1]) {
+			return drvName[:i], drvName[i+1:]
+		}
+	}
+	return drvName, ""
+}
+
+func isASCIILetter(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
@@ -0,0 +1,118 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func fakeNixQuery(t *testing.T, closures map[string]string) {
+	t.Helper()
+	oldNixQuery, oldNixExists := nixQuery, NixExists
+	nixQuery = func(_ context.Context, profile string) ([]byte, error) {
+		out, ok := closures[profile]
+		if !ok {
+			return nil, errors.New("profile not found")
+		}
+		return []byte(out), nil
+	}
+	NixExists = true
+	t.Cleanup(func() { nixQuery, NixExists = oldNixQuery, oldNixExists })
+}
+
+func TestInstalledNixPackages(t *testing.T) {
+	fakeNixQuery(t, map[string]string{
+		"/run/current-system/sw": "/nix/store/1b9p07z77phvv2hf6gm9f28syp39f1ag-bash-5.2p26\n" +
+			"/nix/store/5ldnhk1jdbq0xcqx2yg2rqkfbfnvxq0b-openssl-3.0.13-bin\n" +
+			"/nix/store/9zpm3pk0pk0b0gmi2kgsbvd4r0pfb16y-source\n" +
+			"/nix/store/aj1kr0khdcsdbyqlpsg2q7ffawgkr1k6-system-path\n",
+		"/nix/var/nix/profiles/default": "/nix/store/1b9p07z77phvv2hf6gm9f28syp39f1ag-bash-5.2p26\n" +
+			"/nix/store/qn4s8i0bbqhab2ihxm6rrwc3nlmym3gi-nix-2.18.1\n",
+	})
+
+	got, err := InstalledNixPackages(testCtx, DefaultNixProfiles)
+	if err != nil {
+		t.Fatalf("InstalledNixPackages() unexpected error: %v", err)
+	}
+
+	want := []*NixPackage{
+		{Name: "bash", Version: "5.2p26", Location: []string{"/nix/store/1b9p07z77phvv2hf6gm9f28syp39f1ag-bash-5.2p26"}},
+		{Name: "openssl", Version: "3.0.13-bin", Location: []string{"/nix/store/5ldnhk1jdbq0xcqx2yg2rqkfbfnvxq0b-openssl-3.0.13-bin"}},
+		{Name: "nix", Version: "2.18.1", Location: []string{"/nix/store/qn4s8i0bbqhab2ihxm6rrwc3nlmym3gi-nix-2.18.1"}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("InstalledNixPackages() unexpected diff:\n%s", diff)
+	}
+}
+
+func TestInstalledNixPackagesQueryError(t *testing.T) {
+	fakeNixQuery(t, map[string]string{})
+
+	if _, err := NewNixProvider([]string{"/missing"}).GetInstalledPackages(testCtx); err == nil {
+		t.Errorf("GetInstalledPackages() expected error for unknown profile")
+	}
+}
+
+func TestInstalledNixPackagesSkipsFailedProfile(t *testing.T) {
+	fakeNixQuery(t, map[string]string{
+		"/nix/var/nix/profiles/default": "/nix/store/qn4s8i0bbqhab2ihxm6rrwc3nlmym3gi-nix-2.18.1\n",
+	})
+
+	got, err := InstalledNixPackages(testCtx, DefaultNixProfiles)
+	if err != nil {
+		t.Fatalf("InstalledNixPackages() unexpected error: %v", err)
+	}
+
+	want := []*NixPackage{
+		{Name: "nix", Version: "2.18.1", Location: []string{"/nix/store/qn4s8i0bbqhab2ihxm6rrwc3nlmym3gi-nix-2.18.1"}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("InstalledNixPackages() unexpected diff:\n%s", diff)
+	}
+}
+
+func TestNixProviderWithoutNix(t *testing.T) {
+	fakeNixQuery(t, map[string]string{})
+	NixExists = false
+
+	got, err := NewNixProvider(DefaultNixProfiles).GetInstalledPackages(testCtx)
+	if err != nil {
+		t.Fatalf("GetInstalledPackages() unexpected error: %v", err)
+	}
+	if got.Nix != nil {
+		t.Errorf("GetInstalledPackages() unexpected nix packages without nix installed: %v", got.Nix)
+	}
+}
+
+func TestParseNixDrvName(t *testing.T) {
+	tests := []struct {
+		drvName, name, version string
+	}{
+		{"bash-5.2p26", "bash", "5.2p26"},
+		{"python3.11-requests-2.31.0", "python3.11-requests", "2.31.0"},
+		{"source", "source", ""},
+		{"system-path", "system-path", ""},
+	}
+	for _, tt := range tests {
+		name, version := parseNixDrvName(tt.drvName)
+		if name != tt.name || version != tt.version {
+			t.Errorf("parseNixDrvName(%q) = (%q, %q), want: (%q, %q)", tt.drvName, name, version, tt.name, tt.version)
+		}
+	}
+}
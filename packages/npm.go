@@ -0,0 +1,153 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/osconfig/clog"
+	"github.com/package-url/packageurl-go"
+)
+
+const nodeModules = "node_modules"
+
+type npmProvider struct {
+	roots    []string
+	maxDepth int
+}
+
+// NewNpmProvider returns a provider that reports the packages installed in the
+// node_modules directories found under roots as Packages.Npm. Directories nested
+// deeper than maxDepth levels below a root are not scanned.
+func NewNpmProvider(roots []string, maxDepth int) InstalledPackagesProvider {
+	return npmProvider{roots: roots, maxDepth: maxDepth}
+}
+
+func (p npmProvider) GetInstalledPackages(ctx context.Context) (Packages, error) {
+	pkgs, err := InstalledNpmPackages(ctx, p.roots, p.maxDepth)
+	if err != nil {
+		return Packages{}, err
+	}
+	return Packages{Npm: pkgs}, nil
+}
+
+type packageJSON struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type npmScanner struct {
+	maxDepth int
+	visited  map[string]bool
+	seen     map[string]*NpmPackage
+	pkgs     []*NpmPackage
+}
+
+// InstalledNpmPackages scans roots up to maxDepth directories deep for packages installed
+// in node_modules directories. Symlinked directories are followed, each directory is
+// scanned only once so symlink cycles are skipped. A package installed in multiple
+// places is reported once with all the directories as location.
+func InstalledNpmPackages(ctx context.Context, roots []string, maxDepth int) ([]*NpmPackage, error) {
+	s := &npmScanner{maxDepth: maxDepth, visited: map[string]bool{}, seen: map[string]*NpmPackage{}}
+	for _, root := range roots {
+		if err := s.scan(ctx, root, 0, false); err != nil {
+			return nil, err
+		}
+	}
+	return s.pkgs, nil
+}
+
+func (s *npmScanner) scan(ctx context.Context, dir string, depth int, isPackage bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		clog.Debugf(ctx, "Error resolving %q: %v", dir, err)
+		return nil
+	}
+	if s.visited[resolved] {
+		return nil
+	}
+	s.visited[resolved] = true
+
+	if isPackage {
+		s.addPackage(ctx, dir)
+	}
+	if depth >= s.maxDepth {
+		return nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		clog.Debugf(ctx, "Error reading %q: %v", dir, err)
+		return nil
+	}
+	// Packages live directly in node_modules or in a scope directory (node_modules/@scope).
+	base := filepath.Base(dir)
+	packagesDir := base == nodeModules || (strings.HasPrefix(base, "@") && filepath.Base(filepath.Dir(dir)) == nodeModules)
+	for _, e := range entries {
+		p := filepath.Join(dir, e.Name())
+		if e.Type()&os.ModeSymlink != 0 {
+			if fi, err := os.Stat(p); err != nil || !fi.IsDir() {
+				continue
+			}
+		} else if !e.IsDir() {
+			continue
+		}
+		isPackage := packagesDir && !strings.HasPrefix(e.Name(), "@") && !strings.HasPrefix(e.Name(), ".")
+		if err := s.scan(ctx, p, depth+1, isPackage); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *npmScanner) addPackage(ctx context.Context, dir string) {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return
+	}
+	var pj packageJSON
+	if err := json.Unmarshal(data, &pj); err != nil {
+		clog.Debugf(ctx, "Error parsing package.json in %q: %v", dir, err)
+		return
+	}
+	if pj.Name == "" {
+		return
+	}
+
+	key := pj.Name + "@" + pj.Version
+	if pkg, ok := s.seen[key]; ok {
+		pkg.Location = append(pkg.Location, dir)
+		return
+	}
+	pkg := &NpmPackage{Name: pj.Name, Version: pj.Version, Purl: npmPurl(pj.Name, pj.Version), Location: []string{dir}}
+	s.seen[key] = pkg
+	s.pkgs = append(s.pkgs, pkg)
+}
+
+func npmPurl(name, version string) string {
+	namespace := ""
+	if scope, n, ok := strings.Cut(name, "/"); ok {
+		namespace, name = scope, n
+	}
+	return BuildPurl(packageurl.TypeNPM, namespace, name, version, nil)
+}
@@ -0,0 +1,100 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func writePackageJSON(t *testing.T, dir, content string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newNodeModulesFixture creates the following tree:
+//
+//	app/package.json                              (the application itself, not reported)
+//	app/node_modules/lodash                       lodash@4.17.21
+//	app/node_modules/@types/node                  @types/node@20.1.0
+//	app/node_modules/express/node_modules/lodash  lodash@4.17.21
+//	app/node_modules/.bin                         (skipped)
+//	app/node_modules/cycle -> app                 (symlink cycle)
+//	app/deep/a/b/node_modules/left-pad            (nested deeper than the max depth)
+func newNodeModulesFixture(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	app := filepath.Join(root, "app")
+	writePackageJSON(t, app, `{"name": "app", "version": "1.0.0"}`)
+	writePackageJSON(t, filepath.Join(app, "node_modules", "lodash"), `{"name": "lodash", "version": "4.17.21"}`)
+	writePackageJSON(t, filepath.Join(app, "node_modules", "@types", "node"), `{"name": "@types/node", "version": "20.1.0"}`)
+	writePackageJSON(t, filepath.Join(app, "node_modules", "express"), `{"name": "express", "version": "4.18.2"}`)
+	writePackageJSON(t, filepath.Join(app, "node_modules", "express", "node_modules", "lodash"), `{"name": "lodash", "version": "4.17.21"}`)
+	writePackageJSON(t, filepath.Join(app, "node_modules", ".bin"), `{"name": "bin", "version": "0.0.1"}`)
+	writePackageJSON(t, filepath.Join(app, "deep", "a", "b", "node_modules", "left-pad"), `{"name": "left-pad", "version": "1.3.0"}`)
+	if err := os.Symlink(app, filepath.Join(app, "node_modules", "cycle")); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestInstalledNpmPackages(t *testing.T) {
+	root := newNodeModulesFixture(t)
+	app := filepath.Join(root, "app")
+
+	got, err := InstalledNpmPackages(context.Background(), []string{root}, 5)
+	if err != nil {
+		t.Fatalf("InstalledNpmPackages() unexpected error: %v", err)
+	}
+
+	want := []*NpmPackage{
+		{Name: "@types/node", Version: "20.1.0", Purl: "pkg:npm/%40types/node@20.1.0", Location: []string{filepath.Join(app, "node_modules", "@types", "node")}},
+		{Name: "express", Version: "4.18.2", Purl: "pkg:npm/express@4.18.2", Location: []string{filepath.Join(app, "node_modules", "express")}},
+		{Name: "lodash", Version: "4.17.21", Purl: "pkg:npm/lodash@4.17.21", Location: []string{
+			filepath.Join(app, "node_modules", "express", "node_modules", "lodash"),
+			filepath.Join(app, "node_modules", "lodash"),
+		}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("InstalledNpmPackages() unexpected diff:\n%s", diff)
+	}
+}
+
+func TestInstalledNpmPackagesMaxDepth(t *testing.T) {
+	root := newNodeModulesFixture(t)
+
+	got, err := NewNpmProvider([]string{root}, 7).GetInstalledPackages(context.Background())
+	if err != nil {
+		t.Fatalf("GetInstalledPackages() unexpected error: %v", err)
+	}
+
+	var names []string
+	for _, pkg := range got.Npm {
+		names = append(names, pkg.Name)
+	}
+	want := []string{"left-pad", "@types/node", "express", "lodash"}
+	if diff := cmp.Diff(want, names); diff != "" {
+		t.Errorf("GetInstalledPackages() unexpected packages diff:\n%s", diff)
+	}
+}
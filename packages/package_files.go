@@ -0,0 +1,84 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"context"
+	"os"
+
+	"github.com/GoogleCloudPlatform/osconfig/clog"
+)
+
+// MaxPackageFiles is the highest number of files PackageFilesProvider reports for a
+// package, larger limits are lowered to it to bound the size of the inventory.
+const MaxPackageFiles = 50
+
+// packageFilesQuery returns the paths owned by an installed deb or rpm package, in the
+// order of the package database. It is nil on operating systems without a supported
+// package manager.
+var packageFilesQuery func(ctx context.Context, pkg *PkgInfo) ([]string, error)
+
+// isPackageFile reports whether a path owned by a package is reported, package databases
+// also list the directories a package creates.
+var isPackageFile = func(path string) bool {
+	fi, err := os.Lstat(path)
+	return err == nil && !fi.IsDir()
+}
+
+type packageFilesProvider struct {
+	provider InstalledPackagesProvider
+	maxFiles int
+}
+
+// PackageFilesProvider creates an InstalledPackagesProvider decorator that records the
+// first maxFiles files owned by each deb and rpm package in PkgInfo.Files, maxFiles is
+// capped at MaxPackageFiles. Packages whose files cannot be listed are left unchanged.
+func PackageFilesProvider(provider InstalledPackagesProvider, maxFiles int) InstalledPackagesProvider {
+	return packageFilesProvider{provider: provider, maxFiles: min(maxFiles, MaxPackageFiles)}
+}
+
+func (p packageFilesProvider) GetInstalledPackages(ctx context.Context) (Packages, error) {
+	pkgs, err := p.provider.GetInstalledPackages(ctx)
+	if packageFilesQuery == nil || p.maxFiles <= 0 {
+		return pkgs, err
+	}
+	for _, list := range [][]*PkgInfo{pkgs.Deb, pkgs.Rpm} {
+		for _, pkg := range list {
+			paths, err := packageFilesQuery(ctx, pkg)
+			if err != nil {
+				clog.Debugf(ctx, "Error listing the files of package %s: %v", pkg.Name, err)
+				continue
+			}
+			pkg.Files, pkg.FilesTruncated = firstPackageFiles(paths, p.maxFiles)
+		}
+	}
+	return pkgs, err
+}
+
+// firstPackageFiles returns the first max files in paths and whether more were found.
+// Paths are checked lazily, so large packages cost no more than small ones.
+func firstPackageFiles(paths []string, max int) ([]string, bool) {
+	var files []string
+	for _, path := range paths {
+		if !isPackageFile(path) {
+			continue
+		}
+		if len(files) == max {
+			return files, true
+		}
+		files = append(files, path)
+	}
+	return files, false
+}
@@ -0,0 +1,77 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"strings"
+)
+
+func init() {
+	packageFilesQuery = queryPackageFiles
+}
+
+// queryPackageFiles lists the files of a deb package with dpkg-query -L and of an rpm
+// package with rpmquery -l.
+func queryPackageFiles(ctx context.Context, pkg *PkgInfo) ([]string, error) {
+	var out []byte
+	var err error
+	switch pkg.Type {
+	case typeDebian:
+		if !DpkgQueryExists {
+			return nil, nil
+		}
+		name := pkg.Name
+		if pkg.RawArch != "" {
+			name += ":" + pkg.RawArch
+		}
+		out, err = run(ctx, dpkgQuery, []string{"-L", name})
+	case typeRPM:
+		if !RPMQueryExists {
+			return nil, nil
+		}
+		out, err = run(ctx, rpmquery, []string{"-l", pkg.Name})
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parsePackageFiles(out), nil
+}
+
+// parsePackageFiles returns the absolute paths in the output of dpkg-query -L or
+// rpmquery -l, notes such as diversions and "(contains no files)" are skipped.
+func parsePackageFiles(data []byte) []string {
+	/*
+		/.
+		/usr
+		/usr/bin
+		/usr/bin/curl
+		diverted by other-package to: /usr/bin/curl.real
+	*/
+	var paths []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "/") || line == "/." {
+			continue
+		}
+		paths = append(paths, line)
+	}
+	return paths
+}
@@ -0,0 +1,51 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/osconfig/util/utiltest"
+)
+
+func TestParsePackageFiles(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{
+			name: "dpkg-query",
+			data: "/.\n/usr\n/usr/bin\n/usr/bin/curl\ndiverted by other-package to: /usr/bin/curl.real\n",
+			want: []string{"/usr", "/usr/bin", "/usr/bin/curl"},
+		},
+		{
+			name: "rpmquery",
+			data: "/usr/bin/bash\n/usr/share/doc/bash\n",
+			want: []string{"/usr/bin/bash", "/usr/share/doc/bash"},
+		},
+		{
+			name: "rpmquery without files",
+			data: "(contains no files)\n",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			utiltest.AssertEquals(t, parsePackageFiles([]byte(tt.data)), tt.want)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/osconfig/util/utiltest"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPackageFilesProvider(t *testing.T) {
+	ctx := context.Background()
+	// Directories are listed by the package databases but not reported.
+	dirs := map[string]bool{"/usr": true, "/usr/bin": true}
+	utiltest.OverrideVariable(t, &isPackageFile, func(path string) bool { return !dirs[path] })
+
+	var queried []string
+	utiltest.OverrideVariable(t, &packageFilesQuery, func(_ context.Context, pkg *PkgInfo) ([]string, error) {
+		queried = append(queried, pkg.Name)
+		switch pkg.Name {
+		case "coreutils":
+			var paths []string
+			for i := 0; i < 100; i++ {
+				paths = append(paths, fmt.Sprintf("/usr/bin/tool%d", i))
+			}
+			return append([]string{"/usr", "/usr/bin"}, paths...), nil
+		case "curl":
+			return []string{"/usr", "/usr/bin", "/usr/bin/curl", "/usr/share/man/man1/curl.1.gz"}, nil
+		case "bash":
+			return []string{"/usr", "/usr/bin", "/usr/bin/bash", "/usr/bin/sh", "/usr/bin/rbash"}, nil
+		}
+		return nil, errors.New("package is not installed")
+	})
+
+	newPkgs := func() Packages {
+		return Packages{
+			Deb: []*PkgInfo{{Name: "coreutils", Type: "deb"}, {Name: "curl", Type: "deb"}, {Name: "missing", Type: "deb"}},
+			Rpm: []*PkgInfo{{Name: "bash", Type: "rpm"}},
+			Pip: []*PipPackage{{PkgInfo: PkgInfo{Name: "requests", Type: "pypi"}}},
+		}
+	}
+
+	got, err := PackageFilesProvider(staticInstalledPackagesProvider{pkgs: newPkgs()}, 3).GetInstalledPackages(ctx)
+	if err != nil {
+		t.Fatalf("GetInstalledPackages() unexpected error: %v", err)
+	}
+	want := Packages{
+		Deb: []*PkgInfo{
+			{Name: "coreutils", Type: "deb", Files: []string{"/usr/bin/tool0", "/usr/bin/tool1", "/usr/bin/tool2"}, FilesTruncated: true},
+			{Name: "curl", Type: "deb", Files: []string{"/usr/bin/curl", "/usr/share/man/man1/curl.1.gz"}},
+			{Name: "missing", Type: "deb"},
+		},
+		Rpm: []*PkgInfo{{Name: "bash", Type: "rpm", Files: []string{"/usr/bin/bash", "/usr/bin/sh", "/usr/bin/rbash"}}},
+		Pip: []*PipPackage{{PkgInfo: PkgInfo{Name: "requests", Type: "pypi"}}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("GetInstalledPackages() returned unexpected packages (-want +got):\n%s", diff)
+	}
+	utiltest.AssertEquals(t, queried, []string{"coreutils", "curl", "missing", "bash"})
+
+	// Limits above MaxPackageFiles are lowered to it.
+	got, _ = PackageFilesProvider(staticInstalledPackagesProvider{pkgs: newPkgs()}, 1000).GetInstalledPackages(ctx)
+	utiltest.AssertEquals(t, len(got.Deb[0].Files), MaxPackageFiles)
+	utiltest.AssertEquals(t, got.Deb[0].FilesTruncated, true)
+
+	// Nothing is queried without a limit.
+	queried = nil
+	got, _ = PackageFilesProvider(staticInstalledPackagesProvider{pkgs: newPkgs()}, 0).GetInstalledPackages(ctx)
+	if len(queried) != 0 || got.Deb[0].Files != nil {
+		t.Errorf("files are expected not to be listed with a zero limit, queried: %v", queried)
+	}
+}
+
+func TestPackageFilesProviderKeepsError(t *testing.T) {
+	utiltest.OverrideVariable(t, &isPackageFile, func(string) bool { return true })
+	utiltest.OverrideVariable(t, &packageFilesQuery, func(context.Context, *PkgInfo) ([]string, error) {
+		return []string{"/usr/bin/vim"}, nil
+	})
+	wantErr := errors.New("error listing installed rpm packages")
+	provider := PackageFilesProvider(staticInstalledPackagesProvider{
+		pkgs: Packages{Deb: []*PkgInfo{{Name: "vim", Type: "deb"}}},
+		err:  wantErr,
+	}, 5)
+
+	got, err := provider.GetInstalledPackages(context.Background())
+	if !errors.Is(err, wantErr) {
+		t.Errorf("GetInstalledPackages() error = %v, want: %v", err, wantErr)
+	}
+	utiltest.AssertEquals(t, got.Deb[0].Files, []string{"/usr/bin/vim"})
+}
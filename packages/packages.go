@@ -17,10 +17,13 @@
 package packages
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"os/exec"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -38,6 +41,8 @@ var (
 	DpkgQueryExists bool
 	// YumExists indicates whether yum is installed.
 	YumExists bool
+	// DnfExists indicates whether dnf is installed.
+	DnfExists bool
 	// ZypperExists indicates whether zypper is installed.
 	ZypperExists bool
 	// RPMExists indicates whether rpm is installed.
@@ -54,6 +59,12 @@ var (
 	GooGetExists bool
 	// MSIExists indicates whether MSIs can be installed.
 	MSIExists bool
+	// PkgExists indicates whether FreeBSD pkg is installed.
+	PkgExists bool
+	// NixExists indicates whether nix is installed.
+	NixExists bool
+	// SystemctlExists indicates whether systemctl is installed.
+	SystemctlExists bool
 
 	noarch = osinfo.NormalizeArchitecture("noarch")
 
@@ -105,11 +116,144 @@ type Packages struct {
 	ZypperPatches      []*ZypperPatch        `json:"zypperPatches,omitempty"`
 	COS                []*PkgInfo            `json:"cos,omitempty"`
 	Gem                []*PkgInfo            `json:"gem,omitempty"`
-	Pip                []*PkgInfo            `json:"pip,omitempty"`
+	Pip                []*PipPackage         `json:"pip,omitempty"`
 	GooGet             []*PkgInfo            `json:"googet,omitempty"`
 	WUA                []*WUAPackage         `json:"wua,omitempty"`
 	QFE                []*QFEPackage         `json:"qfe,omitempty"`
 	WindowsApplication []*WindowsApplication `json:"-"`
+	KernelModules      []*PkgInfo            `json:"kernelModules,omitempty"`
+	GoModules          []*GoModule           `json:"goModules,omitempty"`
+	Npm                []*NpmPackage         `json:"npm,omitempty"`
+	Cargo              []*CargoPackage       `json:"cargo,omitempty"`
+	ContainerImages    []*ContainerImage     `json:"containerImages,omitempty"`
+	Pkg                []*FreeBSDPackage     `json:"pkg,omitempty"`
+	Nix                []*NixPackage         `json:"nix,omitempty"`
+	Conda              []*CondaPackage       `json:"conda,omitempty"`
+	SystemdUnits       []*SystemdUnit        `json:"systemdUnits,omitempty"`
+	WindowsServices    []*WindowsService     `json:"windowsServices,omitempty"`
+	Firmware           []*Firmware           `json:"firmware,omitempty"`
+	ListeningPorts     []*ListeningPort      `json:"listeningPorts,omitempty"`
+	Certificates       []*Certificate        `json:"certificates,omitempty"`
+	IDEExtensions      []*IDEExtension       `json:"ideExtensions,omitempty"`
+	GooGetRepositories []*GooGetRepository   `json:"googetRepositories,omitempty"`
+	Repositories       []*PackageRepository  `json:"repositories,omitempty"`
+	LoadedLibraries    []*LoadedLibrary      `json:"loadedLibraries,omitempty"`
+	EnvironmentModules []*EnvironmentModule  `json:"environmentModules,omitempty"`
+
+	// RPMBackend is the package manager managing the packages in Rpm.
+	RPMBackend RPMBackend `json:"rpmBackend,omitempty"`
+}
+
+// SystemdUnit describes a systemd service unit.
+type SystemdUnit struct {
+	Name string
+	// ActiveState is the high-level unit activation state, e.g. "active" or "inactive".
+	ActiveState string
+	// SubState is the low-level unit activation state, e.g. "running" or "exited".
+	SubState string
+	// UnitFileState is the enablement of the unit file, e.g. "enabled", "disabled" or "masked".
+	UnitFileState string
+}
+
+// WindowsService describes a Windows service.
+type WindowsService struct {
+	Name        string
+	DisplayName string
+	// StartType is how the service is started, e.g. "auto", "auto-delayed", "manual" or "disabled".
+	StartType string
+	// State is the current state of the service, e.g. "running" or "stopped".
+	State string
+}
+
+// Firmware describes the system firmware (BIOS or UEFI) as reported by SMBIOS.
+type Firmware struct {
+	Vendor  string
+	Version string
+	// ReleaseDate is the firmware release date in the SMBIOS format, MM/DD/YYYY.
+	ReleaseDate string
+}
+
+// ListeningPort describes a socket listening for network connections.
+type ListeningPort struct {
+	// Protocol is "tcp", "tcp6", "udp" or "udp6".
+	Protocol string
+	Address  string
+	Port     int
+	// PID and Process identify the owning process, they are empty when it cannot be
+	// determined, e.g. without sufficient permissions.
+	PID     int
+	Process string
+}
+
+// LoadedLibrary describes a shared library mapped by running processes.
+type LoadedLibrary struct {
+	Path string
+	// Version is parsed from the file name, e.g. "3" for libssl.so.3 or "2.31" for
+	// libc-2.31.so, it is empty when the name carries no version.
+	Version string
+	// Processes are the names of the processes that mapped the library, sorted.
+	Processes []string
+	// Deleted indicates that the mapped file was removed or replaced, e.g. by a package
+	// upgrade, after the processes loaded it.
+	Deleted bool
+}
+
+// EnvironmentModule describes an Lmod or Environment Modules modulefile.
+type EnvironmentModule struct {
+	// Name is the path of the module below the module path, e.g. "mpi/openmpi".
+	Name, Version string
+	// Path is the modulefile, ModulePath is the module path it was found in.
+	Path, ModulePath string
+	// Format is "lua" for Lmod modulefiles and "tcl" for Tcl modulefiles.
+	Format string
+	// Default indicates that the version is the default of the module, as selected by
+	// a default symlink or a .version file.
+	Default bool
+}
+
+// Certificate describes a certificate of the system trust store.
+type Certificate struct {
+	Subject string
+	Issuer  string
+	// Fingerprint is the hex encoded SHA-256 digest of the DER encoded certificate.
+	Fingerprint string
+	NotAfter    time.Time
+	// Expired reports whether NotAfter had passed when the certificate was collected.
+	Expired bool
+	// Location is the file or the certificate store the certificate was read from.
+	Location string
+}
+
+// RPMBackend identifies the package manager that manages the rpm packages of a host.
+type RPMBackend string
+
+const (
+	// RPMBackendUnknown is used when no rpm package manager was detected.
+	RPMBackendUnknown RPMBackend = ""
+	// RPMBackendDnf is used on hosts managed by dnf.
+	RPMBackendDnf RPMBackend = "dnf"
+	// RPMBackendYum is used on hosts managed by yum.
+	RPMBackendYum RPMBackend = "yum"
+	// RPMBackendZypper is used on hosts managed by zypper.
+	RPMBackendZypper RPMBackend = "zypper"
+)
+
+// DetectRPMBackend returns the package manager managing the rpm packages of the host,
+// dnf is preferred over yum which it replaces, and yum over zypper.
+func DetectRPMBackend() RPMBackend {
+	return detectRPMBackend(DnfExists, YumExists, ZypperExists)
+}
+
+func detectRPMBackend(dnfExists, yumExists, zypperExists bool) RPMBackend {
+	switch {
+	case dnfExists:
+		return RPMBackendDnf
+	case yumExists:
+		return RPMBackendYum
+	case zypperExists:
+		return RPMBackendZypper
+	}
+	return RPMBackendUnknown
 }
 
 // PkgInfo describes a package.
@@ -117,6 +261,34 @@ type PkgInfo struct {
 	Name, Arch, RawArch, Version, Type, Purl string
 
 	Source Source
+
+	// Size is the installed size of the package in bytes, 0 when unknown.
+	Size int64 `json:",omitempty"`
+	// Vendor is the vendor of rpm packages.
+	Vendor string `json:",omitempty"`
+	// Maintainer is the maintainer of deb packages.
+	Maintainer string `json:",omitempty"`
+	// Digest is the header checksum of rpm packages prefixed with its algorithm, e.g.
+	// "sha256:..." or "sha1:..." on rpm versions without SHA256HEADER. dpkg keeps no checksum of installed deb packages.
+	Digest string `json:",omitempty"`
+	// Repository is the repository an available apt, yum or zypper update is published
+	// by, e.g. "Ubuntu:22.04/jammy-updates" or "updates".
+	Repository string `json:",omitempty"`
+	// Security indicates that an available update is published by a security
+	// repository, e.g. the bookworm-security suite, or fixes a yum security advisory.
+	Security bool `json:",omitempty"`
+	// Explicit reports whether an installed package was requested by a user rather than
+	// installed as a dependency, nil when the package manager does not record it.
+	Explicit *bool `json:",omitempty"`
+	// Files are the first files owned by an installed package, reported only when
+	// enabled with PackageFilesProvider. FilesTruncated indicates that the package owns
+	// more files than were reported.
+	Files          []string `json:",omitempty"`
+	FilesTruncated bool     `json:",omitempty"`
+	// Category is the portage category of COS packages, e.g. "app-shells", and BuildID
+	// the BUILD_ID of the COS image the package was shipped with.
+	Category string `json:",omitempty"`
+	BuildID  string `json:",omitempty"`
 }
 
 const (
@@ -126,6 +298,8 @@ const (
 	typeGooGet = "googet"
 	typeGem    = "gem"
 	typePypi   = "pypi"
+
+	typeKernelModule = "kernel-module"
 )
 
 // Source represents source package from which binary package was built.
@@ -137,9 +311,30 @@ func (i *PkgInfo) String() string {
 	return fmt.Sprintf("%s %s %s", i.Name, i.Arch, i.Version)
 }
 
+// PipPackage describes an installed pip package or an available pip update.
+type PipPackage struct {
+	PkgInfo
+
+	// Location is the site-packages directory the package is installed in.
+	Location string `json:",omitempty"`
+	// Virtualenv indicates that the package is installed in a virtual environment.
+	Virtualenv bool `json:",omitempty"`
+	// LocalProject is the project directory of a package installed from a local path
+	// instead of a package index, e.g. with pip install -e.
+	LocalProject string `json:",omitempty"`
+	// Editable indicates that the package is an editable install of LocalProject.
+	Editable bool `json:",omitempty"`
+	// License and Summary are read from the METADATA file of the dist-info directory of
+	// the package.
+	License string `json:",omitempty"`
+	Summary string `json:",omitempty"`
+}
+
 // ZypperPatch describes a Zypper patch.
 type ZypperPatch struct {
 	Name, Category, Severity, Summary, Purl string
+	// Status is the zypper status of the patch, e.g. "needed" or "applied".
+	Status string `json:",omitempty"`
 }
 
 // WUAPackage describes a Windows Update Agent package.
@@ -155,6 +350,32 @@ type WUAPackage struct {
 	CategoryIDs              []string
 	RevisionNumber           int32
 	Purl                     string
+	// MsrcSeverity is the Microsoft Security Response Center severity rating of the
+	// update, e.g. "Critical" or "Important", empty when the update is not rated.
+	MsrcSeverity string
+	// MaxDownloadSize is the maximum download size of the update in bytes, 0 when unknown.
+	MaxDownloadSize int64 `json:",omitempty"`
+	// RebootRequired indicates whether installing the update requires a reboot, nil when
+	// unknown. For installed updates it indicates a reboot pending to complete the install.
+	RebootRequired *bool `json:",omitempty"`
+}
+
+// wuaSecurityUpdatesCategoryID is the ID of the "Security Updates" update classification.
+const wuaSecurityUpdatesCategoryID = "0fa1201d-4330-4fa8-8ae9-b877473b6441"
+
+// IsSecurityUpdate reports whether the update is classified as a security update.
+func (p *WUAPackage) IsSecurityUpdate() bool {
+	for _, id := range p.CategoryIDs {
+		if strings.EqualFold(id, wuaSecurityUpdatesCategoryID) {
+			return true
+		}
+	}
+	for _, category := range p.Categories {
+		if category == "Security Updates" {
+			return true
+		}
+	}
+	return false
 }
 
 // QFEPackage describes a Windows Quick Fix Engineering package.
@@ -162,6 +383,95 @@ type QFEPackage struct {
 	Caption, Description, HotFixID, InstalledOn, Purl string
 }
 
+// GoModule describes a Go module embedded in one or more Go binaries.
+type GoModule struct {
+	Path, Version, Purl string
+	// Binaries lists the paths of the binaries the module is built into.
+	Binaries []string
+}
+
+// NpmPackage describes a package installed in a node_modules directory.
+type NpmPackage struct {
+	Name, Version, Purl string
+	// Location lists the directories the package is installed in.
+	Location []string
+}
+
+// CargoPackage describes a Rust crate installed by cargo install or locked in a
+// Cargo.lock file.
+type CargoPackage struct {
+	Name, Version, Purl string
+	// Source is the cargo source of the crate, e.g.
+	// "registry+https://github.com/rust-lang/crates.io-index".
+	Source string
+	// Location lists the installed binaries and the Cargo.lock files of the crate.
+	Location []string
+}
+
+// IDEExtension describes a VS Code extension or a JetBrains plugin.
+type IDEExtension struct {
+	// IDE is "vscode" or "jetbrains".
+	IDE string
+	// ID is the identifier of the extension, e.g. "ms-python.python" or "org.rust.lang".
+	ID                       string
+	Name, Publisher, Version string
+	// Location is the directory or the jar the extension is installed in.
+	Location string
+}
+
+// GooGetRepository describes a repository configured in a GooGet repo file.
+type GooGetRepository struct {
+	Name, URL string
+	// UseOAuth reports whether GooGet authenticates to the repository with the
+	// credentials of the instance service account.
+	UseOAuth bool
+	// Priority is the priority of the repository, 0 when the repo file does not set it.
+	Priority int
+	// Location is the repo file the repository is configured in.
+	Location string
+}
+
+// PackageRepository describes a repository configured for apt, yum or zypper.
+type PackageRepository struct {
+	// Manager is "apt", "yum" or "zypper".
+	Manager string
+	// Name is the repo id for yum and zypper, and the URI and suite for apt.
+	Name, URL string
+	Enabled   bool
+	// GPGCheck reports whether the signatures of the packages or the repository
+	// metadata are verified, as configured in the repo file.
+	GPGCheck bool
+	// Location is the file the repository is configured in.
+	Location string
+}
+
+// FreeBSDPackage describes a package installed by FreeBSD pkg.
+type FreeBSDPackage struct {
+	Name, Version, Purl string
+	// Origin is the ports tree origin of the package, e.g. "www/nginx".
+	Origin string
+}
+
+// Category returns the ports category of the package, the first element of its origin.
+func (p *FreeBSDPackage) Category() string {
+	category, _, _ := strings.Cut(p.Origin, "/")
+	return category
+}
+
+// NixPackage describes a derivation installed in the nix store.
+type NixPackage struct {
+	Name, Version string
+	// Location lists the store path of the derivation.
+	Location []string
+}
+
+// CondaPackage describes a package installed in a conda environment.
+type CondaPackage struct {
+	Name, Version, Build, Purl string
+	// Location lists the prefix of the environment the package is installed in.
+	Location []string
+}
+
 // WindowsApplication describes a Windows Application.
 type WindowsApplication struct {
 	DisplayName    string
@@ -169,7 +479,12 @@ type WindowsApplication struct {
 	InstallDate    time.Time
 	Publisher      string
 	HelpLink       string
-	Purl           string
+	// InstallSource is the location the application was installed from.
+	InstallSource string
+	// UninstallString is the command used to uninstall the application,
+	// MSI installs use msiexec while EXE installs point to the vendor uninstaller.
+	UninstallString string
+	Purl            string
 }
 
 func run(ctx context.Context, cmd string, args []string) ([]byte, error) {
@@ -186,7 +501,19 @@ func runWithDeadline(ctx context.Context, timeout time.Duration, cmd string, arg
 	return run(ctxWithTimeout, cmd, args)
 }
 
-func formatFieldsMappingToFormattingString(fieldsMapping map[string]string) string {
+// freeTextSeparator separates the JSON object of a package query line from the values
+// of the free text fields that follow it. The package managers do not escape the
+// values they substitute, so fields that may contain quotes or backslashes, e.g. the
+// deb maintainer, are kept out of the JSON object.
+const freeTextSeparator = "\x1f"
+
+// freeTextField is a field written after the JSON object of a package query line.
+type freeTextField struct {
+	selector string
+	set      func(*packageMetadata, string)
+}
+
+func formatFieldsMappingToFormattingString(fieldsMapping map[string]string, freeTextFields []freeTextField) string {
 	fieldsDescriptors := make([]string, 0, len(fieldsMapping))
 
 	for name, selector := range fieldsMapping {
@@ -200,10 +527,35 @@ func formatFieldsMappingToFormattingString(fieldsMapping map[string]string) stri
 	// Sort descriptors to get predictable result.
 	sort.Strings(fieldsDescriptors)
 
-	// Returns string to format all information in json
-	// Example: {"package":"${Package}","architecture":"${Architecture}","version":"${Version}","status":"${db:Status-Status}"...}\n
+	var freeText strings.Builder
+	for _, f := range freeTextFields {
+		freeText.WriteString(freeTextSeparator + f.selector)
+	}
+
+	// Returns string to format all information in json followed by the free text fields
+	// Example: {"package":"${Package}","architecture":"${Architecture}","version":"${Version}","status":"${db:Status-Status}"...}\x1f${Maintainer}\n
 	// See dpkgInfoFieldsMapping for full set of fields.
-	return "\\{" + strings.Join(fieldsDescriptors, ",") + "\\}\n"
+	return "\\{" + strings.Join(fieldsDescriptors, ",") + "\\}" + freeText.String() + "\n"
+}
+
+// parsePackageMetadata parses a package query line formatted with
+// formatFieldsMappingToFormattingString and freeTextFields.
+func parsePackageMetadata(line []byte, freeTextFields []freeTextField) (packageMetadata, error) {
+	object, rest, found := bytes.Cut(line, []byte(freeTextSeparator))
+	var pm packageMetadata
+	if err := json.Unmarshal(object, &pm); err != nil {
+		return pm, err
+	}
+	if !found {
+		return pm, nil
+	}
+	values := strings.Split(string(rest), freeTextSeparator)
+	for i, f := range freeTextFields {
+		if i < len(values) {
+			f.set(&pm, values[i])
+		}
+	}
+	return pm, nil
 }
 
 type packageMetadata struct {
@@ -213,9 +565,18 @@ type packageMetadata struct {
 	Status        string `json:"status"`
 	SourceName    string `json:"source_name"`
 	SourceVersion string `json:"source_version"`
+	InstalledSize string `json:"installed_size"`
+	Vendor        string `json:"-"`
+	Maintainer    string `json:"-"`
+	Digest        string `json:"digest"`
 }
 
 func pkgInfoFromPackageMetadata(pm packageMetadata, packageType string) *PkgInfo {
+	size, _ := strconv.ParseInt(pm.InstalledSize, 10, 64)
+	if packageType == typeDebian {
+		// dpkg reports Installed-Size in KiB.
+		size *= 1024
+	}
 	return &PkgInfo{
 		Name:    pm.Package,
 		Arch:    osinfo.NormalizeArchitecture(pm.Architecture),
@@ -224,8 +585,27 @@ func pkgInfoFromPackageMetadata(pm packageMetadata, packageType string) *PkgInfo
 			Name:    pm.SourceName,
 			Version: pm.SourceVersion,
 		},
-		Type: packageType,
+		Type:       packageType,
+		Size:       size,
+		Vendor:     pm.Vendor,
+		Maintainer: pm.Maintainer,
+		Digest:     digest(packageType, pm.Digest),
+	}
+}
+
+// digest prefixes the package checksum with the algorithm used by the package manager.
+func digest(packageType, checksum string) string {
+	if packageType != typeRPM {
+		return ""
+	}
+	// rpm reports SHA256HEADER, or SHA1HEADER on versions without it, as hex.
+	switch len(checksum) {
+	case 64:
+		return "sha256:" + checksum
+	case 40:
+		return "sha1:" + checksum
 	}
+	return ""
 }
 
 type ptyRunner struct{}
@@ -68,6 +68,7 @@ func getPackageUpdates(ctx context.Context, oi osinfo.OSInfo) (Packages, []strin
 			clog.Debugf(ctx, "Error: %s", msg)
 			errs = append(errs, msg)
 		} else {
+			setYumSecurityUpdates(ctx, yum)
 			yum = enrichRpmPkgInfoWithPurl(yum, shortname, oi.Version)
 			pkgs.Yum = yum
 		}
@@ -147,7 +148,9 @@ func getInstalledPackages(ctx context.Context, oi osinfo.OSInfo) (Packages, []st
 			errs = append(errs, msg)
 		} else {
 			rpm = enrichRpmPkgInfoWithPurl(rpm, shortname, oi.Version)
+			setExplicitRPMPackages(ctx, rpm)
 			pkgs.Rpm = rpm
+			pkgs.RPMBackend = DetectRPMBackend()
 		}
 	}
 	if ZypperExists {
@@ -169,6 +172,7 @@ func getInstalledPackages(ctx context.Context, oi osinfo.OSInfo) (Packages, []st
 			errs = append(errs, msg)
 		} else {
 			deb = enrichDebPkgInfoWithPurl(deb, shortname, oi.Version)
+			setExplicitDebPackages(ctx, deb)
 			pkgs.Deb = deb
 		}
 	}
@@ -203,6 +207,16 @@ func getInstalledPackages(ctx context.Context, oi osinfo.OSInfo) (Packages, []st
 			pkgs.Pip = pip
 		}
 	}
+	if PkgExists {
+		pkg, err := InstalledFreeBSDPackages(ctx)
+		if err != nil {
+			msg := fmt.Sprintf("error listing installed FreeBSD pkg packages: %v", err)
+			clog.Debugf(ctx, "Error: %s", msg)
+			errs = append(errs, msg)
+		} else {
+			pkgs.Pkg = pkg
+		}
+	}
 
 	return pkgs, errs
 }
@@ -214,7 +228,7 @@ func enrichDebPkgInfoWithPurl(pkgs []*PkgInfo, shortname string, version string)
 			"arch":   pkg.Arch,
 			"distro": version, // For Deb, accepted distro format is version (eg. distro=9.1)
 		}
-		pkgs[i].Purl = packageurl.NewPackageURL(pkg.Type, shortname, pkg.Name, pkg.Version, packageurl.QualifiersFromMap(qualifiersMap), "").ToString()
+		pkgs[i].Purl = BuildPurl(pkg.Type, shortname, pkg.Name, pkg.Version, qualifiersMap)
 	}
 	return pkgs
 }
@@ -225,7 +239,7 @@ func enrichRpmPkgInfoWithPurl(pkgs []*PkgInfo, shortname string, version string)
 			"arch":   pkg.Arch,
 			"distro": version, // For RPM, accepted distro format is version (eg. distro=9.1)
 		}
-		pkgs[i].Purl = packageurl.NewPackageURL(pkg.Type, shortname, pkg.Name, pkg.Version, packageurl.QualifiersFromMap(qualifiersMap), "").ToString()
+		pkgs[i].Purl = BuildPurl(pkg.Type, shortname, pkg.Name, pkg.Version, qualifiersMap)
 	}
 	return pkgs
 }
@@ -236,29 +250,34 @@ func enrichCosPkgInfoWithPurl(pkgs []*PkgInfo, shortname string, version string)
 			"arch":   pkg.Arch,
 			"distro": fmt.Sprintf("%s-%s", shortname, version), // For COS, required distro format is OS and version (eg. distro=cos-101)
 		}
-		pkgs[i].Purl = packageurl.NewPackageURL(pkg.Type, shortname, pkg.Name, pkg.Version, packageurl.QualifiersFromMap(qualifiersMap), "").ToString()
+		pkgs[i].Purl = BuildPurl(pkg.Type, shortname, pkg.Name, pkg.Version, qualifiersMap)
 	}
 	return pkgs
 }
 
 func enrichGemPkgInfoWithPurl(pkgs []*PkgInfo) []*PkgInfo {
 	for i, pkg := range pkgs {
-		pkgs[i].Purl = packageurl.NewPackageURL(pkg.Type, "", pkg.Name, pkg.Version, packageurl.Qualifiers{}, "").ToString()
+		pkgs[i].Purl = BuildPurl(pkg.Type, "", pkg.Name, pkg.Version, nil)
 	}
 	return pkgs
 }
 
-func enrichPipPkgInfoWithPurl(pkgs []*PkgInfo) []*PkgInfo {
+func enrichPipPkgInfoWithPurl(pkgs []*PipPackage) []*PipPackage {
 	for i, pkg := range pkgs {
-		pkgs[i].Purl = packageurl.NewPackageURL(pkg.Type, "", pkg.Name, pkg.Version, packageurl.Qualifiers{}, "").ToString()
+		// Packages installed from a local path are not the PyPI releases of the same
+		// name and version, a pypi purl would match them against PyPI advisories.
+		purlType := pkg.Type
+		if pkg.LocalProject != "" {
+			purlType = packageurl.TypeGeneric
+		}
+		pkgs[i].Purl = BuildPurl(purlType, "", pkg.Name, pkg.Version, nil)
 	}
 	return pkgs
 }
 
 func enrichZypperPatchWithPurl(pkgs []*ZypperPatch, shortname string) []*ZypperPatch {
 	for i, pkg := range pkgs {
-		qualifiers := packageurl.Qualifiers{packageurl.Qualifier{Key: "severity", Value: pkg.Severity}}
-		pkgs[i].Purl = packageurl.NewPackageURL(packageurl.TypeGeneric, shortname, pkg.Name, "", qualifiers, "").ToString()
+		pkgs[i].Purl = BuildPurl(packageurl.TypeGeneric, shortname, pkg.Name, "", map[string]string{"severity": pkg.Severity})
 	}
 	return pkgs
 }
@@ -266,7 +285,7 @@ func enrichZypperPatchWithPurl(pkgs []*ZypperPatch, shortname string) []*ZypperP
 // NewInstalledPackagesProvider makes provider that uses osv-scalibr as its implementation if enabled by config, otherwise falls back to default legacy implementation.
 func NewInstalledPackagesProvider(osinfoProvider osinfo.Provider) InstalledPackagesProvider {
 	if agentconfig.ScalibrLinuxEnabled() {
-		return scalibrInstalledPackagesProvider{
+		provider := scalibrInstalledPackagesProvider{
 			extractors: []string{
 				"os/cos",
 				"os/dpkg",
@@ -274,6 +293,11 @@ func NewInstalledPackagesProvider(osinfoProvider osinfo.Provider) InstalledPacka
 			},
 			osinfoProvider: osinfoProvider,
 		}
+		if merge := agentconfig.ScalibrLegacyMerge(); merge != "" {
+			provider.legacy = defaultInstalledPackagesProvider{osinfoProvider: osinfoProvider}
+			provider.preferLegacy = merge == agentconfig.ScalibrLegacyMergePreferLegacy
+		}
+		return provider
 	}
 
 	return defaultInstalledPackagesProvider{
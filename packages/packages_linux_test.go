@@ -44,6 +44,12 @@ func TestGetPackageUpdate(t *testing.T) {
 			stderr: utiltest.BytesFromFile(t, "./testdata/centos-7-1.yum-update.stderr"),
 			err:    nil,
 		},
+		{
+			cmd:    exec.Command(yum, yumSecurityUpdatesArgs...),
+			stdout: []byte(""),
+			stderr: []byte(""),
+			err:    nil,
+		},
 		{
 			cmd:    exec.Command(zypper, zypperListUpdatesArgs...),
 			stdout: utiltest.BytesFromFile(t, "./testdata/sles-12-1.zypper-list-updates.stdout"),
@@ -208,11 +214,17 @@ func TestGetInstalledPackages(t *testing.T) {
 			err:    nil,
 		},
 		{
-			cmd:    exec.Command("/usr/bin/pip", "list", "--format=json"),
+			cmd:    exec.Command("/usr/bin/pip", "list", "--format=json", "--verbose"),
 			stdout: utiltest.BytesFromFile(t, "./testdata/linux-pip-list-format-json.stdout"),
 			stderr: []byte(""),
 			err:    nil,
 		},
+		{
+			cmd:    exec.Command(pkgCmd, pkgQueryArgs...),
+			stdout: []byte("curl\t8.7.1\tftp/curl\n"),
+			stderr: []byte(""),
+			err:    nil,
+		},
 	}
 
 	oi := osinfo.OSInfo{Hostname: "Hostname",
@@ -236,9 +248,14 @@ func TestGetInstalledPackages(t *testing.T) {
 	setExpectations(mockCommandRunner, wantCommandChain)
 
 	installedPackagesProvider := NewInstalledPackagesProvider(oiProvider)
-	if _, err := installedPackagesProvider.GetInstalledPackages(context.Background()); err != nil {
+	pkgs, err := installedPackagesProvider.GetInstalledPackages(context.Background())
+	if err != nil {
 		t.Errorf("unexpected error, got: %v, want: <nil>", err)
 	}
+	wantPkg := []*FreeBSDPackage{{Name: "curl", Version: "8.7.1", Origin: "ftp/curl", Purl: "pkg:freebsd/curl@8.7.1"}}
+	if diff := cmp.Diff(wantPkg, pkgs.Pkg); diff != "" {
+		t.Errorf("unexpected FreeBSD pkg packages, diff:\n%s", diff)
+	}
 }
 func Test_getInstalledPackages(t *testing.T) {
 	enableAllInstalledPackages()
@@ -270,11 +287,17 @@ func Test_getInstalledPackages(t *testing.T) {
 			err:    fmt.Errorf("gem failed"),
 		},
 		{
-			cmd:    exec.Command("/usr/bin/pip", "list", "--format=json"),
+			cmd:    exec.Command("/usr/bin/pip", "list", "--format=json", "--verbose"),
 			stdout: utiltest.BytesFromFile(t, "./testdata/linux-pip-list-format-json.stdout"),
 			stderr: []byte(""),
 			err:    fmt.Errorf("pip list failed"),
 		},
+		{
+			cmd:    exec.Command(pkgCmd, pkgQueryArgs...),
+			stdout: []byte(""),
+			stderr: []byte(""),
+			err:    fmt.Errorf("pkg query failed"),
+		},
 	}
 
 	oi := osinfo.OSInfo{Hostname: "Hostname",
@@ -295,9 +318,10 @@ func Test_getInstalledPackages(t *testing.T) {
 	setExpectations(mockCommandRunner, wantCommandChain)
 
 	wantErrors := []string{
-		`error listing installed rpm packages: error running /usr/bin/rpmquery with args ["--queryformat" "\\{\"architecture\":\"%{ARCH}\",\"package\":\"%{NAME}\",\"source_name\":\"%{SOURCERPM}\",\"version\":\"%|EPOCH?{%{EPOCH}:}:{}|%{VERSION}-%{RELEASE}\"\\}\n" "-a"]: rpm query failed, stdout: "", stderr: ""`,
+		`error listing installed rpm packages: error running /usr/bin/rpmquery with args ["--queryformat" "\\{\"architecture\":\"%{ARCH}\",\"digest\":\"%{SHA256HEADER}\",\"installed_size\":\"%{SIZE}\",\"package\":\"%{NAME}\",\"source_name\":\"%{SOURCERPM}\",\"version\":\"%|EPOCH?{%{EPOCH}:}:{}|%{VERSION}-%{RELEASE}\"\\}\x1f%{VENDOR}\n" "-a"]: rpm query failed, stdout: "", stderr: ""`,
 		`error getting zypper installed patches: error running /usr/bin/zypper with args ["--gpg-auto-import-keys" "-q" "list-patches" "--all"]: zypper list patches failed, stdout: "", stderr: ""`,
-		`error listing installed deb packages: error running /usr/bin/dpkg-query with args ["-W" "-f" "\\{\"architecture\":\"${Architecture}\",\"package\":\"${Package}\",\"source_name\":\"${source:Package}\",\"source_version\":\"${source:Version}\",\"status\":\"${db:Status-Status}\",\"version\":\"${Version}\"\\}\n"]: dpkg query failed, stdout: "", stderr: ""`,
+		`error listing installed deb packages: error running /usr/bin/dpkg-query with args ["-W" "-f" "\\{\"architecture\":\"${Architecture}\",\"installed_size\":\"${Installed-Size}\",\"package\":\"${Package}\",\"source_name\":\"${source:Package}\",\"source_version\":\"${source:Version}\",\"status\":\"${db:Status-Status}\",\"version\":\"${Version}\"\\}\x1f${Maintainer}\n"]: dpkg query failed, stdout: "", stderr: ""`,
+		`error listing installed FreeBSD pkg packages: error running /usr/sbin/pkg with args ["query" "-a" "%n\\t%v\\t%o"]: pkg query failed, stdout: "", stderr: ""`,
 	}
 
 	_, errs := getInstalledPackages(context.Background(), oi)
@@ -443,28 +467,45 @@ func Test_enrichGemPkgInfoWithPurl(t *testing.T) {
 func Test_enrichPipPkgInfoWithPurl(t *testing.T) {
 	tests := []struct {
 		name                string
-		pkgInfo             []*PkgInfo
-		wantEnrichedPkgInfo []*PkgInfo
+		pkgInfo             []*PipPackage
+		wantEnrichedPkgInfo []*PipPackage
 	}{
 		{
 			name: "Correctly create PURL for PyPI packages",
-			pkgInfo: []*PkgInfo{
-				{
+			pkgInfo: []*PipPackage{
+				{PkgInfo: PkgInfo{
 					Name:    "PipPkg",
 					Arch:    "x86_64",
 					RawArch: "noarch",
 					Version: "Version",
 					Type:    "pypi",
-				},
+				}},
 			},
-			wantEnrichedPkgInfo: []*PkgInfo{
-				{
+			wantEnrichedPkgInfo: []*PipPackage{
+				{PkgInfo: PkgInfo{
 					Name:    "PipPkg",
 					Arch:    "x86_64",
 					RawArch: "noarch",
 					Version: "Version",
 					Type:    "pypi",
 					Purl:    "pkg:pypi/PipPkg@Version",
+				}},
+			},
+		},
+		{
+			name: "Create generic PURL for editable installs",
+			pkgInfo: []*PipPackage{
+				{
+					PkgInfo:      PkgInfo{Name: "myproject", Version: "0.1.0", Type: "pypi"},
+					LocalProject: "/home/user/src/myproject",
+					Editable:     true,
+				},
+			},
+			wantEnrichedPkgInfo: []*PipPackage{
+				{
+					PkgInfo:      PkgInfo{Name: "myproject", Version: "0.1.0", Type: "pypi", Purl: "pkg:generic/myproject@0.1.0"},
+					LocalProject: "/home/user/src/myproject",
+					Editable:     true,
 				},
 			},
 		}}
@@ -493,6 +534,7 @@ func enableAllInstalledPackages() {
 	COSPkgInfoExists = true
 	GemExists = true
 	PipExists = true
+	PkgExists = true
 }
 
 type stubOsInfoProvider struct {
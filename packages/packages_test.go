@@ -20,6 +20,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"testing"
 
 	utilmocks "github.com/GoogleCloudPlatform/osconfig/util/mocks"
 	"github.com/golang/mock/gomock"
@@ -84,3 +85,25 @@ func helperLoadBytes(name string) ([]byte, error) {
 	}
 	return bytes, nil
 }
+
+func TestDetectRPMBackend(t *testing.T) {
+	tests := []struct {
+		name                               string
+		dnfExists, yumExists, zypperExists bool
+		want                               RPMBackend
+	}{
+		{name: "yum only", yumExists: true, want: RPMBackendYum},
+		{name: "zypper only", zypperExists: true, want: RPMBackendZypper},
+		{name: "dnf with yum compatibility", dnfExists: true, yumExists: true, want: RPMBackendDnf},
+		{name: "dnf only", dnfExists: true, want: RPMBackendDnf},
+		{name: "yum and zypper", yumExists: true, zypperExists: true, want: RPMBackendYum},
+		{name: "none", want: RPMBackendUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectRPMBackend(tt.dnfExists, tt.yumExists, tt.zypperExists); got != tt.want {
+				t.Errorf("detectRPMBackend() = %q, want: %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -155,29 +155,32 @@ func (p defaultInstalledPackagesProvider) getInstalledPackages(ctx context.Conte
 
 func enrichGoogetPkgInfoWithPurl(pkgs []*PkgInfo) []*PkgInfo {
 	for i, pkg := range pkgs {
-		pkgs[i].Purl = packageurl.NewPackageURL(pkg.Type, purlNamespace, pkg.Name, pkg.Version, packageurl.Qualifiers{}, "").ToString()
+		pkgs[i].Purl = BuildPurl(pkg.Type, purlNamespace, pkg.Name, pkg.Version, nil)
 	}
 	return pkgs
 }
 
 func enrichWuaWithPurl(pkgs []*WUAPackage) []*WUAPackage {
 	for i, pkg := range pkgs {
-		pkgs[i].Purl = packageurl.NewPackageURL(packageurl.TypeGeneric, purlNamespace, pkg.Title, pkg.UpdateID, packageurl.Qualifiers{}, "").ToString()
+		pkgs[i].Purl = BuildPurl(packageurl.TypeGeneric, purlNamespace, pkg.Title, pkg.UpdateID, nil)
 	}
 	return pkgs
 }
 
 func enrichQfeWithPurl(pkgs []*QFEPackage) []*QFEPackage {
 	for i, pkg := range pkgs {
-		pkgs[i].Purl = packageurl.NewPackageURL(packageurl.TypeGeneric, purlNamespace, pkg.Caption, pkg.HotFixID, packageurl.Qualifiers{}, "").ToString()
+		pkgs[i].Purl = BuildPurl(packageurl.TypeGeneric, purlNamespace, pkg.Caption, pkg.HotFixID, nil)
 	}
 	return pkgs
 }
 
 func enrichWindowsApplicationWithPurl(pkgs []*WindowsApplication) []*WindowsApplication {
 	for i, pkg := range pkgs {
-		qualifiers := packageurl.Qualifiers{packageurl.Qualifier{Key: "publisher", Value: pkg.Publisher}}
-		pkgs[i].Purl = packageurl.NewPackageURL(packageurl.TypeGeneric, purlNamespace, pkg.DisplayName, pkg.DisplayVersion, qualifiers, "").ToString()
+		if purl, ok := windowsApplicationPurl(pkg); ok {
+			pkgs[i].Purl = purl
+			continue
+		}
+		pkgs[i].Purl = BuildPurl(packageurl.TypeGeneric, purlNamespace, pkg.DisplayName, pkg.DisplayVersion, map[string]string{"publisher": pkg.Publisher})
 	}
 	return pkgs
 }
@@ -15,9 +15,14 @@
 package packages
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/GoogleCloudPlatform/osconfig/util"
@@ -26,8 +31,8 @@ import (
 var (
 	pip string
 
-	pipListArgs        = []string{"list", "--format=json"}
-	pipOutdatedArgs    = append(pipListArgs, "--outdated")
+	pipListArgs        = []string{"list", "--format=json", "--verbose"}
+	pipOutdatedArgs    = []string{"list", "--format=json", "--outdated"}
 	pipListTimeout     = 15 * time.Second
 	pipOutdatedTimeout = 15 * time.Second
 )
@@ -45,12 +50,24 @@ type pipUpdatesPkg struct {
 }
 
 type pipInstalledPkg struct {
-	Name    string `json:"name"`
-	Version string `json:"version"`
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	Location string `json:"location"`
+	// EditableProjectLocation is only set for editable installs, by pip 21.3 and later.
+	EditableProjectLocation string `json:"editable_project_location"`
+}
+
+// pipDirectURL is the PEP 610 direct_url.json of packages installed from a URL or a
+// local path rather than from a package index.
+type pipDirectURL struct {
+	URL     string `json:"url"`
+	DirInfo struct {
+		Editable bool `json:"editable"`
+	} `json:"dir_info"`
 }
 
 // PipUpdates queries for all available pip updates.
-func PipUpdates(ctx context.Context) ([]*PkgInfo, error) {
+func PipUpdates(ctx context.Context) ([]*PipPackage, error) {
 	out, err := runWithDeadline(ctx, pipOutdatedTimeout, pip, pipOutdatedArgs)
 	if err != nil {
 		return nil, err
@@ -61,16 +78,16 @@ func PipUpdates(ctx context.Context) ([]*PkgInfo, error) {
 		return nil, err
 	}
 
-	var pkgs []*PkgInfo
+	var pkgs []*PipPackage
 	for _, pkg := range pipUpdates {
-		pkgs = append(pkgs, &PkgInfo{Name: pkg.Name, Arch: noarch, Version: pkg.LatestVersion, Type: typePypi})
+		pkgs = append(pkgs, &PipPackage{PkgInfo: PkgInfo{Name: pkg.Name, Arch: noarch, Version: pkg.LatestVersion, Type: typePypi}})
 	}
 
 	return pkgs, nil
 }
 
 // InstalledPipPackages queries for all installed pip packages.
-func InstalledPipPackages(ctx context.Context) ([]*PkgInfo, error) {
+func InstalledPipPackages(ctx context.Context) ([]*PipPackage, error) {
 	out, err := runWithDeadline(ctx, pipListTimeout, pip, pipListArgs)
 	if err != nil {
 		return nil, err
@@ -81,10 +98,136 @@ func InstalledPipPackages(ctx context.Context) ([]*PkgInfo, error) {
 		return nil, err
 	}
 
-	var pkgs []*PkgInfo
+	var pkgs []*PipPackage
 	for _, pkg := range pipUpdates {
-		pkgs = append(pkgs, &PkgInfo{Name: pkg.Name, Arch: noarch, Version: pkg.Version, Type: typePypi})
+		info := &PipPackage{
+			PkgInfo:    PkgInfo{Name: pkg.Name, Arch: noarch, Version: pkg.Version, Type: typePypi},
+			Location:   pkg.Location,
+			Virtualenv: isVirtualenvSitePackages(pkg.Location),
+		}
+		if pkg.EditableProjectLocation != "" {
+			info.LocalProject, info.Editable = pkg.EditableProjectLocation, true
+		} else {
+			info.LocalProject, info.Editable = pipLocalProject(pkg.Location, pkg.Name, pkg.Version)
+		}
+		if distInfo := pipDistInfo(pkg.Location, pkg.Name, pkg.Version); distInfo != "" {
+			info.License, info.Summary = pipMetadata(filepath.Join(distInfo, "METADATA"))
+		}
+		pkgs = append(pkgs, info)
 	}
 
 	return pkgs, nil
 }
+
+// isVirtualenvSitePackages reports whether the site-packages directory location belongs to
+// a virtual environment, i.e. the environment prefix holds a pyvenv.cfg file. The prefix is
+// two levels up on Windows (<prefix>/Lib/site-packages) and three levels up otherwise
+// (<prefix>/lib/pythonX.Y/site-packages).
+func isVirtualenvSitePackages(location string) bool {
+	if location == "" {
+		return false
+	}
+	dir := filepath.Dir(filepath.Dir(location))
+	for i := 0; i < 2; i++ {
+		if util.Exists(filepath.Join(dir, "pyvenv.cfg")) {
+			return true
+		}
+		dir = filepath.Dir(dir)
+	}
+	return false
+}
+
+// pipLocalProject returns the project directory of a package installed from a local path
+// and whether it is an editable install, based on the direct_url.json of its dist-info
+// directory. Packages installed from a package index have no direct_url.json.
+func pipLocalProject(location, name, version string) (string, bool) {
+	distInfo := pipDistInfo(location, name, version)
+	if distInfo == "" {
+		return "", false
+	}
+	b, err := os.ReadFile(filepath.Join(distInfo, "direct_url.json"))
+	if err != nil {
+		return "", false
+	}
+	var direct pipDirectURL
+	if err := json.Unmarshal(b, &direct); err != nil {
+		return "", false
+	}
+	u, err := url.Parse(direct.URL)
+	if err != nil || u.Scheme != "file" {
+		return "", false
+	}
+	path := u.Path
+	// Windows paths are written as file:///C:/path.
+	if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+	return filepath.FromSlash(path), direct.DirInfo.Editable
+}
+
+// pipDistInfo returns the dist-info directory of a package installed in the site-packages
+// directory location, or "" when it is not found.
+func pipDistInfo(location, name, version string) string {
+	if location == "" {
+		return ""
+	}
+	// Distribution names are normalized with underscores in dist-info directory names.
+	for _, n := range []string{name, strings.ReplaceAll(name, "-", "_"), strings.ToLower(strings.ReplaceAll(name, "-", "_"))} {
+		dir := filepath.Join(location, n+"-"+version+".dist-info")
+		if util.Exists(dir) {
+			return dir
+		}
+	}
+	return ""
+}
+
+// pipMetadata returns the license and summary from the headers of the METADATA file at
+// path, both are empty when the file is missing. The SPDX License-Expression is preferred
+// over the free form License, which is reduced to its first line as some packages embed
+// the whole license text, and the last License classifier is the fallback.
+func pipMetadata(path string) (license, summary string) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", ""
+	}
+	defer f.Close()
+
+	var expression, classifier string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// The headers end at the first empty line, the description follows.
+		if line == "" {
+			break
+		}
+		// Continuation lines of multi-line headers start with whitespace.
+		if line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(key) {
+		case "license-expression":
+			expression = value
+		case "license":
+			license = value
+		case "summary":
+			summary = value
+		case "classifier":
+			if strings.HasPrefix(value, "License ::") {
+				parts := strings.Split(value, "::")
+				classifier = strings.TrimSpace(parts[len(parts)-1])
+			}
+		}
+	}
+	switch {
+	case expression != "":
+		license = expression
+	case license == "" || strings.EqualFold(license, "UNKNOWN"):
+		license = classifier
+	}
+	return license, summary
+}
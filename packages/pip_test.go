@@ -3,7 +3,9 @@ package packages
 import (
 	"context"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"reflect"
 	"testing"
 
@@ -17,14 +19,14 @@ func TestInstalledPipPackages(t *testing.T) {
 		name                  string
 		expectedCommandsChain []expectedCommand
 		expectedResultsFile   string
-		expectedResults       []*PkgInfo
+		expectedResults       []*PipPackage
 		expectedError         error
 	}{
 		{
 			name: "`pip list` mapped output matches snapshot",
 			expectedCommandsChain: []expectedCommand{
 				{
-					cmd:    exec.Command("/usr/bin/pip", "list", "--format=json"),
+					cmd:    exec.Command("/usr/bin/pip", "list", "--format=json", "--verbose"),
 					stdout: utiltest.BytesFromFile(t, "./testdata/linux-pip-list-format-json.stdout"),
 					stderr: []byte(""),
 					err:    nil,
@@ -37,19 +39,19 @@ func TestInstalledPipPackages(t *testing.T) {
 			name: "`pip list` non-zero exit code propagates as error",
 			expectedCommandsChain: []expectedCommand{
 				{
-					cmd:    exec.Command("/usr/bin/pip", "list", "--format=json"),
+					cmd:    exec.Command("/usr/bin/pip", "list", "--format=json", "--verbose"),
 					stdout: []byte(""),
 					stderr: []byte(""),
 					err:    fmt.Errorf("unexpected error"),
 				},
 			},
-			expectedError: fmt.Errorf("error running /usr/bin/pip with args [\"list\" \"--format=json\"]: unexpected error, stdout: \"\", stderr: \"\""),
+			expectedError: fmt.Errorf("error running /usr/bin/pip with args [\"list\" \"--format=json\" \"--verbose\"]: unexpected error, stdout: \"\", stderr: \"\""),
 		},
 		{
 			name: "`pip list` invalid json output propagates as error",
 			expectedCommandsChain: []expectedCommand{
 				{
-					cmd:    exec.Command("/usr/bin/pip", "list", "--format=json"),
+					cmd:    exec.Command("/usr/bin/pip", "list", "--format=json", "--verbose"),
 					stdout: utiltest.BytesFromFile(t, "./testdata/linux-pip-list-format-json.stdout")[:100],
 					stderr: []byte(""),
 					err:    nil,
@@ -61,7 +63,7 @@ func TestInstalledPipPackages(t *testing.T) {
 			name: "`pip list` empty json output maps to nil",
 			expectedCommandsChain: []expectedCommand{
 				{
-					cmd:    exec.Command("/usr/bin/pip", "list", "--format=json"),
+					cmd:    exec.Command("/usr/bin/pip", "list", "--format=json", "--verbose"),
 					stdout: []byte("[]"),
 					stderr: []byte(""),
 					err:    nil,
@@ -98,12 +100,162 @@ func TestInstalledPipPackages(t *testing.T) {
 	}
 }
 
+func TestInstalledPipPackagesLocalInstalls(t *testing.T) {
+	sitePackages := t.TempDir()
+	writeDirectURL := func(distInfo, content string) {
+		t.Helper()
+		dir := filepath.Join(sitePackages, distInfo)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "direct_url.json"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Packages installed from an index have a dist-info directory without direct_url.json.
+	if err := os.MkdirAll(filepath.Join(sitePackages, "requests-2.31.0.dist-info"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeDirectURL("my_lib-1.0.0.dist-info", `{"url": "file:///home/user/src/my-lib", "dir_info": {}}`)
+	writeDirectURL("old_editable-0.2.0.dist-info", `{"url": "file:///home/user/src/old-editable", "dir_info": {"editable": true}}`)
+	writeDirectURL("from_vcs-3.0.0.dist-info", `{"url": "https://github.com/example/from-vcs", "vcs_info": {"vcs": "git"}}`)
+
+	stdout := fmt.Sprintf(`[
+		{"name": "requests", "version": "2.31.0", "location": %[1]q, "installer": "pip"},
+		{"name": "myproject", "version": "0.1.0", "location": %[1]q, "editable_project_location": "/home/user/src/myproject", "installer": "pip"},
+		{"name": "my-lib", "version": "1.0.0", "location": %[1]q, "installer": "pip"},
+		{"name": "old-editable", "version": "0.2.0", "location": %[1]q, "installer": "pip"},
+		{"name": "from-vcs", "version": "3.0.0", "location": %[1]q, "installer": "pip"}
+	]`, sitePackages)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockCommandRunner := utilmocks.NewMockCommandRunner(mockCtrl)
+	runner = mockCommandRunner
+	setExpectations(mockCommandRunner, []expectedCommand{{
+		cmd:    exec.Command("/usr/bin/pip", "list", "--format=json", "--verbose"),
+		stdout: []byte(stdout),
+		stderr: []byte(""),
+	}})
+
+	pkgs, err := InstalledPipPackages(context.Background())
+	if err != nil {
+		t.Fatalf("InstalledPipPackages() error = %v", err)
+	}
+
+	want := map[string]struct {
+		localProject string
+		editable     bool
+	}{
+		"requests":     {},
+		"myproject":    {"/home/user/src/myproject", true},
+		"my-lib":       {filepath.FromSlash("/home/user/src/my-lib"), false},
+		"old-editable": {filepath.FromSlash("/home/user/src/old-editable"), true},
+		"from-vcs":     {},
+	}
+	if len(pkgs) != len(want) {
+		t.Fatalf("InstalledPipPackages() returned %d packages, want: %d", len(pkgs), len(want))
+	}
+	for _, pkg := range pkgs {
+		w := want[pkg.Name]
+		if pkg.LocalProject != w.localProject || pkg.Editable != w.editable {
+			t.Errorf("InstalledPipPackages() %s: LocalProject = %q, Editable = %v, want: %q, %v", pkg.Name, pkg.LocalProject, pkg.Editable, w.localProject, w.editable)
+		}
+	}
+}
+
+func TestInstalledPipPackagesMetadata(t *testing.T) {
+	sitePackages := filepath.Join("testdata", "pip", "site-packages")
+	stdout := fmt.Sprintf(`[
+		{"name": "requests", "version": "2.31.0", "location": %[1]q, "installer": "pip"},
+		{"name": "typing-extensions", "version": "4.9.0", "location": %[1]q, "installer": "pip"},
+		{"name": "legacy-pkg", "version": "1.0", "location": %[1]q, "installer": "pip"},
+		{"name": "no-metadata", "version": "0.1", "location": %[1]q, "installer": "pip"},
+		{"name": "missing", "version": "1.0", "location": %[1]q, "installer": "pip"}
+	]`, sitePackages)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockCommandRunner := utilmocks.NewMockCommandRunner(mockCtrl)
+	runner = mockCommandRunner
+	setExpectations(mockCommandRunner, []expectedCommand{{
+		cmd:    exec.Command("/usr/bin/pip", "list", "--format=json", "--verbose"),
+		stdout: []byte(stdout),
+		stderr: []byte(""),
+	}})
+
+	pkgs, err := InstalledPipPackages(context.Background())
+	if err != nil {
+		t.Fatalf("InstalledPipPackages() error = %v", err)
+	}
+
+	want := map[string]struct {
+		license string
+		summary string
+	}{
+		"requests":          {"Apache 2.0", "Python HTTP for Humans."},
+		"typing-extensions": {"PSF-2.0", "Backported and Experimental Type Hints for Python 3.8+"},
+		"legacy-pkg":        {"MIT License", "A package without a License header."},
+		"no-metadata":       {},
+		"missing":           {},
+	}
+	if len(pkgs) != len(want) {
+		t.Fatalf("InstalledPipPackages() returned %d packages, want: %d", len(pkgs), len(want))
+	}
+	for _, pkg := range pkgs {
+		w := want[pkg.Name]
+		if pkg.License != w.license || pkg.Summary != w.summary {
+			t.Errorf("InstalledPipPackages() %s: License = %q, Summary = %q, want: %q, %q", pkg.Name, pkg.License, pkg.Summary, w.license, w.summary)
+		}
+	}
+}
+
+func TestIsVirtualenvSitePackages(t *testing.T) {
+	root := t.TempDir()
+	venv := filepath.Join(root, "venv")
+	winVenv := filepath.Join(root, "winvenv")
+	for _, dir := range []string{
+		filepath.Join(venv, "lib", "python3.11", "site-packages"),
+		filepath.Join(winVenv, "Lib", "site-packages"),
+		filepath.Join(root, "usr", "lib", "python3", "dist-packages"),
+		filepath.Join(root, "home", "user", ".local", "lib", "python3.11", "site-packages"),
+	} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, cfg := range []string{filepath.Join(venv, "pyvenv.cfg"), filepath.Join(winVenv, "pyvenv.cfg")} {
+		if err := os.WriteFile(cfg, []byte("home = /usr/bin\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		location string
+		want     bool
+	}{
+		{"venv", filepath.Join(venv, "lib", "python3.11", "site-packages"), true},
+		{"windows venv", filepath.Join(winVenv, "Lib", "site-packages"), true},
+		{"system", filepath.Join(root, "usr", "lib", "python3", "dist-packages"), false},
+		{"user site", filepath.Join(root, "home", "user", ".local", "lib", "python3.11", "site-packages"), false},
+		{"unknown", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isVirtualenvSitePackages(tt.location); got != tt.want {
+				t.Errorf("isVirtualenvSitePackages(%q) = %v, want: %v", tt.location, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestPipUpdates(t *testing.T) {
 	tests := []struct {
 		name                  string
 		expectedCommandsChain []expectedCommand
 		expectedResultsFile   string
-		expectedResults       []*PkgInfo
+		expectedResults       []*PipPackage
 		expectedError         error
 	}{
 		{
@@ -0,0 +1,31 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import "github.com/package-url/packageurl-go"
+
+// BuildPurl returns the package URL for a package so that every package manager
+// formats purls the same way. Each component is percent-encoded as required by the
+// purl spec, qualifiers are sorted by key and qualifiers with an empty value are
+// omitted, as the spec treats them as absent.
+func BuildPurl(purlType, namespace, name, version string, qualifiers map[string]string) string {
+	set := make(map[string]string, len(qualifiers))
+	for k, v := range qualifiers {
+		if v != "" {
+			set[k] = v
+		}
+	}
+	return packageurl.NewPackageURL(purlType, namespace, name, version, packageurl.QualifiersFromMap(set), "").ToString()
+}
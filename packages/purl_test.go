@@ -0,0 +1,70 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import "testing"
+
+func TestBuildPurl(t *testing.T) {
+	tests := []struct {
+		name                              string
+		purlType, namespace, pkg, version string
+		qualifiers                        map[string]string
+		want                              string
+	}{
+		{
+			name:     "deb with epoch and tilde",
+			purlType: "deb", namespace: "debian", pkg: "libc6", version: "1:2.36-9+deb12u4~bpo1",
+			qualifiers: map[string]string{"arch": "amd64", "distro": "12", "source": "glibc"},
+			want:       "pkg:deb/debian/libc6@1%3A2.36-9%2Bdeb12u4~bpo1?arch=amd64&distro=12&source=glibc",
+		},
+		{
+			name:     "rpm with plus in the name",
+			purlType: "rpm", namespace: "rhel", pkg: "libstdc++", version: "11.4.1-3.el9",
+			qualifiers: map[string]string{"distro": "9.4", "arch": "x86_64"},
+			want:       "pkg:rpm/rhel/libstdc%2B%2B@11.4.1-3.el9?arch=x86_64&distro=9.4",
+		},
+		{
+			name:     "spaces and at sign",
+			purlType: "generic", namespace: "microsoft", pkg: "Foo App @ Work", version: "1.0 beta",
+			qualifiers: map[string]string{"publisher": "Foo & Co"},
+			want:       "pkg:generic/microsoft/Foo%20App%20%40%20Work@1.0%20beta?publisher=Foo+%26+Co",
+		},
+		{
+			name:     "slash in the namespace is kept as a separator",
+			purlType: "golang", namespace: "github.com/foo", pkg: "bar", version: "v1.2.3",
+			want: "pkg:golang/github.com/foo/bar@v1.2.3",
+		},
+		{
+			name:     "empty qualifiers are omitted",
+			purlType: "deb", namespace: "ubuntu", pkg: "bash", version: "5.1",
+			qualifiers: map[string]string{"arch": "", "distro": "22.04", "source": ""},
+			want:       "pkg:deb/ubuntu/bash@5.1?distro=22.04",
+		},
+		{
+			name:     "no version",
+			purlType: "generic", namespace: "sles", pkg: "SUSE-2024-1",
+			qualifiers: map[string]string{"severity": "important"},
+			want:       "pkg:generic/sles/SUSE-2024-1?severity=important",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BuildPurl(tt.purlType, tt.namespace, tt.pkg, tt.version, tt.qualifiers); got != tt.want {
+				t.Errorf("BuildPurl() = %q, want: %q", got, tt.want)
+			}
+		})
+	}
+}
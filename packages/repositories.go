@@ -0,0 +1,265 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/osconfig/agentconfig"
+	"github.com/GoogleCloudPlatform/osconfig/clog"
+)
+
+// Package managers reported in PackageRepository.Manager.
+const (
+	repoManagerApt    = "apt"
+	repoManagerYum    = "yum"
+	repoManagerZypper = "zypper"
+)
+
+var (
+	aptSourcesList = "/etc/apt/sources.list"
+	aptSourcesDir  = agentconfig.AptRepoDir()
+	yumReposDir    = agentconfig.YumRepoDir()
+	zypperReposDir = agentconfig.ZypperRepoDir()
+)
+
+type repositoriesProvider struct{}
+
+// NewRepositoriesProvider returns a provider that reports the repositories configured
+// for apt, yum and zypper as Packages.Repositories.
+func NewRepositoriesProvider() InstalledPackagesProvider {
+	return repositoriesProvider{}
+}
+
+func (repositoriesProvider) GetInstalledPackages(ctx context.Context) (Packages, error) {
+	repos, err := PackageRepositories(ctx)
+	if err != nil {
+		return Packages{}, err
+	}
+	return Packages{Repositories: repos}, nil
+}
+
+// PackageRepositories reads the repositories configured in the apt sources, the yum
+// repo files and the zypper repo files. Managers without configuration are skipped.
+func PackageRepositories(ctx context.Context) ([]*PackageRepository, error) {
+	var repos []*PackageRepository
+	for _, src := range []struct {
+		pattern string
+		parse   func([]byte) []*PackageRepository
+	}{
+		{aptSourcesList, parseAptSourcesList},
+		{filepath.Join(aptSourcesDir, "*.list"), parseAptSourcesList},
+		{filepath.Join(aptSourcesDir, "*.sources"), parseAptSources},
+		{filepath.Join(yumReposDir, "*.repo"), func(data []byte) []*PackageRepository {
+			return parseRepoFile(data, repoManagerYum, false)
+		}},
+		{filepath.Join(zypperReposDir, "*.repo"), func(data []byte) []*PackageRepository {
+			return parseRepoFile(data, repoManagerZypper, true)
+		}},
+	} {
+		paths, err := filepath.Glob(src.pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				clog.Debugf(ctx, "Unable to read repo file %q: %v", path, err)
+				continue
+			}
+			for _, repo := range src.parse(data) {
+				repo.Location = path
+				repos = append(repos, repo)
+			}
+		}
+	}
+	return repos, nil
+}
+
+// parseRepoBool parses a boolean option of a repo file, returning def when the value
+// is not recognized.
+func parseRepoBool(value string, def bool) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "1", "yes", "true", "on":
+		return true
+	case "0", "no", "false", "off":
+		return false
+	}
+	return def
+}
+
+// parseAptSourcesList parses the one-line-style apt sources. Commented out entries are
+// reported as disabled, deb-src entries are skipped as no packages are installed from them.
+func parseAptSourcesList(data []byte) []*PackageRepository {
+	/*
+	   deb [arch=amd64 signed-by=/usr/share/keyrings/cloud.google.gpg] https://packages.cloud.google.com/apt cloud-sdk main
+	   # deb http://deb.debian.org/debian bookworm-backports main
+	*/
+	var repos []*PackageRepository
+	for _, ln := range strings.Split(string(data), "\n") {
+		ln = strings.TrimSpace(ln)
+		enabled := true
+		if strings.HasPrefix(ln, "#") {
+			enabled = false
+			ln = strings.TrimSpace(strings.TrimLeft(ln, "#"))
+		}
+		fields := strings.Fields(ln)
+		if len(fields) < 3 || fields[0] != "deb" {
+			continue
+		}
+		fields = fields[1:]
+
+		gpgCheck := true
+		if strings.HasPrefix(fields[0], "[") {
+			var options []string
+			for len(fields) > 0 {
+				opt := fields[0]
+				fields = fields[1:]
+				options = append(options, strings.Fields(strings.Trim(opt, "[]"))...)
+				if strings.HasSuffix(opt, "]") {
+					break
+				}
+			}
+			for _, opt := range options {
+				if key, value, ok := strings.Cut(opt, "="); ok && key == "trusted" {
+					gpgCheck = !parseRepoBool(value, false)
+				}
+			}
+		}
+		if len(fields) < 2 {
+			continue
+		}
+		repos = append(repos, &PackageRepository{
+			Manager:  repoManagerApt,
+			Name:     fields[0] + " " + fields[1],
+			URL:      fields[0],
+			Enabled:  enabled,
+			GPGCheck: gpgCheck,
+		})
+	}
+	return repos
+}
+
+// parseAptSources parses the deb822-style apt sources, reporting a repository for
+// each URI and suite of the deb entries.
+func parseAptSources(data []byte) []*PackageRepository {
+	/*
+	   Types: deb deb-src
+	   URIs: http://deb.debian.org/debian
+	   Suites: bookworm bookworm-updates
+	   Components: main
+	   Signed-By: /usr/share/keyrings/debian-archive-keyring.gpg
+	*/
+	var repos []*PackageRepository
+	for _, paragraph := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n\n") {
+		fields := map[string]string{}
+		for _, ln := range strings.Split(paragraph, "\n") {
+			// Comments and continuation lines, e.g. of an inline Signed-By key, are skipped.
+			if ln == "" || strings.HasPrefix(ln, "#") || strings.HasPrefix(ln, " ") || strings.HasPrefix(ln, "\t") {
+				continue
+			}
+			if key, value, ok := strings.Cut(ln, ":"); ok {
+				fields[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+			}
+		}
+		isDeb := false
+		for _, t := range strings.Fields(fields["types"]) {
+			if t == "deb" {
+				isDeb = true
+			}
+		}
+		if !isDeb {
+			continue
+		}
+		enabled := parseRepoBool(fields["enabled"], true)
+		gpgCheck := !parseRepoBool(fields["trusted"], false)
+		for _, uri := range strings.Fields(fields["uris"]) {
+			for _, suite := range strings.Fields(fields["suites"]) {
+				repos = append(repos, &PackageRepository{
+					Manager:  repoManagerApt,
+					Name:     uri + " " + suite,
+					URL:      uri,
+					Enabled:  enabled,
+					GPGCheck: gpgCheck,
+				})
+			}
+		}
+	}
+	return repos
+}
+
+// parseRepoFile parses a yum or zypper repo file. The URL is the first baseurl, or the
+// mirrorlist or metalink when there is none, repositories without a URL are skipped.
+// Repositories are enabled unless disabled, gpgcheck defaults to defaultGPGCheck.
+func parseRepoFile(data []byte, manager string, defaultGPGCheck bool) []*PackageRepository {
+	/*
+	   [google-cloud-sdk]
+	   name=Google Cloud SDK
+	   baseurl=https://packages.cloud.google.com/yum/repos/cloud-sdk-el9-x86_64
+	   enabled=1
+	   gpgcheck=1
+	*/
+	type section struct {
+		id      string
+		options map[string]string
+	}
+	var sections []*section
+	var current *section
+	for _, ln := range strings.Split(string(data), "\n") {
+		ln = strings.TrimSpace(ln)
+		if ln == "" || strings.HasPrefix(ln, "#") || strings.HasPrefix(ln, ";") {
+			continue
+		}
+		if strings.HasPrefix(ln, "[") && strings.HasSuffix(ln, "]") {
+			current = &section{id: strings.TrimSpace(ln[1 : len(ln)-1]), options: map[string]string{}}
+			sections = append(sections, current)
+			continue
+		}
+		key, value, ok := strings.Cut(ln, "=")
+		// Continuation lines of a multi-valued baseurl have no key and are skipped.
+		if current == nil || !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		if _, seen := current.options[key]; !seen {
+			current.options[key] = strings.TrimSpace(value)
+		}
+	}
+
+	var repos []*PackageRepository
+	for _, s := range sections {
+		var url string
+		for _, key := range []string{"baseurl", "mirrorlist", "metalink"} {
+			if urls := strings.FieldsFunc(s.options[key], func(r rune) bool { return r == ',' || r == ' ' }); len(urls) > 0 {
+				url = urls[0]
+				break
+			}
+		}
+		if url == "" {
+			continue
+		}
+		repos = append(repos, &PackageRepository{
+			Manager:  manager,
+			Name:     s.id,
+			URL:      url,
+			Enabled:  parseRepoBool(s.options["enabled"], true),
+			GPGCheck: parseRepoBool(s.options["gpgcheck"], defaultGPGCheck),
+		})
+	}
+	return repos
+}
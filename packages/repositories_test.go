@@ -0,0 +1,84 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/osconfig/util/utiltest"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPackageRepositories(t *testing.T) {
+	root := filepath.Join("testdata", "repos")
+	utiltest.OverrideVariable(t, &aptSourcesList, filepath.Join(root, "apt", "sources.list"))
+	utiltest.OverrideVariable(t, &aptSourcesDir, filepath.Join(root, "apt", "sources.list.d"))
+	utiltest.OverrideVariable(t, &yumReposDir, filepath.Join(root, "yum.repos.d"))
+	utiltest.OverrideVariable(t, &zypperReposDir, filepath.Join(root, "zypp", "repos.d"))
+
+	sourcesList := filepath.Join(root, "apt", "sources.list")
+	managedList := filepath.Join(root, "apt", "sources.list.d", "google_osconfig_managed.list")
+	debianSources := filepath.Join(root, "apt", "sources.list.d", "debian.sources")
+	yumRepo := filepath.Join(root, "yum.repos.d", "google-cloud.repo")
+	zypperRepo := filepath.Join(root, "zypp", "repos.d", "repo-oss.repo")
+
+	got, err := PackageRepositories(context.Background())
+	if err != nil {
+		t.Fatalf("PackageRepositories() unexpected error: %v", err)
+	}
+	want := []*PackageRepository{
+		{Manager: "apt", Name: "http://deb.debian.org/debian bookworm", URL: "http://deb.debian.org/debian", Enabled: true, GPGCheck: true, Location: sourcesList},
+		{Manager: "apt", Name: "http://deb.debian.org/debian bookworm-backports", URL: "http://deb.debian.org/debian", Enabled: false, GPGCheck: true, Location: sourcesList},
+		{Manager: "apt", Name: "https://packages.cloud.google.com/apt cloud-sdk", URL: "https://packages.cloud.google.com/apt", Enabled: true, GPGCheck: true, Location: managedList},
+		{Manager: "apt", Name: "http://mirror.example.com/debian stable", URL: "http://mirror.example.com/debian", Enabled: true, GPGCheck: false, Location: managedList},
+		{Manager: "apt", Name: "http://deb.debian.org/debian bookworm", URL: "http://deb.debian.org/debian", Enabled: true, GPGCheck: true, Location: debianSources},
+		{Manager: "apt", Name: "http://deb.debian.org/debian bookworm-updates", URL: "http://deb.debian.org/debian", Enabled: true, GPGCheck: true, Location: debianSources},
+		{Manager: "apt", Name: "https://thirdparty.example.com/apt stable", URL: "https://thirdparty.example.com/apt", Enabled: false, GPGCheck: false, Location: debianSources},
+		{Manager: "yum", Name: "google-cloud-sdk", URL: "https://packages.cloud.google.com/yum/repos/cloud-sdk-el9-x86_64", Enabled: true, GPGCheck: true, Location: yumRepo},
+		{Manager: "yum", Name: "epel", URL: "https://mirrors.fedoraproject.org/metalink?repo=epel-9&arch=$basearch", Enabled: false, GPGCheck: false, Location: yumRepo},
+		{Manager: "zypper", Name: "repo-oss", URL: "http://download.opensuse.org/distribution/leap/15.6/repo/oss/", Enabled: true, GPGCheck: true, Location: zypperRepo},
+		{Manager: "zypper", Name: "thirdparty", URL: "https://thirdparty.example.com/zypper", Enabled: true, GPGCheck: false, Location: zypperRepo},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("PackageRepositories() unexpected diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestPackageRepositoriesNotConfigured(t *testing.T) {
+	dir := t.TempDir()
+	utiltest.OverrideVariable(t, &aptSourcesList, filepath.Join(dir, "sources.list"))
+	utiltest.OverrideVariable(t, &aptSourcesDir, filepath.Join(dir, "sources.list.d"))
+	utiltest.OverrideVariable(t, &yumReposDir, filepath.Join(dir, "yum.repos.d"))
+	utiltest.OverrideVariable(t, &zypperReposDir, filepath.Join(dir, "repos.d"))
+
+	got, err := NewRepositoriesProvider().GetInstalledPackages(context.Background())
+	if err != nil {
+		t.Fatalf("GetInstalledPackages() unexpected error: %v", err)
+	}
+	if got.Repositories != nil {
+		t.Errorf("GetInstalledPackages() = %v, want no repositories", got.Repositories)
+	}
+}
+
+func TestParseRepoFileBaseURLList(t *testing.T) {
+	data := []byte("[updates]\nbaseurl=http://a.example.com/repo\n  http://b.example.com/repo\n")
+	got := parseRepoFile(data, "yum", false)
+	want := []*PackageRepository{{Manager: "yum", Name: "updates", URL: "http://a.example.com/repo", Enabled: true}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("parseRepoFile() unexpected diff (-want +got):\n%s", diff)
+	}
+}
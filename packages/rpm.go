@@ -17,9 +17,9 @@ package packages
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"runtime"
+	"strings"
 
 	"github.com/GoogleCloudPlatform/osconfig/clog"
 	"github.com/GoogleCloudPlatform/osconfig/util"
@@ -33,17 +33,39 @@ var (
 		"package":      "%{NAME}",
 		"architecture": "%{ARCH}",
 		// %|EPOCH?{%{EPOCH}:}:{}| == if EPOCH then prepend "%{EPOCH}:" to version.
-		"version":     "%|EPOCH?{%{EPOCH}:}:{}|%{VERSION}-%{RELEASE}",
-		"source_name": "%{SOURCERPM}",
+		"version":        "%|EPOCH?{%{EPOCH}:}:{}|%{VERSION}-%{RELEASE}",
+		"source_name":    "%{SOURCERPM}",
+		"installed_size": "%{SIZE}",
 	}
 
-	rpmInstallArgs = []string{"--upgrade", "--replacepkgs", "-v"}
-	rpmqueryArgs   = []string{"--queryformat", formatFieldsMappingToFormattingString(rpmqueryFields)}
+	rpmInstallArgs         = []string{"--upgrade", "--replacepkgs", "-v"}
+	rpmqueryFreeTextFields = []freeTextField{
+		{selector: "%{VENDOR}", set: func(pm *packageMetadata, v string) { pm.Vendor = v }},
+	}
+
+	rpmqueryArgs = []string{"--queryformat", rpmqueryFormat("SHA256HEADER")}
+	// rpm before 4.14 (e.g. RHEL 7, SLES 12) has no SHA256HEADER tag and fails the
+	// whole query on it, those versions are queried for SHA1HEADER instead.
+	rpmqueryLegacyArgs = []string{"--queryformat", rpmqueryFormat("SHA1HEADER")}
 
-	rpmqueryInstalledArgs = append(rpmqueryArgs, "-a")
-	rpmqueryRPMArgs       = append(rpmqueryArgs, "-p")
+	rpmqueryInstalledArgs       = append(rpmqueryArgs, "-a")
+	rpmqueryRPMArgs             = append(rpmqueryArgs, "-p")
+	rpmqueryLegacyInstalledArgs = append(rpmqueryLegacyArgs, "-a")
+	rpmqueryLegacyRPMArgs       = append(rpmqueryLegacyArgs, "-p")
+
+	// rpmSHA256HeaderUnsupported is set once rpm rejected the SHA256HEADER tag.
+	rpmSHA256HeaderUnsupported bool
 )
 
+// rpmqueryFormat returns the rpmquery format with the header checksum read from digestTag.
+func rpmqueryFormat(digestTag string) string {
+	fields := map[string]string{"digest": "%{" + digestTag + "}"}
+	for name, selector := range rpmqueryFields {
+		fields[name] = selector
+	}
+	return formatFieldsMappingToFormattingString(fields, rpmqueryFreeTextFields)
+}
+
 func init() {
 	if runtime.GOOS != "windows" {
 		rpmquery = "/usr/bin/rpmquery"
@@ -53,6 +75,21 @@ func init() {
 	RPMExists = util.Exists(rpm)
 }
 
+// runRPMQuery runs rpmquery with args, or with legacyArgs when rpm does not know the
+// SHA256HEADER tag.
+func runRPMQuery(ctx context.Context, args, legacyArgs []string) ([]byte, error) {
+	if !rpmSHA256HeaderUnsupported {
+		out, err := run(ctx, rpmquery, args)
+		// rpm reports `error: incorrect format: unknown tag: "SHA256HEADER"`.
+		if err == nil || !strings.Contains(err.Error(), "unknown tag") {
+			return out, err
+		}
+		clog.Debugf(ctx, "rpm does not support the SHA256HEADER tag, querying SHA1HEADER instead")
+		rpmSHA256HeaderUnsupported = true
+	}
+	return run(ctx, rpmquery, legacyArgs)
+}
+
 func parseInstalledRPMPackages(ctx context.Context, data []byte) []*PkgInfo {
 	/*
 		Each line contains an entry in a json format, keep in mind that whole output is not valid json.
@@ -65,8 +102,8 @@ func parseInstalledRPMPackages(ctx context.Context, data []byte) []*PkgInfo {
 
 	var result []*PkgInfo
 	for _, entry := range lines {
-		var rpm packageMetadata
-		if err := json.Unmarshal(entry, &rpm); err != nil {
+		rpm, err := parsePackageMetadata(entry, rpmqueryFreeTextFields)
+		if err != nil {
 			clog.Debugf(ctx, "unable to parse rpm package info, err %s, raw - %s", err, string(entry))
 			continue
 		}
@@ -88,11 +125,19 @@ func normalizeRpmMetadata(rpm *packageMetadata) {
 	if rpm.SourceName == "(none)" {
 		rpm.SourceName = rpm.Package
 	}
+
+	if rpm.Vendor == "(none)" {
+		rpm.Vendor = ""
+	}
+
+	if rpm.Digest == "(none)" {
+		rpm.Digest = ""
+	}
 }
 
 // InstalledRPMPackages queries for all installed rpm packages.
 func InstalledRPMPackages(ctx context.Context) ([]*PkgInfo, error) {
-	out, err := run(ctx, rpmquery, rpmqueryInstalledArgs)
+	out, err := runRPMQuery(ctx, rpmqueryInstalledArgs, rpmqueryLegacyInstalledArgs)
 	if err != nil {
 		return nil, err
 	}
@@ -108,7 +153,7 @@ func RPMInstall(ctx context.Context, path string) error {
 
 // RPMPkgInfo gets PkgInfo from a rpm package.
 func RPMPkgInfo(ctx context.Context, path string) (*PkgInfo, error) {
-	out, err := run(ctx, rpmquery, append(rpmqueryRPMArgs, path))
+	out, err := runRPMQuery(ctx, append(rpmqueryRPMArgs, path), append(rpmqueryLegacyRPMArgs, path))
 	if err != nil {
 		return nil, err
 	}
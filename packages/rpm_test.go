@@ -48,6 +48,33 @@ func TestParseInstalledRPMPackages(t *testing.T) {
 				{Name: "gpg-pubkey", Arch: "all", Version: "b6792c39-53c4fbdd", Source: Source{Name: "gpg-pubkey"}, Type: "rpm"},
 			},
 		},
+		{
+			name: "Installed size reported in bytes",
+			data: []byte(`{"architecture":"x86_64","installed_size":"86012345","package":"gcc","source_name":"gcc-11.4.1-3.el9.src.rpm","version":"11.4.1-3.el9"}`),
+			want: []*PkgInfo{
+				{Name: "gcc", Arch: "x86_64", Version: "11.4.1-3.el9", Source: Source{Name: "gcc-11.4.1-3.el9.src.rpm"}, Type: "rpm", Size: 86012345},
+			},
+		},
+		{
+			name: "Vendor reported, (none) vendor dropped",
+			data: []byte("" +
+				`{"architecture":"x86_64","package":"gcc","source_name":"gcc-11.4.1-3.el9.src.rpm","version":"11.4.1-3.el9"}` + "\x1fRocky Enterprise Software Foundation\n" +
+				`{"architecture":"(none)","package":"gpg-pubkey","source_name":"(none)","version":"b6792c39-53c4fbdd"}` + "\x1f(none)"),
+			want: []*PkgInfo{
+				{Name: "gcc", Arch: "x86_64", Version: "11.4.1-3.el9", Source: Source{Name: "gcc-11.4.1-3.el9.src.rpm"}, Type: "rpm", Vendor: "Rocky Enterprise Software Foundation"},
+				{Name: "gpg-pubkey", Arch: "all", Version: "b6792c39-53c4fbdd", Source: Source{Name: "gpg-pubkey"}, Type: "rpm"},
+			},
+		},
+		{
+			name: "SHA256HEADER reported as digest, (none) digest dropped",
+			data: []byte("" +
+				`{"architecture":"x86_64","digest":"4b6f1f5a3c2f9e0d8a7b6c5d4e3f2a1b0c9d8e7f6a5b4c3d2e1f0a9b8c7d6e5f","package":"gcc","source_name":"gcc-11.4.1-3.el9.src.rpm","version":"11.4.1-3.el9"}` + "\n" +
+				`{"architecture":"(none)","digest":"(none)","package":"gpg-pubkey","source_name":"(none)","version":"b6792c39-53c4fbdd"}`),
+			want: []*PkgInfo{
+				{Name: "gcc", Arch: "x86_64", Version: "11.4.1-3.el9", Source: Source{Name: "gcc-11.4.1-3.el9.src.rpm"}, Type: "rpm", Digest: "sha256:4b6f1f5a3c2f9e0d8a7b6c5d4e3f2a1b0c9d8e7f6a5b4c3d2e1f0a9b8c7d6e5f"},
+				{Name: "gpg-pubkey", Arch: "all", Version: "b6792c39-53c4fbdd", Source: Source{Name: "gpg-pubkey"}, Type: "rpm"},
+			},
+		},
 		{
 			name: "No valid pacakges",
 			data: []byte("nothing here"),
@@ -114,7 +141,7 @@ func TestInstalledRPMPackages(t *testing.T) {
 			},
 			},
 			wantPkgs: nil,
-			wantErr:  errors.New("error running /usr/bin/rpmquery with args [\"--queryformat\" \"\\\\{\\\"architecture\\\":\\\"%{ARCH}\\\",\\\"package\\\":\\\"%{NAME}\\\",\\\"source_name\\\":\\\"%{SOURCERPM}\\\",\\\"version\\\":\\\"%|EPOCH?{%{EPOCH}:}:{}|%{VERSION}-%{RELEASE}\\\"\\\\}\\n\" \"-a\"]: unexpected error, stdout: \"stdout\", stderr: \"stderr\""),
+			wantErr:  errors.New("error running /usr/bin/rpmquery with args [\"--queryformat\" \"\\\\{\\\"architecture\\\":\\\"%{ARCH}\\\",\\\"digest\\\":\\\"%{SHA256HEADER}\\\",\\\"installed_size\\\":\\\"%{SIZE}\\\",\\\"package\\\":\\\"%{NAME}\\\",\\\"source_name\\\":\\\"%{SOURCERPM}\\\",\\\"version\\\":\\\"%|EPOCH?{%{EPOCH}:}:{}|%{VERSION}-%{RELEASE}\\\"\\\\}\\x1f%{VENDOR}\\n\" \"-a\"]: unexpected error, stdout: \"stdout\", stderr: \"stderr\""),
 		},
 		{
 			name: "centos-7-1 mapped stdout matches snapshot",
@@ -197,6 +224,45 @@ func TestInstalledRPMPackages(t *testing.T) {
 	}
 }
 
+func TestInstalledRPMPackagesWithoutSHA256Header(t *testing.T) {
+	utiltest.OverrideVariable(t, &rpmSHA256HeaderUnsupported, false)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockCommandRunner := utilmocks.NewMockCommandRunner(mockCtrl)
+	runner = mockCommandRunner
+
+	legacyStdout := []byte(`{"architecture":"x86_64","digest":"8f1d1e3c0a9b2c4d6e8f0a1b3c5d7e9f1a2b4c6d","package":"bash","source_name":"bash-4.2.46-34.el7.src.rpm","version":"4.2.46-34.el7"}`)
+	setExpectations(mockCommandRunner, []expectedCommand{
+		{
+			cmd:    exec.Command(rpmquery, rpmqueryInstalledArgs...),
+			stderr: []byte(`error: incorrect format: unknown tag: "SHA256HEADER"`),
+			err:    errors.New("exit status 1"),
+		},
+		{
+			cmd:    exec.Command(rpmquery, rpmqueryLegacyInstalledArgs...),
+			stdout: legacyStdout,
+		},
+		// Once rejected, SHA256HEADER is not queried again.
+		{
+			cmd:    exec.Command(rpmquery, rpmqueryLegacyInstalledArgs...),
+			stdout: legacyStdout,
+		},
+	})
+
+	want := []*PkgInfo{{Name: "bash", Arch: "x86_64", Version: "4.2.46-34.el7", Source: Source{Name: "bash-4.2.46-34.el7.src.rpm"}, Type: "rpm", Digest: "sha1:8f1d1e3c0a9b2c4d6e8f0a1b3c5d7e9f1a2b4c6d"}}
+	for i := 0; i < 2; i++ {
+		pkgs, err := InstalledRPMPackages(testCtx)
+		if err != nil {
+			t.Fatalf("InstalledRPMPackages: unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(pkgs, want) {
+			t.Errorf("InstalledRPMPackages pkgs: want %v, got %v", want, pkgs)
+		}
+	}
+}
+
 func TestRPMPkgInfo(t *testing.T) {
 	tests := []struct {
 		name string
@@ -275,7 +341,7 @@ func TestRPMPkgInfo(t *testing.T) {
 				},
 			},
 			expectedResult: nil,
-			expectedError:  errors.New("error running /usr/bin/rpmquery with args [\"--queryformat\" \"\\\\{\\\"architecture\\\":\\\"%{ARCH}\\\",\\\"package\\\":\\\"%{NAME}\\\",\\\"source_name\\\":\\\"%{SOURCERPM}\\\",\\\"version\\\":\\\"%|EPOCH?{%{EPOCH}:}:{}|%{VERSION}-%{RELEASE}\\\"\\\\}\\n\" \"-p\" \"/tmp/gcc.rpm\"]: unexpected error, stdout: \"stdout\", stderr: \"stderr\""),
+			expectedError:  errors.New("error running /usr/bin/rpmquery with args [\"--queryformat\" \"\\\\{\\\"architecture\\\":\\\"%{ARCH}\\\",\\\"digest\\\":\\\"%{SHA256HEADER}\\\",\\\"installed_size\\\":\\\"%{SIZE}\\\",\\\"package\\\":\\\"%{NAME}\\\",\\\"source_name\\\":\\\"%{SOURCERPM}\\\",\\\"version\\\":\\\"%|EPOCH?{%{EPOCH}:}:{}|%{VERSION}-%{RELEASE}\\\"\\\\}\\x1f%{VENDOR}\\n\" \"-p\" \"/tmp/gcc.rpm\"]: unexpected error, stdout: \"stdout\", stderr: \"stderr\""),
 		},
 	}
 
@@ -134,6 +134,11 @@ type scalibrInstalledPackagesProvider struct {
 	osinfoProvider osinfo.Provider
 	scanRootPaths  []string
 	dirsToSkip     []string
+
+	// legacy collects the packages merged into the scalibr ones, nil disables merging.
+	legacy InstalledPackagesProvider
+	// preferLegacy keeps the legacy entry of the packages found by both.
+	preferLegacy bool
 }
 
 func (p scalibrInstalledPackagesProvider) GetInstalledPackages(ctx context.Context) (Packages, error) {
@@ -153,6 +158,9 @@ func (p scalibrInstalledPackagesProvider) GetInstalledPackages(ctx context.Conte
 	}
 
 	pkgs := pkgInfosFromExtractorPackages(ctx, scan, &osinfo)
+	if pkgs.Rpm != nil {
+		pkgs.RPMBackend = DetectRPMBackend()
+	}
 
 	// TODO: replace zypper patches legacy extractor with implemented "os/zypper" extractor
 	if ZypperExists {
@@ -162,5 +170,98 @@ func (p scalibrInstalledPackagesProvider) GetInstalledPackages(ctx context.Conte
 		}
 		pkgs.ZypperPatches = zypperPatches
 	}
+
+	if p.legacy != nil {
+		legacy, err := p.legacy.GetInstalledPackages(ctx)
+		if err != nil {
+			// The legacy packages only complement the scan, keep what was collected.
+			clog.Debugf(ctx, "Error listing legacy installed packages to merge: %v", err)
+		}
+		pkgs = mergeLegacyPackages(pkgs, legacy, p.preferLegacy)
+	}
 	return pkgs, err
 }
+
+// mergeLegacyPackages merges the package lists of legacy into pkgs. Packages found by both,
+// by type, name, version and architecture, are kept once: the legacy entry when preferLegacy
+// is set, the scalibr entry otherwise. The preferred entries come first, in their order,
+// followed by the remaining entries of the other list. Lists other than []*PkgInfo, e.g.
+// ZypperPatches, are taken from the other provider only when the preferred one reported none.
+func mergeLegacyPackages(pkgs, legacy Packages, preferLegacy bool) Packages {
+	preferred, other := pkgs, legacy
+	if preferLegacy {
+		preferred, other = legacy, pkgs
+	}
+
+	merged := preferred
+	merged.Yum = mergePkgInfos(preferred.Yum, other.Yum)
+	merged.Rpm = mergePkgInfos(preferred.Rpm, other.Rpm)
+	merged.Apt = mergePkgInfos(preferred.Apt, other.Apt)
+	merged.Deb = mergePkgInfos(preferred.Deb, other.Deb)
+	merged.Zypper = mergePkgInfos(preferred.Zypper, other.Zypper)
+	merged.COS = mergePkgInfos(preferred.COS, other.COS)
+	merged.Gem = mergePkgInfos(preferred.Gem, other.Gem)
+	merged.GooGet = mergePkgInfos(preferred.GooGet, other.GooGet)
+	merged.KernelModules = mergePkgInfos(preferred.KernelModules, other.KernelModules)
+
+	merged.ZypperPatches = preferredOrOther(preferred.ZypperPatches, other.ZypperPatches)
+	merged.Pip = preferredOrOther(preferred.Pip, other.Pip)
+	merged.WUA = preferredOrOther(preferred.WUA, other.WUA)
+	merged.QFE = preferredOrOther(preferred.QFE, other.QFE)
+	merged.WindowsApplication = preferredOrOther(preferred.WindowsApplication, other.WindowsApplication)
+	merged.GoModules = preferredOrOther(preferred.GoModules, other.GoModules)
+	merged.Npm = preferredOrOther(preferred.Npm, other.Npm)
+	merged.Cargo = preferredOrOther(preferred.Cargo, other.Cargo)
+	merged.ContainerImages = preferredOrOther(preferred.ContainerImages, other.ContainerImages)
+	merged.Pkg = preferredOrOther(preferred.Pkg, other.Pkg)
+	merged.Nix = preferredOrOther(preferred.Nix, other.Nix)
+	merged.Conda = preferredOrOther(preferred.Conda, other.Conda)
+	merged.SystemdUnits = preferredOrOther(preferred.SystemdUnits, other.SystemdUnits)
+	merged.WindowsServices = preferredOrOther(preferred.WindowsServices, other.WindowsServices)
+	merged.Firmware = preferredOrOther(preferred.Firmware, other.Firmware)
+	merged.ListeningPorts = preferredOrOther(preferred.ListeningPorts, other.ListeningPorts)
+	merged.Certificates = preferredOrOther(preferred.Certificates, other.Certificates)
+	merged.IDEExtensions = preferredOrOther(preferred.IDEExtensions, other.IDEExtensions)
+	merged.GooGetRepositories = preferredOrOther(preferred.GooGetRepositories, other.GooGetRepositories)
+	merged.Repositories = preferredOrOther(preferred.Repositories, other.Repositories)
+	merged.LoadedLibraries = preferredOrOther(preferred.LoadedLibraries, other.LoadedLibraries)
+	merged.EnvironmentModules = preferredOrOther(preferred.EnvironmentModules, other.EnvironmentModules)
+
+	if merged.RPMBackend == RPMBackendUnknown {
+		merged.RPMBackend = other.RPMBackend
+	}
+	return merged
+}
+
+// mergePkgInfos returns preferred followed by the packages of other that are not in
+// preferred, by type, name, version and architecture. Entries of the same list are never
+// dropped, e.g. the x86_64 and i686 builds of a multilib package.
+func mergePkgInfos(preferred, other []*PkgInfo) []*PkgInfo {
+	if len(other) == 0 {
+		return preferred
+	}
+	key := func(pkg *PkgInfo) string {
+		return pkg.Type + "\x00" + pkg.Name + "\x00" + pkg.Version + "\x00" + pkg.Arch
+	}
+	inPreferred := make(map[string]bool, len(preferred))
+	merged := make([]*PkgInfo, 0, len(preferred)+len(other))
+	for _, pkg := range preferred {
+		inPreferred[key(pkg)] = true
+		merged = append(merged, pkg)
+	}
+	for _, pkg := range other {
+		if inPreferred[key(pkg)] {
+			continue
+		}
+		merged = append(merged, pkg)
+	}
+	return merged
+}
+
+// preferredOrOther returns preferred, or other when only other is non empty.
+func preferredOrOther[T any](preferred, other []T) []T {
+	if len(preferred) == 0 && len(other) != 0 {
+		return other
+	}
+	return preferred
+}
@@ -2,6 +2,7 @@ package packages
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path"
 	"reflect"
@@ -162,3 +163,130 @@ func TestScalibrIntegration(t *testing.T) {
 		}
 	}
 }
+
+type staticInstalledPackagesProvider struct {
+	pkgs Packages
+	err  error
+}
+
+func (p staticInstalledPackagesProvider) GetInstalledPackages(context.Context) (Packages, error) {
+	return p.pkgs, p.err
+}
+
+func TestMergeLegacyPackages(t *testing.T) {
+	scalibrPkgs := Packages{
+		Deb: []*PkgInfo{
+			{Name: "7zip", Version: "24.09+dfsg-4", Type: "deb", Purl: "pkg:deb/debian/7zip@24.09%2Bdfsg-4"},
+			{Name: "llvm-16", Version: "1:16.0.6-27+build3", Type: "deb"},
+		},
+	}
+	legacyPkgs := Packages{
+		Deb: []*PkgInfo{
+			{Name: "7zip", Version: "24.09+dfsg-4", Type: "deb", Purl: "pkg:deb/legacy/7zip@24.09%2Bdfsg-4"},
+			{Name: "llvm-16", Version: "1:16.0.6-28", Type: "deb"},
+			{Name: "git", Version: "1:2.39.5-0+deb12u1", Type: "deb"},
+		},
+		Pip: []*PipPackage{{PkgInfo: PkgInfo{Name: "requests", Version: "2.31.0", Type: "pypi"}}},
+	}
+
+	tests := []struct {
+		name         string
+		preferLegacy bool
+		want         Packages
+	}{
+		{
+			name: "prefer scalibr",
+			want: Packages{
+				Deb: []*PkgInfo{
+					{Name: "7zip", Version: "24.09+dfsg-4", Type: "deb", Purl: "pkg:deb/debian/7zip@24.09%2Bdfsg-4"},
+					{Name: "llvm-16", Version: "1:16.0.6-27+build3", Type: "deb"},
+					{Name: "llvm-16", Version: "1:16.0.6-28", Type: "deb"},
+					{Name: "git", Version: "1:2.39.5-0+deb12u1", Type: "deb"},
+				},
+				Pip: []*PipPackage{{PkgInfo: PkgInfo{Name: "requests", Version: "2.31.0", Type: "pypi"}}},
+			},
+		},
+		{
+			name:         "prefer legacy",
+			preferLegacy: true,
+			want: Packages{
+				Deb: []*PkgInfo{
+					{Name: "7zip", Version: "24.09+dfsg-4", Type: "deb", Purl: "pkg:deb/legacy/7zip@24.09%2Bdfsg-4"},
+					{Name: "llvm-16", Version: "1:16.0.6-28", Type: "deb"},
+					{Name: "git", Version: "1:2.39.5-0+deb12u1", Type: "deb"},
+					{Name: "llvm-16", Version: "1:16.0.6-27+build3", Type: "deb"},
+				},
+				Pip: []*PipPackage{{PkgInfo: PkgInfo{Name: "requests", Version: "2.31.0", Type: "pypi"}}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mergeLegacyPackages(scalibrPkgs, legacyPkgs, tt.preferLegacy)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("mergeLegacyPackages() unexpected diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMergeLegacyPackagesKeepsMultilib(t *testing.T) {
+	scalibrPkgs := Packages{
+		Rpm: []*PkgInfo{
+			{Name: "glibc", Version: "2.17-326.el7", Arch: "x86_64", Type: "rpm"},
+			{Name: "glibc", Version: "2.17-326.el7", Arch: "i686", Type: "rpm"},
+		},
+	}
+	legacyPkgs := Packages{
+		Rpm: []*PkgInfo{
+			{Name: "glibc", Version: "2.17-326.el7", Arch: "x86_64", Type: "rpm", Vendor: "CentOS"},
+			{Name: "zlib", Version: "1.2.7-21.el7", Arch: "x86_64", Type: "rpm"},
+			{Name: "zlib", Version: "1.2.7-21.el7", Arch: "i686", Type: "rpm"},
+		},
+	}
+
+	want := Packages{
+		Rpm: []*PkgInfo{
+			{Name: "glibc", Version: "2.17-326.el7", Arch: "x86_64", Type: "rpm"},
+			{Name: "glibc", Version: "2.17-326.el7", Arch: "i686", Type: "rpm"},
+			{Name: "zlib", Version: "1.2.7-21.el7", Arch: "x86_64", Type: "rpm"},
+			{Name: "zlib", Version: "1.2.7-21.el7", Arch: "i686", Type: "rpm"},
+		},
+	}
+	got := mergeLegacyPackages(scalibrPkgs, legacyPkgs, false)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mergeLegacyPackages() unexpected diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestScalibrIntegrationMergesLegacy(t *testing.T) {
+	withZypperDisabled(t)
+	provider := scalibrInstalledPackagesProvider{
+		osinfoProvider: stubProvider{},
+		extractors:     []string{"os/dpkg"},
+		scanRootPaths:  []string{arrangeVirtualRoot(t, "./testdata/debian.dpkg-status", "/var/lib/dpkg/status")},
+		dirsToSkip:     []string{},
+		legacy: staticInstalledPackagesProvider{
+			pkgs: Packages{
+				Deb: []*PkgInfo{{Name: "7zip", Version: "24.09+dfsg-4", Arch: "x86_64", Type: "deb", Purl: "pkg:deb/legacy/7zip"}},
+				Gem: []*PkgInfo{{Name: "rake", Version: "13.0.6", Type: "gem"}},
+			},
+			err: errors.New("error listing installed pip packages"),
+		},
+	}
+
+	pkgs, err := provider.GetInstalledPackages(context.Background())
+	if err != nil {
+		t.Fatalf("GetInstalledPackages() unexpected error: %v", err)
+	}
+	want := Packages{
+		Deb: []*PkgInfo{
+			{Name: "7zip", Version: "24.09+dfsg-4", Arch: "x86_64", Source: Source{Name: "7zip", Version: "24.09+dfsg-4"}, Type: "deb", Purl: "pkg:deb/linux/7zip@24.09%2Bdfsg-4?arch=amd64"},
+			{Name: "llvm-16", Version: "1:16.0.6-27+build3", Arch: "x86_64", Source: Source{Name: "llvm-toolchain-16", Version: "1:16.0.6-27+build3"}, Type: "deb", Purl: "pkg:deb/linux/llvm-16@1%3A16.0.6-27%2Bbuild3?arch=amd64&source=llvm-toolchain-16"},
+		},
+		Gem: []*PkgInfo{{Name: "rake", Version: "13.0.6", Type: "gem"}},
+	}
+	if diff := cmp.Diff(want, pkgs); diff != "" {
+		t.Errorf("GetInstalledPackages() unexpected diff (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,125 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"bytes"
+	"context"
+	"sort"
+
+	"github.com/GoogleCloudPlatform/osconfig/util"
+)
+
+var (
+	systemctl string
+
+	systemctlListUnitFilesArgs = []string{"list-unit-files", "--type=service", "--no-legend", "--no-pager"}
+	systemctlListUnitsArgs     = []string{"list-units", "--type=service", "--all", "--plain", "--no-legend", "--no-pager"}
+
+	// systemctlQuery runs systemctl with args and returns its output.
+	systemctlQuery = func(ctx context.Context, args []string) ([]byte, error) {
+		return run(ctx, systemctl, args)
+	}
+)
+
+func init() {
+	for _, p := range []string{"/bin/systemctl", "/usr/bin/systemctl"} {
+		if util.Exists(p) {
+			systemctl = p
+			break
+		}
+	}
+	SystemctlExists = systemctl != ""
+}
+
+type systemdProvider struct{}
+
+// NewSystemdProvider returns a provider that reports the enabled, running and masked
+// systemd services as Packages.SystemdUnits, nothing is reported without systemd.
+func NewSystemdProvider() InstalledPackagesProvider {
+	return systemdProvider{}
+}
+
+func (systemdProvider) GetInstalledPackages(ctx context.Context) (Packages, error) {
+	if !SystemctlExists {
+		return Packages{}, nil
+	}
+	units, err := SystemdUnits(ctx)
+	if err != nil {
+		return Packages{}, err
+	}
+	return Packages{SystemdUnits: units}, nil
+}
+
+// SystemdUnits queries for the systemd services that are enabled, active or masked,
+// disabled services that are not running are skipped.
+func SystemdUnits(ctx context.Context) ([]*SystemdUnit, error) {
+	unitFiles, err := systemctlQuery(ctx, systemctlListUnitFilesArgs)
+	if err != nil {
+		return nil, err
+	}
+	loadedUnits, err := systemctlQuery(ctx, systemctlListUnitsArgs)
+	if err != nil {
+		return nil, err
+	}
+	return parseSystemdUnits(unitFiles, loadedUnits), nil
+}
+
+func parseSystemdUnits(unitFiles, loadedUnits []byte) []*SystemdUnit {
+	units := map[string]*SystemdUnit{}
+	unit := func(name string) *SystemdUnit {
+		if _, ok := units[name]; !ok {
+			units[name] = &SystemdUnit{Name: name, ActiveState: "inactive", SubState: "dead"}
+		}
+		return units[name]
+	}
+
+	/*
+	   sshd.service                 enabled  disabled
+	   debug-shell.service          disabled disabled
+	   ctrl-alt-del.service         masked   enabled
+	*/
+	for _, ln := range bytes.Split(bytes.TrimSpace(unitFiles), []byte("\n")) {
+		fields := bytes.Fields(ln)
+		if len(fields) < 2 {
+			continue
+		}
+		unit(string(fields[0])).UnitFileState = string(fields[1])
+	}
+
+	/*
+	   sshd.service                 loaded    active   running OpenSSH server daemon
+	   systemd-fsck-root.service    loaded    active   exited  File System Check on Root Device
+	   ctrl-alt-del.service         masked    inactive dead    ctrl-alt-del.service
+	*/
+	for _, ln := range bytes.Split(bytes.TrimSpace(loadedUnits), []byte("\n")) {
+		fields := bytes.Fields(ln)
+		if len(fields) < 4 {
+			continue
+		}
+		u := unit(string(fields[0]))
+		u.ActiveState = string(fields[2])
+		u.SubState = string(fields[3])
+	}
+
+	var pkgs []*SystemdUnit
+	for _, u := range units {
+		if u.ActiveState == "active" || u.UnitFileState == "enabled" || u.UnitFileState == "masked" {
+			pkgs = append(pkgs, u)
+		}
+	}
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].Name < pkgs[j].Name })
+	return pkgs
+}
@@ -0,0 +1,72 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSystemdUnits(t *testing.T) {
+	unitFiles := "sshd.service                 enabled  disabled\n" +
+		"debug-shell.service          disabled disabled\n" +
+		"ctrl-alt-del.service         masked   enabled\n" +
+		"cloud-init.service           disabled enabled\n" +
+		"systemd-fsck-root.service    static   -\n"
+	loadedUnits := "sshd.service                 loaded    active   running OpenSSH server daemon\n" +
+		"cloud-init.service           loaded    active   exited  Cloud-init: Network Stage\n" +
+		"systemd-fsck-root.service    loaded    inactive dead    File System Check on Root Device\n" +
+		"ctrl-alt-del.service         masked    inactive dead    ctrl-alt-del.service\n"
+
+	oldQuery := systemctlQuery
+	defer func() { systemctlQuery = oldQuery }()
+	systemctlQuery = func(_ context.Context, args []string) ([]byte, error) {
+		switch args[0] {
+		case "list-unit-files":
+			return []byte(unitFiles), nil
+		case "list-units":
+			return []byte(loadedUnits), nil
+		}
+		return nil, errors.New("unexpected systemctl command " + strings.Join(args, " "))
+	}
+
+	got, err := SystemdUnits(context.Background())
+	if err != nil {
+		t.Fatalf("SystemdUnits() unexpected error: %v", err)
+	}
+	want := []*SystemdUnit{
+		{Name: "cloud-init.service", ActiveState: "active", SubState: "exited", UnitFileState: "disabled"},
+		{Name: "ctrl-alt-del.service", ActiveState: "inactive", SubState: "dead", UnitFileState: "masked"},
+		{Name: "sshd.service", ActiveState: "active", SubState: "running", UnitFileState: "enabled"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SystemdUnits() = %v, want: %v", got, want)
+	}
+}
+
+func TestSystemdUnitsError(t *testing.T) {
+	oldQuery := systemctlQuery
+	defer func() { systemctlQuery = oldQuery }()
+	systemctlQuery = func(context.Context, []string) ([]byte, error) {
+		return nil, errors.New("systemd is not running")
+	}
+
+	if _, err := SystemdUnits(context.Background()); err == nil {
+		t.Errorf("SystemdUnits() expected an error")
+	}
+}
@@ -0,0 +1 @@
+module_version("mpi/openmpi/4.1.6", "stable")
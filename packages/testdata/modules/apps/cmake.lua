@@ -0,0 +1 @@
+prepend_path("PATH", "/opt/cmake/bin")
@@ -0,0 +1,2 @@
+help([[GNU Compiler Collection]])
+prepend_path("PATH", "/opt/gcc/13.2.0/bin")
@@ -0,0 +1,41 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// installDateLayouts are the layouts of the InstallDate value of uninstall registry keys,
+// YYYYMMDD as documented and the ISO date some installers write instead. Neither depends
+// on the locale of the system.
+var installDateLayouts = []string{"20060102", "2006-01-02"}
+
+// parseInstallDate parses the InstallDate registry value of an application. The zero
+// time is returned for an empty value and, with an error, for a value in an unknown format.
+func parseInstallDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range installDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized install date %q", s)
+}
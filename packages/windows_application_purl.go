@@ -0,0 +1,72 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/package-url/packageurl-go"
+)
+
+// WindowsApplicationPurlMapping maps the Windows applications of a well-known publisher
+// and product to a purl that vulnerability feeds can match, e.g. pkg:generic/google/chrome.
+type WindowsApplicationPurlMapping struct {
+	// Publisher is compared case-insensitively to the Publisher of the application, an
+	// empty Publisher matches any publisher.
+	Publisher string
+	// DisplayName matches the DisplayName of the application.
+	DisplayName *regexp.Regexp
+	// Type, Namespace and Name are the components of the purl, Type defaults to generic.
+	// The generic purls use the CPE vendor and product names.
+	Type, Namespace, Name string
+	// Qualifiers are added to the purl, e.g. the tag_id of pkg:swid purls.
+	Qualifiers map[string]string
+}
+
+// WindowsApplicationPurlMappings are checked in order, the first mapping matching an
+// application sets its purl. Applications without a mapping keep the pkg:generic purl
+// built from their display name and publisher. Callers may add mappings before the
+// inventory is collected.
+var WindowsApplicationPurlMappings = []WindowsApplicationPurlMapping{
+	{Publisher: "Google LLC", DisplayName: regexp.MustCompile(`^Google Chrome$`), Namespace: "google", Name: "chrome"},
+	{Publisher: "Microsoft Corporation", DisplayName: regexp.MustCompile(`^Microsoft Edge$`), Namespace: "microsoft", Name: "edge_chromium"},
+	{Publisher: "Mozilla", DisplayName: regexp.MustCompile(`^Mozilla Firefox\b`), Namespace: "mozilla", Name: "firefox"},
+	{Publisher: "Igor Pavlov", DisplayName: regexp.MustCompile(`^7-Zip\b`), Namespace: "7-zip", Name: "7-zip"},
+	{Publisher: "Notepad++ Team", DisplayName: regexp.MustCompile(`^Notepad\+\+\b`), Namespace: "notepad-plus-plus", Name: "notepad++"},
+	{Publisher: "Python Software Foundation", DisplayName: regexp.MustCompile(`^Python \d+\.\d+\.\d+ \((32|64)-bit\)$`), Namespace: "python", Name: "python"},
+	{Publisher: "The Git Development Community", DisplayName: regexp.MustCompile(`^Git\b`), Namespace: "git-scm", Name: "git"},
+	{Publisher: "VideoLAN", DisplayName: regexp.MustCompile(`^VLC media player$`), Namespace: "videolan", Name: "vlc_media_player"},
+	{Publisher: "PuTTY", DisplayName: regexp.MustCompile(`^PuTTY release\b`), Namespace: "putty", Name: "putty"},
+}
+
+// windowsApplicationPurl returns the purl of the first mapping matching app, it reports
+// false when no mapping matches.
+func windowsApplicationPurl(app *WindowsApplication) (string, bool) {
+	for _, m := range WindowsApplicationPurlMappings {
+		if m.Publisher != "" && !strings.EqualFold(strings.TrimSpace(app.Publisher), m.Publisher) {
+			continue
+		}
+		if m.DisplayName == nil || !m.DisplayName.MatchString(app.DisplayName) {
+			continue
+		}
+		purlType := m.Type
+		if purlType == "" {
+			purlType = packageurl.TypeGeneric
+		}
+		return BuildPurl(purlType, m.Namespace, m.Name, app.DisplayVersion, m.Qualifiers), true
+	}
+	return "", false
+}
@@ -0,0 +1,73 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/osconfig/util/utiltest"
+)
+
+func TestWindowsApplicationPurl(t *testing.T) {
+	tests := []struct {
+		name   string
+		app    *WindowsApplication
+		want   string
+		wantOK bool
+	}{
+		{
+			name:   "mapped",
+			app:    &WindowsApplication{DisplayName: "Google Chrome", DisplayVersion: "129.0.6668.90", Publisher: "Google LLC"},
+			want:   "pkg:generic/google/chrome@129.0.6668.90",
+			wantOK: true,
+		},
+		{
+			name:   "mapped with case-insensitive publisher",
+			app:    &WindowsApplication{DisplayName: "Mozilla Firefox (x64 en-US)", DisplayVersion: "131.0", Publisher: "mozilla"},
+			want:   "pkg:generic/mozilla/firefox@131.0",
+			wantOK: true,
+		},
+		{
+			name: "unmapped",
+			app:  &WindowsApplication{DisplayName: "Contoso Line of Business App", DisplayVersion: "1.2.3", Publisher: "Contoso Ltd."},
+		},
+		{
+			name: "mapped name of another publisher",
+			app:  &WindowsApplication{DisplayName: "Google Chrome", DisplayVersion: "1.0", Publisher: "Repackager Inc."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := windowsApplicationPurl(tt.app)
+			utiltest.AssertEquals(t, ok, tt.wantOK)
+			utiltest.AssertEquals(t, got, tt.want)
+		})
+	}
+}
+
+func TestWindowsApplicationPurlCustomMapping(t *testing.T) {
+	utiltest.OverrideVariable(t, &WindowsApplicationPurlMappings, append([]WindowsApplicationPurlMapping{{
+		DisplayName: regexp.MustCompile(`^Contoso Agent$`),
+		Type:        "swid",
+		Name:        "Contoso Agent",
+		Qualifiers:  map[string]string{"tag_id": "contoso-agent-1"},
+	}}, WindowsApplicationPurlMappings...))
+
+	got, ok := windowsApplicationPurl(&WindowsApplication{DisplayName: "Contoso Agent", DisplayVersion: "2.0", Publisher: "Contoso Ltd."})
+	utiltest.AssertEquals(t, ok, true)
+	utiltest.AssertEquals(t, got, "pkg:swid/Contoso%20Agent@2.0?tag_id=contoso-agent-1")
+}
@@ -0,0 +1,52 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseInstallDate(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{in: "20240102", want: time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC)},
+		{in: " 20231231 ", want: time.Date(2023, time.December, 31, 0, 0, 0, 0, time.UTC)},
+		{in: "2024-01-02", want: time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC)},
+		{in: ""},
+		{in: "20241301", wantErr: true},
+		{in: "20240230", wantErr: true},
+		{in: "2024012", wantErr: true},
+		{in: "01/02/2024", wantErr: true},
+		{in: "2024AB02", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseInstallDate(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseInstallDate(%q) error = %v, wantErr: %v", tt.in, err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseInstallDate(%q) = %v, want: %v", tt.in, got, tt.want)
+			}
+			if tt.wantErr && !got.IsZero() {
+				t.Errorf("parseInstallDate(%q) = %v, want the zero time", tt.in, got)
+			}
+		})
+	}
+}
@@ -16,49 +16,33 @@ package packages
 
 import (
 	"context"
-	"strconv"
-	"time"
 
 	"github.com/GoogleCloudPlatform/osconfig/clog"
 	"golang.org/x/sys/windows/registry"
 )
 
-func parseDate(dateString string) time.Time {
-	if len(dateString) != 8 {
-		return time.Time{}
-	}
-
-	year, err := strconv.ParseInt(dateString[0:4], 10, 32)
-	if err != nil {
-		return time.Time{}
-	}
-	month, err := strconv.ParseInt(dateString[4:6], 10, 32)
-	if err != nil {
-		return time.Time{}
-	}
-	day, err := strconv.ParseInt(dateString[6:8], 10, 32)
-	if err != nil {
-		return time.Time{}
-	}
-
-	return time.Date(int(year), time.Month(month), int(day), 0, 0, 0, 0, time.Now().Location())
-}
-
 func getWindowsApplication(ctx context.Context, k *registry.Key) *WindowsApplication {
 	displayName, _, errName := k.GetStringValue("DisplayName")
-	_, _, errUninstall := k.GetStringValue("UninstallString")
+	uninstallString, _, errUninstall := k.GetStringValue("UninstallString")
 
 	if errName == nil && errUninstall == nil {
 		displayVersion, _, _ := k.GetStringValue("DisplayVersion")
 		publisher, _, _ := k.GetStringValue("Publisher")
 		installDate, _, _ := k.GetStringValue("InstallDate")
 		helpLink, _, _ := k.GetStringValue("HelpLink")
+		installSource, _, _ := k.GetStringValue("InstallSource")
+		date, err := parseInstallDate(installDate)
+		if err != nil {
+			clog.Warningf(ctx, "Error parsing the install date of %q: %v", displayName, err)
+		}
 		return &WindowsApplication{
-			DisplayName:    displayName,
-			DisplayVersion: displayVersion,
-			Publisher:      publisher,
-			InstallDate:    parseDate(installDate),
-			HelpLink:       helpLink,
+			DisplayName:     displayName,
+			DisplayVersion:  displayVersion,
+			Publisher:       publisher,
+			InstallDate:     date,
+			HelpLink:        helpLink,
+			InstallSource:   installSource,
+			UninstallString: uninstallString,
 		}
 	}
 	return nil
@@ -0,0 +1,53 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"context"
+	"sort"
+)
+
+// windowsServicesQuery enumerates the services of the Service Control Manager,
+// it is only set on Windows.
+var windowsServicesQuery func(ctx context.Context) ([]*WindowsService, error)
+
+type windowsServicesProvider struct{}
+
+// NewWindowsServicesProvider returns a provider that reports the Windows services as
+// Packages.WindowsServices, nothing is reported on other operating systems.
+func NewWindowsServicesProvider() InstalledPackagesProvider {
+	return windowsServicesProvider{}
+}
+
+func (windowsServicesProvider) GetInstalledPackages(ctx context.Context) (Packages, error) {
+	if windowsServicesQuery == nil {
+		return Packages{}, nil
+	}
+	services, err := WindowsServices(ctx)
+	if err != nil {
+		return Packages{}, err
+	}
+	return Packages{WindowsServices: services}, nil
+}
+
+// WindowsServices queries for all Windows services sorted by name.
+func WindowsServices(ctx context.Context) ([]*WindowsService, error) {
+	services, err := windowsServicesQuery(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+	return services, nil
+}
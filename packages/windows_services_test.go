@@ -0,0 +1,70 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWindowsServicesProvider(t *testing.T) {
+	oldQuery := windowsServicesQuery
+	defer func() { windowsServicesQuery = oldQuery }()
+
+	tests := []struct {
+		name    string
+		query   func(context.Context) ([]*WindowsService, error)
+		want    Packages
+		wantErr bool
+	}{
+		{
+			name: "Services",
+			query: func(context.Context) ([]*WindowsService, error) {
+				return []*WindowsService{
+					{Name: "wuauserv", DisplayName: "Windows Update", StartType: "manual", State: "running"},
+					{Name: "GCEAgent", DisplayName: "Google Compute Engine Agent", StartType: "auto", State: "running"},
+				}, nil
+			},
+			want: Packages{WindowsServices: []*WindowsService{
+				{Name: "GCEAgent", DisplayName: "Google Compute Engine Agent", StartType: "auto", State: "running"},
+				{Name: "wuauserv", DisplayName: "Windows Update", StartType: "manual", State: "running"},
+			}},
+		},
+		{
+			name: "Error",
+			query: func(context.Context) ([]*WindowsService, error) {
+				return nil, errors.New("access denied")
+			},
+			wantErr: true,
+		},
+		{
+			name: "NotWindows",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			windowsServicesQuery = tt.query
+			got, err := NewWindowsServicesProvider().GetInstalledPackages(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetInstalledPackages() error = %v, wantErr: %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetInstalledPackages() = %+v, want: %+v", got, tt.want)
+			}
+		})
+	}
+}
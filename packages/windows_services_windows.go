@@ -0,0 +1,111 @@
+//  Copyright 2026 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package packages
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/GoogleCloudPlatform/osconfig/clog"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+func init() {
+	windowsServicesQuery = queryWindowsServices
+}
+
+func queryWindowsServices(ctx context.Context) ([]*WindowsService, error) {
+	m, err := mgr.Connect()
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to the service control manager: %v", err)
+	}
+	defer m.Disconnect()
+
+	names, err := m.ListServices()
+	if err != nil {
+		return nil, fmt.Errorf("error listing services: %v", err)
+	}
+
+	var services []*WindowsService
+	for _, name := range names {
+		service, err := windowsService(m, name)
+		if err != nil {
+			// Services can be removed while listing them or be inaccessible, skip them.
+			clog.Debugf(ctx, "Unable to query service %q: %v", name, err)
+			continue
+		}
+		services = append(services, service)
+	}
+	return services, nil
+}
+
+func windowsService(m *mgr.Mgr, name string) (*WindowsService, error) {
+	s, err := m.OpenService(name)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+
+	config, err := s.Config()
+	if err != nil {
+		return nil, err
+	}
+	status, err := s.Query()
+	if err != nil {
+		return nil, err
+	}
+	return &WindowsService{
+		Name:        name,
+		DisplayName: config.DisplayName,
+		StartType:   windowsServiceStartType(config),
+		State:       windowsServiceState(status.State),
+	}, nil
+}
+
+func windowsServiceStartType(config mgr.Config) string {
+	switch config.StartType {
+	case mgr.StartAutomatic:
+		if config.DelayedAutoStart {
+			return "auto-delayed"
+		}
+		return "auto"
+	case mgr.StartManual:
+		return "manual"
+	case mgr.StartDisabled:
+		return "disabled"
+	}
+	return fmt.Sprintf("unknown(%d)", config.StartType)
+}
+
+func windowsServiceState(state svc.State) string {
+	switch state {
+	case svc.Stopped:
+		return "stopped"
+	case svc.StartPending:
+		return "start-pending"
+	case svc.StopPending:
+		return "stop-pending"
+	case svc.Running:
+		return "running"
+	case svc.ContinuePending:
+		return "continue-pending"
+	case svc.PausePending:
+		return "pause-pending"
+	case svc.Paused:
+		return "paused"
+	}
+	return fmt.Sprintf("unknown(%d)", state)
+}
@@ -271,6 +271,60 @@ func (u *IUpdate) moreInfoURLs() ([]string, error) {
 	return ss, nil
 }
 
+// Values of IInstallationBehavior.RebootBehavior.
+const (
+	installationRebootBehaviorNeverReboots         = 0
+	installationRebootBehaviorAlwaysRequiresReboot = 1
+	installationRebootBehaviorCanRequestReboot     = 2
+)
+
+// rebootRequired reports whether the update requires a reboot, nil when unknown. IUpdate
+// RebootRequired is only set once an update is installed, the reboot of an update that is
+// not installed yet is predicted from its installation behavior, unknown when it may
+// request one.
+func (u *IUpdate) rebootRequired() (*bool, error) {
+	isInstalledRaw, err := u.GetProperty("IsInstalled")
+	if err != nil {
+		return nil, fmt.Errorf(`IUpdate.GetProperty("IsInstalled"): %v`, err)
+	}
+	if isInstalled, _ := isInstalledRaw.Value().(bool); isInstalled {
+		rebootRequiredRaw, err := u.GetProperty("RebootRequired")
+		if err != nil {
+			return nil, fmt.Errorf(`IUpdate.GetProperty("RebootRequired"): %v`, err)
+		}
+		if v, ok := rebootRequiredRaw.Value().(bool); ok {
+			return &v, nil
+		}
+		return nil, nil
+	}
+
+	behaviorRaw, err := u.GetProperty("InstallationBehavior")
+	if err != nil {
+		return nil, fmt.Errorf(`IUpdate.GetProperty("InstallationBehavior"): %v`, err)
+	}
+	behavior := behaviorRaw.ToIDispatch()
+	if behavior == nil {
+		return nil, nil
+	}
+	defer behavior.Release()
+
+	rebootBehavior, err := behavior.GetProperty("RebootBehavior")
+	if err != nil {
+		return nil, fmt.Errorf(`InstallationBehavior.GetProperty("RebootBehavior"): %v`, err)
+	}
+	var required bool
+	switch rebootBehavior.Val {
+	case installationRebootBehaviorNeverReboots:
+		required = false
+	case installationRebootBehaviorAlwaysRequiresReboot:
+		required = true
+	default:
+		// installationRebootBehaviorCanRequestReboot depends on the state of the system.
+		return nil, nil
+	}
+	return &required, nil
+}
+
 func (c *IUpdateCollection) extractPkg(item int) (*WUAPackage, error) {
 	updt, err := c.Item(item)
 	if err != nil {
@@ -308,6 +362,21 @@ func (c *IUpdateCollection) extractPkg(item int) (*WUAPackage, error) {
 		return nil, fmt.Errorf(`updt.GetProperty("SupportURL"): %v`, err)
 	}
 
+	msrcSeverity, err := updt.GetProperty("MsrcSeverity")
+	if err != nil {
+		return nil, fmt.Errorf(`updt.GetProperty("MsrcSeverity"): %v`, err)
+	}
+
+	maxDownloadSize, err := updt.GetProperty("MaxDownloadSize")
+	if err != nil {
+		return nil, fmt.Errorf(`updt.GetProperty("MaxDownloadSize"): %v`, err)
+	}
+
+	rebootRequired, err := updt.rebootRequired()
+	if err != nil {
+		return nil, err
+	}
+
 	lastDeploymentChangeTimeRaw, err := updt.GetProperty("LastDeploymentChangeTime")
 	if err != nil {
 		return nil, fmt.Errorf(`updt.GetProperty("LastDeploymentChangeTime"): %v`, err)
@@ -346,9 +415,32 @@ func (c *IUpdateCollection) extractPkg(item int) (*WUAPackage, error) {
 		MoreInfoURLs:             moreInfoURLs,
 		RevisionNumber:           int32(revisionNumber.Val),
 		LastDeploymentChangeTime: lastDeploymentChangeTime,
+		MsrcSeverity:             msrcSeverity.ToString(),
+		MaxDownloadSize:          decimalVariantToInt64(maxDownloadSize),
+		RebootRequired:           rebootRequired,
 	}, nil
 }
 
+// decimalVariantToInt64 returns the value of an integer or DECIMAL variant, 0 for other types.
+func decimalVariantToInt64(v *ole.VARIANT) int64 {
+	if v.VT == ole.VT_DECIMAL {
+		// The DECIMAL overlays the whole variant, its low 64 bits are stored in Val.
+		// Sizes have neither a scale nor high bits set.
+		return v.Val
+	}
+	switch i := v.Value().(type) {
+	case int32:
+		return int64(i)
+	case uint32:
+		return int64(i)
+	case int64:
+		return i
+	case uint64:
+		return int64(i)
+	}
+	return 0
+}
+
 // WUAUpdates queries the Windows Update Agent API searcher with the provided query.
 func WUAUpdates(ctx context.Context, query string) ([]WUAPackage, error) {
 	session, err := NewUpdateSession()
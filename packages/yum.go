@@ -31,19 +31,27 @@ import (
 
 var (
 	yum string
+	dnf string
 
 	yumInstallArgs           = []string{"install", "--assumeyes"}
 	yumRemoveArgs            = []string{"remove", "--assumeyes"}
 	yumCheckUpdateArgs       = []string{"check-update", "--assumeyes"}
 	yumListUpdatesArgs       = []string{"update", "--assumeno", "--color=never"}
 	yumListUpdateMinimalArgs = []string{"update-minimal", "--assumeno", "--color=never"}
+	// yumSecurityUpdatesArgs lists the packages of the available security advisories.
+	yumSecurityUpdatesArgs = []string{"updateinfo", "list", "--security", "--quiet"}
+	// dnfUserInstalledArgs lists the packages installed on request of a user, repositories
+	// are not needed as the dnf history is local. dnf5 does not end entries with a newline.
+	dnfUserInstalledArgs = []string{"repoquery", "--userinstalled", "--quiet", "--disablerepo=*", "--queryformat", "%{name}.%{arch}\n"}
 )
 
 func init() {
 	if runtime.GOOS != "windows" {
 		yum = "/usr/bin/yum"
+		dnf = "/usr/bin/dnf"
 	}
 	YumExists = util.Exists(yum)
+	DnfExists = util.Exists(dnf)
 }
 
 type yumUpdateOpts struct {
@@ -132,7 +140,7 @@ func parseYumUpdates(data []byte) []*PkgInfo {
 			break
 		}
 
-		pkgs = append(pkgs, &PkgInfo{Name: string(pkg[0]), Arch: osinfo.NormalizeArchitecture(string(pkg[1])), RawArch: string(pkg[1]), Version: string(pkg[2]), Type: typeRPM})
+		pkgs = append(pkgs, &PkgInfo{Name: string(pkg[0]), Arch: osinfo.NormalizeArchitecture(string(pkg[1])), RawArch: string(pkg[1]), Version: string(pkg[2]), Type: typeRPM, Repository: string(pkg[3])})
 	}
 	return pkgs
 }
@@ -156,6 +164,84 @@ func getYumTXFile(data []byte) string {
 	return ""
 }
 
+// setExplicitRPMPackages sets Explicit on pkgs from the packages dnf records as installed
+// on request of a user. Nothing is set on hosts not managed by dnf.
+func setExplicitRPMPackages(ctx context.Context, pkgs []*PkgInfo) {
+	if DetectRPMBackend() != RPMBackendDnf {
+		return
+	}
+	out, err := run(ctx, dnf, dnfUserInstalledArgs)
+	if err != nil {
+		clog.Debugf(ctx, "Unable to list user installed dnf packages: %v", err)
+		return
+	}
+	userInstalled := map[string]bool{}
+	for _, ln := range strings.Split(string(out), "\n") {
+		ln = strings.TrimSpace(ln)
+		if i := strings.LastIndex(ln, "."); i > 0 {
+			userInstalled[ln[:i]+"."+osinfo.NormalizeArchitecture(ln[i+1:])] = true
+		}
+	}
+	for _, pkg := range pkgs {
+		explicit := userInstalled[pkg.Name+"."+pkg.Arch]
+		pkg.Explicit = &explicit
+	}
+}
+
+func parseYumSecurityUpdates(data []byte) map[string]bool {
+	/*
+		Each line lists a package of a security advisory, yum prints "security" instead of
+		the severity for advisories without one.
+
+		RLSA-2025:3893 Important/Sec. kernel-4.18.0-553.50.1.el8_10.x86_64
+		RLSA-2024:9540 Moderate/Sec.  bind-export-libs-32:9.11.36-16.el8_10.4.x86_64
+		RHSA-2024:1249 security       systemd-219-78.el7_9.9.x86_64
+	*/
+	security := map[string]bool{}
+	for _, ln := range strings.Split(string(data), "\n") {
+		flds := strings.Fields(ln)
+		if len(flds) != 3 || !(strings.HasSuffix(flds[1], "/Sec.") || flds[1] == "security") {
+			continue
+		}
+		// The package is "<name>-<[epoch:]version>-<release>.<arch>".
+		nevra := flds[2]
+		i := strings.LastIndex(nevra, ".")
+		if i < 0 {
+			continue
+		}
+		nvr, arch := nevra[:i], nevra[i+1:]
+		j := strings.LastIndex(nvr, "-")
+		if j < 0 {
+			continue
+		}
+		k := strings.LastIndex(nvr[:j], "-")
+		if k <= 0 {
+			continue
+		}
+		security[nvr[:k]+"."+arch] = true
+	}
+	return security
+}
+
+// setYumSecurityUpdates sets Security on the updates of pkgs that fix a security
+// advisory. Nothing is set when the advisories cannot be listed.
+func setYumSecurityUpdates(ctx context.Context, pkgs []*PkgInfo) {
+	if len(pkgs) == 0 {
+		return
+	}
+	out, err := run(ctx, yum, yumSecurityUpdatesArgs)
+	if err != nil {
+		clog.Debugf(ctx, "Unable to list yum security advisories: %v", err)
+		return
+	}
+	security := parseYumSecurityUpdates(out)
+	for _, pkg := range pkgs {
+		if security[pkg.Name+"."+pkg.RawArch] {
+			pkg.Security = true
+		}
+	}
+}
+
 // YumUpdates queries for all available yum updates.
 func YumUpdates(ctx context.Context, opts ...YumUpdateOption) ([]*PkgInfo, error) {
 	// We just use check-update to ensure all repo keys are synced as we run
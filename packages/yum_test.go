@@ -250,6 +250,40 @@ func TestYumUpdates(t *testing.T) {
 	}
 }
 
+func TestSetExplicitRPMPackages(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockCommandRunner := utilmocks.NewMockCommandRunner(mockCtrl)
+	runner = mockCommandRunner
+	utiltest.OverrideVariable(t, &DnfExists, true)
+	expectedCmd := utilmocks.EqCmd(exec.Command(dnf, dnfUserInstalledArgs...))
+
+	mockCommandRunner.EXPECT().Run(testCtx, expectedCmd).Return([]byte("vim-enhanced.x86_64\n\ngit.x86_64\n"), nil, nil).Times(1)
+	pkgs := []*PkgInfo{{Name: "vim-enhanced", Arch: "x86_64"}, {Name: "glibc", Arch: "x86_64"}}
+	setExplicitRPMPackages(testCtx, pkgs)
+	for i, want := range []bool{true, false} {
+		if pkgs[i].Explicit == nil {
+			t.Fatalf("%s Explicit not set", pkgs[i].Name)
+		}
+		utiltest.AssertEquals(t, *pkgs[i].Explicit, want)
+	}
+
+	mockCommandRunner.EXPECT().Run(testCtx, expectedCmd).Return(nil, []byte("stderr"), errors.New("dnf failed")).Times(1)
+	pkgs = []*PkgInfo{{Name: "vim-enhanced", Arch: "x86_64"}}
+	setExplicitRPMPackages(testCtx, pkgs)
+	if pkgs[0].Explicit != nil {
+		t.Errorf("Explicit = %v, want unset when dnf fails", *pkgs[0].Explicit)
+	}
+
+	// Hosts not managed by dnf do not record user installed packages.
+	utiltest.OverrideVariable(t, &DnfExists, false)
+	setExplicitRPMPackages(testCtx, pkgs)
+	if pkgs[0].Explicit != nil {
+		t.Errorf("Explicit = %v, want unset without dnf", *pkgs[0].Explicit)
+	}
+}
+
 func contains(names []string, name string) bool {
 	for _, n := range names {
 		if n == name {
@@ -280,9 +314,9 @@ func TestParseYumUpdates(t *testing.T) {
 		want []*PkgInfo
 	}{
 		{"NormalCase", data, []*PkgInfo{
-			{Name: "kernel", Arch: "x86_64", RawArch: "x86_64", Version: "2.6.32-754.24.3.el6", Type: "rpm"},
-			{Name: "foo", Arch: "all", RawArch: "noarch", Version: "2.0.0-1", Type: "rpm"},
-			{Name: "bar", Arch: "x86_64", RawArch: "x86_64", Version: "2.0.0-1", Type: "rpm"}}},
+			{Name: "kernel", Arch: "x86_64", RawArch: "x86_64", Version: "2.6.32-754.24.3.el6", Type: "rpm", Repository: "updates"},
+			{Name: "foo", Arch: "all", RawArch: "noarch", Version: "2.0.0-1", Type: "rpm", Repository: "BaseOS"},
+			{Name: "bar", Arch: "x86_64", RawArch: "x86_64", Version: "2.0.0-1", Type: "rpm", Repository: "repo"}}},
 		{"NoPackages", []byte("nothing here"), nil},
 		{"nil", nil, nil},
 	}
@@ -295,6 +329,70 @@ func TestParseYumUpdates(t *testing.T) {
 	}
 }
 
+func TestSetYumSecurityUpdates(t *testing.T) {
+	tests := []struct {
+		name         string
+		updates      string
+		securityInfo string
+		want         []string
+	}{
+		{
+			name:         "rhel-7-1 yum advisories",
+			updates:      "./testdata/rhel-7-1.yum-update.stdout",
+			securityInfo: "./testdata/rhel-7-1.yum-updateinfo-security.stdout",
+			want:         []string{"kernel", "kernel-tools", "kernel-tools-libs", "python-perf", "systemd"},
+		},
+		{
+			name:         "rocky8-8 dnf advisories",
+			updates:      "./testdata/rocky8-8.yum-update.stdout",
+			securityInfo: "./testdata/rocky8-8.yum-updateinfo-security.stdout",
+			want:         []string{"kernel", "kernel-core", "kernel-modules", "bind-export-libs", "curl", "expat", "glibc"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			mockCommandRunner := utilmocks.NewMockCommandRunner(mockCtrl)
+			SetCommandRunner(mockCommandRunner)
+			setExpectations(mockCommandRunner, []expectedCommand{{
+				cmd:    exec.Command(yum, yumSecurityUpdatesArgs...),
+				stdout: utiltest.BytesFromFile(t, tt.securityInfo),
+			}})
+
+			pkgs := parseYumUpdates(utiltest.BytesFromFile(t, tt.updates))
+			setYumSecurityUpdates(testCtx, pkgs)
+
+			var got []string
+			seen := map[string]bool{}
+			for _, pkg := range pkgs {
+				if pkg.Security && !seen[pkg.Name] {
+					seen[pkg.Name] = true
+					got = append(got, pkg.Name)
+				}
+			}
+			utiltest.AssertEquals(t, got, tt.want)
+		})
+	}
+}
+
+func TestSetYumSecurityUpdatesError(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockCommandRunner := utilmocks.NewMockCommandRunner(mockCtrl)
+	SetCommandRunner(mockCommandRunner)
+	setExpectations(mockCommandRunner, []expectedCommand{{
+		cmd: exec.Command(yum, yumSecurityUpdatesArgs...),
+		err: errors.New("updateinfo not supported"),
+	}})
+
+	pkgs := []*PkgInfo{{Name: "kernel", Arch: "x86_64", RawArch: "x86_64", Version: "3.10.0-1160.119.1.el7", Type: "rpm"}}
+	setYumSecurityUpdates(testCtx, pkgs)
+	if pkgs[0].Security {
+		t.Errorf("setYumSecurityUpdates() flagged %q without security advisories", pkgs[0].Name)
+	}
+}
+
 func TestParseYumUpdatesWithInstallingDependenciesKeywords(t *testing.T) {
 	data := []byte(`
 	=================================================================================================================================================================================
@@ -313,9 +411,9 @@ func TestParseYumUpdatesWithInstallingDependenciesKeywords(t *testing.T) {
 		want []*PkgInfo
 	}{
 		{"NormalCase", data, []*PkgInfo{
-			{Name: "kernel", Arch: "x86_64", RawArch: "x86_64", Version: "2.6.32-754.24.3.el6", Type: "rpm"},
-			{Name: "foo", Arch: "all", RawArch: "noarch", Version: "2.0.0-1", Type: "rpm"},
-			{Name: "bar", Arch: "x86_64", RawArch: "x86_64", Version: "2.0.0-1", Type: "rpm"}}},
+			{Name: "kernel", Arch: "x86_64", RawArch: "x86_64", Version: "2.6.32-754.24.3.el6", Type: "rpm", Repository: "updates"},
+			{Name: "foo", Arch: "all", RawArch: "noarch", Version: "2.0.0-1", Type: "rpm", Repository: "BaseOS"},
+			{Name: "bar", Arch: "x86_64", RawArch: "x86_64", Version: "2.0.0-1", Type: "rpm", Repository: "repo"}}},
 		{"NoPackages", []byte("nothing here"), nil},
 		{"nil", nil, nil},
 	}
@@ -147,7 +147,8 @@ func parseZypperUpdates(data []byte) []*PkgInfo {
 		name := string(bytes.TrimSpace(pkg[2]))
 		arch := string(bytes.TrimSpace(pkg[5]))
 		ver := string(bytes.TrimSpace(pkg[4]))
-		pkgs = append(pkgs, &PkgInfo{Name: name, Arch: osinfo.NormalizeArchitecture(arch), Version: ver, Type: typeRPM})
+		repository := string(bytes.TrimSpace(pkg[1]))
+		pkgs = append(pkgs, &PkgInfo{Name: name, Arch: osinfo.NormalizeArchitecture(arch), Version: ver, Type: typeRPM, Repository: repository})
 	}
 	return pkgs
 }
@@ -212,7 +213,7 @@ func parseZypperPatch(tableLine []byte) (*ZypperPatch, string, error) {
 		summary = string(bytes.TrimSpace(patch[7]))
 	}
 
-	return &ZypperPatch{Name: name, Category: category, Severity: severity, Summary: summary}, status, nil
+	return &ZypperPatch{Name: name, Category: category, Severity: severity, Summary: summary, Status: status}, status, nil
 }
 
 func zypperPatches(ctx context.Context, opts ...ZypperListOption) ([]byte, error) {
@@ -78,8 +78,8 @@ this is junk data`
 		want []*PkgInfo
 	}{
 		{"NormalCase", []byte(normalCase), []*PkgInfo{
-			{Name: "at", Arch: "x86_64", Version: "3.1.14-8.3.1", Type: "rpm"},
-			{Name: "autoyast2-installation", Arch: "all", Version: "3.2.22-2.9.2", Type: "rpm"}}},
+			{Name: "at", Arch: "x86_64", Version: "3.1.14-8.3.1", Type: "rpm", Repository: "SLES12-SP3-Updates"},
+			{Name: "autoyast2-installation", Arch: "all", Version: "3.2.22-2.9.2", Type: "rpm", Repository: "SLES12-SP3-Updates"}}},
 		{"NoPackages", []byte("nothing here"), nil},
 		{"nil", nil, nil},
 	}
@@ -134,7 +134,7 @@ func TestZypperUpdates(t *testing.T) {
 					stderr: []byte("stderr"),
 				},
 			},
-			expectedResults: []*PkgInfo{{Name: "at", Arch: "x86_64", Version: "3.1.14-8.3.1", Type: "rpm"}},
+			expectedResults: []*PkgInfo{{Name: "at", Arch: "x86_64", Version: "3.1.14-8.3.1", Type: "rpm", Repository: "SLES12-SP3-Updates"}},
 		},
 		{
 			name: "sles-12-1 mapped list-updates stdout matches snapshot",
@@ -211,14 +211,14 @@ some junk data`
 		{
 			"NormalCase",
 			[]byte(normalCase),
-			[]*ZypperPatch{{"SUSE-SLE-Module-Basesystem-15-SP1-2019-1206", "security", "low", "Security update for bzip2" /*PURL: */, ""}},
-			[]*ZypperPatch{{"SUSE-SLE-Module-Basesystem-15-SP1-2019-1221", "security", "moderate", "Security update for libxslt" /*PURL: */, ""}, {"SUSE-SLE-Module-Basesystem-15-SP1-2019-1258", "recommended", "moderate", "Recommended update for postfix" /*PURL: */, ""}},
+			[]*ZypperPatch{{"SUSE-SLE-Module-Basesystem-15-SP1-2019-1206", "security", "low", "Security update for bzip2" /*PURL: */, "", "applied"}},
+			[]*ZypperPatch{{"SUSE-SLE-Module-Basesystem-15-SP1-2019-1221", "security", "moderate", "Security update for libxslt" /*PURL: */, "", "needed"}, {"SUSE-SLE-Module-Basesystem-15-SP1-2019-1258", "recommended", "moderate", "Recommended update for postfix" /*PURL: */, "", "needed"}},
 		},
 		{
 			"WithSinceField",
 			[]byte(withSinceField),
-			[]*ZypperPatch{{"SUSE-SLE-Module-Basesystem-15-SP1-2019-1206", "security", "low", "Security update for bzip2" /*PURL: */, ""}},
-			[]*ZypperPatch{{"SUSE-SLE-Module-Basesystem-15-SP1-2019-1221", "security", "moderate", "Security update for libxslt" /*PURL: */, ""}, {"SUSE-SLE-Module-Basesystem-15-SP1-2019-1258", "recommended", "moderate", "Recommended update for postfix", ""}},
+			[]*ZypperPatch{{"SUSE-SLE-Module-Basesystem-15-SP1-2019-1206", "security", "low", "Security update for bzip2" /*PURL: */, "", "applied"}},
+			[]*ZypperPatch{{"SUSE-SLE-Module-Basesystem-15-SP1-2019-1221", "security", "moderate", "Security update for libxslt" /*PURL: */, "", "needed"}, {"SUSE-SLE-Module-Basesystem-15-SP1-2019-1258", "recommended", "moderate", "Recommended update for postfix", "", "needed"}},
 		},
 		{"NoPackages", []byte("nothing here"), nil, nil},
 		{"nil", nil, nil, nil},
@@ -279,7 +279,7 @@ func TestZypperPatches(t *testing.T) {
 					stderr: []byte("stderr"),
 				},
 			},
-			expectedResults: []*ZypperPatch{{"SUSE-SLE-Module-Basesystem-15-SP1-2019-1258", "recommended", "moderate", "Recommended update for postfix", ""}},
+			expectedResults: []*ZypperPatch{{"SUSE-SLE-Module-Basesystem-15-SP1-2019-1258", "recommended", "moderate", "Recommended update for postfix", "", "needed"}},
 		},
 		{
 			name: "sles-12-1 mapped list-patches stdout matches snapshot",
@@ -344,7 +344,7 @@ func TestZypperInstalledPatches(t *testing.T) {
 		t.Errorf("unexpected error: %v", err)
 	}
 
-	want := []*ZypperPatch{{"SUSE-SLE-Module-Basesystem-15-SP1-2019-1258", "recommended", "moderate", "Recommended update for postfix", ""}}
+	want := []*ZypperPatch{{"SUSE-SLE-Module-Basesystem-15-SP1-2019-1258", "recommended", "moderate", "Recommended update for postfix", "", "applied"}}
 	if !reflect.DeepEqual(ret, want) {
 		t.Errorf("ZypperInstalledPatches() = %v, want %v", ret, want)
 	}
@@ -337,7 +337,7 @@ func (s stubOsInfoProvider) GetOSInfo(ctx context.Context) (osinfo.OSInfo, error
 func TestAptChanges(t *testing.T) {
 	ctx := context.Background()
 
-	dpkgQueryArgs := []string{"-W", "-f", `\{"architecture":"${Architecture}","package":"${Package}","source_name":"${source:Package}","source_version":"${source:Version}","status":"${db:Status-Status}","version":"${Version}"\}` + "\n"}
+	dpkgQueryArgs := []string{"-W", "-f", `\{"architecture":"${Architecture}","installed_size":"${Installed-Size}","package":"${Package}","source_name":"${source:Package}","source_version":"${source:Version}","status":"${db:Status-Status}","version":"${Version}"\}` + "\x1f${Maintainer}\n"}
 	aptUpgradableArgs := []string{"--just-print", "-qq", "dist-upgrade"}
 	aptEnv := []string{"DEBIAN_FRONTEND=noninteractive"}
 
@@ -369,7 +369,7 @@ func TestAptChanges(t *testing.T) {
 					Err: errors.New("dpkg-query error"),
 				},
 			},
-			wantErr: errors.New("error running /usr/bin/dpkg-query with args [\"-W\" \"-f\" \"\\\\{\\\"architecture\\\":\\\"${Architecture}\\\",\\\"package\\\":\\\"${Package}\\\",\\\"source_name\\\":\\\"${source:Package}\\\",\\\"source_version\\\":\\\"${source:Version}\\\",\\\"status\\\":\\\"${db:Status-Status}\\\",\\\"version\\\":\\\"${Version}\\\"\\\\}\\n\"]: dpkg-query error, stdout: \"\", stderr: \"\""),
+			wantErr: errors.New("error running /usr/bin/dpkg-query with args [\"-W\" \"-f\" \"\\\\{\\\"architecture\\\":\\\"${Architecture}\\\",\\\"installed_size\\\":\\\"${Installed-Size}\\\",\\\"package\\\":\\\"${Package}\\\",\\\"source_name\\\":\\\"${source:Package}\\\",\\\"source_version\\\":\\\"${source:Version}\\\",\\\"status\\\":\\\"${db:Status-Status}\\\",\\\"version\\\":\\\"${Version}\\\"\\\\}\\x1f${Maintainer}\\n\"]: dpkg-query error, stdout: \"\", stderr: \"\""),
 		},
 		{
 			name:       "apt-get update failure, want update error",
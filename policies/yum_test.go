@@ -91,7 +91,7 @@ func TestYumRepositories(t *testing.T) {
 func TestYumChanges(t *testing.T) {
 	ctx := context.Background()
 
-	rpmQueryArgs := []string{"--queryformat", `\{"architecture":"%{ARCH}","package":"%{NAME}","source_name":"%{SOURCERPM}","version":"%|EPOCH?{%{EPOCH}:}:{}|%{VERSION}-%{RELEASE}"\}` + "\n", "-a"}
+	rpmQueryArgs := []string{"--queryformat", `\{"architecture":"%{ARCH}","digest":"%{SHA256HEADER}","installed_size":"%{SIZE}","package":"%{NAME}","source_name":"%{SOURCERPM}","version":"%|EPOCH?{%{EPOCH}:}:{}|%{VERSION}-%{RELEASE}"\}` + "\x1f%{VENDOR}\n", "-a"}
 	yumCheckUpdateArgs := []string{"check-update", "--assumeyes"}
 	yumListUpdatesArgs := []string{"update", "--assumeno", "--color=never"}
 	yumCheckUpdateErr := exec.Command("/bin/bash", "-c", "exit 100").Run()
@@ -124,7 +124,7 @@ func TestYumChanges(t *testing.T) {
 					Err: errors.New("rpmquery error"),
 				},
 			},
-			wantErr: errors.New("error running /usr/bin/rpmquery with args [\"--queryformat\" \"\\\\{\\\"architecture\\\":\\\"%{ARCH}\\\",\\\"package\\\":\\\"%{NAME}\\\",\\\"source_name\\\":\\\"%{SOURCERPM}\\\",\\\"version\\\":\\\"%|EPOCH?{%{EPOCH}:}:{}|%{VERSION}-%{RELEASE}\\\"\\\\}\\n\" \"-a\"]: rpmquery error, stdout: \"\", stderr: \"\""),
+			wantErr: errors.New("error running /usr/bin/rpmquery with args [\"--queryformat\" \"\\\\{\\\"architecture\\\":\\\"%{ARCH}\\\",\\\"digest\\\":\\\"%{SHA256HEADER}\\\",\\\"installed_size\\\":\\\"%{SIZE}\\\",\\\"package\\\":\\\"%{NAME}\\\",\\\"source_name\\\":\\\"%{SOURCERPM}\\\",\\\"version\\\":\\\"%|EPOCH?{%{EPOCH}:}:{}|%{VERSION}-%{RELEASE}\\\"\\\\}\\x1f%{VENDOR}\\n\" \"-a\"]: rpmquery error, stdout: \"\", stderr: \"\""),
 		},
 		{
 			name:       "yum check-update failure, want check-update error",
@@ -91,7 +91,7 @@ func TestZypperRepositories(t *testing.T) {
 func TestZypperChanges(t *testing.T) {
 	ctx := context.Background()
 
-	rpmQueryArgs := []string{"--queryformat", `\{"architecture":"%{ARCH}","package":"%{NAME}","source_name":"%{SOURCERPM}","version":"%|EPOCH?{%{EPOCH}:}:{}|%{VERSION}-%{RELEASE}"\}` + "\n", "-a"}
+	rpmQueryArgs := []string{"--queryformat", `\{"architecture":"%{ARCH}","digest":"%{SHA256HEADER}","installed_size":"%{SIZE}","package":"%{NAME}","source_name":"%{SOURCERPM}","version":"%|EPOCH?{%{EPOCH}:}:{}|%{VERSION}-%{RELEASE}"\}` + "\x1f%{VENDOR}\n", "-a"}
 	zypperListUpdatesArgs := []string{"--gpg-auto-import-keys", "-q", "list-updates"}
 
 	mockCtrl := gomock.NewController(t)
@@ -122,7 +122,7 @@ func TestZypperChanges(t *testing.T) {
 					Err: errors.New("rpmquery error"),
 				},
 			},
-			wantErr: errors.New("error running /usr/bin/rpmquery with args [\"--queryformat\" \"\\\\{\\\"architecture\\\":\\\"%{ARCH}\\\",\\\"package\\\":\\\"%{NAME}\\\",\\\"source_name\\\":\\\"%{SOURCERPM}\\\",\\\"version\\\":\\\"%|EPOCH?{%{EPOCH}:}:{}|%{VERSION}-%{RELEASE}\\\"\\\\}\\n\" \"-a\"]: rpmquery error, stdout: \"\", stderr: \"\""),
+			wantErr: errors.New("error running /usr/bin/rpmquery with args [\"--queryformat\" \"\\\\{\\\"architecture\\\":\\\"%{ARCH}\\\",\\\"digest\\\":\\\"%{SHA256HEADER}\\\",\\\"installed_size\\\":\\\"%{SIZE}\\\",\\\"package\\\":\\\"%{NAME}\\\",\\\"source_name\\\":\\\"%{SOURCERPM}\\\",\\\"version\\\":\\\"%|EPOCH?{%{EPOCH}:}:{}|%{VERSION}-%{RELEASE}\\\"\\\\}\\x1f%{VENDOR}\\n\" \"-a\"]: rpmquery error, stdout: \"\", stderr: \"\""),
 		},
 		{
 			name:          "zypper list-updates failure, want list-updates error",
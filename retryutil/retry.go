@@ -65,7 +65,9 @@ func RetryFunc(ctx context.Context, maxRetryTime time.Duration, desc string, f f
 		}
 
 		clog.Errorf(ctx, "Error %s, attempt %d, retrying in %s: %v", desc, i, ns, err)
-		currentSleeper.Sleep(ns)
+		if err := currentSleeper.Sleep(ctx, ns); err != nil {
+			return err
+		}
 	}
 }
 
@@ -102,7 +104,9 @@ func RetryAPICall(ctx context.Context, maxRetryTime time.Duration, name string,
 		}
 
 		clog.Warningf(ctx, "Error calling %s, attempt %d, retrying in %s: %v", name, i, ns, err)
-		currentSleeper.Sleep(ns)
+		if err := currentSleeper.Sleep(ctx, ns); err != nil {
+			return err
+		}
 	}
 }
 
@@ -134,11 +138,19 @@ func isResourceExhausted(s *status.Status) bool {
 }
 
 type sleeper interface {
-	Sleep(d time.Duration)
+	// Sleep waits for d or until ctx is done, in which case it returns ctx.Err().
+	Sleep(ctx context.Context, d time.Duration) error
 }
 
 type defaultSleeper struct{}
 
-func (ds defaultSleeper) Sleep(d time.Duration) {
-	time.Sleep(d)
+func (ds defaultSleeper) Sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
 }
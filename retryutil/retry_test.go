@@ -197,7 +197,9 @@ func Test_defaultSleeper(t *testing.T) {
 	timeToSleep := 200 * time.Millisecond
 	before := time.Now()
 
-	sleeper.Sleep(timeToSleep)
+	if err := sleeper.Sleep(context.Background(), timeToSleep); err != nil {
+		t.Errorf("sleeper.Sleep unexpected error: %v", err)
+	}
 
 	after := time.Now()
 	elapsed := after.Sub(before)
@@ -209,6 +211,44 @@ func Test_defaultSleeper(t *testing.T) {
 	}
 }
 
+func Test_defaultSleeperCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	before := time.Now()
+	err := defaultSleeper{}.Sleep(ctx, time.Minute)
+
+	if err != context.Canceled {
+		t.Errorf("sleeper.Sleep unexpected error, expect %v, got %v", context.Canceled, err)
+	}
+	if elapsed := time.Since(before); elapsed > time.Second {
+		t.Errorf("sleeper.Sleep returned after %s, expected prompt return on cancellation", elapsed)
+	}
+}
+
+func TestRetryAPICallCancelledDuringBackoff(t *testing.T) {
+	currentSleeper = defaultSleeper{}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	var calls int
+	before := time.Now()
+	err := RetryAPICall(ctx, time.Hour, "test", func() error {
+		calls++
+		return status.Error(codes.Unavailable, "unavailable")
+	})
+
+	if err != context.Canceled {
+		t.Errorf("RetryAPICall unexpected error, expect %v, got %v", context.Canceled, err)
+	}
+	if calls != 1 {
+		t.Errorf("RetryAPICall called the function %d times, expect 1", calls)
+	}
+	if elapsed := time.Since(before); elapsed > time.Second {
+		t.Errorf("RetryAPICall returned after %s, expected prompt return on cancellation", elapsed)
+	}
+}
+
 func abs(d int64) int64 {
 	if d < 0 {
 		return d * -1
@@ -239,4 +279,4 @@ func callsCollector(expectedToFailTimes int, failWith error) (func() error, *int
 
 type noOpSleeper struct{}
 
-func (noOpSleeper) Sleep(d time.Duration) { /*no op*/ }
+func (noOpSleeper) Sleep(ctx context.Context, d time.Duration) error { return nil /*no op*/ }
@@ -0,0 +1,229 @@
+package utiltest
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/kr/pretty"
+)
+
+// sprintSnapshot formats v like pretty.Sprint, but leaves out zero struct fields tagged
+// `json:",omitempty"`, so adding optional fields to a type does not rewrite the snapshots
+// of values that do not set them.
+func sprintSnapshot(v any) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 4, 4, 1, ' ', 0)
+	p := &snapshotPrinter{Writer: w, tw: w}
+	p.printValue(reflect.ValueOf(v), true)
+	w.Flush()
+	return b.String()
+}
+
+// snapshotPrinter mirrors the printer of github.com/kr/pretty for the kinds snapshots
+// are made of, values of other kinds are formatted by pretty itself.
+type snapshotPrinter struct {
+	io.Writer
+	tw *tabwriter.Writer
+}
+
+func (p *snapshotPrinter) indent() *snapshotPrinter {
+	tw := tabwriter.NewWriter(p.Writer, 4, 4, 1, ' ', 0)
+	return &snapshotPrinter{Writer: &indentWriter{w: tw, pre: true}, tw: tw}
+}
+
+func (p *snapshotPrinter) printValue(v reflect.Value, showType bool) {
+	if v.IsValid() && v.CanInterface() {
+		if goStringer, ok := v.Interface().(fmt.GoStringer); ok {
+			io.WriteString(p, goStringer.GoString())
+			return
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		p.printInline(v, v.Bool(), showType)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		p.printInline(v, v.Int(), showType)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		p.printInline(v, v.Uint(), showType)
+	case reflect.Float32, reflect.Float64:
+		p.printInline(v, v.Float(), showType)
+	case reflect.String:
+		io.WriteString(p, strconv.Quote(v.String()))
+	case reflect.Struct:
+		p.printStruct(v, showType)
+	case reflect.Interface:
+		if v.IsNil() {
+			io.WriteString(p, "nil")
+			return
+		}
+		p.printValue(v.Elem(), showType)
+	case reflect.Array, reflect.Slice:
+		p.printList(v, showType)
+	case reflect.Ptr:
+		if v.IsNil() {
+			fmt.Fprintf(p, "(%s)(nil)", v.Type())
+			return
+		}
+		io.WriteString(p, "&")
+		p.printValue(v.Elem(), true)
+	case reflect.Invalid:
+		io.WriteString(p, "nil")
+	default:
+		if showType {
+			fmt.Fprintf(p, "%# v", pretty.Formatter(v.Interface()))
+		} else {
+			io.WriteString(p, pretty.Sprint(v.Interface()))
+		}
+	}
+}
+
+func (p *snapshotPrinter) printInline(v reflect.Value, x any, showType bool) {
+	if showType {
+		fmt.Fprintf(p, "%s(%#v)", v.Type(), x)
+	} else {
+		fmt.Fprintf(p, "%#v", x)
+	}
+}
+
+func (p *snapshotPrinter) printStruct(v reflect.Value, showType bool) {
+	t := v.Type()
+	if showType {
+		io.WriteString(p, t.String())
+	}
+	io.WriteString(p, "{")
+	var fields []int
+	for i := 0; i < v.NumField(); i++ {
+		if isOmitEmpty(t.Field(i)) && v.Field(i).IsZero() {
+			continue
+		}
+		fields = append(fields, i)
+	}
+	if !v.IsZero() && len(fields) > 0 {
+		expand := !canInline(t)
+		pp := p
+		if expand {
+			io.WriteString(p, "\n")
+			pp = p.indent()
+		}
+		for n, i := range fields {
+			f := t.Field(i)
+			io.WriteString(pp, f.Name+":")
+			if expand {
+				io.WriteString(pp, "\t")
+			}
+			field := v.Field(i)
+			if field.Kind() == reflect.Interface && !field.IsNil() {
+				field = field.Elem()
+			}
+			pp.printValue(field, f.Type.Kind() == reflect.Interface || f.Type.Kind() == reflect.Struct)
+			if expand {
+				io.WriteString(pp, ",\n")
+			} else if n < len(fields)-1 {
+				io.WriteString(pp, ", ")
+			}
+		}
+		if expand {
+			pp.tw.Flush()
+		}
+	}
+	io.WriteString(p, "}")
+}
+
+func (p *snapshotPrinter) printList(v reflect.Value, showType bool) {
+	t := v.Type()
+	if showType {
+		io.WriteString(p, t.String())
+	}
+	if v.Kind() == reflect.Slice && v.IsNil() {
+		if showType {
+			io.WriteString(p, "(nil)")
+		} else {
+			io.WriteString(p, "nil")
+		}
+		return
+	}
+	io.WriteString(p, "{")
+	expand := !canInline(t)
+	pp := p
+	if expand {
+		io.WriteString(p, "\n")
+		pp = p.indent()
+	}
+	for i := 0; i < v.Len(); i++ {
+		pp.printValue(v.Index(i), t.Elem().Kind() == reflect.Interface)
+		if expand {
+			io.WriteString(pp, ",\n")
+		} else if i < v.Len()-1 {
+			io.WriteString(pp, ", ")
+		}
+	}
+	if expand {
+		pp.tw.Flush()
+	}
+	io.WriteString(p, "}")
+}
+
+func isOmitEmpty(f reflect.StructField) bool {
+	_, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == "omitempty" {
+			return true
+		}
+	}
+	return false
+}
+
+// canInline reports whether values of t are printed on a single line, like pretty does.
+func canInline(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Map:
+		return !canExpand(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if canExpand(t.Field(i).Type) {
+				return false
+			}
+		}
+		return true
+	case reflect.Array, reflect.Slice:
+		return !canExpand(t.Elem())
+	case reflect.Interface, reflect.Ptr, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return false
+	}
+	return true
+}
+
+func canExpand(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Map, reflect.Struct, reflect.Interface, reflect.Array, reflect.Slice, reflect.Ptr:
+		return true
+	}
+	return false
+}
+
+// indentWriter prefixes every line written to w with a tab.
+type indentWriter struct {
+	w   io.Writer
+	pre bool
+}
+
+func (iw *indentWriter) Write(b []byte) (int, error) {
+	for i, c := range b {
+		if iw.pre {
+			if _, err := iw.w.Write([]byte{'\t'}); err != nil {
+				return i, err
+			}
+			iw.pre = false
+		}
+		if _, err := iw.w.Write([]byte{c}); err != nil {
+			return i, err
+		}
+		iw.pre = c == '\n'
+	}
+	return len(b), nil
+}
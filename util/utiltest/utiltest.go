@@ -13,7 +13,6 @@ import (
 	utilmocks "github.com/GoogleCloudPlatform/osconfig/util/mocks"
 	"github.com/golang/mock/gomock"
 	"github.com/google/go-cmp/cmp"
-	"github.com/kr/pretty"
 )
 
 // AssertFormatMatch verifies that the got matches the wantFormat regular expression.
@@ -68,6 +67,7 @@ func removeSnapshotDraft(filepath string) {
 }
 
 // MatchSnapshot compares the actual data against a stored snapshot file.
+// The data is formatted like pretty.Sprint, without zero fields tagged omitempty.
 //
 // If the snapshot file doesn't exist, it creates a new draft file
 // (with a .draft suffix) containing the actual data and marks test failed.
@@ -81,7 +81,7 @@ func removeSnapshotDraft(filepath string) {
 func MatchSnapshot(t testReporter, actual any, snapshotFilepath string) {
 	t.Helper()
 
-	nextSnapshot := pretty.Sprint(actual)
+	nextSnapshot := sprintSnapshot(actual)
 
 	prevSnapshotBytes, err := os.ReadFile(snapshotFilepath)
 	if errors.Is(err, os.ErrNotExist) {
@@ -157,3 +157,38 @@ func Test_ExistingEqualSnapshot_PassesAndRemovesOutdatedDraft(t *testing.T) {
 		t.Errorf("unexpected testing.T calls: %v", spyT.calls)
 	}
 }
+
+func Test_SnapshotOmitsZeroOmitEmptyFields(t *testing.T) {
+	type Source struct {
+		Name, Version string
+	}
+	type Pkg struct {
+		Name     string
+		Source   Source
+		Size     int64    `json:",omitempty"`
+		Vendor   string   `json:",omitempty"`
+		Files    []string `json:",omitempty"`
+		Explicit *bool    `json:",omitempty"`
+	}
+	pkgs := []*Pkg{
+		{Name: "bash", Source: Source{Name: "bash-src"}},
+		{Name: "glibc", Vendor: "Rocky", Files: []string{"/usr/lib64/libc.so.6"}},
+	}
+
+	// Then zero omitempty fields are left out and the remaining fields stay aligned
+	expectedSnapshotContent := `[]*utiltest.Pkg{
+    &utiltest.Pkg{
+        Name:   "bash",
+        Source: utiltest.Source{Name:"bash-src", Version:""},
+    },
+    &utiltest.Pkg{
+        Name:   "glibc",
+        Source: utiltest.Source{},
+        Vendor: "Rocky",
+        Files:  {"/usr/lib64/libc.so.6"},
+    },
+}`
+	if diff := cmp.Diff(expectedSnapshotContent, sprintSnapshot(pkgs)); diff != "" {
+		t.Errorf("unexpected snapshot content, diff: %s", diff)
+	}
+}